@@ -21,20 +21,31 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
-	//"github.com/boynton/data"
+	"github.com/boynton/api/graphql"
+	"github.com/boynton/api/jsonschema"
 	"github.com/boynton/api/model"
 	"github.com/boynton/api/openapi"
+	"github.com/boynton/api/postman"
+	"github.com/boynton/api/protobuf"
 	//	"github.com/boynton/api/sadl"
 	"github.com/boynton/api/smithy"
 	"github.com/boynton/api/swagger"
+	"github.com/boynton/api/traffic"
+	"github.com/boynton/data"
 )
 
 var ImportFileExtensions = map[string]string{
-	".api":    "api",
-	".smithy": "smithy",
-	".sadl":   "sadl",
-	".rdl":    "rdl",
+	".api":     "api",
+	".smithy":  "smithy",
+	".sadl":    "sadl",
+	".rdl":     "rdl",
+	".proto":   "protobuf",
+	".graphql": "graphql",
+	".gql":     "graphql",
+	".har":     "traffic",
+	".http":    "traffic",
 }
 
 func determineFormat(path string) string {
@@ -63,6 +74,15 @@ func determineFormat(path string) string {
 		if _, ok := raw["swagger"]; ok {
 			return "swagger"
 		}
+		if dialect, ok := raw["$schema"].(string); ok && strings.Contains(dialect, "json-schema.org") {
+			return "jsonschema"
+		}
+		if traffic.IsHarFile(path) {
+			return "traffic"
+		}
+		if postman.IsCollectionFile(path) {
+			return "postman"
+		}
 		return "api"
 	}
 	if ext == ".yaml" {
@@ -114,10 +134,28 @@ func expandPaths(paths []string) ([]string, string, error) {
 			}
 		}
 	}
-	return result, format, nil
+	return uniquePaths(result), format, nil
+}
+
+// uniquePaths drops the paths that refer to a file already in the list, so that a file named both
+// directly and through its directory, or by both a build config and the command line, is read once.
+func uniquePaths(paths []string) []string {
+	seen := make(map[string]bool, 0)
+	var result []string
+	for _, path := range paths {
+		key, err := filepath.Abs(path)
+		if err != nil {
+			key = filepath.Clean(path)
+		}
+		if !seen[key] {
+			seen[key] = true
+			result = append(result, path)
+		}
+	}
+	return result
 }
 
-func AssembleModel(paths []string, tags []string, ns string, parseOnly bool, noValidate bool) (*model.Schema, error) {
+func AssembleModel(paths []string, tags []string, ns string, parseOnly bool, noValidate bool, conf *data.Object) (*model.Schema, error) {
 	flatPathList, format, err := expandPaths(paths)
 	if err != nil {
 		return nil, err
@@ -133,7 +171,7 @@ func AssembleModel(paths []string, tags []string, ns string, parseOnly bool, noV
 	case "api":
 		schema, err = model.Load(flatPathList, tags)
 	case "smithy":
-		schema, err = smithy.Import(flatPathList, tags, parseOnly)
+		schema, err = smithy.Import(flatPathList, tags, parseOnly, noValidate)
 	case "sadl":
 		//schema, err = sadl.Import(flatPathList, tags)
 		err = fmt.Errorf("sadl.Import NYI")
@@ -141,6 +179,16 @@ func AssembleModel(paths []string, tags []string, ns string, parseOnly bool, noV
 		schema, err = openapi.Import(flatPathList, tags, ns)
 	case "swagger":
 		schema, err = swagger.Import(flatPathList, tags, ns)
+	case "protobuf":
+		schema, err = protobuf.Import(flatPathList, tags, ns)
+	case "graphql":
+		schema, err = graphql.Import(flatPathList, tags, ns, conf)
+	case "jsonschema":
+		schema, err = jsonschema.Import(flatPathList, tags, ns)
+	case "traffic":
+		schema, err = traffic.Import(flatPathList, tags, ns, conf)
+	case "postman":
+		schema, err = postman.Import(flatPathList, tags, ns, conf)
 	case "rdl":
 		err = fmt.Errorf("rdl.Import NYI")
 	default:
@@ -153,3 +201,30 @@ func AssembleModel(paths []string, tags []string, ns string, parseOnly bool, noV
 	}
 	return schema, err
 }
+
+func AssembleProjection(paths []string, tags []string, proj *smithy.Projection, noValidate bool) (*model.Schema, error) {
+	flatPathList, format, err := expandPaths(paths)
+	if err != nil {
+		return nil, err
+	}
+	if format != "smithy" {
+		return nil, fmt.Errorf("Projections require smithy input files")
+	}
+	schema, err := smithy.ImportProjection(flatPathList, tags, proj, noValidate)
+	if err == nil && !noValidate {
+		err = schema.Validate()
+	}
+	return schema, err
+}
+
+func MigrateSmithy(paths []string, conf *data.Object) error {
+	flatPathList, format, err := expandPaths(paths)
+	if err != nil {
+		return err
+	}
+	if format != "smithy" {
+		return fmt.Errorf("Migration requires smithy input files")
+	}
+	migrator := new(smithy.Migrator)
+	return migrator.Migrate(flatPathList, conf)
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/boynton/api/contract"
+	"github.com/boynton/api/model"
+	"github.com/boynton/data"
+)
+
+// ContractTest implements "api test": it assembles the model from the files, replays the examples of its operations
+// against the server, and writes a report. It returns the exit code of the tool, 2 if any example failed.
+func ContractTest(args []string) int {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	pUrl := flags.String("url", "", "The URL of the server, by default the service's base")
+	pIgnore := flags.String("ignore", "", "Comma-separated fields (names or dotted paths) and headers not compared")
+	pFormat := flags.String("format", "text", "The report format: text, json, or junit")
+	pOut := flags.String("o", "", "The file to write the report to (defaults to stdout)")
+	pTimeout := flags.Duration("timeout", 30*time.Second, "The timeout of each request")
+	pNoValidate := flags.Bool("v", false, "Suppress validation of the assembled model")
+	pNs := flags.String("ns", "", "The namespace to force if absent")
+	var tags Tags
+	flags.Var(&tags, "t", "Tag of entities to include. Prefix tag with '-' to exclude that tag")
+	flags.Parse(args)
+	files := flags.Args()
+	if len(files) == 0 {
+		fmt.Println("usage: api test [-url url] [-ignore fields] [-format text|json|junit] [-o file] [-timeout d] [-v] [-ns namespace] [-t tag]* file ...")
+		flags.PrintDefaults()
+		return 1
+	}
+	schema, err := AssembleModel(files, tags, *pNs, false, *pNoValidate, data.NewObject())
+	if err != nil {
+		fmt.Printf("*** %v\n", err)
+		return 4
+	}
+	if *pUrl == "" && !strings.Contains(schema.Base, "://") {
+		fmt.Println("*** The model has no base URL, use -url to specify the server")
+		return 1
+	}
+	runner := &contract.Runner{
+		Schema: schema,
+		Url:    *pUrl,
+		Client: &http.Client{Timeout: *pTimeout},
+	}
+	for _, ig := range strings.Split(*pIgnore, ",") {
+		if ig = strings.TrimSpace(ig); ig != "" {
+			runner.Ignore = append(runner.Ignore, ig)
+		}
+	}
+	report := runner.Run()
+	var w io.Writer = os.Stdout
+	if *pOut != "" {
+		f, err := os.Create(*pOut)
+		if err != nil {
+			fmt.Printf("*** %v\n", err)
+			return 4
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := contract.WriteReport(w, *pFormat, report); err != nil {
+		fmt.Printf("*** %v\n", err)
+		return 4
+	}
+	if report.Tests == 0 {
+		model.Warning("The model has no examples to test\n")
+	}
+	if report.Failures > 0 || report.Errors > 0 {
+		return 2
+	}
+	return 0
+}
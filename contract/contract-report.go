@@ -0,0 +1,119 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package contract
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/boynton/api/model"
+)
+
+// WriteReport writes the report in the format: "text" (one line per example, followed by its failures), "json", or
+// "junit" (the XML understood by most CI systems, with a test case per example, named for its operation).
+func WriteReport(w io.Writer, format string, report *Report) error {
+	switch format {
+	case "", "text":
+		return writeText(w, report)
+	case "json":
+		_, err := io.WriteString(w, model.Pretty(report))
+		return err
+	case "junit":
+		return writeJunit(w, report)
+	}
+	return fmt.Errorf("Unsupported report format: %q", format)
+}
+
+func writeText(w io.Writer, report *Report) error {
+	var b strings.Builder
+	for _, result := range report.Results {
+		outcome := "PASS"
+		if result.Error != "" {
+			outcome = "ERROR"
+		} else if len(result.Failures) > 0 {
+			outcome = "FAIL"
+		}
+		fmt.Fprintf(&b, "%-5s %s: %s (%s %s)\n", outcome, result.Operation, result.Example, result.Method, result.Url)
+		if result.Error != "" {
+			fmt.Fprintf(&b, "      %s\n", result.Error)
+		}
+		for _, f := range result.Failures {
+			fmt.Fprintf(&b, "      %s\n", f)
+		}
+	}
+	passed := report.Tests - report.Failures - report.Errors
+	fmt.Fprintf(&b, "%d examples, %d passed, %d failed, %d errors (%.3fs)\n", report.Tests, passed, report.Failures, report.Errors, report.Time)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func writeJunit(w io.Writer, report *Report) error {
+	suite := junitSuite{
+		Name:     report.Name,
+		Tests:    report.Tests,
+		Failures: report.Failures,
+		Errors:   report.Errors,
+		Time:     fmt.Sprintf("%.3f", report.Time),
+	}
+	for _, result := range report.Results {
+		tc := junitCase{ClassName: result.Operation, Name: result.Example, Time: fmt.Sprintf("%.3f", result.Time)}
+		if result.Error != "" {
+			tc.Error = &junitProblem{Message: result.Error, Text: result.Method + " " + result.Url}
+		} else if len(result.Failures) > 0 {
+			msg := "1 difference from the example"
+			if len(result.Failures) > 1 {
+				msg = fmt.Sprintf("%d differences from the example", len(result.Failures))
+			}
+			tc.Failure = &junitProblem{Message: msg, Text: strings.Join(result.Failures, "\n")}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	b, err := xml.MarshalIndent(junitSuites{Suites: []junitSuite{suite}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, xml.Header+string(b)+"\n")
+	return err
+}
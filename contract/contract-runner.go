@@ -0,0 +1,272 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boynton/api/httptrace"
+	"github.com/boynton/api/model"
+)
+
+// Runner replays the examples of a model's operations against a live endpoint, and checks each response against the
+// example's output, or error: the status, the headers bound to output fields, and the body, compared structurally.
+type Runner struct {
+	Schema *model.Schema
+	// Url is the URL of the server. The path of the service's Base is appended to it. If empty, the Base is used.
+	Url    string
+	Client *http.Client
+	// Ignore lists the fields whose values are not compared, i.e. generated ids and timestamps. An entry is either a
+	// field name, matching at any depth, or a dotted path from the root of the body, like "item.modified", or a header.
+	Ignore []string
+}
+
+// Result is the outcome of replaying a single example.
+type Result struct {
+	Operation string   `json:"operation"`
+	Example   string   `json:"example"`
+	Method    string   `json:"method"`
+	Url       string   `json:"url"`
+	Status    int      `json:"status,omitempty"`
+	Time      float64  `json:"time"`
+	Failures  []string `json:"failures,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+func (result *Result) Passed() bool {
+	return result.Error == "" && len(result.Failures) == 0
+}
+
+// Report is the outcome of a run, the results are in the order of the operations and their examples.
+type Report struct {
+	Name     string    `json:"name"`
+	Tests    int       `json:"tests"`
+	Failures int       `json:"failures"`
+	Errors   int       `json:"errors"`
+	Time     float64   `json:"time"`
+	Results  []*Result `json:"results"`
+}
+
+// Run replays every example, in order, so that examples that depend on earlier ones (i.e. a read after a create)
+// work against a stateful server.
+func (runner *Runner) Run() *Report {
+	report := &Report{Name: string(runner.Schema.Id)}
+	start := time.Now()
+	for _, op := range runner.Schema.Operations {
+		for _, ex := range op.Examples {
+			result := runner.RunExample(op, ex)
+			report.Tests++
+			if result.Error != "" {
+				report.Errors++
+			} else if len(result.Failures) > 0 {
+				report.Failures++
+			}
+			report.Results = append(report.Results, result)
+		}
+	}
+	report.Time = time.Since(start).Seconds()
+	return report
+}
+
+func (runner *Runner) baseUrl() string {
+	base := runner.Schema.Base
+	if runner.Url == "" {
+		return strings.TrimRight(base, "/")
+	}
+	if u, err := url.Parse(base); err == nil {
+		base = u.Path
+	}
+	return strings.TrimRight(runner.Url, "/") + strings.TrimRight(base, "/")
+}
+
+// RunExample sends the request of the example, and compares the response with the example's.
+func (runner *Runner) RunExample(op *model.OperationDef, example *model.OperationExample) *Result {
+	result := &Result{Operation: model.StripNamespace(op.Id), Example: example.Title, Method: op.HttpMethod}
+	ex, err := httptrace.ExampleExchange(runner.Schema, op, example)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Url = runner.baseUrl() + ex.Uri
+	var body io.Reader
+	if ex.RequestBody != nil {
+		b, err := json.Marshal(ex.RequestBody)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(ex.Method, result.Url, body)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+	for _, h := range ex.RequestHeaders {
+		req.Header.Add(h.Name, h.Value)
+	}
+	client := runner.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	start := time.Now()
+	res, err := client.Do(req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer res.Body.Close()
+	resBody, err := io.ReadAll(res.Body)
+	result.Time = time.Since(start).Seconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Status = res.StatusCode
+	if res.StatusCode != ex.Status {
+		result.fail("status: expected %d, got %d", ex.Status, res.StatusCode)
+	}
+	for _, h := range ex.ResponseHeaders {
+		if runner.ignored(h.Name, h.Name) {
+			continue
+		}
+		if actual := res.Header.Get(h.Name); actual != h.Value {
+			result.fail("header %s: expected %q, got %q", h.Name, h.Value, actual)
+		}
+	}
+	if ex.ResponseBody != nil {
+		var actual any
+		if err := json.Unmarshal(resBody, &actual); err != nil {
+			result.fail("body: expected JSON, got %q", abbreviate(string(resBody)))
+		} else {
+			runner.compare("", "", normalize(ex.ResponseBody), actual, result)
+		}
+	}
+	return result
+}
+
+func (result *Result) fail(format string, args ...any) {
+	result.Failures = append(result.Failures, fmt.Sprintf(format, args...))
+}
+
+var indexPattern = regexp.MustCompile(`\[[0-9]+\]`)
+
+func (runner *Runner) ignored(path, name string) bool {
+	unindexed := indexPattern.ReplaceAllString(path, "")
+	for _, ig := range runner.Ignore {
+		if strings.EqualFold(ig, name) || ig == unindexed {
+			return true
+		}
+	}
+	return false
+}
+
+// compare adds a failure for each difference between the expected and actual JSON values. Objects must have the same
+// fields, and lists the same length, except for the ignored fields.
+func (runner *Runner) compare(path, name string, expected, actual any, result *Result) {
+	if path != "" && runner.ignored(path, name) {
+		return
+	}
+	where := "body"
+	if path != "" {
+		where = "body." + path
+	}
+	switch e := expected.(type) {
+	case map[string]any:
+		a, ok := actual.(map[string]any)
+		if !ok {
+			result.fail("%s: expected an object, got %s", where, abbreviate(model.JsonEncode(actual)))
+			return
+		}
+		for _, key := range sortedKeys(e) {
+			if v, ok := a[key]; ok {
+				runner.compare(join(path, key), key, e[key], v, result)
+			} else if !runner.ignored(join(path, key), key) {
+				result.fail("%s.%s: missing", where, key)
+			}
+		}
+		for _, key := range sortedKeys(a) {
+			if _, ok := e[key]; !ok && !runner.ignored(join(path, key), key) {
+				result.fail("%s.%s: unexpected, got %s", where, key, abbreviate(model.JsonEncode(a[key])))
+			}
+		}
+	case []any:
+		a, ok := actual.([]any)
+		if !ok {
+			result.fail("%s: expected a list, got %s", where, abbreviate(model.JsonEncode(actual)))
+			return
+		}
+		if len(a) != len(e) {
+			result.fail("%s: expected %d items, got %d", where, len(e), len(a))
+		}
+		for i := 0; i < len(e) && i < len(a); i++ {
+			runner.compare(fmt.Sprintf("%s[%d]", path, i), name, e[i], a[i], result)
+		}
+	default:
+		if !model.Equivalent(expected, actual) {
+			result.fail("%s: expected %s, got %s", where, abbreviate(model.JsonEncode(expected)), abbreviate(model.JsonEncode(actual)))
+		}
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func abbreviate(s string) string {
+	if len(s) > 80 {
+		return s[:77] + "..."
+	}
+	return s
+}
+
+// normalize returns the JSON form of an example value, with objects as map[string]any and numbers as float64.
+func normalize(v any) any {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var result any
+	if err := json.Unmarshal(b, &result); err != nil {
+		return v
+	}
+	return result
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package contract
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/boynton/api/model"
+)
+
+// itemSchema has a single operation, GetItem, with an example for each of the item ids served by itemServer.
+func itemSchema(ids ...string) *model.Schema {
+	schema := model.NewSchema()
+	schema.Id = "example#ItemService"
+	op := &model.OperationDef{
+		Id:         "example#GetItem",
+		HttpMethod: "GET",
+		HttpUri:    "/items/{id}",
+		Input: &model.OperationInput{
+			Id: "example#GetItemInput",
+			Fields: model.OperationInputFieldList{
+				{Name: "id", Type: "base#String", Required: true, HttpPath: true},
+			},
+		},
+		Output: &model.OperationOutput{
+			Id:         "example#GetItemOutput",
+			HttpStatus: 200,
+			Fields: model.OperationOutputFieldList{
+				{Name: "item", Type: "base#Any", HttpPayload: true},
+			},
+		},
+	}
+	for _, id := range ids {
+		op.Examples = append(op.Examples, &model.OperationExample{
+			Title:  id,
+			Input:  map[string]any{"id": id},
+			Output: map[string]any{"item": map[string]any{"id": id, "name": "Widget", "count": 3}},
+		})
+	}
+	schema.Operations = append(schema.Operations, op)
+	return schema
+}
+
+// itemServer answers as the examples expect for "ok", with a 404 for "missing", and with a body of the wrong shape
+// for "reshaped".
+func itemServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch strings.TrimPrefix(r.URL.Path, "/items/") {
+		case "ok":
+			w.Write([]byte(`{"id":"ok","name":"Widget","count":3}`))
+		case "missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"not found"}`))
+		case "reshaped":
+			w.Write([]byte(`{"id":"reshaped","title":"Widget","count":[3]}`))
+		}
+	}))
+}
+
+func TestRunnerPasses(t *testing.T) {
+	server := itemServer()
+	defer server.Close()
+	runner := &Runner{Schema: itemSchema("ok"), Url: server.URL}
+	report := runner.Run()
+	if report.Tests != 1 || report.Failures != 0 || report.Errors != 0 {
+		t.Fatalf("expected 1 passing test, got %+v, %v", report, report.Results[0].Failures)
+	}
+	if result := report.Results[0]; result.Status != 200 || result.Url != server.URL+"/items/ok" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestRunnerStatusMismatch(t *testing.T) {
+	server := itemServer()
+	defer server.Close()
+	runner := &Runner{Schema: itemSchema("missing"), Url: server.URL}
+	report := runner.Run()
+	if report.Tests != 1 || report.Failures != 1 {
+		t.Fatalf("expected 1 failing test, got %+v", report)
+	}
+	if !hasFailure(report.Results[0], "status: expected 200, got 404") {
+		t.Errorf("expected a status failure, got %v", report.Results[0].Failures)
+	}
+}
+
+func TestRunnerBodyShapeMismatch(t *testing.T) {
+	server := itemServer()
+	defer server.Close()
+	runner := &Runner{Schema: itemSchema("reshaped"), Url: server.URL}
+	report := runner.Run()
+	if report.Tests != 1 || report.Failures != 1 {
+		t.Fatalf("expected 1 failing test, got %+v", report)
+	}
+	result := report.Results[0]
+	for _, expected := range []string{"body.name: missing", "body.title: unexpected", "body.count: expected 3, got [3]"} {
+		if !hasFailure(result, expected) {
+			t.Errorf("expected a failure starting with %q, got %v", expected, result.Failures)
+		}
+	}
+	if hasFailure(result, "status") || hasFailure(result, "body.id") {
+		t.Errorf("unexpected failures: %v", result.Failures)
+	}
+}
+
+func TestRunnerIgnore(t *testing.T) {
+	server := itemServer()
+	defer server.Close()
+	runner := &Runner{Schema: itemSchema("reshaped"), Url: server.URL, Ignore: []string{"name", "title", "count"}}
+	report := runner.Run()
+	if report.Failures != 0 {
+		t.Errorf("expected the ignored fields to pass, got %v", report.Results[0].Failures)
+	}
+}
+
+func hasFailure(result *Result, prefix string) bool {
+	for _, f := range result.Failures {
+		if strings.HasPrefix(f, prefix) {
+			return true
+		}
+	}
+	return false
+}
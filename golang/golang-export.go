@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"fmt"
 	"path"
+	"sort"
 	"strings"
 
 	"github.com/boynton/api/model"
@@ -38,7 +39,9 @@ type Generator struct {
 	decimalPrefix       string //derived from the decimalPackage
 	timestampPackage    string //use this package for the Timestamp implementation. If "", then generate one in this package
 	timestampPrefix     string
-	prefixEnums         bool //prefix enum symbols with the typename to avoid collisions
+	prefixEnums         bool   //prefix enum symbols with the typename to avoid collisions
+	router              string //"gorilla" (the default), or "stdlib" for the Go 1.22 http.ServeMux patterns
+	fuzz                bool   //also generate the table tests and fuzz targets of the server
 }
 
 func (gen *Generator) GenerateResource(rez *model.ResourceDef) error {
@@ -70,6 +73,15 @@ func (gen *Generator) Generate(schema *model.Schema, config *data.Object) error
 		gen.decimalPackage = "github.com/boynton/data"
 	}
 	gen.decimalPrefix = path.Base(gen.decimalPackage) + "."
+	gen.router = config.GetString("golang.router")
+	switch gen.router {
+	case "":
+		gen.router = "gorilla"
+	case "gorilla", "stdlib":
+	default:
+		return fmt.Errorf("Unsupported golang.router: %q (use \"gorilla\" or \"stdlib\")", gen.router)
+	}
+	gen.fuzz = config.GetBool("golang.fuzz")
 	gen.timestampPackage = config.GetString("golang.timestampPackage")
 	if gen.timestampPackage != "" {
 		gen.timestampPrefix = path.Base(gen.timestampPackage) + "."
@@ -115,14 +127,20 @@ func (gen *Generator) Generate(schema *model.Schema, config *data.Object) error
 		if err != nil {
 			return err
 		}
-		/*
-			fname = gen.FileName(fbase + "_client", ".go")
-			s = gen.GenerateClient(ns, model)
-			err = gen.Write(s, fname, "\n\n------------------" + fname + "\n")
+		fname = gen.FileName(fbase+"_client", ".go")
+		s = gen.GenerateClient()
+		err = gen.Write(s, fname, "\n\n------------------"+fname+"\n")
+		if err != nil {
+			return err
+		}
+		if gen.fuzz {
+			fname = gen.FileName(fbase+"_fuzz_test", ".go")
+			s = gen.GenerateFuzzTests()
+			err = gen.Write(s, fname, "\n\n------------------"+fname+"\n")
 			if err != nil {
 				return err
 			}
-		*/
+		}
 	}
 	return nil
 }
@@ -133,9 +151,11 @@ func (gen *Generator) Validate() error {
 }
 
 type GolangWriter struct {
-	buf    bytes.Buffer
-	writer *bufio.Writer
-	gen    *Generator
+	buf      bytes.Buffer
+	writer   *bufio.Writer
+	gen      *Generator
+	imports  map[string]bool
+	patterns []string
 }
 
 func (gen *Generator) golangBaseTypeName(bt model.BaseType) string {
@@ -324,7 +344,7 @@ func (gen *Generator) goImports(forDef bool) map[string]bool {
 				includes["fmt"] = true
 				includes["math/big"] = true
 			}
-		case model.BaseType_Enum:
+		case model.BaseType_Enum, model.BaseType_Union:
 			if forDef {
 				includes["encoding/json"] = true
 				includes["fmt"] = true
@@ -388,7 +408,7 @@ func (gen *Generator) generateType(td *model.TypeDef, w *GolangWriter) {
 				opt = ",omitempty"
 			}
 			name := string(f.Name)
-			w.Emitf("    %s %s `json:\"%s%s\"`\n", model.Capitalize(name), w.gen.golangTypeRef(f.Type), model.Uncapitalize(name), opt)
+			w.Emitf("    %s %s `json:\"%s%s\"`\n", model.Capitalize(name), gen.fieldTypeRef(f.Type, f.Required, fieldConstraints(f)), model.Uncapitalize(name), opt)
 		}
 		w.Emitf("}\n")
 	case model.BaseType_Union:
@@ -420,6 +440,15 @@ func (gen *Generator) generateType(td *model.TypeDef, w *GolangWriter) {
 		w.Emitf("    if err := json.Unmarshal(b, &tmp); err != nil {\n")
 		w.Emitf("        return err\n")
 		w.Emitf("    }\n")
+		w.Emitf("    n := 0\n")
+		for _, f := range td.Fields {
+			w.Emitf("    if tmp.%s != nil {\n", model.Capitalize(string(f.Name)))
+			w.Emitf("        n++\n")
+			w.Emitf("    }\n")
+		}
+		w.Emitf("    if n > 1 {\n")
+		w.Emitf("        return fmt.Errorf(\"%s: Only one variant may be present, found %%d\", n)\n", tname)
+		w.Emitf("    }\n")
 		p := "if "
 		for _, f := range td.Fields {
 			fname := model.Capitalize(string(f.Name))
@@ -463,7 +492,11 @@ func (gen *Generator) generateType(td *model.TypeDef, w *GolangWriter) {
 		w.Emitf(")\n")
 		w.Emitf("var names%s = []string{\n", tname)
 		for _, e := range td.Elements {
-			w.Emitf("    %s%s: %q,\n", prefix, e.Symbol, e.Value) //assumes string enum!
+			value := e.Value
+			if value == "" {
+				value = string(e.Symbol) //an element without a value is encoded as its symbol
+			}
+			w.Emitf("    %s%s: %q,\n", prefix, e.Symbol, value) //assumes string enum!
 		}
 		w.Emitf("}\n")
 		w.Emitf("func (e %s) String() string {\n", tname)
@@ -506,15 +539,23 @@ func (gen *Generator) GenerateTypes() string {
 		gen: gen,
 	}
 	w.Begin()
-	w.Emit("/* Generated */\n")
-	w.Emitf("\npackage %s\n", gen.pkg)
-	imports := gen.goImports(true)
-	if len(imports) > 0 {
-		w.Emit(declareImports(imports))
-	}
 	for _, td := range gen.Schema.Types {
 		gen.generateType(td, w)
+		gen.generateValidate(td, w)
+	}
+	w.Emit(validationUtilSource)
+	body := w.End()
+	imports := gen.goImports(true)
+	imports["fmt"] = true
+	imports["strings"] = true
+	for pkg := range w.imports {
+		imports[pkg] = true
 	}
+	w.Begin()
+	w.Emit("/* Generated */\n")
+	w.Emitf("\npackage %s\n", gen.pkg)
+	w.Emit(declareImports(imports))
+	w.Emit(body)
 	return w.End()
 }
 
@@ -523,25 +564,70 @@ func (gen *Generator) GenerateOperations() string {
 		gen: gen,
 	}
 	w.Begin()
-	w.Emit("/* Generated */\n")
-	w.Emitf("\npackage %s\n", gen.pkg)
-	imports := gen.goImports(false)
-	if len(imports) > 0 {
-		w.Emit(declareImports(imports))
-	}
-
 	if gen.Schema.Operations != nil && gen.Schema.ServiceName() != "" {
 		w.EmitServiceInterface()
 	}
-
+	body := w.End()
+	imports := gen.operationImports()
+	for pkg := range w.imports {
+		imports[pkg] = true
+	}
+	w.Begin()
+	w.Emit("/* Generated */\n")
+	w.Emitf("\npackage %s\n", gen.pkg)
+	w.Emit(declareImports(imports))
+	w.Emit(body)
 	return w.End()
 }
 
+// operationImports returns the imports used by the service interface and the operation input, output, and exception
+// types.
+func (gen *Generator) operationImports() map[string]bool {
+	imports := make(map[string]bool, 0)
+	if gen.serviceContext() {
+		imports["context"] = true
+	}
+	uses := func(id model.AbsoluteIdentifier) {
+		tref := gen.golangTypeRef(id)
+		if strings.Contains(tref, gen.decimalPrefix+"Decimal") || strings.Contains(tref, gen.decimalPrefix+"Integer") {
+			imports[gen.decimalPackage] = true
+		}
+		if gen.timestampPackage != "" && strings.Contains(tref, gen.timestampPrefix+"Timestamp") {
+			imports[gen.timestampPackage] = true
+		}
+	}
+	for _, op := range gen.Schema.Operations {
+		if op.Input != nil {
+			for _, f := range op.Input.Fields {
+				uses(f.Type)
+			}
+		}
+		if op.Output != nil {
+			for _, f := range op.Output.Fields {
+				uses(f.Type)
+			}
+		}
+		for _, eid := range op.Exceptions {
+			if e := gen.Schema.GetExceptionDef(eid); e != nil {
+				for _, f := range e.Fields {
+					uses(f.Type)
+				}
+			}
+		}
+	}
+	return imports
+}
+
 func declareImports(imports map[string]bool) string {
 	s := ""
 	if len(imports) > 0 {
-		s = "\nimport(\n"
+		var names []string
 		for i := range imports {
+			names = append(names, i)
+		}
+		sort.Strings(names)
+		s = "\nimport(\n"
+		for _, i := range names {
 			s = s + fmt.Sprintf("    %q\n", i)
 		}
 		s = s + ")\n"
@@ -549,22 +635,6 @@ func declareImports(imports map[string]bool) string {
 	return s
 }
 
-func (gen *Generator) baseConverter(typeref model.AbsoluteIdentifier, def interface{}, body string) string {
-	s := gen.golangTypeRef(typeref) //this expands to primitives. Still need a typecast if golang.inlinePrimitives=false
-	switch s {
-	case "int8", "int16", "int32", "int64":
-		r := fmt.Sprintf("intParam(%s, %d)", body, data.AsInt64(def))
-		if s != "int64" {
-			r = s + "(" + r + ")"
-		}
-		return r
-	case "*data.Timestamp":
-		return fmt.Sprintf("timestampParam(%s, %q)", body, data.AsString(def))
-	default:
-		return fmt.Sprintf("stringParam(%s, %q)", body, data.AsString(def))
-	}
-}
-
 func (gen *Generator) GenerateServer() string {
 	schema := gen.Schema
 	w := &GolangWriter{
@@ -574,23 +644,26 @@ func (gen *Generator) GenerateServer() string {
 	w.Begin()
 	w.Emit("\n/* Generated */\n")
 	w.Emitf("\npackage %s\n", gen.pkg)
-	imports := gen.goImports(false)
-	imports["github.com/gorilla/mux"] = true
-	imports["github.com/gorilla/handlers"] = true
-	imports["net/http"] = true
-	imports["net/url"] = true
-	imports["encoding/json"] = true
-	imports["strings"] = true
-	imports["strconv"] = true
-	imports["log"] = true
-	imports["fmt"] = true
-	imports["io"] = true
-	imports["os"] = true
-	if len(imports) > 0 {
-		w.Emit(declareImports(imports))
+	imports := map[string]bool{
+		"encoding/json": true,
+		"fmt":           true,
+		"io":            true,
+		"log":           true,
+		"net/http":      true,
+		"net/url":       true,
+		"strconv":       true,
+		"strings":       true,
 	}
+	if gen.router == "stdlib" {
+		imports["time"] = true
+	} else {
+		imports["github.com/gorilla/mux"] = true
+		imports["github.com/gorilla/handlers"] = true
+		imports["os"] = true
+	}
+	w.Emit(declareImports(imports))
+	w.Emit("\n")
 
-	w.Emit("var _ = data.ParseTimestamp\n\n")
 	adaptorName := model.Uncapitalize(serviceName) + "Adaptor"
 	w.Emitf("type %s struct {\n", adaptorName)
 	w.Emitf("    impl %s\n", serviceName)
@@ -598,78 +671,7 @@ func (gen *Generator) GenerateServer() string {
 	w.Emit("\n")
 
 	for _, op := range schema.Operations {
-		opName := gen.golangTypeName(op.Id)
-		opInput := ""
-		opOutput := ""
-		opStatus := int32(0)
-		if op.Input != nil {
-			opInput = gen.golangTypeName(op.Input.Id)
-		}
-		if op.Output != nil {
-			opOutput = gen.golangTypeName(op.Output.Id)
-			opStatus = op.Output.HttpStatus
-		}
-		w.Emitf("func (handler *%s) %sHandler(w http.ResponseWriter, r *http.Request) {\n", adaptorName, opName)
-		arg := ""
-		q := false
-		if opInput != "" {
-			w.Emitf("    req := new(%s)\n", opInput)
-			for _, f := range op.Input.Fields {
-				if f.HttpQuery != "" {
-					q = true
-				} else if f.HttpPath {
-					body := fmt.Sprintf("mux.Vars(r)[%q]", f.Name)
-					w.Emitf("    req.%s = %s\n", model.Capitalize(string(f.Name)), gen.baseConverter(f.Type, f.Default, body))
-				} else if f.HttpPayload {
-					w.Emitf("    err := json.NewDecoder(r.Body).Decode(&req.%s)\n", model.Capitalize(string(f.Name)))
-					w.Emitf("    if err != nil {\n")
-					w.Emitf("        errorResponse(w, 400, fmt.Sprint(err))\n")
-					w.Emitf("        return\n")
-					w.Emitf("    }\n")
-				} else {
-					//?headers
-				}
-			}
-			if q {
-				w.Emitf("    err := r.ParseForm()\n")
-				w.Emitf("    if err != nil {\n")
-				w.Emitf("        errorResponse(w, 400, fmt.Sprint(err))\n")
-				w.Emitf("        return\n")
-				w.Emitf("    }\n")
-				for _, f := range op.Input.Fields {
-					if f.HttpQuery != "" {
-						body := fmt.Sprintf("r.Form.Get(%q)", f.Name)
-						w.Emitf("    req.%s = %s\n", model.Capitalize(string(f.Name)), gen.baseConverter(f.Type, f.Default, body))
-					}
-				}
-				w.Emitf("    // emit the query params here\n")
-			}
-			//headers!
-			//entity!
-			arg = "req"
-		}
-		result := ""
-		resPayload := "nil"
-		if opOutput != "" {
-			resPayload = "res." + model.Capitalize(op.OutputHttpPayloadName())
-			result = "res, "
-		}
-		w.Emitf("    %serr := handler.impl.%s(%s)\n", result, opName, arg)
-		w.Emit("    if err != nil {\n")
-		w.Emit("        switch e := err.(type) {\n")
-		for _, eid := range op.Exceptions {
-			w.Emitf("       case %s:\n", gen.golangTypeRef(eid))
-			e := gen.Schema.GetExceptionDef(eid)
-			p := gen.entityPayload(e)
-			w.Emitf("           jsonResponse(w, %d, e%s)\n", e.HttpStatus, p)
-		}
-		w.Emit("        default:\n")
-		w.Emit("            jsonResponse(w, 500, &serverError{Message: fmt.Sprint(err)})\n")
-		w.Emit("        }\n")
-		w.Emit("    } else {\n")
-		w.Emitf("        jsonResponse(w, %d, %s)\n", opStatus, resPayload)
-		w.Emit("    }\n")
-		w.Emit("}\n\n")
+		gen.generateHandler(op, adaptorName, w)
 	}
 	w.Emitf("func InitServer(impl %s, baseURL string) http.Handler {\n", serviceName)
 	w.Emitf("    adaptor := &%s{\n", adaptorName)
@@ -680,31 +682,227 @@ func (gen *Generator) GenerateServer() string {
 	w.Emitf("        log.Fatal(err)\n")
 	w.Emitf("    }\n")
 	w.Emitf("    b := u.Path\n")
-	w.Emitf("    r := mux.NewRouter()\n\n")
-	for _, op := range schema.Operations {
-		opName := gen.golangTypeName(op.Id)
-		w.Emitf("    r.HandleFunc(b+%q, func(w http.ResponseWriter, r *http.Request) {\n", op.HttpUri)
-		w.Emitf("        adaptor.%sHandler(w, r)\n", opName)
-		w.Emitf("    }).Methods(%q)\n", op.HttpMethod)
-	}
-	w.Emitf("    r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {\n")
-	w.Emit("        jsonResponse(w, 404, &serverError{Message: fmt.Sprintf(\"Not Found: %s\", r.URL.Path)})\n")
-	w.Emitf("    })\n")
+	if gen.router == "stdlib" {
+		w.Emitf("    r := http.NewServeMux()\n\n")
+		for _, op := range schema.Operations {
+			w.Emitf("    r.HandleFunc(%q+b+%q, adaptor.%sHandler)\n", op.HttpMethod+" ", routePattern(op.HttpUri, "..."), gen.golangTypeName(op.Id))
+		}
+	} else {
+		w.Emitf("    r := mux.NewRouter()\n\n")
+		//a greedy label matches any characters, newlines included
+		for _, op := range schema.Operations {
+			w.Emitf("    r.HandleFunc(b+%q, adaptor.%sHandler).Methods(%q)\n", routePattern(op.HttpUri, ":(?s).*"), gen.golangTypeName(op.Id), op.HttpMethod)
+		}
+		w.Emitf("    r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {\n")
+		w.Emit("        jsonResponse(w, 404, &serverError{Error: http.StatusText(404), Message: fmt.Sprintf(\"Not Found: %s\", r.URL.Path)})\n")
+		w.Emitf("    })\n")
+	}
 	w.Emitf("    return r\n")
 	w.Emitf("}\n\n")
 	w.Emit(serverUtilSource)
+	if gen.router == "stdlib" {
+		w.Emit(stdlibServerUtilSource)
+	} else {
+		w.Emit(gorillaServerUtilSource)
+	}
 	return w.End()
 }
 
-func (gen *Generator) entityPayload(out *model.OperationOutput) string {
-	for _, field := range out.Fields {
-		if field.HttpPayload {
-			return "." + model.Capitalize(string(field.Name))
+// routePattern returns the path pattern of an operation for the router, with the given suffix replacing the "+" of a
+// greedy label.
+func routePattern(uri string, greedy string) string {
+	if n := strings.Index(uri, "?"); n >= 0 {
+		uri = uri[:n]
+	}
+	return strings.ReplaceAll(uri, "+}", greedy+"}")
+}
+
+func (gen *Generator) generateHandler(op *model.OperationDef, adaptorName string, w *GolangWriter) {
+	opName := gen.golangTypeName(op.Id)
+	w.Emitf("func (handler *%s) %sHandler(w http.ResponseWriter, r *http.Request) {\n", adaptorName, opName)
+	arg := ""
+	if op.Input != nil {
+		w.Emitf("    req := new(%s)\n", gen.golangTypeName(op.Input.Id))
+		binding := model.HttpInputBinding(op)
+		for _, seg := range binding.Path {
+			if f := seg.Field; f != nil {
+				value := fmt.Sprintf("mux.Vars(r)[%q]", f.Name)
+				if gen.router == "stdlib" {
+					value = fmt.Sprintf("r.PathValue(%q)", f.Name)
+				}
+				gen.bindParam("path parameter", string(f.Name), value, f.Default, true, f.Type, "req."+model.Capitalize(string(f.Name)), "    ", w)
+			}
+		}
+		if len(binding.Query) > 0 {
+			w.Emit("    query := r.URL.Query()\n")
+		}
+		for _, f := range binding.Query {
+			target := "req." + model.Capitalize(string(f.Name))
+			name := string(f.HttpQuery)
+			if td := gen.Schema.GetTypeDef(f.Type); td != nil && td.Base == model.BaseType_List {
+				if f.Required {
+					w.Emitf("    if len(query[%q]) == 0 {\n", name)
+					w.Emitf("        errorResponse(w, 400, %q)\n", fmt.Sprintf("Missing required query parameter %q", name))
+					w.Emit("        return\n")
+					w.Emit("    }\n")
+				}
+				w.Emitf("    for _, v := range query[%q] {\n", name)
+				w.Emitf("        var item %s\n", gen.golangTypeRef(td.Items))
+				gen.bindParam("query parameter", name, "v", nil, true, td.Items, "item", "        ", w)
+				w.Emitf("        %s = append(%s, item)\n", target, target)
+				w.Emit("    }\n")
+			} else {
+				gen.bindParam("query parameter", name, fmt.Sprintf("query.Get(%q)", name), f.Default, f.Required, f.Type, target, "    ", w)
+			}
+		}
+		for _, f := range binding.Headers {
+			target := "req." + model.Capitalize(string(f.Name))
+			if td := gen.Schema.GetTypeDef(f.Type); td != nil && td.Base == model.BaseType_List {
+				w.Emitf("    if !bindListParam(w, \"header\", %q, r.Header.Values(%q), %v, %v, &%s) {\n", f.HttpHeader, f.HttpHeader, f.Required, gen.quotedParam(td.Items), target)
+				w.Emit("        return\n")
+				w.Emit("    }\n")
+			} else {
+				gen.bindParam("header", f.HttpHeader, fmt.Sprintf("r.Header.Get(%q)", f.HttpHeader), f.Default, f.Required, f.Type, target, "    ", w)
+			}
+		}
+		if payload := binding.Payload; payload != nil {
+			w.Emitf("    if !bindBody(w, r, %v, &req.%s) {\n", payload.Required, model.Capitalize(string(payload.Name)))
+			w.Emit("        return\n")
+			w.Emit("    }\n")
+		} else if len(binding.Members) > 0 {
+			w.Emit("    if !bindBody(w, r, false, req) {\n")
+			w.Emit("        return\n")
+			w.Emit("    }\n")
 		}
+		w.Emit("    if err := req.Validate(); err != nil {\n")
+		w.Emit("        validationResponse(w, err)\n")
+		w.Emit("        return\n")
+		w.Emit("    }\n")
+		arg = ", req"
+	}
+	hasOutput := op.Output != nil && op.Output.Id != ""
+	result := ""
+	if hasOutput {
+		result = "res, "
 	}
-	return ""
+	if gen.serviceContext() {
+		arg = "r.Context()" + arg
+	} else {
+		arg = strings.TrimPrefix(arg, ", ")
+	}
+	w.Emitf("    %serr := handler.impl.%s(%s)\n", result, opName, arg)
+	w.Emit("    if err != nil {\n")
+	var cases []*model.OperationOutput
+	for _, eid := range op.Exceptions {
+		if e := gen.Schema.GetExceptionDef(eid); e != nil {
+			cases = append(cases, e)
+		}
+	}
+	if len(cases) > 0 {
+		usesVar := false
+		for _, e := range cases {
+			if len(e.Fields) > 0 {
+				usesVar = true
+			}
+		}
+		if usesVar {
+			w.Emit("        switch e := err.(type) {\n")
+		} else {
+			w.Emit("        switch err.(type) {\n")
+		}
+		for _, e := range cases {
+			w.Emitf("        case %s:\n", gen.golangTypeRef(e.Id))
+			status := e.HttpStatus
+			if status == 0 {
+				status = 500
+			}
+			gen.writeResponse(e, "e", status, "            ", w)
+		}
+		w.Emit("        default:\n")
+		w.Emit("            jsonResponse(w, 500, &serverError{Error: http.StatusText(500), Message: fmt.Sprint(err)})\n")
+		w.Emit("        }\n")
+	} else {
+		w.Emit("        jsonResponse(w, 500, &serverError{Error: http.StatusText(500), Message: fmt.Sprint(err)})\n")
+	}
+	w.Emit("        return\n")
+	w.Emit("    }\n")
+	if hasOutput {
+		status := op.Output.HttpStatus
+		if status == 0 {
+			status = 200
+		}
+		gen.writeResponse(op.Output, "res", status, "    ", w)
+	} else {
+		status := int32(204)
+		if op.Output != nil && op.Output.HttpStatus != 0 {
+			status = op.Output.HttpStatus
+		}
+		w.Emitf("    w.WriteHeader(%d)\n", status)
+	}
+	w.Emit("}\n\n")
 }
 
+// bindParam emits the decoding of a path, query, or header parameter into the target, responding with a 400 if it is
+// missing but required, or malformed.
+func (gen *Generator) bindParam(kind, name, value string, dflt interface{}, required bool, tid model.AbsoluteIdentifier, target string, indent string, w *GolangWriter) {
+	def := ""
+	if dflt != nil {
+		def = fmt.Sprint(dflt)
+		if d, ok := dflt.(*data.Decimal); ok {
+			def = d.String()
+		}
+	}
+	w.Emitf("%sif !bindParam(w, %q, %q, %s, %q, %v, %v, &%s) {\n", indent, kind, name, value, def, required && def == "", gen.quotedParam(tid), target)
+	w.Emitf("%s    return\n", indent)
+	w.Emitf("%s}\n", indent)
+}
+
+// quotedParam is true if the text of a parameter of the type is a JSON string, rather than a JSON literal.
+func (gen *Generator) quotedParam(tid model.AbsoluteIdentifier) bool {
+	switch gen.Schema.BaseType(tid) {
+	case model.BaseType_String, model.BaseType_Timestamp, model.BaseType_Enum, model.BaseType_Blob:
+		return true
+	}
+	return false
+}
+
+// emitSetHeader emits the setting of a header to the value of expr. The items of a list are separated by commas.
+func (gen *Generator) emitSetHeader(indent, header, name, expr string, tid model.AbsoluteIdentifier, w *GolangWriter) {
+	if gen.Schema.BaseType(tid) != model.BaseType_List {
+		w.Emitf("%s%s.Set(%q, fmt.Sprint(%s))\n", indent, header, name, expr)
+		return
+	}
+	w.Emitf("%sitems := make([]string, 0, len(%s))\n", indent, expr)
+	w.Emitf("%sfor _, item := range %s {\n", indent, expr)
+	w.Emitf("%s    items = append(items, fmt.Sprint(item))\n", indent)
+	w.Emitf("%s}\n", indent)
+	w.Emitf("%s%s.Set(%q, strings.Join(items, \",\"))\n", indent, header, name)
+}
+
+// writeResponse emits the headers and payload of an output or exception held in the given variable.
+func (gen *Generator) writeResponse(out *model.OperationOutput, v string, status int32, indent string, w *GolangWriter) {
+	payload := ""
+	for _, f := range out.Fields {
+		fname := v + "." + model.Capitalize(string(f.Name))
+		if f.HttpHeader != "" {
+			w.Emitf("%sif %s {\n", indent, gen.isSet(fname, f.Type))
+			gen.emitSetHeader(indent+"    ", "w.Header()", f.HttpHeader, fname, f.Type, w)
+			w.Emitf("%s}\n", indent)
+		} else if f.HttpPayload {
+			payload = fname
+		} else if payload == "" {
+			payload = v
+		}
+	}
+	if payload == "" || status == 204 || status == 304 {
+		w.Emitf("%sw.WriteHeader(%d)\n", indent, status)
+	} else {
+		w.Emitf("%sjsonResponse(w, %d, %s)\n", indent, status, payload)
+	}
+}
+
+// GenerateClient produces a client for the service, with a method for each operation. The HTTP bindings are those of
+// the model the server is generated from, and exceptions are returned as the corresponding error types.
 func (gen *Generator) GenerateClient() string {
 	w := &GolangWriter{
 		gen: gen,
@@ -713,14 +911,229 @@ func (gen *Generator) GenerateClient() string {
 	w.Begin()
 	w.Emit("/* Generated */\n")
 	w.Emitf("\npackage %s\n", gen.pkg)
-	imports := gen.goImports(false)
-	if len(imports) > 0 {
-		w.Emit(declareImports(imports))
+	imports := map[string]bool{
+		"bytes":         true,
+		"context":       true,
+		"encoding/json": true,
+		"fmt":           true,
+		"io":            true,
+		"net/http":      true,
+		"net/url":       true,
+		"strconv":       true,
+		"strings":       true,
+		"time":          true,
+	}
+	w.Emit(declareImports(imports))
+	w.Emit("\n")
+	w.Emit(clientUtilSource)
+	for _, op := range gen.Schema.Operations {
+		gen.generateClientMethod(op, w)
 	}
-
 	return w.End()
 }
 
+func (gen *Generator) generateClientMethod(op *model.OperationDef, w *GolangWriter) {
+	opName := gen.golangTypeName(op.Id)
+	param := ""
+	if op.Input != nil {
+		param = ", req " + gen.golangTypeRef(op.Input.Id)
+	}
+	result := "error"
+	fail := "return "
+	if op.Output != nil && op.Output.Id != "" {
+		result = "(" + gen.golangTypeRef(op.Output.Id) + ", error)"
+		fail = "return nil, "
+	}
+	w.Emit("\n")
+	if op.Comment != "" {
+		w.Emit(model.FormatComment("", "// ", op.Comment, 80, false))
+	}
+	w.Emitf("func (client *Client) %s(ctx context.Context%s) %s {\n", opName, param, result)
+	binding := model.HttpInputBinding(op)
+	w.Emitf("    path := %s\n", gen.clientPath(binding))
+	w.Emit("    query := url.Values{}\n")
+	for _, f := range binding.Query {
+		fname, set := gen.inputValue(f)
+		if !f.Required {
+			w.Emitf("    if %s {\n", set)
+		} else {
+			w.Emit("    {\n")
+		}
+		if gen.Schema.BaseType(f.Type) == model.BaseType_List {
+			w.Emitf("        for _, v := range %s {\n", fname)
+			w.Emitf("            query.Add(%q, fmt.Sprint(v))\n", f.HttpQuery)
+			w.Emit("        }\n")
+		} else {
+			w.Emitf("        query.Set(%q, fmt.Sprint(%s))\n", f.HttpQuery, fname)
+		}
+		w.Emit("    }\n")
+	}
+	w.Emit("    var body []byte\n")
+	if binding.Payload != nil {
+		fname, set := gen.inputValue(binding.Payload)
+		w.Emitf("    if %s {\n", set)
+		w.Emit("        b, err := json.Marshal(" + fname + ")\n")
+		w.Emit("        if err != nil {\n")
+		w.Emitf("            %serr\n", fail)
+		w.Emit("        }\n")
+		w.Emit("        body = b\n")
+		w.Emit("    }\n")
+	} else if len(binding.Members) > 0 {
+		w.Emit("    members := make(map[string]interface{}, 0)\n")
+		for _, f := range binding.Members {
+			fname, set := gen.inputValue(f)
+			if f.Required {
+				w.Emitf("    members[%q] = %s\n", f.Name, fname)
+			} else {
+				w.Emitf("    if %s {\n", set)
+				w.Emitf("        members[%q] = %s\n", f.Name, fname)
+				w.Emit("    }\n")
+			}
+		}
+		w.Emit("    b, err := json.Marshal(members)\n")
+		w.Emit("    if err != nil {\n")
+		w.Emitf("        %serr\n", fail)
+		w.Emit("    }\n")
+		w.Emit("    body = b\n")
+	}
+	w.Emitf("    hreq, err := client.newRequest(ctx, %q, path, query, body)\n", op.HttpMethod)
+	w.Emit("    if err != nil {\n")
+	w.Emitf("        %serr\n", fail)
+	w.Emit("    }\n")
+	for _, f := range binding.Headers {
+		fname, set := gen.inputValue(f)
+		if f.Required {
+			w.Emit("    {\n")
+		} else {
+			w.Emitf("    if %s {\n", set)
+		}
+		gen.emitSetHeader("        ", "hreq.Header", f.HttpHeader, fname, f.Type, w)
+		w.Emit("    }\n")
+	}
+	w.Emit("    res, err := client.do(hreq)\n")
+	w.Emit("    if err != nil {\n")
+	w.Emitf("        %serr\n", fail)
+	w.Emit("    }\n")
+	w.Emit("    defer res.Body.Close()\n")
+	if len(op.Exceptions) > 0 {
+		w.Emit("    switch res.StatusCode {\n")
+		statuses := make(map[int32]bool, 0)
+		for _, eid := range op.Exceptions {
+			edef := gen.Schema.GetExceptionDef(eid)
+			if edef == nil || statuses[edef.HttpStatus] {
+				continue
+			}
+			statuses[edef.HttpStatus] = true
+			w.Emitf("    case %d:\n", edef.HttpStatus)
+			w.Emitf("        e := new(%s)\n", gen.golangTypeName(eid))
+			gen.decodeResponse(edef, "e", "_ = ", "        ", w)
+			w.Emitf("        %se\n", fail)
+		}
+		w.Emit("    }\n")
+	}
+	w.Emit("    if res.StatusCode < 200 || res.StatusCode >= 300 {\n")
+	w.Emitf("        %snewResponseError(res)\n", fail)
+	w.Emit("    }\n")
+	if op.Output != nil && op.Output.Id != "" {
+		w.Emitf("    out := new(%s)\n", gen.golangTypeName(op.Output.Id))
+		gen.decodeResponse(op.Output, "out", "err = ", "    ", w)
+		w.Emit("    return out, nil\n")
+	} else {
+		w.Emit("    return nil\n")
+	}
+	w.Emit("}\n")
+}
+
+// decodeResponse emits the decoding of the payload and headers of a response into the fields of the given variable.
+// Unless the assignment ignores them, decoding errors are returned. A malformed optional header is always ignored.
+func (gen *Generator) decodeResponse(out *model.OperationOutput, v string, assign string, indent string, w *GolangWriter) {
+	check := func(assign string) {
+		if assign == "err = " {
+			w.Emitf("%sif err != nil {\n", indent)
+			w.Emitf("%s    return nil, err\n", indent)
+			w.Emitf("%s}\n", indent)
+		}
+	}
+	var payload *model.OperationOutputField
+	members := false
+	for _, f := range out.Fields {
+		if f.HttpPayload {
+			payload = f
+		} else if f.HttpHeader == "" {
+			members = true
+		}
+	}
+	if payload != nil {
+		w.Emitf("%s%sdecodeBody(res, &%s.%s)\n", indent, assign, v, model.Capitalize(string(payload.Name)))
+		check(assign)
+	} else if members {
+		w.Emitf("%s%sdecodeBody(res, %s)\n", indent, assign, v)
+		check(assign)
+	}
+	for _, f := range out.Fields {
+		if f.HttpHeader != "" {
+			hassign := assign
+			if !f.Required {
+				hassign = "_ = "
+			}
+			list, quoted := false, gen.quotedParam(f.Type)
+			if td := gen.Schema.GetTypeDef(f.Type); td != nil && td.Base == model.BaseType_List {
+				list, quoted = true, gen.quotedParam(td.Items)
+			}
+			w.Emitf("%s%sdecodeHeader(res.Header, %q, %v, %v, &%s.%s)\n", indent, hassign, f.HttpHeader, quoted, list, v, model.Capitalize(string(f.Name)))
+			check(hassign)
+		}
+	}
+}
+
+// inputValue returns the expression for the value of an input field of the request, and the condition for it being set.
+func (gen *Generator) inputValue(f *model.OperationInputField) (string, string) {
+	fname := "req." + model.Capitalize(string(f.Name))
+	if gen.rangedOptional(f.Type, f.Required, inputConstraints(f)) {
+		return "*" + fname, fname + " != nil"
+	}
+	return fname, gen.isSet(fname, f.Type)
+}
+
+// clientPath returns the expression for the path of an operation, substituting its path fields.
+func (gen *Generator) clientPath(binding *model.InputBinding) string {
+	var parts []string
+	for _, seg := range binding.Path {
+		if seg.Label == "" {
+			parts = append(parts, fmt.Sprintf("%q", seg.Literal))
+		} else if seg.Greedy {
+			parts = append(parts, fmt.Sprintf("escapePath(fmt.Sprint(req.%s))", model.Capitalize(seg.Label)))
+		} else {
+			parts = append(parts, fmt.Sprintf("url.PathEscape(fmt.Sprint(req.%s))", model.Capitalize(seg.Label)))
+		}
+	}
+	return strings.Join(parts, " + ")
+}
+
+// isSet returns the condition that an optional field has a value, which is when it is not the zero value.
+// serviceContext is true if the methods of the service interface take the request's context.Context as their first
+// argument. Only the stdlib router does, so that the interface of existing gorilla servers does not change.
+func (gen *Generator) serviceContext() bool {
+	return gen.router == "stdlib"
+}
+
+func (gen *Generator) isSet(expr string, id model.AbsoluteIdentifier) string {
+	tref := gen.golangTypeRef(id)
+	if strings.HasPrefix(tref, "*") || tref == "any" {
+		return expr + " != nil"
+	}
+	switch gen.Schema.BaseType(id) {
+	case model.BaseType_List, model.BaseType_Map, model.BaseType_Blob:
+		return "len(" + expr + ") > 0"
+	case model.BaseType_Bool:
+		return expr
+	case model.BaseType_String:
+		return expr + " != \"\""
+	default:
+		return expr + " != 0"
+	}
+}
+
 func (w *GolangWriter) Begin() {
 	w.buf.Reset()
 	w.writer = bufio.NewWriter(&w.buf)
@@ -731,6 +1144,14 @@ func (w *GolangWriter) End() string {
 	return w.buf.String()
 }
 
+// Import notes a package used by the code emitted.
+func (w *GolangWriter) Import(pkg string) {
+	if w.imports == nil {
+		w.imports = make(map[string]bool, 0)
+	}
+	w.imports[pkg] = true
+}
+
 func (w *GolangWriter) Emit(s string) {
 	w.writer.WriteString(s)
 }
@@ -751,13 +1172,17 @@ func (w *GolangWriter) EmitServiceInterface() error {
 		}
 		w.Emitf("type %s interface {\n", gen.golangTypeName(schema.Id)) //!
 		for _, op := range schema.Operations {
-			in := ""
+			var params []string
+			if gen.serviceContext() {
+				params = append(params, "context.Context")
+			}
 			if op.Input != nil {
 				//in = w.golangTypeRef(op.Input.Id, false)
-				in = gen.golangTypeRef(op.Input.Id)
+				params = append(params, gen.golangTypeRef(op.Input.Id))
 			}
+			in := strings.Join(params, ", ")
 			out := "error"
-			if op.Output.Id != "" {
+			if op.Output != nil && op.Output.Id != "" {
 				//out = "(" + w.golangTypeRef(op.Output.Id, false) + ", error)"
 				out = "(" + gen.golangTypeRef(op.Output.Id) + ", error)"
 			}
@@ -773,13 +1198,15 @@ func (w *GolangWriter) EmitServiceInterface() error {
 						if !f.Required {
 							opt = ",omitempty"
 						}
-						w.Emitf("    %s %s `json:\"%s%s\"`\n", f.Name.Capitalized(), w.gen.golangTypeRef(f.Type), f.Name.Uncapitalized(), opt)
+						w.Emitf("    %s %s `json:\"%s%s\"`\n", f.Name.Capitalized(), gen.fieldTypeRef(f.Type, f.Required, inputConstraints(f)), f.Name.Uncapitalized(), opt)
 					}
-					w.Emitf("}\n\n")
+					w.Emitf("}\n")
+					gen.emitStructValidate(gen.golangTypeName(op.Input.Id), inputFields(op.Input.Fields), w)
+					w.Emit("\n")
 					w.gen.Emitted(op.Input.Id)
 				}
 			}
-			if op.Output.Id != "" {
+			if op.Output != nil && op.Output.Id != "" {
 				if !w.gen.HasEmitted(op.Output.Id) {
 					w.Emitf("type %s struct {\n", gen.golangTypeName(op.Output.Id))
 					for _, f := range op.Output.Fields {
@@ -815,12 +1242,13 @@ func (w *GolangWriter) EmitServiceInterface() error {
 							//w.Emitf("    %s %s `json:\"%s%s\"`\n", f.Name.Capitalized(), golangTypeRef(f.Type, !isRequired), f.Name.Uncapitalized(), opt)
 							w.Emitf("    %s %s `json:\"%s%s\"`\n", f.Name.Capitalized(), gen.golangTypeRef(f.Type), f.Name.Uncapitalized(), opt)
 						}
-						if msg == "" {
-							msg = "String()"
-						}
 						w.Emitf("}\n\n")
 						w.Emitf("func (e *%s) Error() string {\n", eType)
-						w.Emitf("    return e.%s\n", msg)
+						if msg != "" {
+							w.Emitf("    return e.%s\n", msg)
+						} else {
+							w.Emitf("    return %q\n", fmt.Sprintf("%s (HTTP status %d)", eType, e.HttpStatus))
+						}
 						w.Emitf("}\n\n")
 						w.gen.Emitted(e.Id)
 					}
@@ -835,68 +1263,90 @@ func (w *GolangWriter) EmitServiceInterface() error {
 var serverUtilSource = `func jsonResponse(w http.ResponseWriter, status int, entity interface{}) {
     w.Header().Set("Content-Type", "application/json")
     w.WriteHeader(status)
-    io.WriteString(w, data.Pretty(entity))
-}
-
-func param(r *http.Request, name string) string {
-    return mux.Vars(r)[name]
+    enc := json.NewEncoder(w)
+    enc.SetIndent("", "  ")
+    enc.Encode(entity)
 }
 
 func errorResponse(w http.ResponseWriter, status int, message string) {
     jsonResponse(w, status, &serverError{Error: http.StatusText(status), Message: message})
 }
 
-func stringParam(val string, def string) string {
-    if val == "" {
-        return def
+// validationResponse responds with a 400 describing each constraint violation of the request.
+func validationResponse(w http.ResponseWriter, err error) {
+    e := &serverError{Error: http.StatusText(400), Message: err.Error()}
+    if ve, ok := err.(*ValidationError); ok {
+        e.Violations = ve.Violations
     }
-    return val
+    jsonResponse(w, 400, e)
 }
 
-func timestampParam(val string, def string) *data.Timestamp {
-    if val != "" {
-        ts, err := data.ParseTimestamp(val)
-        if err != nil {
-            return &ts
+// bindParam decodes the value of a path, query, or header parameter into target. An empty value is replaced with the
+// default, if any. A missing required value or a malformed value is rejected with a 400 response.
+func bindParam(w http.ResponseWriter, kind string, name string, value string, def string, required bool, quoted bool, target interface{}) bool {
+    if value == "" {
+        value = def
+    }
+    if value == "" {
+        if required {
+            errorResponse(w, 400, fmt.Sprintf("Missing required %s %q", kind, name))
+            return false
         }
+        return true
     }
-    ts, err := data.ParseTimestamp(def)
-    if err != nil {
-        return &ts
+    if quoted {
+        value = strconv.Quote(value)
+    }
+    if err := json.Unmarshal([]byte(value), target); err != nil {
+        errorResponse(w, 400, fmt.Sprintf("Bad value for %s %q: %v", kind, name, err))
+        return false
     }
-    return nil
+    return true
 }
 
-func intParam(val string, def int64) int64 {
-    if val != "" {
-        i, err := strconv.ParseInt(val, 10, 64)
-        if err == nil {
-            return i
+// bindListParam decodes the comma-separated items of the values of a header into the slice target. A missing required
+// value or a malformed item is rejected with a 400 response.
+func bindListParam(w http.ResponseWriter, kind string, name string, values []string, required bool, quoted bool, target interface{}) bool {
+    var items []string
+    for _, v := range values {
+        for _, item := range strings.Split(v, ",") {
+            if item = strings.TrimSpace(item); item != "" {
+                if quoted {
+                    item = strconv.Quote(item)
+                }
+                items = append(items, item)
+            }
         }
     }
-    return def
-}
-
-func normalizeHeaderValue(key string, value interface{}) string {
-    switch v := value.(type) {
-    case *data.Timestamp:
-        return v.ToRfc2616String()
-    case string:
-        return v
+    if len(items) == 0 {
+        if required {
+            errorResponse(w, 400, fmt.Sprintf("Missing required %s %q", kind, name))
+            return false
+        }
+        return true
     }
-    return fmt.Sprint(value)
-}
-
-func intFromString(s string) int64 {
-	var n int64 = 0
-	_, _ = fmt.Sscanf(s, "%d", &n)
-	return n
+    if err := json.Unmarshal([]byte("["+strings.Join(items, ",")+"]"), target); err != nil {
+        errorResponse(w, 400, fmt.Sprintf("Bad value for %s %q: %v", kind, name, err))
+        return false
+    }
+    return true
 }
 
-func floatFromString(s string) float64 {
-	var n float64 = 0
-	_, _ = fmt.Sscanf(s, "%g", &n)
-	return n
+// bindBody decodes the JSON request body into target. An empty body is rejected with a 400 response if required.
+func bindBody(w http.ResponseWriter, r *http.Request, required bool, target interface{}) bool {
+    err := json.NewDecoder(r.Body).Decode(target)
+    if err == io.EOF {
+        if required {
+            errorResponse(w, 400, "Missing required request body")
+            return false
+        }
+        return true
+    }
+    if err != nil {
+        errorResponse(w, 400, fmt.Sprintf("Bad request body: %v", err))
+        return false
+    }
+    return true
 }
 
 // FoldHttpHeaderName adapts to the Go misfeature: all headers are
@@ -908,8 +1358,11 @@ func FoldHttpHeaderName(name string) string {
 type serverError struct {
     Error string  ` + "`json:\"error\"`" + `
     Message string ` + "`json:\"message\"`" + `
+    Violations []FieldViolation ` + "`json:\"violations,omitempty\"`" + `
 }
+`
 
+var gorillaServerUtilSource = `
 func WebLog(h http.Handler) http.Handler {
 	return handlers.CombinedLoggingHandler(os.Stdout, h)
 }
@@ -918,3 +1371,219 @@ func AllowCors(next http.Handler, host string) http.Handler {
    return handlers.CORS(handlers.AllowedOrigins([]string{"*"}), handlers.AllowedHeaders([]string{"Content-Type", "api_key", "Authorization"}), handlers.AllowedMethods([]string{"GET","PUT","DELETE","POST","OPTIONS"}))(next)
 }
 `
+
+var stdlibServerUtilSource = `
+type statusRecorder struct {
+    http.ResponseWriter
+    status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+    rec.status = status
+    rec.ResponseWriter.WriteHeader(status)
+}
+
+func WebLog(h http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        start := time.Now()
+        rec := &statusRecorder{ResponseWriter: w, status: 200}
+        h.ServeHTTP(rec, r)
+        log.Printf("%s %s %s %d %v", r.RemoteAddr, r.Method, r.URL.RequestURI(), rec.status, time.Since(start))
+    })
+}
+
+func AllowCors(next http.Handler, host string) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        h := w.Header()
+        h.Set("Access-Control-Allow-Origin", "*")
+        h.Set("Access-Control-Allow-Headers", "Content-Type, api_key, Authorization")
+        h.Set("Access-Control-Allow-Methods", "GET, PUT, DELETE, POST, OPTIONS")
+        if r.Method == "OPTIONS" {
+            w.WriteHeader(204)
+            return
+        }
+        next.ServeHTTP(w, r)
+    })
+}
+`
+
+var clientUtilSource = `// Doer sends HTTP requests. An *http.Client is a Doer.
+type Doer interface {
+    Do(req *http.Request) (*http.Response, error)
+}
+
+// DoerFunc adapts a function to a Doer.
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+    return f(req)
+}
+
+// Middleware wraps the Doer used to send requests, i.e. to add authentication or retries.
+type Middleware func(next Doer) Doer
+
+// Client calls the service at BaseURL. Requests are sent with HTTPClient (http.DefaultClient if nil) through the
+// Middleware, the first of which sees the request first.
+type Client struct {
+    BaseURL    string
+    HTTPClient *http.Client
+    Middleware []Middleware
+}
+
+func NewClient(baseURL string, middleware ...Middleware) *Client {
+    return &Client{
+        BaseURL:    baseURL,
+        Middleware: middleware,
+    }
+}
+
+// ResponseError is returned for a response with a status that is neither success nor one of the operation's exceptions.
+type ResponseError struct {
+    StatusCode int
+    Body       string
+}
+
+func (e *ResponseError) Error() string {
+    return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+// BearerToken is a Middleware that sets the Authorization header of each request.
+func BearerToken(token string) Middleware {
+    return func(next Doer) Doer {
+        return DoerFunc(func(req *http.Request) (*http.Response, error) {
+            req.Header.Set("Authorization", "Bearer "+token)
+            return next.Do(req)
+        })
+    }
+}
+
+// Retry is a Middleware that retries idempotent requests that failed to connect or got a 429, 502, 503 or 504
+// response, up to the given number of attempts. The delay doubles after each attempt.
+func Retry(attempts int, delay time.Duration) Middleware {
+    return func(next Doer) Doer {
+        return DoerFunc(func(req *http.Request) (*http.Response, error) {
+            for attempt := 1; ; attempt++ {
+                res, err := next.Do(req)
+                if attempt >= attempts || !retryable(req, res, err) {
+                    return res, err
+                }
+                if res != nil {
+                    io.Copy(io.Discard, res.Body)
+                    res.Body.Close()
+                }
+                select {
+                case <-req.Context().Done():
+                    return nil, req.Context().Err()
+                case <-time.After(delay):
+                }
+                delay *= 2
+                if req.GetBody != nil {
+                    body, err := req.GetBody()
+                    if err != nil {
+                        return nil, err
+                    }
+                    req.Body = body
+                }
+            }
+        })
+    }
+}
+
+func retryable(req *http.Request, res *http.Response, err error) bool {
+    switch req.Method {
+    case "GET", "HEAD", "PUT", "DELETE", "OPTIONS":
+    default:
+        return false
+    }
+    if err != nil {
+        return req.Context().Err() == nil
+    }
+    switch res.StatusCode {
+    case 429, 502, 503, 504:
+        return true
+    }
+    return false
+}
+
+func (client *Client) newRequest(ctx context.Context, method string, path string, query url.Values, body []byte) (*http.Request, error) {
+    u := strings.TrimSuffix(client.BaseURL, "/") + path
+    if len(query) > 0 {
+        u += "?" + query.Encode()
+    }
+    var r io.Reader
+    if body != nil {
+        r = bytes.NewReader(body)
+    }
+    req, err := http.NewRequestWithContext(ctx, method, u, r)
+    if err != nil {
+        return nil, err
+    }
+    req.Header.Set("Accept", "application/json")
+    if body != nil {
+        req.Header.Set("Content-Type", "application/json")
+    }
+    return req, nil
+}
+
+func (client *Client) do(req *http.Request) (*http.Response, error) {
+    var doer Doer = http.DefaultClient
+    if client.HTTPClient != nil {
+        doer = client.HTTPClient
+    }
+    for i := len(client.Middleware) - 1; i >= 0; i-- {
+        doer = client.Middleware[i](doer)
+    }
+    return doer.Do(req)
+}
+
+func newResponseError(res *http.Response) error {
+    b, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+    return &ResponseError{StatusCode: res.StatusCode, Body: string(b)}
+}
+
+func decodeBody(res *http.Response, target interface{}) error {
+    err := json.NewDecoder(res.Body).Decode(target)
+    if err == io.EOF {
+        return nil
+    }
+    return err
+}
+
+// decodeHeader decodes the value of a header into target. If list is set, the comma-separated items of all the values
+// of the header are decoded into the slice target.
+func decodeHeader(header http.Header, name string, quoted bool, list bool, target interface{}) error {
+    if !list {
+        v := header.Get(name)
+        if v == "" {
+            return nil
+        }
+        if quoted {
+            v = strconv.Quote(v)
+        }
+        return json.Unmarshal([]byte(v), target)
+    }
+    var items []string
+    for _, v := range header.Values(name) {
+        for _, item := range strings.Split(v, ",") {
+            if item = strings.TrimSpace(item); item != "" {
+                if quoted {
+                    item = strconv.Quote(item)
+                }
+                items = append(items, item)
+            }
+        }
+    }
+    if len(items) == 0 {
+        return nil
+    }
+    return json.Unmarshal([]byte("["+strings.Join(items, ",")+"]"), target)
+}
+
+func escapePath(s string) string {
+    segments := strings.Split(s, "/")
+    for i, seg := range segments {
+        segments[i] = url.PathEscape(seg)
+    }
+    return strings.Join(segments, "/")
+}
+`
@@ -0,0 +1,753 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package golang
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/boynton/api/model"
+)
+
+// fuzzArg is an argument of the requests of an operation in the fuzz tests: the value of a path parameter, query
+// parameter, or header, or the body.
+type fuzzArg struct {
+	name   string //the Go parameter name
+	field  *model.OperationInputField
+	greedy bool
+}
+
+// fuzzCase is a request in the table test of an operation, which also seeds its fuzz target.
+type fuzzCase struct {
+	name   string
+	args   []string
+	result int
+	valid  bool
+}
+
+// fuzzOperation holds what the fuzz tests of an operation are generated from: its arguments, with the input fields
+// bound to the body, and a sample input.
+type fuzzOperation struct {
+	op      *model.OperationDef
+	name    string
+	args    []*fuzzArg
+	payload *model.OperationInputField
+	members []*model.OperationInputField
+	body    bool
+	input   map[string]any
+}
+
+// GenerateFuzzTests produces the table tests and fuzz targets of the server. The server is called with a stub
+// implementation of the service returning samples of the outputs and exceptions, and sent requests built from a sample
+// input of each operation, both as is and mutated to be invalid. The server must not panic, and must respond with the
+// status and body shape of the output or exception returned, or reject the request with a 400 before calling the
+// service.
+func (gen *Generator) GenerateFuzzTests() string {
+	w := &GolangWriter{
+		gen: gen,
+	}
+	w.Begin()
+	w.Emit("/* Generated */\n")
+	w.Emitf("\npackage %s\n", gen.pkg)
+	imports := map[string]bool{
+		"bytes":             true,
+		"encoding/json":     true,
+		"net/http":          true,
+		"net/http/httptest": true,
+		"net/url":           true,
+		"strings":           true,
+		"testing":           true,
+	}
+	if gen.serviceContext() {
+		imports["context"] = true
+	}
+	w.Emit(declareImports(imports))
+	w.Emit(fuzzUtilSource)
+	var ops []*fuzzOperation
+	for _, op := range gen.Schema.Operations {
+		ops = append(ops, gen.fuzzOperation(op))
+	}
+	gen.emitFuzzService(ops, w)
+	for _, fop := range ops {
+		gen.emitFuzzTests(fop, w)
+	}
+	return w.End()
+}
+
+func (gen *Generator) fuzzOperation(op *model.OperationDef) *fuzzOperation {
+	fop := &fuzzOperation{op: op, name: gen.golangTypeName(op.Id)}
+	if op.Input == nil {
+		return fop
+	}
+	for _, f := range op.Input.Fields {
+		if f.HttpPath || f.HttpQuery != "" || f.HttpHeader != "" {
+			greedy := f.HttpPath && strings.Contains(op.HttpUri, "{"+string(f.Name)+"+}")
+			fop.args = append(fop.args, &fuzzArg{name: fuzzArgName(string(f.Name)), field: f, greedy: greedy})
+		} else if f.HttpPayload {
+			fop.payload = f
+		} else {
+			fop.members = append(fop.members, f)
+		}
+	}
+	if fop.payload != nil || len(fop.members) > 0 {
+		fop.body = true
+		fop.args = append(fop.args, &fuzzArg{name: "body"})
+	}
+	//the first sample of the input with path parameters that can be routed
+	for seed := int64(0); seed < 10; seed++ {
+		fop.input = model.NewSampler(gen.Schema, seed).SampleInput(op.Input)
+		if fop.routable(gen.fuzzArgs(fop, fop.input)) {
+			break
+		}
+	}
+	return fop
+}
+
+var fuzzReservedNames = map[string]bool{"t": true, "f": true, "c": true, "ok": true, "req": true, "result": true, "query": true, "header": true, "body": true}
+
+// fuzzArgName returns the Go parameter name for an input field.
+func fuzzArgName(name string) string {
+	name = model.Uncapitalize(name)
+	if token.IsKeyword(name) || fuzzReservedNames[name] {
+		name += "Value"
+	}
+	return name
+}
+
+// routable returns true if the path parameters can be routed, as the generated fuzzPath function decides.
+func (fop *fuzzOperation) routable(args []string) bool {
+	for i, arg := range fop.args {
+		if arg.field == nil || !arg.field.HttpPath {
+			continue
+		}
+		segments := []string{args[i]}
+		if arg.greedy {
+			segments = strings.Split(args[i], "/")
+		}
+		for _, s := range segments {
+			if s == "" || s == "." || s == ".." || strings.Contains(s, "/") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// fuzzArgs returns the arguments of a request with the given input, in the form of a sample input. A missing input
+// field has an empty argument, as does a body without any fields.
+func (gen *Generator) fuzzArgs(fop *fuzzOperation, input map[string]any) []string {
+	var args []string
+	for _, arg := range fop.args {
+		if arg.field == nil {
+			args = append(args, gen.fuzzBody(fop, input))
+			continue
+		}
+		v, ok := input[string(arg.field.Name)]
+		if !ok {
+			args = append(args, "")
+			continue
+		}
+		if items, ok := v.([]any); ok {
+			//the items of a list query parameter or header are separated by commas
+			var values []string
+			for _, item := range items {
+				values = append(values, fuzzParamString(item))
+			}
+			v = strings.Join(values, ",")
+		}
+		args = append(args, fuzzParamString(v))
+	}
+	return args
+}
+
+func fuzzParamString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// fuzzBody returns the JSON request body for the input: the payload field, or the fields without an HTTP binding.
+func (gen *Generator) fuzzBody(fop *fuzzOperation, input map[string]any) string {
+	if fop.payload != nil {
+		v, ok := input[string(fop.payload.Name)]
+		if !ok {
+			return ""
+		}
+		return gen.fuzzJSON(fop.payload.Type, v)
+	}
+	obj := make(map[string]any, 0)
+	for _, f := range fop.members {
+		if v, ok := input[string(f.Name)]; ok {
+			obj[model.Uncapitalize(string(f.Name))] = gen.fuzzValue(f.Type, v)
+		}
+	}
+	b, _ := json.Marshal(obj)
+	return string(b)
+}
+
+// fuzzJSON returns the JSON of a sample value as the generated Go types encode it.
+func (gen *Generator) fuzzJSON(tid model.AbsoluteIdentifier, v any) string {
+	b, _ := json.Marshal(gen.fuzzValue(tid, v))
+	return string(b)
+}
+
+// fuzzOutputJSON returns the JSON of a sample output or exception, as the generated struct for it encodes it.
+func (gen *Generator) fuzzOutputJSON(out *model.OperationOutput, sample map[string]any) string {
+	obj := make(map[string]any, 0)
+	for _, f := range out.Fields {
+		if v, ok := sample[string(f.Name)]; ok {
+			obj[model.Uncapitalize(string(f.Name))] = gen.fuzzValue(f.Type, v)
+		}
+	}
+	b, _ := json.Marshal(obj)
+	return string(b)
+}
+
+// fuzzValue converts a sample value to the JSON names of the generated Go types, which uncapitalize the field names.
+func (gen *Generator) fuzzValue(tid model.AbsoluteIdentifier, v any) any {
+	td := gen.Schema.GetTypeDef(tid)
+	if td == nil {
+		return v
+	}
+	switch val := v.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(val))
+		switch td.Base {
+		case model.BaseType_Struct, model.BaseType_Union:
+			for _, f := range td.Fields {
+				if fv, ok := val[string(f.Name)]; ok {
+					result[model.Uncapitalize(string(f.Name))] = gen.fuzzValue(f.Type, fv)
+				}
+			}
+		case model.BaseType_Map:
+			for k, item := range val {
+				result[k] = gen.fuzzValue(td.Items, item)
+			}
+		default:
+			return v
+		}
+		return result
+	case []any:
+		result := make([]any, len(val))
+		for i, item := range val {
+			result[i] = gen.fuzzValue(td.Items, item)
+		}
+		return result
+	}
+	return v
+}
+
+// fuzzCases returns the requests of the table test of an operation: the sample input with each result of the stub,
+// then the invalid requests derived from it.
+func (gen *Generator) fuzzCases(fop *fuzzOperation) []*fuzzCase {
+	valid := gen.fuzzArgs(fop, fop.input)
+	cases := []*fuzzCase{{name: "valid", args: valid, valid: true}}
+	for i, e := range gen.fuzzExceptions(fop.op) {
+		cases = append(cases, &fuzzCase{name: model.StripNamespace(e.Id), args: valid, result: i + 1, valid: true})
+	}
+	if fop.op.Input == nil {
+		return cases
+	}
+	invalid := func(name string, args []string) {
+		if fop.routable(args) {
+			cases = append(cases, &fuzzCase{name: name, args: args})
+		}
+	}
+	mutated := func(name string, mutate func(input map[string]any)) {
+		input := make(map[string]any, len(fop.input))
+		for k, v := range fop.input {
+			input[k] = v
+		}
+		mutate(input)
+		invalid(name, gen.fuzzArgs(fop, input))
+	}
+	if fop.body {
+		withBody := func(body string) []string {
+			args := append([]string{}, valid...)
+			args[len(args)-1] = body
+			return args
+		}
+		invalid("malformed body", withBody("{"))
+		wrong := "[]"
+		if fop.payload != nil {
+			switch gen.Schema.BaseType(fop.payload.Type) {
+			case model.BaseType_Any:
+				wrong = ""
+			case model.BaseType_List, model.BaseType_Blob:
+				wrong = "{}"
+			}
+		}
+		if wrong != "" {
+			invalid("body of the wrong type", withBody(wrong))
+		}
+	}
+	for _, f := range fop.op.Input.Fields {
+		name := string(f.Name)
+		if f.Required && !f.HttpPath && f.Default == nil && gen.canBeMissing(f.Type) {
+			mutated("missing "+name, func(input map[string]any) {
+				delete(input, name)
+			})
+		}
+		if f.HttpQuery != "" || f.HttpHeader != "" || f.HttpPath {
+			tid := f.Type
+			if td := gen.Schema.GetTypeDef(tid); td != nil && td.Base == model.BaseType_List {
+				tid = td.Items
+			}
+			switch gen.Schema.BaseType(tid) {
+			case model.BaseType_Bool, model.BaseType_Int8, model.BaseType_Int16, model.BaseType_Int32, model.BaseType_Int64, model.BaseType_Float32, model.BaseType_Float64, model.BaseType_Integer, model.BaseType_Decimal, model.BaseType_Enum:
+				mutated("bad "+name, func(input map[string]any) {
+					input[name] = "bad-value"
+				})
+			}
+		}
+		c := constraints{minValue: f.MinValue, maxValue: f.MaxValue, minSize: f.MinSize, maxSize: f.MaxSize, pattern: f.Pattern}
+		if v, desc := gen.fuzzViolation(f.Type, c, fop.input[name]); v != nil {
+			mutated(name+" "+desc, func(input map[string]any) {
+				input[name] = v
+			})
+		}
+	}
+	if fop.payload != nil {
+		//the fields of a struct payload
+		name := string(fop.payload.Name)
+		payload, _ := fop.input[name].(map[string]any)
+		if td := gen.Schema.GetTypeDef(fop.payload.Type); td != nil && td.Base == model.BaseType_Struct && payload != nil {
+			mutatedPayload := func(desc string, mutate func(obj map[string]any)) {
+				mutated(desc, func(input map[string]any) {
+					obj := make(map[string]any, len(payload))
+					for k, v := range payload {
+						obj[k] = v
+					}
+					mutate(obj)
+					input[name] = obj
+				})
+			}
+			for _, f := range td.Fields {
+				fname := string(f.Name)
+				if f.Required && gen.canBeMissing(f.Type) {
+					mutatedPayload("missing "+name+"."+fname, func(obj map[string]any) {
+						delete(obj, fname)
+					})
+				}
+				if v, desc := gen.fuzzViolation(f.Type, fieldConstraints(f), payload[fname]); v != nil {
+					mutatedPayload(name+"."+fname+" "+desc, func(obj map[string]any) {
+						obj[fname] = v
+					})
+				}
+			}
+		}
+	}
+	return cases
+}
+
+// fuzzViolation returns a value violating the constraints of a field, or those of its type, along with a description
+// of the violation, or nil if there is none to be made. The zero value of a type is not a violation, as it is not
+// checked when a field is optional.
+func (gen *Generator) fuzzViolation(tid model.AbsoluteIdentifier, c constraints, sample any) (any, string) {
+	if td := gen.Schema.GetTypeDef(tid); td != nil {
+		c = c.merge(typeConstraints(td))
+	}
+	const maxLength = 4096
+	switch gen.Schema.BaseType(tid) {
+	case model.BaseType_String:
+		if c.maxSize > 0 && c.maxSize < maxLength {
+			return strings.Repeat("x", int(c.maxSize)+1), "too long"
+		}
+		if c.minSize > 1 {
+			return strings.Repeat("x", int(c.minSize)-1), "too short"
+		}
+		if c.pattern != "" {
+			if re, err := regexp.Compile(c.pattern); err == nil {
+				for _, s := range []string{"x", "0", "-", "x y", "!~"} {
+					if !re.MatchString(s) {
+						return s, "not matching its pattern"
+					}
+				}
+			}
+		}
+	case model.BaseType_Blob:
+		if c.maxSize > 0 && c.maxSize < maxLength {
+			return base64.StdEncoding.EncodeToString(make([]byte, c.maxSize+1)), "too long"
+		}
+		if c.minSize > 1 {
+			return base64.StdEncoding.EncodeToString(make([]byte, c.minSize-1)), "too short"
+		}
+	case model.BaseType_List:
+		items, _ := sample.([]any)
+		if len(items) == 0 {
+			break
+		}
+		if c.maxSize > 0 && c.maxSize < maxLength {
+			return fuzzRepeat(items[0], int(c.maxSize)+1), "too long"
+		}
+		if c.minSize > 1 {
+			return fuzzRepeat(items[0], int(c.minSize)-1), "too short"
+		}
+	case model.BaseType_Int8, model.BaseType_Int16, model.BaseType_Int32, model.BaseType_Int64, model.BaseType_Float32, model.BaseType_Float64, model.BaseType_Integer, model.BaseType_Decimal:
+		if c.maxValue != nil {
+			if v := c.maxValue.AsFloat64() + 1; v != 0 {
+				return v, "too large"
+			}
+		}
+		if c.minValue != nil {
+			if v := c.minValue.AsFloat64() - 1; v != 0 {
+				return v, "too small"
+			}
+		}
+	}
+	return nil, ""
+}
+
+// fuzzString returns a Go string literal, raw if that saves escaping the quotes of JSON.
+func fuzzString(s string) string {
+	if strings.Contains(s, "\"") && strconv.CanBackquote(s) {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
+
+// stringParams declares the parameters as strings.
+func stringParams(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	return strings.Join(names, ", ") + " string"
+}
+
+func fuzzRepeat(item any, n int) []any {
+	items := make([]any, n)
+	for i := range items {
+		items[i] = item
+	}
+	return items
+}
+
+// fuzzExceptions returns the exceptions of an operation that the server responds with, in the order of its results
+// after the output.
+func (gen *Generator) fuzzExceptions(op *model.OperationDef) []*model.OperationOutput {
+	var excs []*model.OperationOutput
+	for _, eid := range op.Exceptions {
+		if e := gen.Schema.GetExceptionDef(eid); e != nil {
+			excs = append(excs, e)
+		}
+	}
+	return excs
+}
+
+// fuzzResult returns the declared result of an output or exception: the status, and a function returning a new value
+// of the type of the body, as the server's writeResponse responds.
+func (gen *Generator) fuzzResult(out *model.OperationOutput, status int32) string {
+	body := "nil"
+	if out != nil && out.Id != "" && status != 204 && status != 304 {
+		tname := gen.golangTypeName(out.Id)
+		for _, f := range out.Fields {
+			if f.HttpPayload {
+				body = fmt.Sprintf("func() interface{} { return &new(%s).%s }", tname, model.Capitalize(string(f.Name)))
+				break
+			} else if f.HttpHeader == "" {
+				body = fmt.Sprintf("func() interface{} { return new(%s) }", tname)
+			}
+		}
+	}
+	return fmt.Sprintf("{%d, %s}", status, body)
+}
+
+// emitFuzzService emits the stub implementation of the service. Each operation returns the result chosen by the test:
+// its sample output, or a sample of one of its exceptions.
+func (gen *Generator) emitFuzzService(ops []*fuzzOperation, w *GolangWriter) {
+	w.Emit("\n// fuzzService is a stub implementation of the service. Each operation notes that it was called, and returns the\n")
+	w.Emit("// result chosen by the test: 0 for a sample of its output, or the index of one of its exceptions in its results.\n")
+	w.Emit("type fuzzService struct {\n")
+	w.Emit("    t      *testing.T\n")
+	w.Emit("    called bool\n")
+	w.Emit("    result int\n")
+	w.Emit("}\n")
+	sampler := model.NewSampler(gen.Schema, 0)
+	for _, fop := range ops {
+		op := fop.op
+		var params []string
+		if gen.serviceContext() {
+			params = append(params, "ctx context.Context")
+		}
+		if op.Input != nil {
+			params = append(params, "req "+gen.golangTypeRef(op.Input.Id))
+		}
+		in := strings.Join(params, ", ")
+		hasOutput := op.Output != nil && op.Output.Id != ""
+		out := "error"
+		if hasOutput {
+			out = "(" + gen.golangTypeRef(op.Output.Id) + ", error)"
+		}
+		w.Emitf("\nfunc (s *fuzzService) %s(%s) %s {\n", fop.name, in, out)
+		w.Emit("    s.called = true\n")
+		excs := gen.fuzzExceptions(op)
+		if len(excs) > 0 {
+			nilResult := ""
+			if hasOutput {
+				nilResult = "nil, "
+			}
+			w.Emit("    switch s.result {\n")
+			for i, e := range excs {
+				w.Emitf("    case %d:\n", i+1)
+				w.Emitf("        e := new(%s)\n", gen.golangTypeName(e.Id))
+				w.Emitf("        fuzzDecode(s.t, %s, e)\n", fuzzString(gen.fuzzOutputJSON(e, sampler.SampleOutput(e))))
+				w.Emitf("        return %se\n", nilResult)
+			}
+			w.Emit("    }\n")
+		}
+		if hasOutput {
+			w.Emitf("    res := new(%s)\n", gen.golangTypeName(op.Output.Id))
+			w.Emitf("    fuzzDecode(s.t, %s, res)\n", fuzzString(gen.fuzzOutputJSON(op.Output, sampler.SampleOutput(op.Output))))
+			w.Emit("    return res, nil\n")
+		} else {
+			w.Emit("    return nil\n")
+		}
+		w.Emit("}\n")
+	}
+}
+
+// emitFuzzTests emits the declared results of an operation, the function building its requests, its table test, and
+// its fuzz target.
+func (gen *Generator) emitFuzzTests(fop *fuzzOperation, w *GolangWriter) {
+	op := fop.op
+	name := fop.name
+	results := "fuzz" + name + "Results"
+	status := int32(200)
+	if op.Output == nil || op.Output.Id == "" {
+		status = 204
+	}
+	if op.Output != nil && op.Output.HttpStatus != 0 {
+		status = op.Output.HttpStatus
+	}
+	w.Emitf("\nvar %s = []fuzzResult{\n", results)
+	w.Emitf("    %s,\n", gen.fuzzResult(op.Output, status))
+	for _, e := range gen.fuzzExceptions(op) {
+		status := e.HttpStatus
+		if status == 0 {
+			status = 500
+		}
+		w.Emitf("    %s,\n", gen.fuzzResult(e, status))
+	}
+	w.Emit("}\n")
+
+	//the request builder
+	var params []string
+	for _, arg := range fop.args {
+		params = append(params, arg.name)
+	}
+	routed := ""
+	for _, arg := range fop.args {
+		if arg.field != nil && arg.field.HttpPath {
+			routed = ", or returns nil if its path parameters cannot be routed"
+		}
+	}
+	w.Emitf("\n// fuzz%sRequest builds a %s request%s.\n", name, name, routed)
+	w.Emitf("func fuzz%sRequest(%s) *http.Request {\n", name, stringParams(params))
+	path := strconv.Quote(routePattern(op.HttpUri, ""))
+	query, header := "nil", "nil"
+	for _, arg := range fop.args {
+		f := arg.field
+		switch {
+		case f == nil:
+		case f.HttpPath:
+			w.Emitf("    %sPath, ok := fuzzPath(%s, %v)\n", arg.name, arg.name, arg.greedy)
+			w.Emit("    if !ok {\n")
+			w.Emit("        return nil\n")
+			w.Emit("    }\n")
+			path = strings.Replace(path, "{"+string(f.Name)+"}", "\"+"+arg.name+"Path+\"", 1)
+		case f.HttpQuery != "":
+			if query == "nil" {
+				query = "query"
+				w.Emit("    query := url.Values{}\n")
+			}
+			w.Emitf("    if %s != \"\" {\n", arg.name)
+			if gen.Schema.BaseType(f.Type) == model.BaseType_List {
+				w.Emitf("        for _, v := range strings.Split(%s, \",\") {\n", arg.name)
+				w.Emitf("            query.Add(%q, v)\n", f.HttpQuery)
+				w.Emit("        }\n")
+			} else {
+				w.Emitf("        query.Set(%q, %s)\n", f.HttpQuery, arg.name)
+			}
+			w.Emit("    }\n")
+		case f.HttpHeader != "":
+			if header == "nil" {
+				header = "header"
+				w.Emit("    header := http.Header{}\n")
+			}
+			w.Emitf("    if %s != \"\" {\n", arg.name)
+			w.Emitf("        header.Set(%q, %s)\n", f.HttpHeader, arg.name)
+			w.Emit("    }\n")
+		}
+	}
+	path = strings.TrimSuffix(strings.TrimPrefix(path, "\"\"+"), "+\"\"")
+	body := "\"\""
+	if fop.body {
+		body = "body"
+	}
+	w.Emitf("    return fuzzRequest(%q, %s, %s, %s, %s)\n", op.HttpMethod, path, query, header, body)
+	w.Emit("}\n")
+
+	//the table test
+	cases := "fuzz" + name + "Cases"
+	w.Emitf("\nvar %s = []fuzzCase{\n", cases)
+	for _, c := range gen.fuzzCases(fop) {
+		var args []string
+		for _, a := range c.args {
+			args = append(args, fuzzString(a))
+		}
+		w.Emitf("    {%q, []string{%s}, %d, %v},\n", c.name, strings.Join(args, ", "), c.result, c.valid)
+	}
+	w.Emit("}\n")
+	var caseArgs []string
+	for i := range fop.args {
+		caseArgs = append(caseArgs, fmt.Sprintf("c.args[%d]", i))
+	}
+	w.Emitf("\nfunc Test%sHandler(t *testing.T) {\n", name)
+	w.Emitf("    for _, c := range %s {\n", cases)
+	w.Emit("        c := c\n")
+	w.Emit("        t.Run(c.name, func(t *testing.T) {\n")
+	w.Emitf("            stub := fuzzServe(t, fuzz%sRequest(%s), c.result, %s)\n", name, strings.Join(caseArgs, ", "), results)
+	w.Emit("            if stub.called != c.valid {\n")
+	w.Emit("                t.Errorf(\"called the service: %v, expected %v\", stub.called, c.valid)\n")
+	w.Emit("            }\n")
+	w.Emit("        })\n")
+	w.Emit("    }\n")
+	w.Emit("}\n")
+
+	//the fuzz target, seeded with the table
+	w.Emitf("\nfunc Fuzz%s(f *testing.F) {\n", name)
+	w.Emitf("    for _, c := range %s {\n", cases)
+	w.Emitf("        f.Add(%s)\n", strings.Join(append(caseArgs, "uint8(c.result)"), ", "))
+	w.Emit("    }\n")
+	fuzzParams := "result uint8"
+	if len(params) > 0 {
+		fuzzParams = stringParams(params) + ", " + fuzzParams
+	}
+	w.Emitf("    f.Fuzz(func(t *testing.T, %s) {\n", fuzzParams)
+	w.Emitf("        req := fuzz%sRequest(%s)\n", name, strings.Join(params, ", "))
+	w.Emit("        if req == nil {\n")
+	w.Emit("            t.Skip()\n")
+	w.Emit("        }\n")
+	w.Emitf("        fuzzServe(t, req, int(result)%%len(%s), %s)\n", results, results)
+	w.Emit("    })\n")
+	w.Emit("}\n")
+}
+
+var fuzzUtilSource = `
+// fuzzResult is a declared result of an operation: the status of its output or of one of its exceptions, and a
+// function returning a new value of the type of the response body, or nil if there is none.
+type fuzzResult struct {
+    status int
+    body   func() interface{}
+}
+
+// fuzzCase is a request of the table test of an operation: the values of its path parameters, query parameters, and
+// headers, and its body, along with the result the service returns and whether the request is valid, i.e. expected
+// to reach the service.
+type fuzzCase struct {
+    name   string
+    args   []string
+    result int
+    valid  bool
+}
+
+// fuzzDecode decodes the sample result of an operation into target, failing the test if the sample does not fit the
+// type generated for it.
+func fuzzDecode(t *testing.T, s string, target interface{}) {
+    t.Helper()
+    if err := json.Unmarshal([]byte(s), target); err != nil {
+        t.Fatalf("cannot decode the sample result %s into a %T: %v", s, target, err)
+    }
+}
+
+// fuzzPath escapes the value of a path parameter. It returns false if the value cannot be routed: it is empty, a dot
+// segment, or has a slash. The segments of a greedy parameter are each escaped instead.
+func fuzzPath(value string, greedy bool) (string, bool) {
+    segments := []string{value}
+    if greedy {
+        segments = strings.Split(value, "/")
+    }
+    for i, s := range segments {
+        if s == "" || s == "." || s == ".." || strings.Contains(s, "/") {
+            return "", false
+        }
+        segments[i] = url.PathEscape(s)
+    }
+    return strings.Join(segments, "/"), true
+}
+
+func fuzzRequest(method string, path string, query url.Values, header http.Header, body string) *http.Request {
+    if len(query) > 0 {
+        path += "?" + query.Encode()
+    }
+    req := httptest.NewRequest(method, path, strings.NewReader(body))
+    for name, values := range header {
+        req.Header[name] = values
+    }
+    return req
+}
+
+// fuzzServe sends the request to the server, with the service returning the given result, and checks that the server
+// responds with that result, or rejects the request with a 400 without calling the service. The status and the shape
+// of the body must be those declared for the result. The server is called directly, so that a panic fails the test.
+func fuzzServe(t *testing.T, req *http.Request, result int, results []fuzzResult) *fuzzService {
+    t.Helper()
+    stub := &fuzzService{t: t, result: result}
+    rec := httptest.NewRecorder()
+    InitServer(stub, "").ServeHTTP(rec, req)
+    body := rec.Body.Bytes()
+    if !stub.called {
+        if rec.Code != 400 {
+            t.Fatalf("rejected the request with status %d, expected 400: %s", rec.Code, body)
+        }
+        fuzzCheckBody(t, body, new(serverError))
+        return stub
+    }
+    expected := results[result]
+    if rec.Code != expected.status {
+        t.Fatalf("responded with status %d, expected %d: %s", rec.Code, expected.status, body)
+    }
+    if expected.body == nil {
+        if len(body) > 0 {
+            t.Fatalf("responded with a body, expected none: %s", body)
+        }
+    } else {
+        fuzzCheckBody(t, body, expected.body())
+    }
+    return stub
+}
+
+// fuzzCheckBody checks that the response body decodes into the target, without any fields the target lacks.
+func fuzzCheckBody(t *testing.T, body []byte, target interface{}) {
+    t.Helper()
+    dec := json.NewDecoder(bytes.NewReader(body))
+    dec.DisallowUnknownFields()
+    if err := dec.Decode(target); err != nil {
+        t.Fatalf("responded with a body that is not a %T: %v: %s", target, err, body)
+    }
+}
+`
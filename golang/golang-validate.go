@@ -0,0 +1,489 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package golang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/boynton/api/model"
+	"github.com/boynton/data"
+)
+
+// constraints are those of a type or of a field referring to it. The constraints of a field add to those of its type.
+type constraints struct {
+	minValue *data.Decimal
+	maxValue *data.Decimal
+	minSize  int64
+	maxSize  int64
+	pattern  string
+	// nonEmpty is set where the value is known not to be empty, so that a minimum size of 1 needs no check
+	nonEmpty bool
+}
+
+// validatedField is a struct, union, or operation input field to be validated.
+type validatedField struct {
+	name     string
+	tid      model.AbsoluteIdentifier
+	required bool
+	c        constraints
+}
+
+func typeConstraints(td *model.TypeDef) constraints {
+	return constraints{minValue: td.MinValue, maxValue: td.MaxValue, minSize: td.MinSize, maxSize: td.MaxSize, pattern: td.Pattern}
+}
+
+func (c constraints) merge(other constraints) constraints {
+	if c.minValue == nil {
+		c.minValue = other.minValue
+	}
+	if c.maxValue == nil {
+		c.maxValue = other.maxValue
+	}
+	if c.minSize == 0 {
+		c.minSize = other.minSize
+	}
+	c.nonEmpty = c.nonEmpty || other.nonEmpty
+	if c.maxSize == 0 {
+		c.maxSize = other.maxSize
+	}
+	if c.pattern == "" {
+		c.pattern = other.pattern
+	}
+	return c
+}
+
+// hasValidate returns true if the Go type generated for the type definition has a Validate method. Types that are
+// inlined or pointers have none, their constraints are checked where they are used instead.
+func (gen *Generator) hasValidate(td *model.TypeDef) bool {
+	switch td.Base {
+	case model.BaseType_Struct, model.BaseType_Union, model.BaseType_Enum:
+		return true
+	case model.BaseType_Bool, model.BaseType_Int8, model.BaseType_Int16, model.BaseType_Int32, model.BaseType_Int64, model.BaseType_Float32, model.BaseType_Float64, model.BaseType_String, model.BaseType_Blob:
+		return !gen.inlinePrimitives
+	case model.BaseType_List, model.BaseType_Map:
+		return !gen.inlineSlicesAndMaps
+	}
+	return false
+}
+
+// isMissing is the negation of isSet.
+func (gen *Generator) isMissing(expr string, id model.AbsoluteIdentifier) string {
+	set := gen.isSet(expr, id)
+	switch {
+	case strings.HasSuffix(set, " != nil"):
+		return strings.TrimSuffix(set, " != nil") + " == nil"
+	case strings.HasSuffix(set, " > 0"):
+		return strings.TrimSuffix(set, " > 0") + " == 0"
+	case strings.HasSuffix(set, " != \"\""):
+		return strings.TrimSuffix(set, " != \"\"") + " == \"\""
+	case strings.HasSuffix(set, " != 0"):
+		return strings.TrimSuffix(set, " != 0") + " == 0"
+	}
+	return "!" + set
+}
+
+// canBeMissing returns true if the zero value of the Go type means that a value is absent. A required number or bool
+// cannot be checked.
+func (gen *Generator) canBeMissing(id model.AbsoluteIdentifier) bool {
+	if strings.HasPrefix(gen.golangTypeRef(id), "*") {
+		return true
+	}
+	switch gen.Schema.BaseType(id) {
+	case model.BaseType_String, model.BaseType_Enum, model.BaseType_List, model.BaseType_Map, model.BaseType_Blob, model.BaseType_Any:
+		return true
+	}
+	return false
+}
+
+// generateValidate emits the Validate method for a type definition, if its Go type can have one.
+func (gen *Generator) generateValidate(td *model.TypeDef, w *GolangWriter) {
+	if !gen.hasValidate(td) {
+		return
+	}
+	tname := gen.golangTypeName(td.Id)
+	switch td.Base {
+	case model.BaseType_Struct:
+		gen.emitStructValidate(tname, structFields(td.Fields), w)
+	case model.BaseType_Union:
+		w.Emitf("\nfunc (s *%s) Validate() error {\n", tname)
+		w.Emit("    var val validator\n")
+		w.Emit("    switch s.Variant {\n")
+		for _, f := range td.Fields {
+			fname := model.Capitalize(string(f.Name))
+			expr, path := "s."+fname, fmt.Sprintf("%q", f.Name)
+			w.Emitf("    case %sVariantTag%s:\n", tname, fname)
+			if tref := gen.golangTypeRef(f.Type); strings.HasPrefix(tref, "*") || tref == "any" {
+				//the variant is selected, but its value could still be missing
+				w.Emitf("        if %s == nil {\n", expr)
+				w.Emitf("            val.add(%s, \"is required\")\n", path)
+				w.Emit("        } else {\n")
+				w.Emit(gen.valueChecks("            ", expr, path, f.Type, fieldConstraints(f), tname+fname, w, 1))
+				w.Emit("        }\n")
+			} else {
+				w.Emit(gen.valueChecks("        ", expr, path, f.Type, fieldConstraints(f), tname+fname, w, 1))
+			}
+			//only the field of the selected variant may be set
+			for _, other := range td.Fields {
+				if other.Name == f.Name {
+					continue
+				}
+				w.Emitf("        if %s {\n", gen.isPresent("s."+model.Capitalize(string(other.Name)), other.Type))
+				w.Emitf("            val.add(%q, \"must not be set in the %s variant\")\n", other.Name, f.Name)
+				w.Emit("        }\n")
+			}
+		}
+		w.Emit("    default:\n")
+		w.Emit("        val.add(\"\", \"a variant must be set\")\n")
+		w.Emit("    }\n")
+		w.Emit("    return val.err()\n")
+		w.Emit("}\n")
+	case model.BaseType_Enum:
+		w.Emitf("\nfunc (v %s) Validate() error {\n", tname)
+		w.Emit("    var val validator\n")
+		w.Emitf("    if v <= 0 || int(v) >= len(names%s) {\n", tname)
+		w.Emitf("        val.add(\"\", \"is not a valid %s: %%d\", int(v))\n", tname)
+		w.Emit("    }\n")
+		w.Emit("    return val.err()\n")
+		w.Emit("}\n")
+	default:
+		//the value itself: primitives, lists, and maps
+		w.Emitf("\nfunc (v %s) Validate() error {\n", tname)
+		w.Emit("    var val validator\n")
+		w.Emit(gen.ownChecks("    ", "v", `""`, td, tname, w, 1))
+		w.Emit("    return val.err()\n")
+		w.Emit("}\n")
+	}
+	w.Emit(w.flushPatterns())
+}
+
+func structFields(fields model.FieldDefList) []*validatedField {
+	var result []*validatedField
+	for _, f := range fields {
+		result = append(result, &validatedField{name: string(f.Name), tid: f.Type, required: f.Required, c: fieldConstraints(f)})
+	}
+	return result
+}
+
+func fieldConstraints(f *model.FieldDef) constraints {
+	return constraints{minValue: f.MinValue, maxValue: f.MaxValue, minSize: f.MinSize, maxSize: f.MaxSize, pattern: f.Pattern}
+}
+
+func inputConstraints(f *model.OperationInputField) constraints {
+	return constraints{minValue: f.MinValue, maxValue: f.MaxValue, minSize: f.MinSize, maxSize: f.MaxSize, pattern: f.Pattern}
+}
+
+func inputFields(fields model.OperationInputFieldList) []*validatedField {
+	var result []*validatedField
+	for _, f := range fields {
+		result = append(result, &validatedField{name: string(f.Name), tid: f.Type, required: f.Required, c: inputConstraints(f)})
+	}
+	return result
+}
+
+// rangedOptional is true for an optional number with a range. Its field is a pointer, so that an explicit zero is told
+// apart from a missing value, and checked against the range.
+func (gen *Generator) rangedOptional(tid model.AbsoluteIdentifier, required bool, c constraints) bool {
+	if required || strings.HasPrefix(gen.golangTypeRef(tid), "*") {
+		return false
+	}
+	switch gen.Schema.BaseType(tid) {
+	case model.BaseType_Int8, model.BaseType_Int16, model.BaseType_Int32, model.BaseType_Int64, model.BaseType_Float32, model.BaseType_Float64:
+	default:
+		return false
+	}
+	if td := gen.Schema.GetTypeDef(tid); td != nil {
+		c = c.merge(typeConstraints(td))
+	}
+	return c.minValue != nil || c.maxValue != nil
+}
+
+// fieldTypeRef returns the Go type of a struct or operation input field.
+func (gen *Generator) fieldTypeRef(tid model.AbsoluteIdentifier, required bool, c constraints) string {
+	if gen.rangedOptional(tid, required, c) {
+		return "*" + gen.golangTypeRef(tid)
+	}
+	return gen.golangTypeRef(tid)
+}
+
+// isPresent is the condition for an optional field to be validated. An empty list, map, or blob is present, unlike a
+// nil one, so that its minimum size is checked.
+func (gen *Generator) isPresent(expr string, id model.AbsoluteIdentifier) string {
+	if !strings.HasPrefix(gen.golangTypeRef(id), "*") {
+		switch gen.Schema.BaseType(id) {
+		case model.BaseType_List, model.BaseType_Map, model.BaseType_Blob:
+			return expr + " != nil"
+		}
+	}
+	return gen.isSet(expr, id)
+}
+
+func (gen *Generator) emitStructValidate(tname string, fields []*validatedField, w *GolangWriter) {
+	w.Emitf("\nfunc (s *%s) Validate() error {\n", tname)
+	w.Emit("    var val validator\n")
+	for _, f := range fields {
+		expr := "s." + model.Capitalize(f.name)
+		path := fmt.Sprintf("%q", f.name)
+		if gen.rangedOptional(f.tid, f.required, f.c) {
+			w.Emitf("    if %s != nil {\n", expr)
+			w.Emit(gen.valueChecks("        ", "(*"+expr+")", path, f.tid, f.c, tname+model.Capitalize(f.name), w, 1))
+			w.Emit("    }\n")
+			continue
+		}
+		c := f.c
+		if gen.Schema.BaseType(f.tid) == model.BaseType_String && gen.canBeMissing(f.tid) {
+			//an empty string is a missing one
+			c.nonEmpty = true
+		}
+		if f.required && !gen.canBeMissing(f.tid) {
+			//a required value that cannot be missing is always checked, including its zero value
+			w.Emit(gen.valueChecks("    ", expr, path, f.tid, c, tname+model.Capitalize(f.name), w, 1))
+			continue
+		}
+		checks := gen.valueChecks("        ", expr, path, f.tid, c, tname+model.Capitalize(f.name), w, 1)
+		if f.required {
+			w.Emitf("    if %s {\n", gen.isMissing(expr, f.tid))
+			w.Emitf("        val.add(%s, \"is required\")\n", path)
+			if checks != "" {
+				w.Emit("    } else {\n")
+				w.Emit(checks)
+			}
+			w.Emit("    }\n")
+		} else if checks != "" {
+			w.Emitf("    if %s {\n", gen.isPresent(expr, f.tid))
+			w.Emit(checks)
+			w.Emit("    }\n")
+		}
+	}
+	w.Emit("    return val.err()\n")
+	w.Emit("}\n")
+	w.Emit(w.flushPatterns())
+}
+
+// valueChecks returns the code checking a value that is present: the given constraints, along with those of its type
+// if that type has no Validate method of its own, and the validation of its type (or items) otherwise.
+func (gen *Generator) valueChecks(indent, expr, path string, tid model.AbsoluteIdentifier, c constraints, patternName string, w *GolangWriter, depth int) string {
+	td := gen.Schema.GetTypeDef(tid)
+	if td != nil && !gen.hasValidate(td) {
+		c = c.merge(typeConstraints(td))
+	}
+	s := gen.constraintChecks(indent, expr, path, tid, c, patternName, w)
+	if td == nil {
+		return s
+	}
+	if gen.hasValidate(td) {
+		return s + fmt.Sprintf("%sval.nested(%s, %s.Validate())\n", indent, path, expr)
+	}
+	return s + gen.itemChecks(indent, expr, path, td, patternName, w, depth)
+}
+
+// ownChecks returns the code checking the value of a type with a Validate method against the constraints of the type.
+func (gen *Generator) ownChecks(indent, expr, path string, td *model.TypeDef, patternName string, w *GolangWriter, depth int) string {
+	return gen.constraintChecks(indent, expr, path, td.Id, typeConstraints(td), patternName, w) + gen.itemChecks(indent, expr, path, td, patternName, w, depth)
+}
+
+// itemChecks returns the code checking each item of a list or map, if the items need checking.
+func (gen *Generator) itemChecks(indent, expr, path string, td *model.TypeDef, patternName string, w *GolangWriter, depth int) string {
+	if td.Base != model.BaseType_List && td.Base != model.BaseType_Map {
+		return ""
+	}
+	i := fmt.Sprintf("i%d", depth)
+	item := fmt.Sprintf("item%d", depth)
+	format := "[%d]"
+	if td.Base == model.BaseType_Map {
+		format = "[%v]"
+	}
+	itemPath := fmt.Sprintf("fmt.Sprintf(%q, %s)", format, i)
+	if path != `""` {
+		itemPath = path + "+" + itemPath
+	}
+	//an item is present even if it is the zero value, so only an item that can be nil is guarded
+	guarded := strings.HasPrefix(gen.golangTypeRef(td.Items), "*") || gen.golangTypeRef(td.Items) == "any"
+	itemIndent := indent + "    "
+	if guarded {
+		itemIndent += "    "
+	}
+	checks := gen.valueChecks(itemIndent, item, itemPath, td.Items, constraints{}, patternName+"Item", w, depth+1)
+	if checks == "" {
+		return ""
+	}
+	w.Import("fmt")
+	coll := expr
+	if strings.HasPrefix(gen.golangTypeRef(td.Id), "*") && td.Base == model.BaseType_Map && expr != "v" {
+		coll = "*" + expr
+	}
+	if !guarded {
+		return fmt.Sprintf("%sfor %s, %s := range %s {\n%s%s}\n", indent, i, item, coll, checks, indent)
+	}
+	return fmt.Sprintf("%sfor %s, %s := range %s {\n%s    if %s != nil {\n%s%s    }\n%s}\n", indent, i, item, coll, indent, item, checks, indent, indent)
+}
+
+// constraintChecks returns the code checking a value against the given constraints.
+func (gen *Generator) constraintChecks(indent, expr, path string, tid model.AbsoluteIdentifier, c constraints, patternName string, w *GolangWriter) string {
+	var b strings.Builder
+	bt := gen.Schema.BaseType(tid)
+	if c.pattern != "" && bt == model.BaseType_String {
+		name := w.pattern(patternName, c.pattern)
+		b.WriteString(fmt.Sprintf("%sif !%s.MatchString(string(%s)) {\n", indent, name, expr))
+		b.WriteString(fmt.Sprintf("%s    val.add(%s, %q)\n", indent, path, "must match the pattern "+c.pattern))
+		b.WriteString(fmt.Sprintf("%s}\n", indent))
+	}
+	if c.nonEmpty && c.minSize == 1 {
+		c.minSize = 0
+	}
+	if c.minSize > 0 || c.maxSize > 0 {
+		size := ""
+		switch bt {
+		case model.BaseType_String:
+			w.Import("unicode/utf8")
+			size = fmt.Sprintf("utf8.RuneCountInString(string(%s))", expr)
+		case model.BaseType_List, model.BaseType_Blob:
+			size = fmt.Sprintf("len(%s)", expr)
+		case model.BaseType_Map:
+			if strings.HasPrefix(gen.golangTypeRef(tid), "*") && expr != "v" {
+				size = fmt.Sprintf("len(*%s)", expr)
+			} else {
+				size = fmt.Sprintf("len(%s)", expr)
+			}
+		}
+		if size != "" {
+			if c.minSize > 0 {
+				b.WriteString(fmt.Sprintf("%sif %s < %d {\n", indent, size, c.minSize))
+				b.WriteString(fmt.Sprintf("%s    val.add(%s, \"must have a size of at least %d\")\n", indent, path, c.minSize))
+				b.WriteString(fmt.Sprintf("%s}\n", indent))
+			}
+			if c.maxSize > 0 {
+				b.WriteString(fmt.Sprintf("%sif %s > %d {\n", indent, size, c.maxSize))
+				b.WriteString(fmt.Sprintf("%s    val.add(%s, \"must have a size of at most %d\")\n", indent, path, c.maxSize))
+				b.WriteString(fmt.Sprintf("%s}\n", indent))
+			}
+		}
+	}
+	if c.minValue != nil || c.maxValue != nil {
+		value := ""
+		switch bt {
+		case model.BaseType_Int8, model.BaseType_Int16, model.BaseType_Int32, model.BaseType_Int64, model.BaseType_Float32, model.BaseType_Float64:
+			value = expr
+		case model.BaseType_Decimal:
+			value = gen.bigNumber(expr, tid, "Decimal") + ".AsFloat64()"
+		case model.BaseType_Integer:
+			value = gen.bigNumber(expr, tid, "Integer") + ".AsInt64()"
+		}
+		if value != "" {
+			if c.minValue != nil {
+				b.WriteString(fmt.Sprintf("%sif %s < %s {\n", indent, value, c.minValue.String()))
+				b.WriteString(fmt.Sprintf("%s    val.add(%s, \"must be at least %s\")\n", indent, path, c.minValue.String()))
+				b.WriteString(fmt.Sprintf("%s}\n", indent))
+			}
+			if c.maxValue != nil {
+				b.WriteString(fmt.Sprintf("%sif %s > %s {\n", indent, value, c.maxValue.String()))
+				b.WriteString(fmt.Sprintf("%s    val.add(%s, \"must be at most %s\")\n", indent, path, c.maxValue.String()))
+				b.WriteString(fmt.Sprintf("%s}\n", indent))
+			}
+		}
+	}
+	return b.String()
+}
+
+// bigNumber returns the expression as the Decimal or Integer pointer it is, converting the pointer to a named type
+// declared for it.
+func (gen *Generator) bigNumber(expr string, tid model.AbsoluteIdentifier, name string) string {
+	base := "*" + gen.decimalPrefix + name
+	if gen.golangTypeRef(tid) == base {
+		return expr
+	}
+	return fmt.Sprintf("(%s)(*%s)", base, expr)
+}
+
+// pattern declares a precompiled regular expression, emitted after the current method.
+func (w *GolangWriter) pattern(name string, expr string) string {
+	w.Import("regexp")
+	name = "pattern" + name
+	w.patterns = append(w.patterns, fmt.Sprintf("var %s = regexp.MustCompile(%q)\n", name, expr))
+	return name
+}
+
+func (w *GolangWriter) flushPatterns() string {
+	if len(w.patterns) == 0 {
+		return ""
+	}
+	s := "\n" + strings.Join(w.patterns, "")
+	w.patterns = nil
+	return s
+}
+
+var validationUtilSource = `
+// FieldViolation is a constraint violation found by a Validate method. The field is the path to the offending value
+// from the validated one, i.e. "items[2].title".
+type FieldViolation struct {
+    Field   string ` + "`json:\"field,omitempty\"`" + `
+    Message string ` + "`json:\"message\"`" + `
+}
+
+// ValidationError is returned by the Validate methods, with all the violations that were found.
+type ValidationError struct {
+    Violations []FieldViolation ` + "`json:\"violations\"`" + `
+}
+
+func (e *ValidationError) Error() string {
+    var msgs []string
+    for _, v := range e.Violations {
+        if v.Field != "" {
+            msgs = append(msgs, v.Field+": "+v.Message)
+        } else {
+            msgs = append(msgs, v.Message)
+        }
+    }
+    return strings.Join(msgs, "; ")
+}
+
+type validator struct {
+    violations []FieldViolation
+}
+
+func (val *validator) add(field string, format string, args ...interface{}) {
+    val.violations = append(val.violations, FieldViolation{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (val *validator) nested(field string, err error) {
+    if err == nil {
+        return
+    }
+    ve, ok := err.(*ValidationError)
+    if !ok {
+        val.add(field, "%v", err)
+        return
+    }
+    for _, v := range ve.Violations {
+        name := field
+        if v.Field != "" {
+            if name == "" || strings.HasPrefix(v.Field, "[") {
+                name += v.Field
+            } else {
+                name += "." + v.Field
+            }
+        }
+        val.violations = append(val.violations, FieldViolation{Field: name, Message: v.Message})
+    }
+}
+
+func (val *validator) err() error {
+    if len(val.violations) == 0 {
+        return nil
+    }
+    return &ValidationError{Violations: val.violations}
+}
+`
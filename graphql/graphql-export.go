@@ -0,0 +1,446 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package graphql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/boynton/api/model"
+	"github.com/boynton/data"
+)
+
+const IndentAmount = "  "
+
+// Generator emits a GraphQL SDL schema that mirrors the REST model: the JSON of each object type is the JSON of the
+// corresponding REST entity. Operations using GET become fields of Query, all others become fields of Mutation. An
+// operation with exceptions returns a union of its result and its exception types.
+type Generator struct {
+	model.BaseGenerator
+	ns         model.Namespace
+	scalars    map[model.AbsoluteIdentifier]string
+	custom     map[string]bool
+	inputTypes map[model.AbsoluteIdentifier]bool
+	body       strings.Builder
+}
+
+var builtinScalars = map[string]bool{
+	"String":  true,
+	"Int":     true,
+	"Float":   true,
+	"Boolean": true,
+	"ID":      true,
+}
+
+func (gen *Generator) GenerateResource(rez *model.ResourceDef) error {
+	return nil
+}
+
+func (gen *Generator) GenerateOperation(op *model.OperationDef) error {
+	return nil
+}
+
+func (gen *Generator) GenerateException(op *model.OperationOutput) error {
+	return nil
+}
+
+func (gen *Generator) GenerateType(td *model.TypeDef) error {
+	return nil
+}
+
+func (gen *Generator) Generate(schema *model.Schema, config *data.Object) error {
+	err := gen.Configure(schema, config)
+	if err != nil {
+		return err
+	}
+	gen.ns = model.Namespace(config.GetString("namespace"))
+	if gen.ns == "" {
+		gen.ns = schema.ServiceNamespace()
+		if gen.ns == "" {
+			gen.ns = schema.Namespace
+		}
+	}
+	scalar := func(key, dflt string) string {
+		if s := config.GetString(key); s != "" {
+			return s
+		}
+		return dflt
+	}
+	gen.scalars = map[model.AbsoluteIdentifier]string{
+		"base#Bool":      "Boolean",
+		"base#Int8":      "Int",
+		"base#Int16":     "Int",
+		"base#Int32":     "Int",
+		"base#Float32":   "Float",
+		"base#Float64":   "Float",
+		"base#String":    "String",
+		"base#Int64":     scalar("graphql.int64", "Long"),
+		"base#Integer":   scalar("graphql.integer", "BigInt"),
+		"base#Decimal":   scalar("graphql.decimal", "Decimal"),
+		"base#Timestamp": scalar("graphql.timestamp", "DateTime"),
+		"base#Bytes":     scalar("graphql.blob", "Base64"),
+		"base#Blob":      scalar("graphql.blob", "Base64"),
+		"base#Any":       scalar("graphql.any", "JSON"),
+	}
+	gen.custom = make(map[string]bool, 0)
+	gen.findInputTypes()
+
+	queries, mutations := gen.partitionOperations()
+	err = gen.generateRoot("Query", queries)
+	if err != nil {
+		return err
+	}
+	if len(mutations) > 0 {
+		err = gen.generateRoot("Mutation", mutations)
+		if err != nil {
+			return err
+		}
+	}
+	for _, op := range gen.Operations() {
+		gen.generateOperationResult(op)
+	}
+	for _, td := range gen.Types() {
+		gen.generateTypeDef(td, false)
+	}
+	for _, td := range gen.Types() {
+		if gen.inputTypes[td.Id] {
+			gen.generateTypeDef(td, true)
+		}
+	}
+	for _, edef := range gen.Exceptions() {
+		comment := edef.Comment
+		if comment != "" {
+			comment += "\n"
+		}
+		comment += fmt.Sprintf("Returned with HTTP status %d.", edef.HttpStatus)
+		gen.generateObject(model.StripNamespace(edef.Id), comment, outputFields(edef.Fields), false)
+	}
+
+	gen.Begin()
+	if len(gen.custom) > 0 {
+		var names []string
+		for name := range gen.custom {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			gen.Emitf("scalar %s\n", name)
+		}
+		gen.Emit("\n")
+	}
+	gen.Emit(strings.TrimPrefix(gen.body.String(), "\n"))
+	return gen.Write(gen.End(), gen.FileName(string(gen.ns), ".graphql"), "")
+}
+
+func (gen *Generator) emit(s string) {
+	gen.body.WriteString(s)
+}
+
+func (gen *Generator) emitf(format string, args ...interface{}) {
+	gen.body.WriteString(fmt.Sprintf(format, args...))
+}
+
+// emitDescription emits a comment as a GraphQL description, which is a string literal preceding the definition.
+func (gen *Generator) emitDescription(comment string, indent string) {
+	comment = strings.TrimRight(comment, "\n")
+	if comment == "" {
+		return
+	}
+	if !strings.Contains(comment, "\n") {
+		gen.emitf("%s%q\n", indent, comment)
+		return
+	}
+	gen.emitf("%s\"\"\"\n", indent)
+	for _, line := range strings.Split(comment, "\n") {
+		gen.emitf("%s%s\n", indent, strings.ReplaceAll(strings.TrimRight(line, " "), `"""`, `\"""`))
+	}
+	gen.emitf("%s\"\"\"\n", indent)
+}
+
+func (gen *Generator) partitionOperations() ([]*model.OperationDef, []*model.OperationDef) {
+	var queries, mutations []*model.OperationDef
+	for _, op := range gen.Operations() {
+		if op.HttpMethod == "GET" || op.HttpMethod == "HEAD" {
+			queries = append(queries, op)
+		} else {
+			mutations = append(mutations, op)
+		}
+	}
+	return queries, mutations
+}
+
+func (gen *Generator) generateRoot(name string, ops []*model.OperationDef) error {
+	gen.emit("\n")
+	gen.emitf("type %s {\n", name)
+	if len(ops) == 0 {
+		//an object type must have at least one field, and the schema must have a Query type
+		gen.emitf("%s_empty: Boolean\n", IndentAmount)
+	}
+	for i, op := range ops {
+		if i > 0 {
+			gen.emit("\n")
+		}
+		comment := op.Comment
+		if op.HttpMethod != "" {
+			if comment != "" {
+				comment += "\n"
+			}
+			comment += fmt.Sprintf("%s %s", op.HttpMethod, op.HttpUri)
+		}
+		gen.emitDescription(comment, IndentAmount)
+		field := model.Uncapitalize(model.StripNamespace(op.Id))
+		var args []string
+		if op.Input != nil {
+			for _, f := range op.Input.Fields {
+				arg := fmt.Sprintf("%s: %s", f.Name, gen.typeRef(f.Type, true, f.Required))
+				if f.Comment != "" {
+					arg = fmt.Sprintf("%q\n%s%s", strings.ReplaceAll(f.Comment, "\n", " "), IndentAmount+IndentAmount, arg)
+				}
+				args = append(args, arg)
+			}
+		}
+		result := gen.resultType(op)
+		if len(args) == 0 {
+			gen.emitf("%s%s: %s\n", IndentAmount, field, result)
+		} else {
+			indent := IndentAmount + IndentAmount
+			gen.emitf("%s%s(\n%s%s\n%s): %s\n", IndentAmount, field, indent, strings.Join(args, "\n"+indent), IndentAmount, result)
+		}
+	}
+	gen.emit("}\n")
+	return nil
+}
+
+// resultType determines the type of the field for an operation. A single structure payload is returned as is, any
+// other output is returned as an object type named for the operation.
+func (gen *Generator) resultType(op *model.OperationDef) string {
+	opName := model.StripNamespace(op.Id)
+	if len(op.Exceptions) > 0 {
+		return opName + "Result!"
+	}
+	return gen.successType(op) + "!"
+}
+
+func (gen *Generator) successType(op *model.OperationDef) string {
+	opName := model.StripNamespace(op.Id)
+	if op.Output != nil && len(op.Output.Fields) == 1 && op.Output.Fields[0].HttpPayload {
+		if td := gen.Schema.GetTypeDef(op.Output.Fields[0].Type); td != nil && td.Base == model.BaseType_Struct {
+			return model.StripNamespace(td.Id)
+		}
+	}
+	return opName + "Output"
+}
+
+func (gen *Generator) generateOperationResult(op *model.OperationDef) {
+	opName := model.StripNamespace(op.Id)
+	success := gen.successType(op)
+	if success == opName+"Output" {
+		var fields []*field
+		comment := ""
+		if op.Output != nil {
+			fields = outputFields(op.Output.Fields)
+			comment = op.Output.Comment
+		}
+		gen.generateObject(success, comment, fields, false)
+	}
+	if len(op.Exceptions) > 0 {
+		members := []string{success}
+		var statuses []string
+		for _, eid := range op.Exceptions {
+			members = append(members, model.StripNamespace(eid))
+			if e := gen.Schema.GetExceptionDef(eid); e != nil {
+				statuses = append(statuses, fmt.Sprintf("%s (HTTP %d)", model.StripNamespace(eid), e.HttpStatus))
+			}
+		}
+		gen.emit("\n")
+		gen.emitDescription(fmt.Sprintf("The result of %s: either %s, or one of the errors %s.", model.Uncapitalize(opName), success, strings.Join(statuses, ", ")), "")
+		gen.emitf("union %sResult = %s\n", opName, strings.Join(members, " | "))
+	}
+}
+
+type field struct {
+	name     string
+	typ      model.AbsoluteIdentifier
+	comment  string
+	required bool
+}
+
+func outputFields(fields []*model.OperationOutputField) []*field {
+	var result []*field
+	for _, f := range fields {
+		comment := f.Comment
+		if f.HttpHeader != "" {
+			if comment != "" {
+				comment += "\n"
+			}
+			comment += "From the HTTP header " + f.HttpHeader
+		}
+		result = append(result, &field{name: string(f.Name), typ: f.Type, comment: comment, required: f.Required})
+	}
+	return result
+}
+
+func typeFields(fields []*model.FieldDef) []*field {
+	var result []*field
+	for _, f := range fields {
+		result = append(result, &field{name: string(f.Name), typ: f.Type, comment: f.Comment, required: f.Required})
+	}
+	return result
+}
+
+func (gen *Generator) generateObject(name string, comment string, fields []*field, input bool) {
+	keyword := "type"
+	if input {
+		keyword = "input"
+	}
+	gen.emit("\n")
+	gen.emitDescription(comment, "")
+	gen.emitf("%s %s {\n", keyword, name)
+	if len(fields) == 0 {
+		gen.emitf("%s_empty: Boolean\n", IndentAmount)
+	}
+	for _, f := range fields {
+		gen.emitDescription(f.comment, IndentAmount)
+		gen.emitf("%s%s: %s\n", IndentAmount, f.name, gen.typeRef(f.typ, input, f.required))
+	}
+	gen.emit("}\n")
+}
+
+func (gen *Generator) generateTypeDef(td *model.TypeDef, input bool) {
+	name := gen.typeName(td, input)
+	switch td.Base {
+	case model.BaseType_Struct:
+		gen.generateObject(name, td.Comment, typeFields(td.Fields), input)
+	case model.BaseType_Union:
+		if input {
+			//input unions are expressed with the @oneOf directive: exactly one field must be provided
+			gen.emit("\n")
+			gen.emitDescription(td.Comment, "")
+			gen.emitf("input %s @oneOf {\n", name)
+			for _, f := range td.Fields {
+				gen.emitDescription(f.Comment, IndentAmount)
+				gen.emitf("%s%s: %s\n", IndentAmount, f.Name, gen.typeRef(f.Type, true, false))
+			}
+			gen.emit("}\n")
+			return
+		}
+		//the members of a GraphQL union must be object types, so each variant is wrapped in an object with the
+		//variant's field, which produces the same JSON as the REST union.
+		var members []string
+		for _, f := range td.Fields {
+			members = append(members, name+model.Capitalize(string(f.Name)))
+		}
+		gen.emit("\n")
+		gen.emitDescription(td.Comment, "")
+		gen.emitf("union %s = %s\n", name, strings.Join(members, " | "))
+		for i, f := range td.Fields {
+			gen.generateObject(members[i], "", []*field{{name: string(f.Name), typ: f.Type, comment: f.Comment, required: true}}, false)
+		}
+	case model.BaseType_Enum:
+		if input {
+			return
+		}
+		gen.emit("\n")
+		gen.emitDescription(td.Comment, "")
+		gen.emitf("enum %s {\n", name)
+		for _, el := range td.Elements {
+			gen.emitDescription(el.Comment, IndentAmount)
+			gen.emitf("%s%s\n", IndentAmount, el.Symbol)
+		}
+		gen.emit("}\n")
+	}
+	//lists, maps, and primitive types are referenced inline
+}
+
+func (gen *Generator) typeName(td *model.TypeDef, input bool) string {
+	name := model.StripNamespace(td.Id)
+	if input && (td.Base == model.BaseType_Struct || td.Base == model.BaseType_Union) {
+		return name + "Input"
+	}
+	return name
+}
+
+// typeRef returns the GraphQL type reference for a model type. Lists become lists, but GraphQL has no maps, so they
+// are represented with the scalar used for documents, as is the JSON of the REST model.
+func (gen *Generator) typeRef(id model.AbsoluteIdentifier, input bool, required bool) string {
+	ref := gen.baseTypeRef(id, input)
+	if required {
+		ref += "!"
+	}
+	return ref
+}
+
+func (gen *Generator) baseTypeRef(id model.AbsoluteIdentifier, input bool) string {
+	if s, ok := gen.scalars[id]; ok {
+		return gen.useScalar(s)
+	}
+	td := gen.Schema.GetTypeDef(id)
+	if td == nil {
+		switch id {
+		case "base#List":
+			return "[" + gen.useScalar(gen.scalars["base#Any"]) + "]"
+		case "base#Map", "base#Struct":
+			return gen.useScalar(gen.scalars["base#Any"])
+		}
+		model.Warning("Undefined type %s, using %s\n", id, gen.scalars["base#Any"])
+		return gen.useScalar(gen.scalars["base#Any"])
+	}
+	switch td.Base {
+	case model.BaseType_Struct, model.BaseType_Union, model.BaseType_Enum:
+		return gen.typeName(td, input)
+	case model.BaseType_List:
+		return "[" + gen.typeRef(td.Items, input, true) + "]"
+	case model.BaseType_Map:
+		return gen.useScalar(gen.scalars["base#Any"])
+	}
+	return gen.baseTypeRef(model.AbsoluteIdentifier("base#"+td.Base.String()), input)
+}
+
+func (gen *Generator) useScalar(name string) string {
+	if !builtinScalars[name] {
+		gen.custom[name] = true
+	}
+	return name
+}
+
+// findInputTypes determines the structures and unions used by operation inputs, which need input type variants.
+func (gen *Generator) findInputTypes() {
+	gen.inputTypes = make(map[model.AbsoluteIdentifier]bool, 0)
+	var visit func(id model.AbsoluteIdentifier)
+	visit = func(id model.AbsoluteIdentifier) {
+		td := gen.Schema.GetTypeDef(id)
+		if td == nil || gen.inputTypes[id] {
+			return
+		}
+		switch td.Base {
+		case model.BaseType_Struct, model.BaseType_Union:
+			gen.inputTypes[id] = true
+			for _, f := range td.Fields {
+				visit(f.Type)
+			}
+		case model.BaseType_List, model.BaseType_Map:
+			visit(td.Items)
+		}
+	}
+	for _, op := range gen.Schema.Operations {
+		if op.Input != nil {
+			for _, f := range op.Input.Fields {
+				visit(f.Type)
+			}
+		}
+	}
+}
@@ -0,0 +1,641 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package graphql
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/boynton/api/model"
+	"github.com/boynton/data"
+)
+
+const DefaultBinding = "POST /graphql/{field}"
+
+// Import parses the GraphQL SDL files and builds a model from them. Object, interface, and input types become
+// structures (the fields of implemented interfaces are flattened into each object type), and the fields of the Query
+// and Mutation types become operations. GraphQL has no HTTP binding, so each operation is bound by a convention, which
+// is configured with "graphql.binding" (for all operations) and "graphql.queryBinding" (for Query fields), i.e.
+// "POST /graphql/{field}", the default. Arguments of operations bound to GET are query parameters, otherwise they
+// are the request payload. Operations with arguments that cannot be query parameters are bound to POST.
+func Import(paths []string, tags []string, ns string, conf *data.Object) (*model.Schema, error) {
+	mb := &ModelBuilder{
+		schema:   model.NewSchema(),
+		ns:       model.Namespace(ns),
+		conf:     conf,
+		defs:     make(map[string]*Definition, 0),
+		typeDefs: make(map[model.AbsoluteIdentifier]*model.TypeDef, 0),
+		roots: map[string]string{
+			"query":        "Query",
+			"mutation":     "Mutation",
+			"subscription": "Subscription",
+		},
+	}
+	for _, path := range paths {
+		doc, err := ParseFile(path)
+		if err != nil {
+			return nil, err
+		}
+		mb.docs = append(mb.docs, doc)
+	}
+	err := mb.Build()
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) > 0 {
+		mb.schema.Filter(tags)
+	}
+	return mb.schema, nil
+}
+
+type ModelBuilder struct {
+	schema   *model.Schema
+	ns       model.Namespace
+	conf     *data.Object
+	docs     []*Document
+	defs     map[string]*Definition
+	order    []*Definition
+	roots    map[string]string
+	scalars  map[string]model.AbsoluteIdentifier
+	aliases  map[string]string
+	variants map[string]*Field
+	typeDefs map[model.AbsoluteIdentifier]*model.TypeDef
+}
+
+var builtinScalarTypes = map[string]model.AbsoluteIdentifier{
+	"String":  "base#String",
+	"ID":      "base#String",
+	"Int":     "base#Int32",
+	"Float":   "base#Float64",
+	"Boolean": "base#Bool",
+}
+
+// wellKnownScalars are the custom scalars commonly used for types GraphQL lacks. Others become named string types.
+var wellKnownScalars = map[string]model.AbsoluteIdentifier{
+	"DateTime":   "base#Timestamp",
+	"Timestamp":  "base#Timestamp",
+	"Instant":    "base#Timestamp",
+	"Long":       "base#Int64",
+	"Int64":      "base#Int64",
+	"BigInt":     "base#Integer",
+	"BigInteger": "base#Integer",
+	"Decimal":    "base#Decimal",
+	"BigDecimal": "base#Decimal",
+	"Base64":     "base#Bytes",
+	"Bytes":      "base#Bytes",
+	"Blob":       "base#Bytes",
+	"JSON":       "base#Any",
+	"JSONObject": "base#Any",
+	"Object":     "base#Any",
+	"Any":        "base#Any",
+}
+
+// scalarOptions are the options of the graphql generator that name the scalar used for a type, which are honored here
+// so that a schema generated with them is read back the same way.
+var scalarOptions = map[string]model.AbsoluteIdentifier{
+	"graphql.timestamp": "base#Timestamp",
+	"graphql.decimal":   "base#Decimal",
+	"graphql.int64":     "base#Int64",
+	"graphql.integer":   "base#Integer",
+	"graphql.blob":      "base#Bytes",
+	"graphql.any":       "base#Any",
+}
+
+func (mb *ModelBuilder) Build() error {
+	for _, doc := range mb.docs {
+		for op, name := range doc.RootTypes {
+			mb.roots[op] = name
+		}
+		for _, def := range doc.Definitions {
+			if _, ok := mb.defs[def.Name]; ok {
+				return fmt.Errorf("%s: Duplicate definition of %s", doc.Path, def.Name)
+			}
+			mb.defs[def.Name] = def
+			mb.order = append(mb.order, def)
+		}
+	}
+	for _, doc := range mb.docs {
+		for _, ext := range doc.Extensions {
+			def, ok := mb.defs[ext.Name]
+			if !ok {
+				return fmt.Errorf("%s: Extension of undefined type %s", doc.Path, ext.Name)
+			}
+			def.Interfaces = append(def.Interfaces, ext.Interfaces...)
+			def.Directives = append(def.Directives, ext.Directives...)
+			def.Fields = append(def.Fields, ext.Fields...)
+			def.Values = append(def.Values, ext.Values...)
+			def.Members = append(def.Members, ext.Members...)
+		}
+	}
+	mb.schema.Namespace = mb.ns
+	mb.findScalars()
+	mb.findAliases()
+	mb.findVariants()
+	for _, def := range mb.order {
+		if mb.isRoot(def.Name) || mb.aliases[def.Name] != "" || mb.variants[def.Name] != nil {
+			continue
+		}
+		err := mb.importDefinition(def)
+		if err != nil {
+			return err
+		}
+	}
+	name := mb.conf.GetString("graphql.service")
+	if name == "" && len(mb.docs) > 0 {
+		name = serviceName(mb.docs[0].Path)
+	}
+	mb.schema.Id = model.AbsoluteIdentifier(string(mb.ns) + "#" + name)
+	if len(mb.docs) > 0 {
+		mb.schema.Comment = mb.docs[0].Description
+	}
+	for _, op := range []string{"query", "mutation"} {
+		root := mb.defs[mb.roots[op]]
+		if root == nil {
+			continue
+		}
+		binding := mb.conf.GetString("graphql.binding")
+		if op == "query" && mb.conf.GetString("graphql.queryBinding") != "" {
+			binding = mb.conf.GetString("graphql.queryBinding")
+		}
+		if binding == "" {
+			binding = DefaultBinding
+		}
+		for _, field := range root.Fields {
+			if field.Name == "_empty" {
+				continue
+			}
+			err := mb.importOperation(field, binding, op == "query")
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if root := mb.defs[mb.roots["subscription"]]; root != nil {
+		model.Warning("GraphQL subscriptions are not supported, the fields of %s are ignored\n", root.Name)
+	}
+	return nil
+}
+
+// serviceName derives a service name from a file name, i.e. "library-schema.graphql" becomes "LibrarySchema".
+func serviceName(path string) string {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	var sb strings.Builder
+	upper := true
+	for _, ch := range base {
+		if !isNameChar(ch) || ch == '_' {
+			upper = true
+			continue
+		}
+		if upper && ch >= 'a' && ch <= 'z' {
+			ch = ch - 'a' + 'A'
+		}
+		upper = false
+		sb.WriteRune(ch)
+	}
+	if sb.Len() == 0 || isDigit([]rune(sb.String())[0]) {
+		return "Service" + sb.String()
+	}
+	return sb.String()
+}
+
+func (mb *ModelBuilder) isRoot(name string) bool {
+	for _, root := range mb.roots {
+		if root == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (mb *ModelBuilder) typeId(name string) model.AbsoluteIdentifier {
+	return model.AbsoluteIdentifier(string(mb.ns) + "#" + name)
+}
+
+func (mb *ModelBuilder) findScalars() {
+	mb.scalars = make(map[string]model.AbsoluteIdentifier, 0)
+	for name, id := range builtinScalarTypes {
+		mb.scalars[name] = id
+	}
+	for name, id := range wellKnownScalars {
+		mb.scalars[name] = id
+	}
+	for key, id := range scalarOptions {
+		if name := mb.conf.GetString(key); name != "" {
+			mb.scalars[name] = id
+		}
+	}
+}
+
+// findAliases finds the input types that duplicate an object type or union, as the graphql generator produces for
+// the types used by operation inputs (i.e. "ItemInput" for "Item"). These are read as the type they duplicate.
+func (mb *ModelBuilder) findAliases() {
+	mb.aliases = make(map[string]string, 0)
+	for _, def := range mb.order {
+		if def.Kind != "input" || !strings.HasSuffix(def.Name, "Input") {
+			continue
+		}
+		target := mb.defs[strings.TrimSuffix(def.Name, "Input")]
+		if target == nil || mb.isRoot(target.Name) {
+			continue
+		}
+		switch target.Kind {
+		case "type":
+			if sameFields(def.Fields, target.Fields) {
+				mb.aliases[def.Name] = target.Name
+			}
+		case "union":
+			if HasDirective(def.Directives, "oneOf") && len(def.Fields) == len(target.Members) {
+				mb.aliases[def.Name] = target.Name
+			}
+		}
+	}
+}
+
+func sameFields(a, b []*Field) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, f := range a {
+		if f.Name != b[i].Name {
+			return false
+		}
+	}
+	return true
+}
+
+// findVariants finds the single field object types that wrap the variants of a union, as the graphql generator
+// produces them (i.e. "BookFormatPaper { paper: BookPaper! }" for the "paper" variant of "BookFormat"). A union of
+// such types is read as a union with those fields.
+func (mb *ModelBuilder) findVariants() {
+	mb.variants = make(map[string]*Field, 0)
+	for _, def := range mb.order {
+		if def.Kind != "union" {
+			continue
+		}
+		wrapped := make(map[string]*Field, 0)
+		for _, member := range def.Members {
+			m := mb.defs[member]
+			if m == nil || m.Kind != "type" || len(m.Fields) != 1 || member != def.Name+model.Capitalize(m.Fields[0].Name) {
+				wrapped = nil
+				break
+			}
+			wrapped[member] = m.Fields[0]
+		}
+		for member, field := range wrapped {
+			mb.variants[member] = field
+		}
+	}
+}
+
+// typeRef returns the model type for a GraphQL type reference, defining a list type if needed. Whether the reference
+// is non-null determines if the field is required, the nullability of list items is not preserved.
+func (mb *ModelBuilder) typeRef(t *TypeRef) (model.AbsoluteIdentifier, error) {
+	if t.Elem != nil {
+		items, err := mb.typeRef(t.Elem)
+		if err != nil {
+			return "", err
+		}
+		td := &model.TypeDef{
+			Id:    mb.typeId(model.StripNamespace(items) + "List"),
+			Base:  model.BaseType_List,
+			Items: items,
+		}
+		return td.Id, mb.addTypeDef(td)
+	}
+	if id, ok := mb.scalars[t.Name]; ok {
+		return id, nil
+	}
+	if alias, ok := mb.aliases[t.Name]; ok {
+		return mb.typeId(alias), nil
+	}
+	if _, ok := mb.defs[t.Name]; !ok {
+		model.Warning("Undefined GraphQL type %q, using Any\n", t.Name)
+		return "base#Any", nil
+	}
+	return mb.typeId(t.Name), nil
+}
+
+func (mb *ModelBuilder) addTypeDef(td *model.TypeDef) error {
+	if _, ok := mb.typeDefs[td.Id]; ok {
+		return nil
+	}
+	mb.typeDefs[td.Id] = td
+	return mb.schema.AddTypeDef(td)
+}
+
+func deprecation(comment string, directives []*Directive) string {
+	d := FindDirective(directives, "deprecated")
+	if d == nil {
+		return comment
+	}
+	msg := "Deprecated"
+	if reason, ok := d.Args["reason"].(string); ok && reason != "" {
+		msg += ": " + reason
+	}
+	if comment != "" {
+		return comment + "\n" + msg
+	}
+	return msg
+}
+
+func (mb *ModelBuilder) importDefinition(def *Definition) error {
+	td := &model.TypeDef{
+		Id:      mb.typeId(def.Name),
+		Comment: deprecation(def.Description, def.Directives),
+	}
+	switch def.Kind {
+	case "scalar":
+		if _, ok := mb.scalars[def.Name]; ok {
+			return nil
+		}
+		//an unknown scalar is serialized as a string in JSON
+		td.Base = model.BaseType_String
+		if td.Comment == "" {
+			td.Comment = fmt.Sprintf("The GraphQL scalar %s", def.Name)
+		}
+	case "enum":
+		td.Base = model.BaseType_Enum
+		for _, v := range def.Values {
+			td.Elements = append(td.Elements, &model.EnumElement{
+				Symbol:  model.Identifier(v.Name),
+				Comment: deprecation(v.Description, v.Directives),
+			})
+		}
+	case "union":
+		td.Base = model.BaseType_Union
+		for _, member := range def.Members {
+			if variant, ok := mb.variants[member]; ok {
+				ftype, err := mb.typeRef(variant.Type)
+				if err != nil {
+					return err
+				}
+				td.Fields = append(td.Fields, &model.FieldDef{
+					Name:    model.Identifier(variant.Name),
+					Type:    ftype,
+					Comment: variant.Description,
+				})
+				continue
+			}
+			ftype, err := mb.typeRef(&TypeRef{Name: member})
+			if err != nil {
+				return err
+			}
+			td.Fields = append(td.Fields, &model.FieldDef{
+				Name: model.Identifier(model.Uncapitalize(member)),
+				Type: ftype,
+			})
+		}
+	case "input":
+		td.Base = model.BaseType_Struct
+		if HasDirective(def.Directives, "oneOf") {
+			td.Base = model.BaseType_Union
+		}
+		fields, err := mb.importFields(def, def.Fields)
+		if err != nil {
+			return err
+		}
+		td.Fields = fields
+	default:
+		td.Base = model.BaseType_Struct
+		fields, err := mb.importFields(def, mb.flattenedFields(def))
+		if err != nil {
+			return err
+		}
+		td.Fields = fields
+	}
+	return mb.addTypeDef(td)
+}
+
+// flattenedFields returns the fields of an object or interface type, followed by any fields of the interfaces it
+// implements that it does not itself declare.
+func (mb *ModelBuilder) flattenedFields(def *Definition) []*Field {
+	fields := def.Fields
+	seen := make(map[string]bool, 0)
+	for _, f := range fields {
+		seen[f.Name] = true
+	}
+	for _, name := range def.Interfaces {
+		iface := mb.defs[name]
+		if iface == nil {
+			model.Warning("%s implements undefined interface %s\n", def.Name, name)
+			continue
+		}
+		for _, f := range mb.flattenedFields(iface) {
+			if !seen[f.Name] {
+				seen[f.Name] = true
+				fields = append(fields, f)
+			}
+		}
+	}
+	return fields
+}
+
+func (mb *ModelBuilder) importFields(def *Definition, fields []*Field) ([]*model.FieldDef, error) {
+	var result []*model.FieldDef
+	for _, f := range fields {
+		if f.Name == "_empty" {
+			continue
+		}
+		if len(f.Args) > 0 {
+			model.Warning("The arguments of %s.%s are ignored\n", def.Name, f.Name)
+		}
+		ftype, err := mb.typeRef(f.Type)
+		if err != nil {
+			return nil, err
+		}
+		fd := &model.FieldDef{
+			Name:     model.Identifier(f.Name),
+			Type:     ftype,
+			Comment:  deprecation(f.Description, f.Directives),
+			Required: f.Type.NonNull && def.Kind != "union" && !HasDirective(def.Directives, "oneOf"),
+		}
+		result = append(result, fd)
+	}
+	return result, nil
+}
+
+func parseBinding(binding string, field string) (string, string, error) {
+	parts := strings.Fields(binding)
+	if len(parts) != 2 || !strings.HasPrefix(parts[1], "/") {
+		return "", "", fmt.Errorf("Bad GraphQL HTTP binding %q, expected a method and a path like %q", binding, DefaultBinding)
+	}
+	uri := strings.ReplaceAll(parts[1], "{field}", field)
+	if strings.Contains(uri, "{") {
+		return "", "", fmt.Errorf("Bad GraphQL HTTP binding %q, only the {field} variable is supported", binding)
+	}
+	return strings.ToUpper(parts[0]), uri, nil
+}
+
+// operationId returns the id for the operation of a Query or Mutation field. Fields are often named after the
+// type they return, so if the name is taken, a query becomes "Get<Name>", and failing that "Operation" is appended.
+func (mb *ModelBuilder) operationId(field *Field, query bool) (model.AbsoluteIdentifier, error) {
+	name := model.Capitalize(field.Name)
+	if query && mb.nameTaken(name, len(field.Args) > 0) {
+		name = "Get" + name
+	}
+	if mb.nameTaken(name, len(field.Args) > 0) {
+		name = name + "Operation"
+	}
+	if mb.nameTaken(name, len(field.Args) > 0) {
+		return "", fmt.Errorf("Cannot name the operation for GraphQL field %q, %s is already defined", field.Name, name)
+	}
+	return mb.typeId(name), nil
+}
+
+// nameTaken is true if the name, or the name of an input or output derived from it, is already a type or an
+// operation.
+func (mb *ModelBuilder) nameTaken(name string, hasInput bool) bool {
+	names := []string{name, name + "Output"}
+	if hasInput {
+		names = append(names, name+"Input", name+"RequestBody")
+	}
+	for _, n := range names {
+		if mb.defs[n] != nil || mb.typeDefs[mb.typeId(n)] != nil || mb.schema.GetOperationDef(mb.typeId(n)) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// importOperation converts a field of the Query or Mutation type into an operation, using the HTTP binding
+// convention. The result of the field is the response payload. Arguments that cannot be query parameters
+// change a GET, HEAD, or DELETE binding to POST, so that they can be passed in the payload.
+func (mb *ModelBuilder) importOperation(field *Field, binding string, query bool) error {
+	method, uri, err := parseBinding(binding, field.Name)
+	if err != nil {
+		return err
+	}
+	opId, err := mb.operationId(field, query)
+	if err != nil {
+		return err
+	}
+	op := &model.OperationDef{
+		Id:         opId,
+		Comment:    deprecation(field.Description, field.Directives),
+		HttpMethod: method,
+		HttpUri:    uri,
+	}
+	if len(field.Args) > 0 {
+		op.Input = &model.OperationInput{
+			Id: model.AbsoluteIdentifier(string(opId) + "Input"),
+		}
+		args := &Definition{Kind: "input", Name: model.StripNamespace(opId)}
+		fields, err := mb.importFields(args, field.Args)
+		if err != nil {
+			return err
+		}
+		switch method {
+		case "GET", "HEAD", "DELETE":
+			for _, fd := range fields {
+				if !mb.isQueryable(fd.Type) {
+					model.Warning("Argument %q of %s cannot be bound to a query parameter, so the operation uses POST instead of %s\n", fd.Name, field.Name, method)
+					method = "POST"
+					op.HttpMethod = method
+					break
+				}
+			}
+		}
+		switch method {
+		case "GET", "HEAD", "DELETE":
+			defaults := make(map[model.Identifier]interface{}, 0)
+			for _, arg := range field.Args {
+				defaults[model.Identifier(arg.Name)] = arg.Default
+			}
+			for _, fd := range fields {
+				op.Input.Fields = append(op.Input.Fields, &model.OperationInputField{
+					Name:      fd.Name,
+					Type:      fd.Type,
+					Comment:   fd.Comment,
+					Required:  fd.Required,
+					Default:   defaults[fd.Name],
+					HttpQuery: fd.Name,
+				})
+			}
+		default:
+			if len(fields) == 1 && mb.isStructure(fields[0].Type) {
+				op.Input.Fields = append(op.Input.Fields, &model.OperationInputField{
+					Name:        fields[0].Name,
+					Type:        fields[0].Type,
+					Comment:     fields[0].Comment,
+					Required:    fields[0].Required,
+					HttpPayload: true,
+				})
+				break
+			}
+			//the arguments are the fields of the payload, as the variables of a GraphQL request are
+			td := &model.TypeDef{
+				Id:     model.AbsoluteIdentifier(string(opId) + "RequestBody"),
+				Base:   model.BaseType_Struct,
+				Fields: fields,
+			}
+			err = mb.addTypeDef(td)
+			if err != nil {
+				return err
+			}
+			op.Input.Fields = append(op.Input.Fields, &model.OperationInputField{
+				Name:        model.Identifier(model.Uncapitalize(model.StripNamespace(td.Id))),
+				Type:        td.Id,
+				Required:    true,
+				HttpPayload: true,
+			})
+		}
+	}
+	result, err := mb.typeRef(field.Type)
+	if err != nil {
+		return err
+	}
+	op.Output = &model.OperationOutput{
+		Id:         model.AbsoluteIdentifier(string(opId) + "Output"),
+		HttpStatus: 200,
+		Fields: []*model.OperationOutputField{
+			{
+				Name:        model.Identifier(model.Uncapitalize(model.StripNamespace(result))),
+				Type:        result,
+				Required:    field.Type.NonNull,
+				HttpPayload: true,
+			},
+		},
+	}
+	return mb.schema.AddOperationDef(op)
+}
+
+func (mb *ModelBuilder) isStructure(id model.AbsoluteIdentifier) bool {
+	def := mb.defs[model.StripNamespace(id)]
+	return def != nil && (def.Kind == "type" || def.Kind == "interface" || def.Kind == "input" || def.Kind == "union")
+}
+
+// isQueryable is true for scalars, enums, and lists of them.
+func (mb *ModelBuilder) isQueryable(id model.AbsoluteIdentifier) bool {
+	if id == "base#Any" {
+		return false
+	}
+	if mb.schema.IsBaseType(id) {
+		return true
+	}
+	td := mb.typeDefs[id]
+	if td == nil {
+		def := mb.defs[model.StripNamespace(id)]
+		return def != nil && (def.Kind == "enum" || def.Kind == "scalar")
+	}
+	switch td.Base {
+	case model.BaseType_List:
+		return mb.isQueryable(td.Items)
+	case model.BaseType_Struct, model.BaseType_Union, model.BaseType_Map:
+		return false
+	}
+	return true
+}
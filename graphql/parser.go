@@ -0,0 +1,652 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package graphql
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+)
+
+// Document is the parsed form of a GraphQL SDL file. The root operation types declared by a schema definition are
+// in RootTypes, keyed by operation ("query", "mutation", "subscription"). Extensions ("extend type ...") are kept
+// separately, so they can be merged after all files are read.
+type Document struct {
+	Path        string
+	Description string
+	RootTypes   map[string]string
+	Definitions []*Definition
+	Extensions  []*Definition
+}
+
+// Definition - a type system definition. Kind is one of "type", "interface", "input", "enum", "union", or "scalar".
+type Definition struct {
+	Kind        string
+	Name        string
+	Description string
+	Interfaces  []string
+	Directives  []*Directive
+	Fields      []*Field
+	Values      []*EnumValue
+	Members     []string
+}
+
+// Field - a field of an object, interface, or input type, or an argument of a field. Default is only set for
+// arguments and input fields.
+type Field struct {
+	Name        string
+	Description string
+	Args        []*Field
+	Type        *TypeRef
+	Default     interface{}
+	Directives  []*Directive
+}
+
+// TypeRef - a named type (Name is set), or a list type (Elem is set), possibly non-null.
+type TypeRef struct {
+	Name    string
+	Elem    *TypeRef
+	NonNull bool
+}
+
+func (t *TypeRef) String() string {
+	s := t.Name
+	if t.Elem != nil {
+		s = "[" + t.Elem.String() + "]"
+	}
+	if t.NonNull {
+		s += "!"
+	}
+	return s
+}
+
+type EnumValue struct {
+	Name        string
+	Description string
+	Directives  []*Directive
+}
+
+type Directive struct {
+	Name string
+	Args map[string]interface{}
+}
+
+func HasDirective(directives []*Directive, name string) bool {
+	return FindDirective(directives, name) != nil
+}
+
+func FindDirective(directives []*Directive, name string) *Directive {
+	for _, d := range directives {
+		if d.Name == name {
+			return d
+		}
+	}
+	return nil
+}
+
+type Parser struct {
+	scanner     *Scanner
+	doc         *Document
+	ungotten    *Token
+	description string
+}
+
+func ParseFile(path string) (*Document, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(path, string(b))
+}
+
+func Parse(path string, text string) (*Document, error) {
+	p := &Parser{
+		scanner: NewScanner(path, text),
+		doc: &Document{
+			Path:      path,
+			RootTypes: make(map[string]string, 0),
+		},
+	}
+	err := p.parseDocument()
+	if err != nil {
+		return nil, err
+	}
+	return p.doc, nil
+}
+
+func (p *Parser) GetToken() (Token, error) {
+	if p.ungotten != nil {
+		tok := *p.ungotten
+		p.ungotten = nil
+		return tok, nil
+	}
+	return p.scanner.Scan()
+}
+
+func (p *Parser) UngetToken(tok Token) {
+	p.ungotten = &tok
+}
+
+func (p *Parser) Error(tok Token, msg string) error {
+	return p.scanner.error(tok, msg)
+}
+
+func (p *Parser) SyntaxError(tok Token) error {
+	if tok.Type == EOF {
+		return p.Error(tok, "Unexpected end of file")
+	}
+	return p.Error(tok, fmt.Sprintf("Syntax error at %q", tok.Text))
+}
+
+func (p *Parser) expectName() (string, error) {
+	tok, err := p.GetToken()
+	if err != nil {
+		return "", err
+	}
+	if tok.Type != NAME {
+		return "", p.Error(tok, fmt.Sprintf("Expected a name, found %q", tok.Text))
+	}
+	return tok.Text, nil
+}
+
+func (p *Parser) expectPunctuator(s string) error {
+	tok, err := p.GetToken()
+	if err != nil {
+		return err
+	}
+	if tok.Type != PUNCTUATOR || tok.Text != s {
+		return p.Error(tok, fmt.Sprintf("Expected %q, found %q", s, tok.Text))
+	}
+	return nil
+}
+
+// skipPunctuator consumes the next token if it is the given punctuator, returning true if it did.
+func (p *Parser) skipPunctuator(s string) (bool, error) {
+	tok, err := p.GetToken()
+	if err != nil {
+		return false, err
+	}
+	if tok.Type == PUNCTUATOR && tok.Text == s {
+		return true, nil
+	}
+	p.UngetToken(tok)
+	return false, nil
+}
+
+// parseDescription consumes an optional description string, returning it.
+func (p *Parser) parseDescription() (string, error) {
+	tok, err := p.GetToken()
+	if err != nil {
+		return "", err
+	}
+	if tok.Type == STRING || tok.Type == BLOCK_STRING {
+		return tok.Text, nil
+	}
+	p.UngetToken(tok)
+	return "", nil
+}
+
+func (p *Parser) parseDocument() error {
+	for {
+		desc, err := p.parseDescription()
+		if err != nil {
+			return err
+		}
+		tok, err := p.GetToken()
+		if err != nil {
+			return err
+		}
+		if tok.Type == EOF {
+			return nil
+		}
+		if tok.Type != NAME {
+			return p.SyntaxError(tok)
+		}
+		extend := false
+		if tok.Text == "extend" {
+			extend = true
+			tok, err = p.GetToken()
+			if err != nil {
+				return err
+			}
+		}
+		var def *Definition
+		switch tok.Text {
+		case "schema":
+			if desc != "" {
+				p.doc.Description = desc
+			}
+			err = p.parseSchemaDefinition()
+		case "type", "interface", "input":
+			def, err = p.parseObjectDefinition(tok.Text)
+		case "enum":
+			def, err = p.parseEnumDefinition()
+		case "union":
+			def, err = p.parseUnionDefinition()
+		case "scalar":
+			def = &Definition{Kind: "scalar"}
+			def.Name, err = p.expectName()
+			if err == nil {
+				def.Directives, err = p.parseDirectives()
+			}
+		case "directive":
+			if extend {
+				return p.SyntaxError(tok)
+			}
+			err = p.skipDirectiveDefinition()
+		default:
+			return p.SyntaxError(tok)
+		}
+		if err != nil {
+			return err
+		}
+		if def != nil {
+			def.Description = desc
+			if extend {
+				p.doc.Extensions = append(p.doc.Extensions, def)
+			} else {
+				p.doc.Definitions = append(p.doc.Definitions, def)
+			}
+		}
+	}
+}
+
+func (p *Parser) parseSchemaDefinition() error {
+	_, err := p.parseDirectives()
+	if err != nil {
+		return err
+	}
+	if ok, err := p.skipPunctuator("{"); err != nil || !ok {
+		//an extension of the schema may have only directives
+		return err
+	}
+	for {
+		if ok, err := p.skipPunctuator("}"); err != nil || ok {
+			return err
+		}
+		op, err := p.expectName()
+		if err != nil {
+			return err
+		}
+		err = p.expectPunctuator(":")
+		if err != nil {
+			return err
+		}
+		name, err := p.expectName()
+		if err != nil {
+			return err
+		}
+		p.doc.RootTypes[op] = name
+	}
+}
+
+func (p *Parser) parseObjectDefinition(kind string) (*Definition, error) {
+	def := &Definition{Kind: kind}
+	var err error
+	def.Name, err = p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	tok, err := p.GetToken()
+	if err != nil {
+		return nil, err
+	}
+	if tok.Type == NAME && tok.Text == "implements" {
+		p.skipPunctuator("&")
+		for {
+			iface, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			def.Interfaces = append(def.Interfaces, iface)
+			if ok, err := p.skipPunctuator("&"); err != nil {
+				return nil, err
+			} else if !ok {
+				break
+			}
+		}
+	} else {
+		p.UngetToken(tok)
+	}
+	def.Directives, err = p.parseDirectives()
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := p.skipPunctuator("{"); err != nil || !ok {
+		return def, err
+	}
+	for {
+		if ok, err := p.skipPunctuator("}"); err != nil {
+			return nil, err
+		} else if ok {
+			return def, nil
+		}
+		field, err := p.parseField(kind != "input")
+		if err != nil {
+			return nil, err
+		}
+		def.Fields = append(def.Fields, field)
+	}
+}
+
+// parseField parses a field definition, or (when allowArgs is false) an input value definition, which may have a
+// default value instead of arguments.
+func (p *Parser) parseField(allowArgs bool) (*Field, error) {
+	field := &Field{}
+	var err error
+	field.Description, err = p.parseDescription()
+	if err != nil {
+		return nil, err
+	}
+	field.Name, err = p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if allowArgs {
+		if ok, err := p.skipPunctuator("("); err != nil {
+			return nil, err
+		} else if ok {
+			for {
+				if ok, err := p.skipPunctuator(")"); err != nil {
+					return nil, err
+				} else if ok {
+					break
+				}
+				arg, err := p.parseField(false)
+				if err != nil {
+					return nil, err
+				}
+				field.Args = append(field.Args, arg)
+			}
+		}
+	}
+	err = p.expectPunctuator(":")
+	if err != nil {
+		return nil, err
+	}
+	field.Type, err = p.parseTypeRef()
+	if err != nil {
+		return nil, err
+	}
+	if !allowArgs {
+		if ok, err := p.skipPunctuator("="); err != nil {
+			return nil, err
+		} else if ok {
+			field.Default, err = p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	field.Directives, err = p.parseDirectives()
+	if err != nil {
+		return nil, err
+	}
+	return field, nil
+}
+
+func (p *Parser) parseTypeRef() (*TypeRef, error) {
+	t := &TypeRef{}
+	if ok, err := p.skipPunctuator("["); err != nil {
+		return nil, err
+	} else if ok {
+		t.Elem, err = p.parseTypeRef()
+		if err != nil {
+			return nil, err
+		}
+		err = p.expectPunctuator("]")
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		t.Name, err = p.expectName()
+		if err != nil {
+			return nil, err
+		}
+	}
+	ok, err := p.skipPunctuator("!")
+	if err != nil {
+		return nil, err
+	}
+	t.NonNull = ok
+	return t, nil
+}
+
+func (p *Parser) parseEnumDefinition() (*Definition, error) {
+	def := &Definition{Kind: "enum"}
+	var err error
+	def.Name, err = p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	def.Directives, err = p.parseDirectives()
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := p.skipPunctuator("{"); err != nil || !ok {
+		return def, err
+	}
+	for {
+		if ok, err := p.skipPunctuator("}"); err != nil {
+			return nil, err
+		} else if ok {
+			return def, nil
+		}
+		v := &EnumValue{}
+		v.Description, err = p.parseDescription()
+		if err != nil {
+			return nil, err
+		}
+		v.Name, err = p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		v.Directives, err = p.parseDirectives()
+		if err != nil {
+			return nil, err
+		}
+		def.Values = append(def.Values, v)
+	}
+}
+
+func (p *Parser) parseUnionDefinition() (*Definition, error) {
+	def := &Definition{Kind: "union"}
+	var err error
+	def.Name, err = p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	def.Directives, err = p.parseDirectives()
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := p.skipPunctuator("="); err != nil || !ok {
+		return def, err
+	}
+	p.skipPunctuator("|")
+	for {
+		member, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		def.Members = append(def.Members, member)
+		if ok, err := p.skipPunctuator("|"); err != nil {
+			return nil, err
+		} else if !ok {
+			return def, nil
+		}
+	}
+}
+
+func (p *Parser) parseDirectives() ([]*Directive, error) {
+	var directives []*Directive
+	for {
+		if ok, err := p.skipPunctuator("@"); err != nil {
+			return nil, err
+		} else if !ok {
+			return directives, nil
+		}
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		d := &Directive{Name: name}
+		if ok, err := p.skipPunctuator("("); err != nil {
+			return nil, err
+		} else if ok {
+			d.Args = make(map[string]interface{}, 0)
+			for {
+				if ok, err := p.skipPunctuator(")"); err != nil {
+					return nil, err
+				} else if ok {
+					break
+				}
+				arg, err := p.expectName()
+				if err != nil {
+					return nil, err
+				}
+				err = p.expectPunctuator(":")
+				if err != nil {
+					return nil, err
+				}
+				d.Args[arg], err = p.parseValue()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		directives = append(directives, d)
+	}
+}
+
+// parseValue parses a constant value. Enum values are returned as strings, and null as nil.
+func (p *Parser) parseValue() (interface{}, error) {
+	tok, err := p.GetToken()
+	if err != nil {
+		return nil, err
+	}
+	switch tok.Type {
+	case STRING, BLOCK_STRING:
+		return tok.Text, nil
+	case NUMBER:
+		n, err := strconv.ParseFloat(tok.Text, 64)
+		if err != nil {
+			return nil, p.Error(tok, fmt.Sprintf("Bad number %q", tok.Text))
+		}
+		return n, nil
+	case NAME:
+		switch tok.Text {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return tok.Text, nil
+	case PUNCTUATOR:
+		switch tok.Text {
+		case "[":
+			list := make([]interface{}, 0)
+			for {
+				if ok, err := p.skipPunctuator("]"); err != nil {
+					return nil, err
+				} else if ok {
+					return list, nil
+				}
+				v, err := p.parseValue()
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+		case "{":
+			obj := make(map[string]interface{}, 0)
+			for {
+				if ok, err := p.skipPunctuator("}"); err != nil {
+					return nil, err
+				} else if ok {
+					return obj, nil
+				}
+				key, err := p.expectName()
+				if err != nil {
+					return nil, err
+				}
+				err = p.expectPunctuator(":")
+				if err != nil {
+					return nil, err
+				}
+				obj[key], err = p.parseValue()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return nil, p.SyntaxError(tok)
+}
+
+// skipDirectiveDefinition skips "directive @name(args) repeatable on LOCATION | ...", which has no model equivalent.
+func (p *Parser) skipDirectiveDefinition() error {
+	err := p.expectPunctuator("@")
+	if err != nil {
+		return err
+	}
+	_, err = p.expectName()
+	if err != nil {
+		return err
+	}
+	if ok, err := p.skipPunctuator("("); err != nil {
+		return err
+	} else if ok {
+		for {
+			if ok, err := p.skipPunctuator(")"); err != nil {
+				return err
+			} else if ok {
+				break
+			}
+			_, err := p.parseField(false)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	tok, err := p.GetToken()
+	if err != nil {
+		return err
+	}
+	if tok.Type == NAME && tok.Text == "repeatable" {
+		tok, err = p.GetToken()
+		if err != nil {
+			return err
+		}
+	}
+	if tok.Type != NAME || tok.Text != "on" {
+		return p.SyntaxError(tok)
+	}
+	p.skipPunctuator("|")
+	for {
+		_, err := p.expectName()
+		if err != nil {
+			return err
+		}
+		if ok, err := p.skipPunctuator("|"); err != nil {
+			return err
+		} else if !ok {
+			return nil
+		}
+	}
+}
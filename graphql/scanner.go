@@ -0,0 +1,283 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type TokenType int
+
+const (
+	UNDEFINED TokenType = iota
+	EOF
+	NAME
+	NUMBER
+	STRING
+	BLOCK_STRING
+	PUNCTUATOR
+)
+
+type Token struct {
+	Type  TokenType
+	Text  string
+	Line  int
+	Start int
+}
+
+func (tokenType TokenType) String() string {
+	switch tokenType {
+	case EOF:
+		return "EOF"
+	case NAME:
+		return "NAME"
+	case NUMBER:
+		return "NUMBER"
+	case STRING:
+		return "STRING"
+	case BLOCK_STRING:
+		return "BLOCK_STRING"
+	case PUNCTUATOR:
+		return "PUNCTUATOR"
+	}
+	return "UNDEFINED"
+}
+
+func (tok Token) String() string {
+	if tok.Type == EOF {
+		return "EOF"
+	}
+	return fmt.Sprintf("<%v %q %d:%d>", tok.Type, tok.Text, tok.Line, tok.Start)
+}
+
+// Scanner tokenizes GraphQL SDL. Commas and "#" comments are insignificant, and are skipped. String values are
+// returned unescaped, and block strings have their common indentation removed.
+type Scanner struct {
+	path   string
+	src    []rune
+	pos    int
+	line   int
+	column int
+}
+
+func NewScanner(path string, text string) *Scanner {
+	return &Scanner{path: path, src: []rune(text), line: 1, column: 1}
+}
+
+func (s *Scanner) peek(offset int) rune {
+	if s.pos+offset < len(s.src) {
+		return s.src[s.pos+offset]
+	}
+	return 0
+}
+
+func (s *Scanner) advance() rune {
+	ch := s.src[s.pos]
+	s.pos++
+	if ch == '\n' {
+		s.line++
+		s.column = 1
+	} else {
+		s.column++
+	}
+	return ch
+}
+
+func (s *Scanner) Scan() (Token, error) {
+	for s.pos < len(s.src) {
+		ch := s.peek(0)
+		if ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == ',' || ch == '\uFEFF' {
+			s.advance()
+			continue
+		}
+		if ch == '#' {
+			for s.pos < len(s.src) && s.peek(0) != '\n' {
+				s.advance()
+			}
+			continue
+		}
+		tok := Token{Line: s.line, Start: s.column}
+		switch {
+		case ch == '"' && s.peek(1) == '"' && s.peek(2) == '"':
+			text, err := s.scanBlockString(tok)
+			if err != nil {
+				return tok, err
+			}
+			tok.Type = BLOCK_STRING
+			tok.Text = text
+		case ch == '"':
+			text, err := s.scanString(tok)
+			if err != nil {
+				return tok, err
+			}
+			tok.Type = STRING
+			tok.Text = text
+		case ch == '.' && s.peek(1) == '.' && s.peek(2) == '.':
+			s.advance()
+			s.advance()
+			s.advance()
+			tok.Type = PUNCTUATOR
+			tok.Text = "..."
+		case isDigit(ch) || ch == '-' && isDigit(s.peek(1)):
+			start := s.pos
+			s.advance()
+			for s.pos < len(s.src) && (isNameChar(s.peek(0)) || s.peek(0) == '.' ||
+				(s.peek(0) == '-' || s.peek(0) == '+') && (s.src[s.pos-1] == 'e' || s.src[s.pos-1] == 'E')) {
+				s.advance()
+			}
+			tok.Type = NUMBER
+			tok.Text = string(s.src[start:s.pos])
+		case isNameStart(ch):
+			start := s.pos
+			s.advance()
+			for s.pos < len(s.src) && isNameChar(s.peek(0)) {
+				s.advance()
+			}
+			tok.Type = NAME
+			tok.Text = string(s.src[start:s.pos])
+		case strings.ContainsRune("!$&()/:=@[]{}|", ch):
+			s.advance()
+			tok.Type = PUNCTUATOR
+			tok.Text = string(ch)
+		default:
+			return tok, s.error(tok, fmt.Sprintf("Unexpected character %q", ch))
+		}
+		return tok, nil
+	}
+	return Token{Type: EOF, Line: s.line, Start: s.column}, nil
+}
+
+func (s *Scanner) scanString(tok Token) (string, error) {
+	s.advance()
+	var sb strings.Builder
+	for {
+		if s.pos >= len(s.src) || s.peek(0) == '\n' {
+			return "", s.error(tok, "Unterminated string")
+		}
+		ch := s.advance()
+		if ch == '"' {
+			return sb.String(), nil
+		}
+		if ch != '\\' {
+			sb.WriteRune(ch)
+			continue
+		}
+		if s.pos >= len(s.src) {
+			return "", s.error(tok, "Unterminated string")
+		}
+		esc := s.advance()
+		switch esc {
+		case 'n':
+			sb.WriteRune('\n')
+		case 't':
+			sb.WriteRune('\t')
+		case 'r':
+			sb.WriteRune('\r')
+		case 'b':
+			sb.WriteRune('\b')
+		case 'f':
+			sb.WriteRune('\f')
+		case 'u':
+			digits := ""
+			for i := 0; i < 4 && s.pos < len(s.src); i++ {
+				digits += string(s.advance())
+			}
+			v, err := strconv.ParseUint(digits, 16, 32)
+			if err != nil {
+				return "", s.error(tok, "Bad escape in string")
+			}
+			sb.WriteRune(rune(v))
+		default:
+			sb.WriteRune(esc)
+		}
+	}
+}
+
+func (s *Scanner) scanBlockString(tok Token) (string, error) {
+	s.advance()
+	s.advance()
+	s.advance()
+	var sb strings.Builder
+	for {
+		if s.pos >= len(s.src) {
+			return "", s.error(tok, "Unterminated block string")
+		}
+		if s.peek(0) == '"' && s.peek(1) == '"' && s.peek(2) == '"' {
+			s.advance()
+			s.advance()
+			s.advance()
+			return blockStringValue(sb.String()), nil
+		}
+		if s.peek(0) == '\\' && s.peek(1) == '"' && s.peek(2) == '"' && s.peek(3) == '"' {
+			s.advance()
+			sb.WriteString(`"""`)
+			s.advance()
+			s.advance()
+			s.advance()
+			continue
+		}
+		sb.WriteRune(s.advance())
+	}
+}
+
+// blockStringValue removes the common indentation, and leading and trailing blank lines, of a block string.
+func blockStringValue(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	common := -1
+	for i, line := range lines {
+		if i == 0 {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < len(line) && (common < 0 || indent < common) {
+			common = indent
+		}
+	}
+	if common > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= common {
+				lines[i] = lines[i][common:]
+			} else {
+				lines[i] = strings.TrimLeft(lines[i], " \t")
+			}
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (s *Scanner) error(tok Token, msg string) error {
+	return fmt.Errorf("*** %s:%d:%d: %s", s.path, tok.Line, tok.Start, msg)
+}
+
+func isDigit(ch rune) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isNameStart(ch rune) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch == '_'
+}
+
+func isNameChar(ch rune) bool {
+	return isNameStart(ch) || isDigit(ch)
+}
@@ -291,11 +291,15 @@ func (gen *Generator) GenerateOperation(op *model.OperationDef) error {
 	gen.Emitf("<pre class=\"mknohighlight\"><code>\n")
 	gen.Emitf("%s\n\n", gen.generateApiOperation(op))
 	gen.Emitf("</code></pre>\n")
-	if gen.showExamples && len(op.Examples) > 0 {
+	if gen.showExamples {
+		examples := op.Examples
+		if len(examples) == 0 {
+			examples = []*model.OperationExample{model.NewSampler(gen.Schema, 0).SampleExample(op)}
+		}
 		gen.Emitf("\n<h4>%s Examples</h4>\n", opId)
 		hgen := new(httptrace.Generator)
 		hgen.Configure(gen.Schema, nil)
-		for _, ex := range op.Examples {
+		for _, ex := range examples {
 			snippet, err := hgen.EmitHttpTrace(op, ex)
 			if err != nil {
 				return err
@@ -0,0 +1,175 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package httptrace
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/boynton/api/model"
+	"github.com/boynton/data"
+)
+
+// Header is a single HTTP header. Headers are kept in the order of the fields they are bound to.
+type Header struct {
+	Name  string
+	Value string
+}
+
+// Exchange is the HTTP request and response of an operation example, as the HTTP bindings of the operation map the
+// example's input and output (or error) to them.
+type Exchange struct {
+	Title string
+	// Method is the HTTP method of the request
+	Method string
+	// Uri is the path, with the path labels substituted, and the query of the request. It is percent-encoded.
+	Uri string
+	// RequestHeaders are the headers bound to input fields
+	RequestHeaders []Header
+	// RequestBody is the payload, or the object of the unbound input fields, or nil for no body
+	RequestBody any
+	// Output is the operation output, or the exception, of the response
+	Output *model.OperationOutput
+	// Status is the status of the response
+	Status int
+	// ResponseHeaders are the headers bound to output fields
+	ResponseHeaders []Header
+	// ResponseBody is the payload, or the object of the unbound output fields, or nil for no body
+	ResponseBody any
+}
+
+// ExampleExchange maps an example of the operation to its HTTP request and response.
+func ExampleExchange(schema *model.Schema, op *model.OperationDef, example *model.OperationExample) (*Exchange, error) {
+	ex := &Exchange{Title: example.Title, Method: op.HttpMethod}
+	path := op.HttpUri
+	if n := strings.Index(path, "?"); n >= 0 {
+		path = path[:n]
+	}
+	var query []string
+	reqExample := data.AsObject(example.Input)
+	var members *data.Object
+	if op.Input != nil {
+		for _, in := range op.Input.Fields {
+			inName := string(in.Name)
+			if !reqExample.Has(inName) {
+				continue
+			}
+			v := reqExample.Get(inName)
+			if in.HttpQuery != "" {
+				//a list is the repeated parameter
+				for _, sv := range stringValues(v) {
+					query = append(query, url.QueryEscape(string(in.HttpQuery))+"="+url.QueryEscape(sv))
+				}
+			} else if in.HttpPath {
+				sv := stringValue(v)
+				path = strings.Replace(path, "{"+inName+"}", url.PathEscape(sv), -1)
+				path = strings.Replace(path, "{"+inName+"+}", escapeSegments(sv), -1)
+			} else if in.HttpHeader != "" {
+				ex.RequestHeaders = append(ex.RequestHeaders, Header{Name: in.HttpHeader, Value: headerValue(v)})
+			} else if in.HttpPayload {
+				ex.RequestBody = v
+			} else {
+				if members == nil {
+					members = data.NewObject()
+				}
+				members.Put(inName, v)
+			}
+		}
+	}
+	if ex.RequestBody == nil && members != nil {
+		ex.RequestBody = members
+	}
+	ex.Uri = path
+	if len(query) > 0 {
+		ex.Uri = path + "?" + strings.Join(query, "&")
+	}
+	ex.Output = op.Output
+	exout := example.Output
+	if example.Error != nil {
+		ex.Output = schema.GetExceptionDef(example.Error.ShapeId)
+		exout = example.Error.Output
+		if ex.Output == nil {
+			return nil, fmt.Errorf("The error of example %q of %s is not defined: %s", example.Title, op.Id, example.Error.ShapeId)
+		}
+	}
+	ex.Status = 200
+	if ex.Output == nil {
+		return ex, nil
+	}
+	if ex.Output.HttpStatus != 0 {
+		ex.Status = int(ex.Output.HttpStatus)
+	}
+	respExample := data.AsObject(exout)
+	members = nil
+	for _, o := range ex.Output.Fields {
+		oName := string(o.Name)
+		if !respExample.Has(oName) {
+			continue
+		}
+		v := respExample.Get(oName)
+		if o.HttpHeader != "" {
+			ex.ResponseHeaders = append(ex.ResponseHeaders, Header{Name: o.HttpHeader, Value: headerValue(v)})
+		} else if o.HttpPayload {
+			ex.ResponseBody = v
+		} else {
+			if members == nil {
+				members = data.NewObject()
+			}
+			members.Put(oName, v)
+		}
+	}
+	if ex.ResponseBody == nil && members != nil {
+		ex.ResponseBody = members
+	}
+	return ex, nil
+}
+
+// escapeSegments percent-encodes the value of a greedy path label, keeping its slashes.
+func escapeSegments(s string) string {
+	segments := strings.Split(s, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+// stringValues are the strings of a list's items, or of a single value.
+func stringValues(v any) []string {
+	switch items := v.(type) {
+	case []any:
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			values = append(values, stringValue(item))
+		}
+		return values
+	case []string:
+		return items
+	}
+	return []string{stringValue(v)}
+}
+
+// headerValue is the value of a header, with the items of a list separated by commas.
+func headerValue(v any) string {
+	return strings.Join(stringValues(v), ", ")
+}
+
+func stringValue(s interface{}) string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprint(s)
+}
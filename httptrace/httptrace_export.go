@@ -3,16 +3,25 @@ package httptrace
 import (
 	"fmt"
 	"net/http"
-	"os"
+	"net/url"
 	"strings"
 	"time"
 
-	"github.com/boynton/data"
 	"github.com/boynton/api/model"
+	"github.com/boynton/data"
 )
 
+// FileSuffix is the suffix of the file written for each operation when an output directory is given.
+const FileSuffix = ".http"
+
+// Generator prints the example exchanges of all operations as HTTP/1.1 traces. With an output directory, it writes a
+// file of requests per operation instead, in the format of the REST clients of VS Code and JetBrains IDEs: each request
+// is titled by a "###" line, and the responses are left to the client.
 type Generator struct {
 	model.BaseGenerator
+	host     string
+	basePath string
+	baseUrl  string
 }
 
 func (gen *Generator) Generate(schema *model.Schema, config *data.Object) error {
@@ -20,14 +29,43 @@ func (gen *Generator) Generate(schema *model.Schema, config *data.Object) error
 	if err != nil {
 		return err
 	}
+	gen.host, gen.basePath, gen.baseUrl = "", "", ""
+	if schema.Base != "" {
+		base, err := url.Parse(schema.Base)
+		if err != nil {
+			return fmt.Errorf("Bad service base URL %q: %v", schema.Base, err)
+		}
+		gen.host = base.Host
+		gen.basePath = strings.TrimSuffix(base.EscapedPath(), "/")
+		if base.Host != "" {
+			gen.baseUrl = base.Scheme + "://" + base.Host
+		}
+	}
 	for _, op := range gen.Operations() {
+		if len(op.Examples) == 0 {
+			continue
+		}
+		if gen.OutDir == "" {
+			for _, example := range op.Examples {
+				snippet, err := gen.EmitHttpTrace(op, example)
+				if err != nil {
+					return err
+				}
+				fmt.Println(snippet)
+			}
+			continue
+		}
+		var requests []string
 		for _, example := range op.Examples {
-			snippet, err := gen.EmitHttpTrace(op, example)
+			request, err := gen.EmitHttpRequest(op, example)
 			if err != nil {
-				fmt.Println("*** Error:", err)
-				os.Exit(1)
+				return err
 			}
-			fmt.Println(snippet)
+			requests = append(requests, request)
+		}
+		fname := model.StripNamespace(op.Id) + FileSuffix
+		if err := gen.Write(strings.Join(requests, "\n"), fname, ""); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -49,86 +87,70 @@ func (gen *Generator) GenerateException(op *model.OperationOutput) error {
 	return nil
 }
 
-func stringValue(s interface{}) string {
-	if s == nil {
-		return ""
+// EmitHttpTrace returns the request and response of the example, as HTTP/1.1 messages preceded by the example's title.
+func (gen *Generator) EmitHttpTrace(op *model.OperationDef, example *model.OperationExample) (string, error) {
+	ex, err := ExampleExchange(gen.Schema, op, example)
+	if err != nil {
+		return "", err
 	}
-	return fmt.Sprint(s)
-}
+	body := "#\n# " + ex.Title + "\n#\n"
+	body = body + ex.Method + " " + gen.basePath + ex.Uri + " HTTP/1.1\n" + gen.requestHeaders(ex, true) + "\n"
+	if ex.RequestBody != nil {
+		body = body + data.Pretty(ex.RequestBody)
+	}
+	body = body + "\n"
 
-func (gen *Generator) EmitHttpTrace(op *model.OperationDef, example *model.OperationExample) (string, error) {
-	body := "#\n# " + example.Title + "\n#\n"
-	method := op.HttpMethod
-	path := op.HttpUri
 	bodyExample := ""
-	headers := ""
-	query := ""
-	reqExample := data.AsObject(example.Input)
-	for _, in := range op.Input.Fields {
-		inName := string(in.Name)
-		ex := reqExample.Get(string(inName))
-		if in.HttpQuery != "" {
-			sex := stringValue(ex)
-			if sex != "" {
-				query = query + "&" + inName + "=" + sex
-			}
-		} else if in.HttpPath {
-			sex := stringValue(ex)
-			if in.HttpPath {
-				// urlEncode?
-			}
-			path = strings.Replace(path, "{" + inName + "}", sex, -1)
-		} else if in.HttpHeader != "" {
-			sex := stringValue(ex)
-			headers = headers + in.HttpHeader + ": " + sex + "\n"
-		} else { //in.HttpPayload
-			bodyExample = data.Pretty(ex)
-		}
+	if ex.ResponseBody != nil {
+		bodyExample = data.Pretty(ex.ResponseBody)
 	}
-	if query != "" {
-		query = "?" + query[1:]
+	headers := "Content-Type: application/json; charset=utf-8\n"
+	headers = headers + "Date: " + dateHeader() + "\n"
+	respMessage := fmt.Sprintf("HTTP/1.1 %d %s\n", ex.Status, http.StatusText(ex.Status))
+	for _, h := range ex.ResponseHeaders {
+		headers = headers + h.Name + ": " + h.Value + "\n"
 	}
-	path = path + query
-	headers = headers + "Accept: application/json\n"
-    if op.HttpMethod == "POST" || op.HttpMethod == "PUT" {
-        headers = headers + "Content-Type: application/json; charset=utf-8\n"
-		headers = headers + fmt.Sprintf("Content-Length: %d\n", len(bodyExample))
+	headers = fmt.Sprintf("Content-Length: %d\n", len(bodyExample)) + headers
+	body = body + respMessage + headers + "\n" + bodyExample + "\n"
+	return body, nil
+}
+
+// EmitHttpRequest returns the request of the example in the format of the REST clients: a "###" title line, and the
+// request with the absolute URL of the service's base, if it has one. The client computes the Content-Length.
+func (gen *Generator) EmitHttpRequest(op *model.OperationDef, example *model.OperationExample) (string, error) {
+	ex, err := ExampleExchange(gen.Schema, op, example)
+	if err != nil {
+		return "", err
+	}
+	s := "### " + ex.Title + "\n"
+	s = s + ex.Method + " " + gen.baseUrl + gen.basePath + ex.Uri + " HTTP/1.1\n" + gen.requestHeaders(ex, false)
+	if ex.RequestBody != nil {
+		s = s + "\n" + data.Pretty(ex.RequestBody)
 	}
-	s := method + " " + path + " HTTP/1.1\n" + headers + "\n" + bodyExample
-	body = body + s + "\n"
+	return s, nil
+}
 
-	out := op.Output
-	exout := example.Output
-	if example.Error != nil {
-		out = gen.Schema.GetExceptionDef(example.Error.ShapeId)
-		exout = example.Error.Output
-		if out == nil {
-			panic("error in example is not defined: " + example.Error.ShapeId)
-		}
+// requestHeaders are the headers of the request: the Host of an origin-form request, the headers bound to the input,
+// and those of the JSON content.
+func (gen *Generator) requestHeaders(ex *Exchange, originForm bool) string {
+	headers := ""
+	if originForm && gen.host != "" {
+		headers = "Host: " + gen.host + "\n"
 	}
-	status := out.HttpStatus
-	bodyExample = ""
-	headers = "Content-Type: application/json; charset=utf-8\n"
-	headers = headers + "Date: " + dateHeader() + "\n"
-	respMessage := fmt.Sprintf("HTTP/1.1 %d %s\n", status, http.StatusText(int(status)))
-	respExample := data.AsObject(exout)
-	for _, o := range out.Fields {
-		oName := string(o.Name)
-		ex := respExample.Get(string(oName))
-		if o.HttpHeader != "" {
-			sex := stringValue(ex)
-			headers = headers + o.HttpHeader + ": " + sex + "\n"
-		} else { //body
-			bodyExample = data.Pretty(ex)
+	for _, h := range ex.RequestHeaders {
+		headers = headers + h.Name + ": " + h.Value + "\n"
+	}
+	headers = headers + "Accept: application/json\n"
+	if ex.RequestBody != nil {
+		headers = headers + "Content-Type: application/json; charset=utf-8\n"
+		if originForm {
+			headers = headers + fmt.Sprintf("Content-Length: %d\n", len(data.Pretty(ex.RequestBody)))
 		}
 	}
-	headers = fmt.Sprintf("Content-Length: %d\n", len(bodyExample)) + headers
-	s = respMessage + headers + "\n" + bodyExample
-	body = body + s + "\n"
-	return body, nil
+	return headers
 }
 
 func dateHeader() string {
-	t := time.Now()
-	return t.Format("Mon, 2 Jan 2006 15:04:05 GMT")
+	t := time.Now().UTC()
+	return t.Format(http.TimeFormat)
 }
@@ -0,0 +1,273 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package jsonschema
+
+import (
+	"strings"
+
+	"github.com/boynton/api/model"
+	"github.com/boynton/data"
+)
+
+const SchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+const FileSuffix = ".schema.json"
+
+// Generator writes the types of the model as JSON Schema (draft 2020-12). By default all types are bundled in the
+// "$defs" of a single document, with "-a jsonschema.split" each type is written to its own file, and they refer to
+// each other by relative "$ref"s. Operations are not represented.
+type Generator struct {
+	model.BaseGenerator
+	ns      string
+	split   bool
+	baseUri string
+}
+
+func (gen *Generator) GenerateResource(rez *model.ResourceDef) error {
+	return nil
+}
+
+func (gen *Generator) GenerateOperation(op *model.OperationDef) error {
+	return nil
+}
+
+func (gen *Generator) GenerateException(op *model.OperationOutput) error {
+	return nil
+}
+
+func (gen *Generator) GenerateType(td *model.TypeDef) error {
+	return nil
+}
+
+func (gen *Generator) Generate(schema *model.Schema, config *data.Object) error {
+	err := gen.Configure(schema, config)
+	if err != nil {
+		return err
+	}
+	gen.ns = config.GetString("namespace")
+	if gen.ns == "" {
+		gen.ns = string(schema.ServiceNamespace())
+		if gen.ns == "" {
+			gen.ns = string(schema.Namespace)
+		}
+	}
+	gen.split = config.GetBool("jsonschema.split")
+	gen.baseUri = config.GetString("jsonschema.baseUri")
+	if gen.baseUri != "" && !strings.HasSuffix(gen.baseUri, "/") {
+		gen.baseUri += "/"
+	}
+	if gen.split {
+		for _, td := range gen.Types() {
+			name := model.StripNamespace(td.Id)
+			fname := name + FileSuffix
+			doc := data.NewObject()
+			doc.Put("$schema", SchemaDialect)
+			if gen.baseUri != "" {
+				doc.Put("$id", gen.baseUri+fname)
+			}
+			doc.Put("title", name)
+			for _, b := range gen.TypeSchema(td).Bindings() {
+				doc.Put(b.Key, b.Value)
+			}
+			err = gen.Write(model.Pretty(doc)+"\n", fname, "\n\n------------------"+fname+"\n")
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	fname := gen.FileName(gen.ns, FileSuffix)
+	doc := data.NewObject()
+	doc.Put("$schema", SchemaDialect)
+	if gen.baseUri != "" {
+		doc.Put("$id", gen.baseUri+fname)
+	}
+	if schema.Comment != "" {
+		doc.Put("description", schema.Comment)
+	}
+	defs := data.NewObject()
+	for _, td := range gen.Types() {
+		defs.Put(model.StripNamespace(td.Id), gen.TypeSchema(td))
+	}
+	doc.Put("$defs", defs)
+	return gen.Write(model.Pretty(doc)+"\n", fname, "")
+}
+
+// Ref returns the schema referring to a type. Base types are described inline.
+func (gen *Generator) Ref(id model.AbsoluteIdentifier) *data.Object {
+	if gen.Schema.IsBaseType(id) {
+		return baseTypeSchema(model.StripNamespace(id))
+	}
+	s := data.NewObject()
+	name := model.StripNamespace(id)
+	if gen.split {
+		s.Put("$ref", name+FileSuffix)
+	} else {
+		s.Put("$ref", "#/$defs/"+name)
+	}
+	return s
+}
+
+func baseTypeSchema(base string) *data.Object {
+	s := data.NewObject()
+	switch base {
+	case "Bool":
+		s.Put("type", "boolean")
+	case "Int8", "Int16", "Int32", "Int64":
+		s.Put("type", "integer")
+		s.Put("format", strings.ToLower(base))
+	case "Integer":
+		s.Put("type", "integer")
+		s.Put("format", "big-integer")
+	case "Float32":
+		s.Put("type", "number")
+		s.Put("format", "float")
+	case "Float64":
+		s.Put("type", "number")
+		s.Put("format", "double")
+	case "Decimal":
+		s.Put("type", "number")
+		s.Put("format", "decimal")
+	case "String":
+		s.Put("type", "string")
+	case "Timestamp":
+		s.Put("type", "string")
+		s.Put("format", "date-time")
+	case "Bytes", "Blob":
+		s.Put("type", "string")
+		s.Put("contentEncoding", "base64")
+	case "List":
+		s.Put("type", "array")
+	case "Map", "Struct":
+		s.Put("type", "object")
+	}
+	//Any is the empty schema, which accepts any value
+	return s
+}
+
+// TypeSchema returns the schema for a type definition, including its constraints.
+func (gen *Generator) TypeSchema(td *model.TypeDef) *data.Object {
+	var s *data.Object
+	switch td.Base {
+	case model.BaseType_Struct:
+		s = data.NewObject()
+		s.Put("type", "object")
+		props := data.NewObject()
+		var required []string
+		for _, fd := range td.Fields {
+			props.Put(string(fd.Name), gen.FieldSchema(fd))
+			if fd.Required {
+				required = append(required, string(fd.Name))
+			}
+		}
+		s.Put("properties", props)
+		if len(required) > 0 {
+			s.Put("required", required)
+		}
+	case model.BaseType_Union:
+		//the JSON of a union value is an object with exactly one of the variant fields
+		s = data.NewObject()
+		var variants []*data.Object
+		for _, fd := range td.Fields {
+			v := data.NewObject()
+			v.Put("type", "object")
+			props := data.NewObject()
+			props.Put(string(fd.Name), gen.FieldSchema(fd))
+			v.Put("properties", props)
+			v.Put("required", []string{string(fd.Name)})
+			v.Put("additionalProperties", false)
+			variants = append(variants, v)
+		}
+		s.Put("oneOf", variants)
+	case model.BaseType_Enum:
+		s = data.NewObject()
+		s.Put("type", "string")
+		var values []string
+		for _, el := range td.Elements {
+			if el.Value != "" {
+				values = append(values, el.Value)
+			} else {
+				values = append(values, string(el.Symbol))
+			}
+		}
+		s.Put("enum", values)
+	case model.BaseType_List:
+		s = data.NewObject()
+		s.Put("type", "array")
+		s.Put("items", gen.Ref(td.Items))
+	case model.BaseType_Map:
+		s = data.NewObject()
+		s.Put("type", "object")
+		if td.Keys != "" && !gen.Schema.IsBaseType(td.Keys) {
+			s.Put("propertyNames", gen.Ref(td.Keys))
+		}
+		s.Put("additionalProperties", gen.Ref(td.Items))
+	default:
+		s = baseTypeSchema(td.Base.String())
+	}
+	withConstraints(s, td.Base, td.MinValue, td.MaxValue, td.MinSize, td.MaxSize, td.Pattern)
+	if td.Comment != "" {
+		return withDescription(s, td.Comment)
+	}
+	return s
+}
+
+// FieldSchema returns the schema of a structure or union field, which refers to its type, adding the constraints and
+// description of the field itself.
+func (gen *Generator) FieldSchema(fd *model.FieldDef) *data.Object {
+	s := gen.Ref(fd.Type)
+	withConstraints(s, gen.Schema.BaseType(fd.Type), fd.MinValue, fd.MaxValue, fd.MinSize, fd.MaxSize, fd.Pattern)
+	if fd.Comment != "" {
+		s.Put("description", fd.Comment)
+	}
+	return s
+}
+
+func withDescription(s *data.Object, comment string) *data.Object {
+	result := data.NewObject()
+	result.Put("description", comment)
+	for _, b := range s.Bindings() {
+		result.Put(b.Key, b.Value)
+	}
+	return result
+}
+
+// withConstraints adds the keywords for the constraints of a type or field. Sizes are the length of strings, the
+// number of list items, or the number of map entries.
+func withConstraints(s *data.Object, base model.BaseType, minValue, maxValue *data.Decimal, minSize, maxSize int64, pattern string) {
+	if minValue != nil {
+		s.Put("minimum", minValue)
+	}
+	if maxValue != nil {
+		s.Put("maximum", maxValue)
+	}
+	minKey, maxKey := "minLength", "maxLength"
+	switch base {
+	case model.BaseType_List:
+		minKey, maxKey = "minItems", "maxItems"
+	case model.BaseType_Map, model.BaseType_Struct:
+		minKey, maxKey = "minProperties", "maxProperties"
+	}
+	if minSize > 0 {
+		s.Put(minKey, minSize)
+	}
+	if maxSize > 0 {
+		s.Put(maxKey, maxSize)
+	}
+	if pattern != "" {
+		s.Put("pattern", pattern)
+	}
+}
@@ -0,0 +1,651 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/boynton/api/model"
+	"github.com/boynton/data"
+)
+
+// IsValidFile is true if the JSON file is a JSON Schema document, identified by its "$schema" keyword.
+func IsValidFile(path string) bool {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var raw map[string]interface{}
+	if json.Unmarshal(b, &raw) != nil {
+		return false
+	}
+	dialect, _ := raw["$schema"].(string)
+	return strings.Contains(dialect, "json-schema.org")
+}
+
+// Import reads JSON Schema documents into a model that has only types. Each document defines the types in its "$defs"
+// (or "definitions"), and, if the document itself describes a value, a type named by its "title" or its file name.
+// Documents refer to each other with "$ref"s, by relative file name or "$id". Anonymous object and enum schemas nested
+// in a type are defined as types named after the field they describe.
+func Import(paths []string, tags []string, ns string) (*model.Schema, error) {
+	mb := &ModelBuilder{
+		schema:    model.NewSchema(),
+		ns:        model.Namespace(ns),
+		byUri:     make(map[string]*document, 0),
+		resolving: make(map[string]bool, 0),
+	}
+	mb.schema.Namespace = mb.ns
+	for _, path := range paths {
+		err := mb.load(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := mb.Build()
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) > 0 {
+		mb.schema.Filter(tags)
+	}
+	return mb.schema, nil
+}
+
+type ModelBuilder struct {
+	schema    *model.Schema
+	ns        model.Namespace
+	docs      []*document
+	byUri     map[string]*document
+	resolving map[string]bool
+}
+
+type document struct {
+	path string
+	root *data.Object
+	name string
+	defs *data.Object
+}
+
+func (mb *ModelBuilder) load(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	root := data.NewObject()
+	err = json.Unmarshal(b, root)
+	if err != nil {
+		return fmt.Errorf("Cannot parse JSON Schema %s: %v", path, err)
+	}
+	doc := &document{path: path, root: root}
+	doc.defs = root.GetObject("$defs")
+	if doc.defs == nil {
+		doc.defs = root.GetObject("definitions")
+	}
+	if describesValue(root) {
+		doc.name = root.GetString("title")
+		if doc.name == "" || !model.IsSymbol(doc.name) {
+			doc.name = typeName(strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".json"), ".schema"))
+		}
+	}
+	mb.docs = append(mb.docs, doc)
+	mb.byUri[filepath.Base(path)] = doc
+	if id := root.GetString("$id"); id != "" {
+		mb.byUri[id] = doc
+	}
+	return nil
+}
+
+// describesValue is true if a document has keywords constraining a value, rather than just holding definitions.
+func describesValue(s *data.Object) bool {
+	for _, k := range []string{"type", "properties", "items", "enum", "const", "oneOf", "anyOf", "allOf", "$ref", "additionalProperties"} {
+		if s.Has(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// typeName converts a name like "order-item" to a type name like "OrderItem".
+func typeName(s string) string {
+	var sb strings.Builder
+	upper := true
+	for _, ch := range s {
+		if !model.IsSymbolChar(ch, false) || ch == '_' {
+			upper = true
+			continue
+		}
+		if upper && ch >= 'a' && ch <= 'z' {
+			ch = ch - 'a' + 'A'
+		}
+		upper = false
+		sb.WriteRune(ch)
+	}
+	name := sb.String()
+	if name == "" || !model.IsSymbolChar([]rune(name)[0], true) {
+		name = "T" + name
+	}
+	return name
+}
+
+func (mb *ModelBuilder) typeId(name string) model.AbsoluteIdentifier {
+	return model.AbsoluteIdentifier(string(mb.ns) + "#" + name)
+}
+
+func (mb *ModelBuilder) Build() error {
+	for _, doc := range mb.docs {
+		if doc.defs != nil {
+			for _, name := range doc.defs.Keys() {
+				if !model.IsSymbol(name) {
+					return fmt.Errorf("%s: %q is not a valid type name", doc.path, name)
+				}
+			}
+		}
+	}
+	for _, doc := range mb.docs {
+		if doc.defs != nil {
+			for _, name := range doc.defs.Keys() {
+				err := mb.importNamed(doc, name, doc.defs.GetObject(name))
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if doc.name != "" {
+			err := mb.importNamed(doc, doc.name, doc.root)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// importNamed defines the type for a named schema. A schema that is only a reference to another one does not define a
+// type, references to it are resolved to the type it refers to.
+func (mb *ModelBuilder) importNamed(doc *document, name string, s *data.Object) error {
+	if s == nil {
+		return fmt.Errorf("%s: the definition of %s is not a schema", doc.path, name)
+	}
+	if s.Has("$ref") && !describesValue(withoutKey(s, "$ref")) {
+		return nil
+	}
+	if mb.isAny(doc, s) {
+		model.Warning("%s: %s is not a union of single property objects, references to it are imported as Any\n", doc.path, name)
+		return nil
+	}
+	td, err := mb.typeDef(doc, name, s)
+	if err != nil {
+		return err
+	}
+	return mb.addTypeDef(td)
+}
+
+func withoutKey(s *data.Object, key string) *data.Object {
+	result := data.NewObject()
+	for _, b := range s.Bindings() {
+		if b.Key != key {
+			result.Put(b.Key, b.Value)
+		}
+	}
+	return result
+}
+
+func (mb *ModelBuilder) addTypeDef(td *model.TypeDef) error {
+	if prev := mb.schema.GetTypeDef(td.Id); prev != nil {
+		if model.Equivalent(prev, td) {
+			return nil
+		}
+		return fmt.Errorf("Conflicting definitions of type %s", td.Id)
+	}
+	return mb.schema.AddTypeDef(td)
+}
+
+// resolveRef finds the type a "$ref" refers to: "#/$defs/Name" in the same document, "other.schema.json" or a full
+// "$id", optionally followed by "#/$defs/Name".
+func (mb *ModelBuilder) resolveRef(doc *document, ref string) (model.AbsoluteIdentifier, error) {
+	target := doc
+	fragment := ref
+	if i := strings.Index(ref, "#"); i != 0 {
+		uri := ref
+		fragment = ""
+		if i > 0 {
+			uri = ref[:i]
+			fragment = ref[i:]
+		}
+		target = mb.byUri[uri]
+		if target == nil {
+			target = mb.byUri[filepath.Base(uri)]
+		}
+		if target == nil {
+			return "", fmt.Errorf("%s: cannot resolve $ref %q", doc.path, ref)
+		}
+	}
+	var name string
+	var s *data.Object
+	switch {
+	case fragment == "" || fragment == "#":
+		if target.name == "" {
+			return "", fmt.Errorf("%s: $ref %q refers to a document without a root schema", doc.path, ref)
+		}
+		name = target.name
+		s = target.root
+	case strings.HasPrefix(fragment, "#/$defs/"), strings.HasPrefix(fragment, "#/definitions/"):
+		name = fragment[strings.LastIndex(fragment, "/")+1:]
+		if target.defs != nil {
+			s = target.defs.GetObject(name)
+		}
+	}
+	if s == nil {
+		return "", fmt.Errorf("%s: cannot resolve $ref %q", doc.path, ref)
+	}
+	if s.Has("$ref") && !describesValue(withoutKey(s, "$ref")) {
+		if mb.resolving[ref] {
+			return "", fmt.Errorf("%s: circular $ref %q", doc.path, ref)
+		}
+		mb.resolving[ref] = true
+		defer delete(mb.resolving, ref)
+		return mb.resolveRef(target, s.GetString("$ref"))
+	}
+	if mb.isAny(target, s) {
+		return "base#Any", nil
+	}
+	return mb.typeId(name), nil
+}
+
+// schemaType returns the type of a schema used by a field, list, or map, defining a type for an anonymous object or
+// enum schema, named by hint.
+func (mb *ModelBuilder) schemaType(doc *document, hint string, s *data.Object) (model.AbsoluteIdentifier, error) {
+	if s == nil {
+		return "base#Any", nil
+	}
+	if ref := s.GetString("$ref"); ref != "" {
+		return mb.resolveRef(doc, ref)
+	}
+	if mb.isAny(doc, s) {
+		return "base#Any", nil
+	}
+	switch schemaTypeKeyword(s) {
+	case "boolean":
+		return "base#Bool", nil
+	case "integer":
+		switch s.GetString("format") {
+		case "int8":
+			return "base#Int8", nil
+		case "int16":
+			return "base#Int16", nil
+		case "int32":
+			return "base#Int32", nil
+		case "big-integer":
+			return "base#Integer", nil
+		}
+		return "base#Int64", nil
+	case "number":
+		switch s.GetString("format") {
+		case "float":
+			return "base#Float32", nil
+		case "decimal":
+			return "base#Decimal", nil
+		}
+		return "base#Float64", nil
+	case "string":
+		if s.Has("enum") {
+			break
+		}
+		if s.GetString("format") == "date-time" {
+			return "base#Timestamp", nil
+		}
+		if s.GetString("contentEncoding") == "base64" || s.GetString("format") == "byte" {
+			return "base#Bytes", nil
+		}
+		return "base#String", nil
+	case "array":
+		items, err := mb.schemaType(doc, hint+"Item", s.GetObject("items"))
+		if err != nil {
+			return "", err
+		}
+		td := &model.TypeDef{
+			Id:    mb.typeId(model.StripNamespace(items) + "List"),
+			Base:  model.BaseType_List,
+			Items: items,
+		}
+		return td.Id, mb.addTypeDef(td)
+	case "object":
+		if !s.Has("properties") && !s.Has("allOf") && !s.Has("oneOf") {
+			additional := s.GetObject("additionalProperties")
+			if additional == nil {
+				return "base#Any", nil
+			}
+			td, err := mb.mapTypeDef(doc, hint, s)
+			if err != nil {
+				return "", err
+			}
+			td.Id = mb.typeId(model.StripNamespace(td.Keys) + model.StripNamespace(td.Items) + "Map")
+			return td.Id, mb.addTypeDef(td)
+		}
+	case "":
+		if !s.Has("enum") && !s.Has("oneOf") && !s.Has("allOf") && !s.Has("properties") {
+			return "base#Any", nil
+		}
+	}
+	td, err := mb.typeDef(doc, hint, s)
+	if err != nil {
+		return "", err
+	}
+	return td.Id, mb.addTypeDef(td)
+}
+
+// schemaTypeKeyword returns the "type" of a schema. A list of types with "null" (a nullable type) is taken as the
+// other type.
+func schemaTypeKeyword(s *data.Object) string {
+	switch t := s.Get("type").(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, v := range t {
+			if v != "null" {
+				if str, ok := v.(string); ok {
+					return str
+				}
+			}
+		}
+	}
+	if s.Has("properties") {
+		return "object"
+	}
+	return ""
+}
+
+// typeDef converts a named schema to a type definition.
+func (mb *ModelBuilder) typeDef(doc *document, name string, s *data.Object) (*model.TypeDef, error) {
+	td := &model.TypeDef{
+		Id:      mb.typeId(name),
+		Comment: s.GetString("description"),
+	}
+	if td.Comment == "" && s.GetString("title") != name {
+		td.Comment = s.GetString("title")
+	}
+	switch {
+	case s.Has("enum"):
+		td.Base = model.BaseType_Enum
+		for _, v := range s.GetSlice("enum") {
+			str, ok := v.(string)
+			if !ok {
+				if v == nil {
+					continue
+				}
+				return nil, fmt.Errorf("%s: the enum %s has a non-string value %v", doc.path, name, v)
+			}
+			el := &model.EnumElement{Symbol: model.Identifier(str)}
+			if !model.IsSymbol(str) {
+				el.Symbol = model.Identifier(enumSymbol(str))
+				el.Value = str
+			}
+			td.Elements = append(td.Elements, el)
+		}
+	case s.Has("oneOf") || s.Has("anyOf"):
+		alternatives := s.GetSlice("oneOf")
+		if alternatives == nil {
+			alternatives = s.GetSlice("anyOf")
+		}
+		fields, err := mb.unionFields(doc, name, alternatives)
+		if err != nil {
+			return nil, err
+		}
+		td.Base = model.BaseType_Union
+		td.Fields = fields
+	case s.Has("allOf"):
+		td.Base = model.BaseType_Struct
+		for _, part := range s.GetSlice("allOf") {
+			fields, err := mb.structFields(doc, name, mb.deref(doc, data.AsObject(part)))
+			if err != nil {
+				return nil, err
+			}
+			td.Fields = append(td.Fields, fields...)
+		}
+		fields, err := mb.structFields(doc, name, s)
+		if err != nil {
+			return nil, err
+		}
+		td.Fields = append(td.Fields, fields...)
+	default:
+		switch schemaTypeKeyword(s) {
+		case "object":
+			if !s.Has("properties") && s.GetObject("additionalProperties") != nil {
+				m, err := mb.mapTypeDef(doc, name, s)
+				if err != nil {
+					return nil, err
+				}
+				m.Id = td.Id
+				m.Comment = td.Comment
+				td = m
+				break
+			}
+			td.Base = model.BaseType_Struct
+			fields, err := mb.structFields(doc, name, s)
+			if err != nil {
+				return nil, err
+			}
+			td.Fields = fields
+		case "array":
+			td.Base = model.BaseType_List
+			items, err := mb.schemaType(doc, name+"Item", s.GetObject("items"))
+			if err != nil {
+				return nil, err
+			}
+			td.Items = items
+		case "":
+			return nil, fmt.Errorf("%s: the schema of %s has no type", doc.path, name)
+		default:
+			base, err := mb.schemaType(doc, name, s)
+			if err != nil {
+				return nil, err
+			}
+			td.Base = mb.schema.BaseType(base)
+			if base == "base#Bytes" {
+				td.Base = model.BaseType_Blob
+			}
+		}
+	}
+	applyConstraints(s, &td.MinValue, &td.MaxValue, &td.MinSize, &td.MaxSize, &td.Pattern)
+	return td, nil
+}
+
+// deref returns the schema a "$ref" refers to, for the parts of an allOf.
+func (mb *ModelBuilder) deref(doc *document, s *data.Object) *data.Object {
+	if s == nil {
+		return nil
+	}
+	ref := s.GetString("$ref")
+	if ref == "" {
+		return s
+	}
+	if strings.HasPrefix(ref, "#/") {
+		name := ref[strings.LastIndex(ref, "/")+1:]
+		if doc.defs != nil && doc.defs.GetObject(name) != nil {
+			return mb.deref(doc, doc.defs.GetObject(name))
+		}
+	} else {
+		uri := ref
+		if i := strings.Index(ref, "#"); i > 0 {
+			uri = ref[:i]
+		}
+		target := mb.byUri[uri]
+		if target == nil {
+			target = mb.byUri[filepath.Base(uri)]
+		}
+		if target != nil {
+			if i := strings.Index(ref, "#"); i > 0 {
+				return mb.deref(target, data.AsObject(map[string]interface{}{"$ref": ref[i:]}))
+			}
+			return mb.deref(target, target.root)
+		}
+	}
+	model.Warning("%s: cannot resolve $ref %q in allOf\n", doc.path, ref)
+	return nil
+}
+
+func (mb *ModelBuilder) mapTypeDef(doc *document, hint string, s *data.Object) (*model.TypeDef, error) {
+	items, err := mb.schemaType(doc, hint+"Value", s.GetObject("additionalProperties"))
+	if err != nil {
+		return nil, err
+	}
+	keys := model.AbsoluteIdentifier("base#String")
+	if names := s.GetObject("propertyNames"); names != nil && (names.Has("$ref") || names.Has("enum")) {
+		keys, err = mb.schemaType(doc, hint+"Key", names)
+		if err != nil {
+			return nil, err
+		}
+	}
+	td := &model.TypeDef{
+		Base:  model.BaseType_Map,
+		Keys:  keys,
+		Items: items,
+	}
+	return td, nil
+}
+
+func (mb *ModelBuilder) structFields(doc *document, name string, s *data.Object) ([]*model.FieldDef, error) {
+	if s == nil {
+		return nil, nil
+	}
+	props := s.GetObject("properties")
+	if props == nil {
+		return nil, nil
+	}
+	required := make(map[string]bool, 0)
+	for _, r := range s.GetStringSlice("required") {
+		required[r] = true
+	}
+	var fields []*model.FieldDef
+	for _, pname := range props.Keys() {
+		if !model.IsSymbol(pname) {
+			model.Warning("%s: the property %q of %s is not a valid field name, and is ignored\n", doc.path, pname, name)
+			continue
+		}
+		ps := props.GetObject(pname)
+		hint := name + model.Capitalize(pname)
+		ftype, err := mb.schemaType(doc, hint, ps)
+		if err != nil {
+			return nil, err
+		}
+		fd := &model.FieldDef{
+			Name:     model.Identifier(pname),
+			Type:     ftype,
+			Required: required[pname],
+		}
+		if ps != nil {
+			fd.Comment = ps.GetString("description")
+			if ftype != mb.typeId(hint) {
+				//an anonymous schema defined as a type of its own has the constraints already
+				applyConstraints(ps, &fd.MinValue, &fd.MaxValue, &fd.MinSize, &fd.MaxSize, &fd.Pattern)
+			}
+		}
+		fields = append(fields, fd)
+	}
+	return fields, nil
+}
+
+// isAny is true for a oneOf or anyOf schema that is not a union, which has no equivalent type in the model. The JSON of
+// a union value is an object with a single property, the variant, so each alternative of a union must be one.
+func (mb *ModelBuilder) isAny(doc *document, s *data.Object) bool {
+	alternatives := s.GetSlice("oneOf")
+	if alternatives == nil {
+		alternatives = s.GetSlice("anyOf")
+	}
+	if alternatives == nil || s.Has("properties") {
+		return false
+	}
+	for _, a := range alternatives {
+		alt := mb.deref(doc, data.AsObject(a))
+		if alt == nil {
+			return true
+		}
+		props := alt.GetObject("properties")
+		req := alt.GetStringSlice("required")
+		if props == nil || len(props.Keys()) != 1 || len(req) != 1 || req[0] != props.Keys()[0] {
+			return true
+		}
+	}
+	return false
+}
+
+func (mb *ModelBuilder) unionFields(doc *document, name string, alternatives []interface{}) ([]*model.FieldDef, error) {
+	var fields []*model.FieldDef
+	for _, a := range alternatives {
+		alt := mb.deref(doc, data.AsObject(a))
+		vname := alt.GetStringSlice("required")[0]
+		vs := alt.GetObject("properties").GetObject(vname)
+		ftype, err := mb.schemaType(doc, name+model.Capitalize(vname), vs)
+		if err != nil {
+			return nil, err
+		}
+		fd := &model.FieldDef{
+			Name: model.Identifier(vname),
+			Type: ftype,
+		}
+		if vs != nil {
+			fd.Comment = vs.GetString("description")
+		}
+		fields = append(fields, fd)
+	}
+	return fields, nil
+}
+
+func applyConstraints(s *data.Object, minValue, maxValue **data.Decimal, minSize, maxSize *int64, pattern *string) {
+	if s.Has("minimum") {
+		*minValue = s.GetDecimal("minimum")
+	}
+	if s.Has("maximum") {
+		*maxValue = s.GetDecimal("maximum")
+	}
+	for _, k := range []string{"minLength", "minItems", "minProperties"} {
+		if s.Has(k) {
+			*minSize = s.GetInt64(k)
+		}
+	}
+	for _, k := range []string{"maxLength", "maxItems", "maxProperties"} {
+		if s.Has(k) {
+			*maxSize = s.GetInt64(k)
+		}
+	}
+	if p := s.GetString("pattern"); p != "" {
+		*pattern = p
+	}
+}
+
+// enumSymbol makes a symbol for an enum value that is not one, i.e. "in-stock" becomes "IN_STOCK".
+func enumSymbol(value string) string {
+	var sb strings.Builder
+	for _, ch := range value {
+		if model.IsSymbolChar(ch, false) {
+			if ch >= 'a' && ch <= 'z' {
+				ch = ch - 'a' + 'A'
+			}
+			sb.WriteRune(ch)
+		} else if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "_") {
+			sb.WriteRune('_')
+		}
+	}
+	sym := strings.TrimSuffix(sb.String(), "_")
+	if sym == "" || !model.IsSymbolChar([]rune(sym)[0], true) {
+		sym = "V_" + sym
+	}
+	return sym
+}
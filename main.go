@@ -19,24 +19,44 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/boynton/api/golang"
+	"github.com/boynton/api/graphql"
 	"github.com/boynton/api/html"
 	"github.com/boynton/api/httptrace"
+	"github.com/boynton/api/java"
+	"github.com/boynton/api/jsonschema"
 	"github.com/boynton/api/markdown"
 	"github.com/boynton/api/model"
 	"github.com/boynton/api/openapi"
 	"github.com/boynton/api/plantuml"
+	"github.com/boynton/api/postman"
+	"github.com/boynton/api/protobuf"
+	"github.com/boynton/api/python"
 	"github.com/boynton/api/rdl"
+	"github.com/boynton/api/rust"
 	"github.com/boynton/api/sadl"
+	"github.com/boynton/api/samples"
 	"github.com/boynton/api/smithy"
+	"github.com/boynton/api/typescript"
 	"github.com/boynton/data"
 )
 
 var Version string = "development version"
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "serve":
+			os.Exit(Serve(os.Args[2:]))
+		case "test":
+			os.Exit(ContractTest(os.Args[2:]))
+		case "proxy":
+			os.Exit(Proxy(os.Args[2:]))
+		}
+	}
 	conf := data.NewObject()
 	pNoValidate := flag.Bool("v", false, "Suppress validation of the assembled model")
 	pQuiet := flag.Bool("q", false, "Quiet tool output, make it less verbose")
@@ -49,6 +69,7 @@ func main() {
 	pNs := flag.String("ns", "", "The namespace to force if absent. Also used by the api generator to flatten to a single namespace")
 	pOutdir := flag.String("o", "", "The directory to generate output into (defaults to stdout)")
 	pWarn := flag.String("w", "show", "Warnings. 'show' or 'suppress' or 'error'. Default is 'show'")
+	pProjection := flag.String("projection", "", "Build the named smithy-build.json projections (comma-separated, or 'all'), each into its own output directory")
 	var params Params
 	flag.Var(&params, "a", "Additional named arguments for a generator")
 	var tags Tags
@@ -72,14 +93,48 @@ func main() {
 	}
 	gen := *pGen
 	outdir := *pOutdir
+	for _, a := range params {
+		kv := strings.Split(a, "=")
+		if len(kv) > 1 {
+			conf.Put(kv[0], kv[1])
+		} else {
+			conf.Put(a, true)
+		}
+	}
 	files := flag.Args()
+	if *pProjection != "" {
+		if *pForce {
+			conf.Put("force", true)
+		}
+		err := BuildProjections(*pProjection, files, tags, gen, outdir, conf, *pNoValidate)
+		if err != nil {
+			fmt.Printf("*** %v\n", err)
+			os.Exit(4)
+		}
+		os.Exit(0)
+	}
 	if len(files) == 0 {
 		fmt.Printf("API tool %s [%s]\n", Version, "https://github.com/boynton/api")
-		fmt.Println("usage: api [-vlfhpq] [-w warnlev] [-ns namespace] [-e entityid] [-d outdir] [-g generator] [-a key=val]* [-t tag]* file ...")
+		fmt.Println("usage: api [-vlfhpq] [-w warnlev] [-ns namespace] [-e entityid] [-d outdir] [-g generator] [-a key=val]* [-t tag]* [-projection names] file ...")
 		flag.PrintDefaults()
+		fmt.Println("       api serve [-addr host:port] [-vq] [-ns namespace] [-seed n] [-t tag]* file ...")
+		fmt.Println("       api test [-url url] [-ignore fields] [-format text|json|junit] [-o file] file ...")
+		fmt.Println("       api proxy -target url [-addr host:port] [-o file] [-all] file ...")
 		os.Exit(1)
 	}
-	schema, err := AssembleModel(files, tags, *pNs, *pParseOnly, *pNoValidate)
+	if gen == "smithy-migrate" {
+		conf.Put("outdir", outdir)
+		if *pForce {
+			conf.Put("force", true)
+		}
+		err := MigrateSmithy(files, conf)
+		if err != nil {
+			fmt.Printf("*** %v\n", err)
+			os.Exit(4)
+		}
+		os.Exit(0)
+	}
+	schema, err := AssembleModel(files, tags, *pNs, *pParseOnly, *pNoValidate, conf)
 	if err != nil {
 		model.Error("%s\n", err)
 	}
@@ -125,14 +180,6 @@ func main() {
 	if *pForce {
 		conf.Put("force", true)
 	}
-	for _, a := range params {
-		kv := strings.Split(a, "=")
-		if len(kv) > 1 {
-			conf.Put(kv[0], kv[1])
-		} else {
-			conf.Put(a, true)
-		}
-	}
 	generator, err := Generator(gen)
 	if err == nil {
 		err = generator.Generate(schema, conf)
@@ -143,6 +190,52 @@ func main() {
 	}
 }
 
+// BuildProjections runs the generator once per smithy-build.json projection, writing the output of each
+// into its own directory under outdir (which defaults to "build").
+func BuildProjections(names string, files []string, tags []string, gen string, outdir string, conf *data.Object, noValidate bool) error {
+	path := smithy.FindBuildConfig(files)
+	if path == "" {
+		return fmt.Errorf("Cannot find %s", smithy.BuildConfigFileName)
+	}
+	bconf, err := smithy.LoadBuildConfig(path)
+	if err != nil {
+		return err
+	}
+	var projections []string
+	if names == "all" {
+		projections = bconf.ProjectionNames()
+	} else {
+		projections = strings.Split(names, ",")
+	}
+	if outdir == "" {
+		outdir = "build"
+	}
+	for _, name := range projections {
+		name = strings.TrimSpace(name)
+		proj, err := bconf.GetProjection(name)
+		if err != nil {
+			return err
+		}
+		schema, err := AssembleProjection(append(bconf.SourcePaths(name), files...), tags, proj, noValidate)
+		if err != nil {
+			return fmt.Errorf("projection %q: %v", name, err)
+		}
+		generator, err := Generator(gen)
+		if err != nil {
+			return err
+		}
+		conf.Put("outdir", filepath.Join(outdir, name))
+		err = generator.Generate(schema, conf)
+		if err != nil {
+			return fmt.Errorf("projection %q: %v", name, err)
+		}
+		if !model.MinimizeOutput {
+			fmt.Printf("[projection %q written to %s]\n", name, filepath.Join(outdir, name))
+		}
+	}
+	return nil
+}
+
 type Params []string
 
 func (p *Params) String() string {
@@ -183,15 +276,32 @@ func Generator(genName string) (model.Generator, error) {
 		return new(rdl.Generator), nil
 	case "openapi":
 		return new(openapi.Generator), nil
+	case "jsonschema":
+		return new(jsonschema.Generator), nil
 	case "go", "golang":
 		return new(golang.Generator), nil
+	case "graphql":
+		return new(graphql.Generator), nil
+	case "java":
+		return new(java.Generator), nil
 	case "httptrace":
 		return new(httptrace.Generator), nil
 	case "plantuml":
 		return new(plantuml.Generator), nil
+	case "postman":
+		return new(postman.Generator), nil
+	case "proto":
+		return new(protobuf.Generator), nil
+	case "python":
+		return new(python.Generator), nil
+	case "rust":
+		return new(rust.Generator), nil
+	case "samples":
+		return new(samples.Generator), nil
 	//case "swagger":
 	//case "swagger-ui":
-	//case "ts":
+	case "ts", "typescript":
+		return new(typescript.Generator), nil
 	default:
 		return nil, fmt.Errorf("Unknown generator: %q", genName)
 	}
@@ -202,7 +312,20 @@ func help() {
 Supported API description formats for each input file extension:
    .api      api (the default for this tool
    .smithy   smithy
-   .json     api, smithy, openapi, swagger (inferred by looking at the file contents)
+   .proto    protobuf (messages, enums and services, using google.api.http annotations for the HTTP bindings)
+   .graphql  graphql SDL (also .gql). Query and Mutation fields become operations bound by a convention:
+             "-a graphql.binding=METHOD /path/{field}" for all operations (default "POST /graphql/{field}"), and
+             "-a graphql.queryBinding=..." for Query fields. Arguments are query parameters for GET, otherwise the
+             payload (input objects need the payload, so they make GET a POST). "-a graphql.service=Name" names the
+             service (default derived from the file name). Operations named like a type get a "Get" prefix (queries)
+             or an "Operation" suffix.
+   .har      recorded HTTP traffic (also .http for httptrace files). A model is inferred: requests are clustered into
+             operations by method and path, with id-like path segments as parameters, and the types are inferred
+             from the JSON bodies. Each exchange is kept as an example. "-a traffic.service=Name" names the service.
+   .json     api, smithy, openapi, swagger, jsonschema, har, postman (inferred by looking at the file contents).
+             JSON Schema documents (and directories of them) produce a model with only types. A Postman (v2.1)
+             collection produces an operation per request, a resource per folder, and types inferred from the bodies;
+             its saved responses are the examples. "-a postman.service=Name" names the service.
 
 The '' and 'namespace' options allow specifying those attributes for input formats
 that do not require or support them. Otherwise a default is used based on the model being parsed.
@@ -212,24 +335,116 @@ Supported generators and options used from config if present
 - json: Prints the parsed API data representation in JSON to stdout
 - smithy: Prints the Smithy IDL representation to stdout.
 - smithy-ast: Prints the Smithy AST representation to stdout
+- smithy-migrate: Rewrites Smithy IDL 1.0 files as IDL 2.0, preserving comments and layout. Each file is written with
+   the same name to the -o directory, or to stdout.
+- jsonschema: Prints the types as a JSON Schema (draft 2020-12) document, with each type in its "$defs".
+   "-a jsonschema.split" - write each type to its own <Name>.schema.json file, referring to each other with "$ref"
+   "-a jsonschema.baseUri=https://example.com/schemas/" - the base of the "$id" of each document
 - openapi: Prints the OpenAPI Spec v3 representation to stdout
+- graphql: Prints a GraphQL SDL schema matching the REST model. GET operations become Query fields, others Mutation
+   fields, and operations with exceptions return a union of the result and the exception types.
+   "-a graphql.timestamp=DateTime", "-a graphql.decimal=Decimal", "-a graphql.int64=Long", "-a graphql.integer=BigInt",
+   "-a graphql.blob=Base64", "-a graphql.any=JSON" - the scalars used for those types (the defaults are shown)
 - plantuml: Prints the PlantUML representation of the API to stdout.
+- httptrace: Prints the examples of the operations as traces of HTTP/1.1 requests and responses, with the Host of the
+   service's base URL. With -o, writes a <Operation>.http file of the requests of each operation instead, for the REST
+   clients of VS Code and JetBrains IDEs.
+- postman: Writes a Postman (v2.1) collection, <ns>.postman_collection.json, with a folder for each resource and a
+   request for each operation, filled in from its first example, with every example as a saved response. The URLs
+   start with {{baseUrl}}, which <ns>.postman_environment.json sets to the service's base URL (or http://localhost:8080).
+- samples: Prints sample data synthesised from the model, <ns>.samples.json: an input and output for each operation, an
+   output for each exception, and a value of each type. The values conform to the types and the pattern, size, and
+   value constraints, and are realistic for the names of their fields.
+   "-a samples.seed=n" - the seed of the samples (default 0). The same seed gives the same samples.
+- proto: Prints the proto3 representation of the API (messages, enums, and a service with google.api.http options).
+   "-a proto.package=name" - the proto package, the model's namespace by default
+   "-a proto.goPackage=path" - emit the go_package option
+   "-a proto.lockFile=path" - the JSON file recording assigned field numbers, by default <package>.proto.lock.json in
+   the -o directory. Field numbers are kept stable across runs, removed fields are reserved. A "protoIndex" annotation
+   on a field (i.e. the Smithy trait @com.acme#protoIndex(3)) overrides the number.
+- go (or golang): Generates Go code for the service: <pkg>_types.go, <pkg>_operations.go (the service interface),
+   <pkg>_server.go (an HTTP server calling an implementation of that interface), and <pkg>_client.go (a Client with a
+   method for each operation, returning the exception types as errors, and BearerToken and Retry middleware). The
+   types and operation inputs have a Validate method checking the required fields and the pattern, size, and value
+   constraints of the model, and the server responds 400 with the violations to a request that fails it.
+   "-a golang.timestampPackage=github.com/boynton/data", "-a golang.decimalPackage=..." - the package of those types
+   "-a golang.inlineSlicesAndMaps", "-a golang.inlinePrimitives" - use Go types directly instead of named types
+   "-a golang.router=stdlib" - route with the http.ServeMux method and wildcard patterns of Go 1.22 (the go.mod of the
+   generated code must say 1.22 or later) instead of gorilla/mux. With stdlib, the methods of the service interface
+   also take the request's context.Context, so that the generated Client implements that interface.
+   "-a golang.fuzz" - also generate <pkg>_fuzz_test.go, with a table test and a fuzz target for each operation. They
+   call the server with a stub of the service returning samples of the output and exceptions, and send it requests
+   made from a sample input, as is and made invalid. The server must not panic, and must respond with the status and
+   body shape of the output or exception returned, or with a 400 rejecting an invalid request.
+- java: Generates Java 17 sources in the directory of the package: a record with a builder for each struct and
+   operation input and output, an enum for each enum, and a sealed interface for each union, using Jackson annotations.
+   For a service, an exception class for each exception and a <Service>Client using java.net.http.
+   "-a java.package=com.acme.api" - the package, by default the namespace of the model
+   "-a java.jaxrs" - also generate a <Service>Resource interface with the JAX-RS (jakarta.ws.rs) annotations of the
+   HTTP bindings, for implementing the service
+- python: Generates a Python (3.10 or later) module named for the namespace, with a dataclass for each struct, union,
+   and operation output, an Enum for each enum, an exception class for each exception, and for a service a client using
+   urllib, and an async one using httpx. Attributes and parameters are the snake_case form of the field names, from_dict
+   and to_dict convert to and from JSON, with its field names, timestamps, blobs, and decimals.
+   "-a python.models=pydantic" - use pydantic BaseModel classes instead of dataclasses
+- rust: Generates a Rust module named for the namespace, with a serde struct for each struct (Option for the fields
+   that are not required), an enum for each enum and union (unions are externally tagged), and for a service an
+   ApiError enum with a variant for each exception and an async <Service>Client using reqwest. The crates the code
+   depends on are listed at its top.
+   "-a rust.timestamp=chrono" - the type of timestamps: chrono (the default) or string
+   "-a rust.decimal=rust_decimal" - the type of decimals: rust_decimal (the default), bigdecimal, f64, or string
+   "-a rust.integer=num-bigint" - the type of big integers: num-bigint (the default), i128, or string
+- typescript (or ts): Prints a TypeScript module with the types of the model, the operation inputs and outputs, an error
+   class for each exception, and a fetch-based client class for the service.
+   "-a typescript.typesOnly" - emit only the types
 - sadl: Prints the SADL (an older format similar to api) to stdout. Useful for some additional generators.
 - html: Prints html to stdout
    "-a detail-generator=api" - to generate the detail entries with "api" instead of "smithy", which is the default
+   "-a show-examples" - show the examples of each operation as HTTP traces, or a sample synthesised from the model for
+   an operation without examples
 - markdown: Prints markdown to stdout
    "-a detail-generator=api" - to generate the detail entries with "api" instead of "smithy", which is the default
+   "-a show-examples" - show the examples of each operation as HTTP traces, or a sample synthesised from the model for
+   an operation without examples
    "-a use-html-pre-tag" - use the HTML <pre> tags instead of code fencing, allowing interior links. Not as compatible.
 
 For any generator the following additional parameters are accepted:
 - "-a sort" - causes the operations and types to be alphabetically sorted, by default the original order is preserved
 
-`
-	//not yet:
-	// - java: Generate Java client code
-	// - go: Generate Go client code
-	// - gorilla: Generate Go code for a Gorilla-based server.
-	// - jaxrs: Generate Java code for a Jersey/Jetty/Jackson based JAX-RS servier
+Smithy projections:
+- "-projection name[,name...]" - read the smithy-build.json next to the sources (or in the current directory), apply the
+  transforms of each named projection, and run the generator for each into its own directory under the -o directory
+  (default "build"). Use "all" for every non-abstract projection. Supported transforms: excludeShapesByTag,
+  includeShapesByTag, excludeTraits, renameShapes, flattenNamespaces, removeUnusedShapes, includeServices.
 
+Mock server:
+- "api serve [-addr host:port] file ..." - serve the operations of the model over HTTP (at localhost:8080 by default),
+  routed by their HTTP bindings under the path of the service's base. A request is answered with the output, or error,
+  of the operation's example whose input matches it (naming the example in the X-Api-Example response header), or else
+  with a sample of the output synthesised from the model, as the samples generator does ("-seed n" seeds it). Use "-q"
+  to not log each request.
+
+Contract tests:
+- "api test -url http://localhost:8080 file ..." - replay the examples of the operations, in order, against the server
+  at the URL (the path of the service's base is appended to it, which is used when -url is omitted), and check the
+  status, the headers, and the body of each response against the example's output or error. Bodies are compared
+  structurally. Exits with 2 if any example fails.
+  "-ignore id,item.modified,Date" - fields not compared: a name at any depth, a dotted path in the body, or a header
+  "-format junit -o report.xml" - write the report as JUnit XML (or "json"), instead of text to stdout
+
+Validating proxy:
+- "api proxy -target http://svc file ..." - forward the traffic received at localhost:8080 (see -addr) to the target,
+  and check each exchange against the model: the route matches an operation, its required path, query, and header
+  inputs are present, the inputs and the payloads conform to their types and constraints, and the status is the
+  output's or one of the operation's exceptions'. Exchanges with violations are logged as lines of JSON, to stdout or
+  appended to the -o file. Use "-all" to log every exchange.
+
+Smithy validators:
+- The "validators" metadata of a Smithy model is evaluated when it is imported, along with the "suppressions" metadata
+  and @suppress traits. Supported validators: EmitEachSelector, EmitNoneSelector, UnreferencedShape, CamelCase. Applied
+  custom traits are also checked against the selector of their trait definition. NOTE and WARNING events are shown as
+  warnings (see -w), DANGER and ERROR events fail the import.
+
+`
 	fmt.Println(msg)
 }
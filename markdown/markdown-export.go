@@ -267,11 +267,15 @@ func (gen *Generator) GenerateOperation(op *model.OperationDef) error {
 	opId := StripNamespace(op.Id)
 	gen.Emitf("### %s\n\n", opId)
 	gen.Emitf("```\n%s```\n\n", gen.generateApiOperation(op))
-	if gen.showExamples && len(op.Examples) > 0 {
+	if gen.showExamples {
+		examples := op.Examples
+		if len(examples) == 0 {
+			examples = []*model.OperationExample{model.NewSampler(gen.Schema, 0).SampleExample(op)}
+		}
 		gen.Emitf("\n#### %s Examples\n\n", opId)
 		hgen := new(httptrace.Generator)
 		hgen.Configure(gen.Schema, nil)
-		for _, ex := range op.Examples {
+		for _, ex := range examples {
 			snippet, err := hgen.EmitHttpTrace(op, ex)
 			if err != nil {
 				return err
@@ -0,0 +1,196 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/boynton/api/model"
+)
+
+// ExampleHeader is the response header naming the example a response was taken from. It is absent from the responses
+// synthesised from the output type.
+const ExampleHeader = "X-Api-Example"
+
+// Server is an http.Handler for the operations of a model. Each request is routed to its operation, and answered with
+// the output (or error) of the operation's example whose input best matches the request. When no example matches, the
+// response is a sample of the operation's output, synthesised from the model with the Seed.
+type Server struct {
+	Schema *model.Schema
+	Router *model.Router
+	Seed   int64
+	Quiet  bool
+}
+
+func NewServer(schema *model.Schema) *Server {
+	return &Server{Schema: schema, Router: model.NewRouter(schema)}
+}
+
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	op, params := server.Router.Match(r.Method, r.URL.EscapedPath())
+	if op == nil {
+		if methods := server.Router.Methods(r.URL.EscapedPath()); len(methods) > 0 {
+			w.Header().Set("Allow", strings.Join(methods, ", "))
+			server.fail(w, r, http.StatusMethodNotAllowed, fmt.Sprintf("%s is not allowed for %s", r.Method, r.URL.Path))
+		} else {
+			server.fail(w, r, http.StatusNotFound, fmt.Sprintf("No operation for %s %s", r.Method, r.URL.Path))
+		}
+		return
+	}
+	input, err := model.RequestInput(server.Schema, op, r, params)
+	if err != nil {
+		server.fail(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if ex := MatchExample(op, input); ex != nil {
+		out := op.Output
+		value := ex.Output
+		if ex.Error != nil {
+			out = server.Schema.GetExceptionDef(ex.Error.ShapeId)
+			value = ex.Error.Output
+			if out == nil {
+				server.fail(w, r, http.StatusInternalServerError, fmt.Sprintf("The error of example %q is not defined: %s", ex.Title, ex.Error.ShapeId))
+				return
+			}
+		}
+		w.Header().Set(ExampleHeader, ex.Title)
+		status := server.respond(w, out, normalize(value))
+		server.logf("%s %s -> %s %d (example %q)", r.Method, r.URL, model.StripNamespace(op.Id), status, ex.Title)
+		return
+	}
+	status := server.respond(w, op.Output, model.NewSampler(server.Schema, server.Seed).SampleOutput(op.Output))
+	server.logf("%s %s -> %s %d (synthesised)", r.Method, r.URL, model.StripNamespace(op.Id), status)
+}
+
+func (server *Server) logf(format string, args ...any) {
+	if !server.Quiet {
+		log.Printf(format, args...)
+	}
+}
+
+func (server *Server) fail(w http.ResponseWriter, r *http.Request, status int, message string) {
+	writeJson(w, status, map[string]any{"message": message})
+	server.logf("%s %s -> %d %s", r.Method, r.URL, status, message)
+}
+
+// respond writes the value of an output or exception to the response, with its fields bound to headers or the body as
+// the output specifies, and returns the status.
+func (server *Server) respond(w http.ResponseWriter, out *model.OperationOutput, value any) int {
+	status := http.StatusOK
+	if out == nil {
+		w.WriteHeader(status)
+		return status
+	}
+	if out.HttpStatus != 0 {
+		status = int(out.HttpStatus)
+	}
+	fields, _ := value.(map[string]any)
+	var body any
+	members := make(map[string]any, 0)
+	for _, f := range out.Fields {
+		v, ok := fields[string(f.Name)]
+		if !ok || v == nil {
+			continue
+		}
+		if f.HttpHeader != "" {
+			w.Header().Set(f.HttpHeader, ParamString(v))
+		} else if f.HttpPayload {
+			body = v
+		} else {
+			members[string(f.Name)] = v
+		}
+	}
+	if body == nil && len(members) > 0 {
+		body = members
+	}
+	if body == nil || status == http.StatusNoContent {
+		w.WriteHeader(status)
+		return status
+	}
+	writeJson(w, status, body)
+	return status
+}
+
+func writeJson(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	io.WriteString(w, model.Pretty(body))
+}
+
+// ParamString returns the string form of a value bound to a header, path, or query parameter. A list is joined with
+// commas.
+func ParamString(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case []any:
+		items := make([]string, 0, len(val))
+		for _, item := range val {
+			items = append(items, ParamString(item))
+		}
+		return strings.Join(items, ",")
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// normalize returns the JSON form of a value, with objects as map[string]any and numbers as float64, for comparing
+// values from a request with those of an example.
+func normalize(v any) any {
+	if v == nil {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var result any
+	if err := json.Unmarshal(b, &result); err != nil {
+		return v
+	}
+	return result
+}
+
+// MatchExample returns the example of the operation whose input matches the request input, or nil. Every field of an
+// example's input must be equal to the request's, the example with the most fields wins, and ties go to the first.
+func MatchExample(op *model.OperationDef, input map[string]any) *model.OperationExample {
+	var best *model.OperationExample
+	bestScore := -1
+	for _, ex := range op.Examples {
+		exInput, _ := normalize(ex.Input).(map[string]any)
+		score := 0
+		for name, v := range exInput {
+			if !model.Equivalent(v, input[name]) {
+				score = -1
+				break
+			}
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = ex, score
+		}
+	}
+	return best
+}
@@ -17,6 +17,7 @@ package model
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/boynton/data"
@@ -49,6 +50,10 @@ func (gen *ApiGenerator) Generate(schema *Schema, config *data.Object) error {
 	gen.ns = config.GetString("namespace")
 	if gen.ns == "" {
 		gen.ns = string(schema.ServiceNamespace())
+		if gen.ns == "" {
+			//a model with only types
+			gen.ns = string(schema.Namespace)
+		}
 	}
 	gen.name = string(schema.ServiceName())
 	gen.Begin()
@@ -58,7 +63,14 @@ func (gen *ApiGenerator) Generate(schema *Schema, config *data.Object) error {
 	gen.GenerateExceptions()
 	gen.GenerateTypes()
 	s := gen.End()
-	fname := gen.FileName(gen.name, ".api")
+	fbase := gen.name
+	if fbase == "" {
+		fbase = gen.ns
+	}
+	if fbase == "" {
+		fbase = "model"
+	}
+	fname := gen.FileName(fbase, ".api")
 	err = gen.Write(s, fname, "")
 	return err
 }
@@ -350,6 +362,8 @@ func prettyData(a any, indent string) string {
 	switch v := a.(type) {
 	case string:
 		return fmt.Sprintf("%q", v)
+	case bool:
+		return fmt.Sprint(v)
 	case float64:
 		return fmt.Sprintf("%g", v)
 	case int64:
@@ -376,12 +390,17 @@ func prettyData(a any, indent string) string {
 		i := 0
 		last := len(v) - 1
 		comma := ","
-		for k, v := range v {
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
 			if i == last {
 				comma = ""
 			}
 			i++
-			s = s + fmt.Sprintf("%s%q: %s%s\n", nextIndent, k, prettyData(v, nextIndent), comma)
+			s = s + fmt.Sprintf("%s%q: %s%s\n", nextIndent, k, prettyData(v[k], nextIndent), comma)
 		}
 		return s + indent + "}"
 	default:
@@ -0,0 +1,326 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package model
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/boynton/data"
+)
+
+// Violation is a way in which a JSON value does not conform to its type in the model.
+type Violation struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (v *Violation) String() string {
+	return v.Path + ": " + v.Message
+}
+
+// valueConstraints are those of a type, or of a field referring to it. The constraints of a field add to those of its
+// type.
+type valueConstraints struct {
+	minValue *data.Decimal
+	maxValue *data.Decimal
+	minSize  int64
+	maxSize  int64
+	pattern  string
+}
+
+func (c valueConstraints) merge(other valueConstraints) valueConstraints {
+	if c.minValue == nil {
+		c.minValue = other.minValue
+	}
+	if c.maxValue == nil {
+		c.maxValue = other.maxValue
+	}
+	if c.minSize == 0 {
+		c.minSize = other.minSize
+	}
+	if c.maxSize == 0 {
+		c.maxSize = other.maxSize
+	}
+	if c.pattern == "" {
+		c.pattern = other.pattern
+	}
+	return c
+}
+
+// Conform returns the violations of the type by a value decoded from JSON (i.e. with encoding/json into an any). The
+// path names the value in the violations, i.e. "body".
+func (schema *Schema) Conform(tid AbsoluteIdentifier, value any, path string) []*Violation {
+	var violations []*Violation
+	schema.conform(tid, valueConstraints{}, value, path, &violations)
+	return violations
+}
+
+// ConformInput returns the violations of an operation input field's type and constraints by a value.
+func (schema *Schema) ConformInput(f *OperationInputField, value any, path string) []*Violation {
+	var violations []*Violation
+	c := valueConstraints{minValue: f.MinValue, maxValue: f.MaxValue, minSize: f.MinSize, maxSize: f.MaxSize, pattern: f.Pattern}
+	schema.conform(f.Type, c, value, path, &violations)
+	return violations
+}
+
+// ConformOutput returns the violations of an operation output field's type and constraints by a value.
+func (schema *Schema) ConformOutput(f *OperationOutputField, value any, path string) []*Violation {
+	var violations []*Violation
+	c := valueConstraints{minValue: f.MinValue, maxValue: f.MaxValue, minSize: f.MinSize, maxSize: f.MaxSize, pattern: f.Pattern}
+	schema.conform(f.Type, c, value, path, &violations)
+	return violations
+}
+
+func violate(violations *[]*Violation, path string, format string, args ...any) {
+	*violations = append(*violations, &Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+var integerRanges = map[BaseType][2]float64{
+	BaseType_Int8:  {math.MinInt8, math.MaxInt8},
+	BaseType_Int16: {math.MinInt16, math.MaxInt16},
+	BaseType_Int32: {math.MinInt32, math.MaxInt32},
+	BaseType_Int64: {math.MinInt64, math.MaxInt64},
+}
+
+func (schema *Schema) conform(tid AbsoluteIdentifier, c valueConstraints, value any, path string, violations *[]*Violation) {
+	if tid == "base#Any" {
+		return
+	}
+	td := schema.GetTypeDef(tid)
+	if td != nil {
+		c = c.merge(valueConstraints{minValue: td.MinValue, maxValue: td.MaxValue, minSize: td.MinSize, maxSize: td.MaxSize, pattern: td.Pattern})
+	}
+	bt := schema.BaseType(tid)
+	switch bt {
+	case BaseType_Bool:
+		if _, ok := value.(bool); !ok {
+			violate(violations, path, "expected a boolean, got %s", describe(value))
+		}
+	case BaseType_Int8, BaseType_Int16, BaseType_Int32, BaseType_Int64, BaseType_Integer:
+		n, ok := value.(float64)
+		if !ok || n != math.Trunc(n) {
+			violate(violations, path, "expected an integer, got %s", describe(value))
+			return
+		}
+		if r, ok := integerRanges[bt]; ok && (n < r[0] || n > r[1]) {
+			violate(violations, path, "%v is out of the range of %s", value, bt)
+			return
+		}
+		conformValue(n, c, path, violations)
+	case BaseType_Float32, BaseType_Float64, BaseType_Decimal:
+		n, ok := value.(float64)
+		if !ok {
+			violate(violations, path, "expected a number, got %s", describe(value))
+			return
+		}
+		conformValue(n, c, path, violations)
+	case BaseType_String:
+		s, ok := value.(string)
+		if !ok {
+			violate(violations, path, "expected a string, got %s", describe(value))
+			return
+		}
+		conformSize(int64(utf8.RuneCountInString(s)), c, path, "characters", violations)
+		if c.pattern != "" {
+			if re := compiledPattern(c.pattern); re != nil && !re.MatchString(s) {
+				violate(violations, path, "%q does not match the pattern %q", s, c.pattern)
+			}
+		}
+	case BaseType_Timestamp:
+		s, ok := value.(string)
+		if !ok {
+			violate(violations, path, "expected a timestamp string, got %s", describe(value))
+			return
+		}
+		if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+			violate(violations, path, "%q is not an RFC 3339 timestamp", s)
+		}
+	case BaseType_Blob:
+		s, ok := value.(string)
+		if !ok {
+			violate(violations, path, "expected a base64 string, got %s", describe(value))
+			return
+		}
+		if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+			violate(violations, path, "not valid base64: %v", err)
+		}
+	case BaseType_Enum:
+		s, ok := value.(string)
+		if !ok {
+			violate(violations, path, "expected a string, got %s", describe(value))
+			return
+		}
+		if td != nil {
+			for _, el := range td.Elements {
+				if s == el.Value || (el.Value == "" && s == string(el.Symbol)) {
+					return
+				}
+			}
+			violate(violations, path, "%q is not a %s", s, StripNamespace(tid))
+		}
+	case BaseType_List:
+		items, ok := value.([]any)
+		if !ok {
+			violate(violations, path, "expected a list, got %s", describe(value))
+			return
+		}
+		conformSize(int64(len(items)), c, path, "items", violations)
+		if td != nil && td.Items != "" {
+			for i, item := range items {
+				schema.conform(td.Items, valueConstraints{}, item, fmt.Sprintf("%s[%d]", path, i), violations)
+			}
+		}
+	case BaseType_Map:
+		m, ok := value.(map[string]any)
+		if !ok {
+			violate(violations, path, "expected an object, got %s", describe(value))
+			return
+		}
+		conformSize(int64(len(m)), c, path, "entries", violations)
+		if td == nil {
+			return
+		}
+		for _, key := range sortedKeys(m) {
+			if td.Keys != "" && schema.BaseType(td.Keys) == BaseType_Enum {
+				schema.conform(td.Keys, valueConstraints{}, key, path+"."+key, violations)
+			}
+			if td.Items != "" {
+				schema.conform(td.Items, valueConstraints{}, m[key], path+"."+key, violations)
+			}
+		}
+	case BaseType_Struct:
+		m, ok := value.(map[string]any)
+		if !ok {
+			violate(violations, path, "expected an object, got %s", describe(value))
+			return
+		}
+		if td == nil {
+			return
+		}
+		known := make(map[string]bool, len(td.Fields))
+		for _, f := range td.Fields {
+			name := string(f.Name)
+			known[name] = true
+			v, present := m[name]
+			if !present || v == nil {
+				if f.Required {
+					violate(violations, path+"."+name, "missing required field")
+				}
+				continue
+			}
+			fc := valueConstraints{minValue: f.MinValue, maxValue: f.MaxValue, minSize: f.MinSize, maxSize: f.MaxSize, pattern: f.Pattern}
+			schema.conform(f.Type, fc, v, path+"."+name, violations)
+		}
+		for _, key := range sortedKeys(m) {
+			if !known[key] {
+				violate(violations, path+"."+key, "unexpected field of %s", StripNamespace(tid))
+			}
+		}
+	case BaseType_Union:
+		m, ok := value.(map[string]any)
+		if !ok {
+			violate(violations, path, "expected an object, got %s", describe(value))
+			return
+		}
+		if len(m) != 1 {
+			violate(violations, path, "expected exactly one variant of %s, got %d", StripNamespace(tid), len(m))
+		}
+		if td == nil {
+			return
+		}
+		for _, key := range sortedKeys(m) {
+			var variant *FieldDef
+			for _, f := range td.Fields {
+				if string(f.Name) == key {
+					variant = f
+				}
+			}
+			if variant == nil {
+				violate(violations, path+"."+key, "not a variant of %s", StripNamespace(tid))
+				continue
+			}
+			schema.conform(variant.Type, valueConstraints{}, m[key], path+"."+key, violations)
+		}
+	}
+}
+
+func conformValue(n float64, c valueConstraints, path string, violations *[]*Violation) {
+	if c.minValue != nil && n < c.minValue.AsFloat64() {
+		violate(violations, path, "%v is less than the minimum %s", n, c.minValue)
+	}
+	if c.maxValue != nil && n > c.maxValue.AsFloat64() {
+		violate(violations, path, "%v is greater than the maximum %s", n, c.maxValue)
+	}
+}
+
+func conformSize(size int64, c valueConstraints, path, unit string, violations *[]*Violation) {
+	if c.minSize != 0 && size < c.minSize {
+		violate(violations, path, "%d %s is fewer than the minimum %d", size, unit, c.minSize)
+	}
+	if c.maxSize != 0 && size > c.maxSize {
+		violate(violations, path, "%d %s is more than the maximum %d", size, unit, c.maxSize)
+	}
+}
+
+func describe(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return fmt.Sprint(v)
+	case float64:
+		return fmt.Sprintf("the number %v", v)
+	case string:
+		return fmt.Sprintf("the string %q", v)
+	case []any:
+		return "a list"
+	case map[string]any:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var patternCache = make(map[string]*regexp.Regexp, 0)
+var patternLock sync.Mutex
+
+// compiledPattern returns the compiled pattern, or nil if it is not a valid Go regular expression. Patterns are
+// compiled once, values are checked concurrently by the proxy.
+func compiledPattern(pattern string) *regexp.Regexp {
+	patternLock.Lock()
+	defer patternLock.Unlock()
+	re, ok := patternCache[pattern]
+	if !ok {
+		re, _ = regexp.Compile(pattern)
+		patternCache[pattern] = re
+	}
+	return re
+}
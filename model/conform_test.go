@@ -0,0 +1,47 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConformBlob(t *testing.T) {
+	schema := blobSchema(t)
+	valid := map[string]any{"name": "a.png", "content": "aGVsbG8=", "thumbnail": "d29ybGQ="}
+	if violations := schema.Conform("example#Attachment", valid, "attachment"); len(violations) != 0 {
+		t.Errorf("expected no violations, got %v", violations)
+	}
+	invalid := map[string]any{"name": "a.png", "content": map[string]any{}, "thumbnail": "not base64!"}
+	violations := schema.Conform("example#Attachment", invalid, "attachment")
+	var messages []string
+	for _, v := range violations {
+		messages = append(messages, v.String())
+	}
+	expected := []string{
+		"attachment.content: expected a base64 string",
+		"attachment.thumbnail: not valid base64",
+	}
+	if len(messages) != len(expected) {
+		t.Fatalf("expected %d violations, got %v", len(expected), messages)
+	}
+	for i, prefix := range expected {
+		if !strings.HasPrefix(messages[i], prefix) {
+			t.Errorf("expected a violation starting with %q, got %q", prefix, messages[i])
+		}
+	}
+}
@@ -0,0 +1,266 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// InputBinding is the split of the input fields of an operation by their HTTP binding.
+type InputBinding struct {
+	// Path is the path of the operation's URI, without its query, as literal text and labels
+	Path    []*PathSegment
+	Query   []*OperationInputField
+	Headers []*OperationInputField
+	Payload *OperationInputField
+	// Members are the fields without an HTTP binding, which are the members of the JSON request body when there is no
+	// payload field
+	Members []*OperationInputField
+}
+
+// PathSegment is either literal text of the path of an operation's URI, or a label substituted with the value of an
+// input field.
+type PathSegment struct {
+	Literal string
+	Label   string
+	// Field is the input field of the label, if the input has one
+	Field *OperationInputField
+	// Greedy is set for a label like "{key+}", which spans several path segments: the slashes of its value are kept
+	Greedy bool
+}
+
+// HttpInputBinding returns the HTTP binding of the input fields of an operation.
+func HttpInputBinding(op *OperationDef) *InputBinding {
+	b := &InputBinding{}
+	uri := op.HttpUri
+	if n := strings.Index(uri, "?"); n >= 0 {
+		uri = uri[:n]
+	}
+	for {
+		n := strings.Index(uri, "{")
+		if n < 0 {
+			break
+		}
+		m := strings.Index(uri[n:], "}")
+		if m < 0 {
+			break
+		}
+		if n > 0 {
+			b.Path = append(b.Path, &PathSegment{Literal: uri[:n]})
+		}
+		seg := &PathSegment{Label: uri[n+1 : n+m]}
+		if strings.HasSuffix(seg.Label, "+") {
+			seg.Label = seg.Label[:len(seg.Label)-1]
+			seg.Greedy = true
+		}
+		b.Path = append(b.Path, seg)
+		uri = uri[n+m+1:]
+	}
+	if uri != "" || len(b.Path) == 0 {
+		b.Path = append(b.Path, &PathSegment{Literal: uri})
+	}
+	if op.Input == nil {
+		return b
+	}
+	for _, f := range op.Input.Fields {
+		switch {
+		case f.HttpPath:
+			for _, seg := range b.Path {
+				if seg.Label == string(f.Name) {
+					seg.Field = f
+				}
+			}
+		case f.HttpQuery != "":
+			b.Query = append(b.Query, f)
+		case f.HttpHeader != "":
+			b.Headers = append(b.Headers, f)
+		case f.HttpPayload:
+			b.Payload = f
+		default:
+			b.Members = append(b.Members, f)
+		}
+	}
+	return b
+}
+
+// RequestInput returns the values of an operation's input fields in a request, in their JSON form. The path label
+// values are the ones returned by the Router.
+func RequestInput(schema *Schema, op *OperationDef, r *http.Request, params map[string]string) (map[string]any, error) {
+	input := make(map[string]any, 0)
+	if op.Input == nil {
+		return input, nil
+	}
+	var body []byte
+	var bodyRead bool
+	readBody := func() ([]byte, error) {
+		if !bodyRead && r.Body != nil {
+			var err error
+			if body, err = io.ReadAll(r.Body); err != nil {
+				return nil, err
+			}
+		}
+		bodyRead = true
+		return body, nil
+	}
+	var members map[string]any
+	for _, f := range op.Input.Fields {
+		name := string(f.Name)
+		switch {
+		case f.HttpPath:
+			if s, ok := params[name]; ok {
+				v, err := parseParam(schema, f.Type, s)
+				if err != nil {
+					return nil, fmt.Errorf("Bad path parameter %q: %v", name, err)
+				}
+				input[name] = v
+			}
+		case f.HttpQuery != "":
+			values := r.URL.Query()[string(f.HttpQuery)]
+			if len(values) == 0 {
+				continue
+			}
+			v, err := parseParams(schema, f.Type, values)
+			if err != nil {
+				return nil, fmt.Errorf("Bad query parameter %q: %v", f.HttpQuery, err)
+			}
+			input[name] = v
+		case f.HttpHeader != "":
+			s := r.Header.Get(f.HttpHeader)
+			if s == "" {
+				continue
+			}
+			v, err := parseParams(schema, f.Type, headerValues(schema, f.Type, s))
+			if err != nil {
+				return nil, fmt.Errorf("Bad header %q: %v", f.HttpHeader, err)
+			}
+			input[name] = v
+		default:
+			b, err := readBody()
+			if err != nil {
+				return nil, err
+			}
+			if len(b) == 0 {
+				continue
+			}
+			if f.HttpPayload {
+				var v any
+				if err := json.Unmarshal(b, &v); err != nil {
+					return nil, fmt.Errorf("Bad request body: %v", err)
+				}
+				input[name] = v
+				continue
+			}
+			if members == nil {
+				if err := json.Unmarshal(b, &members); err != nil {
+					return nil, fmt.Errorf("Bad request body: %v", err)
+				}
+			}
+			if v, ok := members[name]; ok {
+				input[name] = v
+			}
+		}
+	}
+	return input, nil
+}
+
+// ResponseOutput returns the values of the fields of an operation output, or exception, in a response, in their JSON
+// form.
+func ResponseOutput(schema *Schema, out *OperationOutput, header http.Header, body []byte) (map[string]any, error) {
+	output := make(map[string]any, 0)
+	var members map[string]any
+	for _, f := range out.Fields {
+		name := string(f.Name)
+		if f.HttpHeader != "" {
+			s := header.Get(f.HttpHeader)
+			if s == "" {
+				continue
+			}
+			v, err := parseParams(schema, f.Type, headerValues(schema, f.Type, s))
+			if err != nil {
+				return nil, fmt.Errorf("Bad header %q: %v", f.HttpHeader, err)
+			}
+			output[name] = v
+			continue
+		}
+		if len(body) == 0 {
+			continue
+		}
+		if f.HttpPayload {
+			var v any
+			if err := json.Unmarshal(body, &v); err != nil {
+				return nil, fmt.Errorf("Bad response body: %v", err)
+			}
+			output[name] = v
+			continue
+		}
+		if members == nil {
+			if err := json.Unmarshal(body, &members); err != nil {
+				return nil, fmt.Errorf("Bad response body: %v", err)
+			}
+		}
+		if v, ok := members[name]; ok {
+			output[name] = v
+		}
+	}
+	return output, nil
+}
+
+// headerValues returns the values of a header bound to a field, the items of a list are separated by commas.
+func headerValues(schema *Schema, tid AbsoluteIdentifier, s string) []string {
+	if schema.BaseType(tid) != BaseType_List {
+		return []string{s}
+	}
+	values := strings.Split(s, ",")
+	for i, item := range values {
+		values[i] = strings.TrimSpace(item)
+	}
+	return values
+}
+
+func parseParams(schema *Schema, tid AbsoluteIdentifier, values []string) (any, error) {
+	if schema.BaseType(tid) != BaseType_List {
+		return parseParam(schema, tid, values[0])
+	}
+	var itemType AbsoluteIdentifier = "base#String"
+	if td := schema.GetTypeDef(tid); td != nil && td.Items != "" {
+		itemType = td.Items
+	}
+	items := make([]any, 0, len(values))
+	for _, s := range values {
+		v, err := parseParam(schema, itemType, s)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+	}
+	return items, nil
+}
+
+func parseParam(schema *Schema, tid AbsoluteIdentifier, s string) (any, error) {
+	switch schema.BaseType(tid) {
+	case BaseType_Bool:
+		return strconv.ParseBool(s)
+	case BaseType_Int8, BaseType_Int16, BaseType_Int32, BaseType_Int64, BaseType_Float32,
+		BaseType_Float64, BaseType_Integer, BaseType_Decimal:
+		return strconv.ParseFloat(s, 64)
+	}
+	return s, nil
+}
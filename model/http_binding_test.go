@@ -0,0 +1,68 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package model
+
+import (
+	"testing"
+)
+
+func TestHttpInputBinding(t *testing.T) {
+	op := &OperationDef{
+		HttpMethod: "PUT",
+		HttpUri:    "/buckets/{bucket}/objects/{key+}?versioned=true",
+		Input: &OperationInput{
+			Fields: OperationInputFieldList{
+				{Name: "key", Type: "base#String", HttpPath: true},
+				{Name: "bucket", Type: "base#String", HttpPath: true},
+				{Name: "token", Type: "base#String", HttpHeader: "X-Token"},
+				{Name: "limit", Type: "base#Int32", HttpQuery: "limit"},
+				{Name: "name", Type: "base#String"},
+				{Name: "size", Type: "base#Int64"},
+			},
+		},
+	}
+	b := HttpInputBinding(op)
+	expected := []PathSegment{
+		{Literal: "/buckets/"},
+		{Label: "bucket"},
+		{Literal: "/objects/"},
+		{Label: "key", Greedy: true},
+	}
+	if len(b.Path) != len(expected) {
+		t.Fatalf("expected %d path segments, got %d", len(expected), len(b.Path))
+	}
+	for i, seg := range b.Path {
+		e := expected[i]
+		if seg.Literal != e.Literal || seg.Label != e.Label || seg.Greedy != e.Greedy {
+			t.Errorf("path segment %d: expected %+v, got %+v", i, e, *seg)
+		}
+		if seg.Label != "" && (seg.Field == nil || string(seg.Field.Name) != seg.Label) {
+			t.Errorf("path segment %d: expected the field %q", i, seg.Label)
+		}
+	}
+	if len(b.Query) != 1 || b.Query[0].Name != "limit" {
+		t.Errorf("expected the query field limit, got %v", b.Query)
+	}
+	if len(b.Headers) != 1 || b.Headers[0].Name != "token" {
+		t.Errorf("expected the header field token, got %v", b.Headers)
+	}
+	if b.Payload != nil {
+		t.Errorf("expected no payload, got %v", b.Payload.Name)
+	}
+	if len(b.Members) != 2 || b.Members[0].Name != "name" || b.Members[1].Name != "size" {
+		t.Errorf("expected the body members name and size, got %v", b.Members)
+	}
+}
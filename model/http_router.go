@@ -0,0 +1,172 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package model
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Router matches HTTP requests to the operations of a schema, by the HttpMethod and HttpUri of each operation under the
+// path of the service's Base URL.
+type Router struct {
+	base   string
+	routes []*route
+}
+
+type route struct {
+	op       *OperationDef
+	segments []string
+	literals int
+	greedy   bool
+}
+
+// NewRouter returns a Router for the operations of the schema. Routes with more literal path segments are preferred, so
+// that "/items/count" is matched before "/items/{id}".
+func NewRouter(schema *Schema) *Router {
+	router := &Router{base: basePath(schema.Base)}
+	for _, op := range schema.Operations {
+		if op.HttpMethod == "" || op.HttpUri == "" {
+			continue
+		}
+		uri := op.HttpUri
+		if n := strings.Index(uri, "?"); n >= 0 {
+			uri = uri[:n]
+		}
+		rt := &route{op: op, segments: splitPath(uri)}
+		for _, seg := range rt.segments {
+			if isLabel(seg) {
+				if strings.HasSuffix(seg, "+}") {
+					rt.greedy = true
+				}
+			} else {
+				rt.literals++
+			}
+		}
+		router.routes = append(router.routes, rt)
+	}
+	sort.SliceStable(router.routes, func(i, j int) bool {
+		ri, rj := router.routes[i], router.routes[j]
+		if ri.greedy != rj.greedy {
+			return rj.greedy
+		}
+		return ri.literals > rj.literals
+	})
+	return router
+}
+
+func basePath(base string) string {
+	if base == "" {
+		return ""
+	}
+	if u, err := url.Parse(base); err == nil {
+		base = u.Path
+	}
+	return strings.TrimRight(base, "/")
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func isLabel(seg string) bool {
+	return strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}")
+}
+
+// Match returns the operation for the method and the escaped path of a request, and the values of its path labels.
+// The operation is nil if no route matches.
+func (router *Router) Match(method, escapedPath string) (*OperationDef, map[string]string) {
+	segments, ok := router.pathSegments(escapedPath)
+	if !ok {
+		return nil, nil
+	}
+	for _, rt := range router.routes {
+		if rt.op.HttpMethod != method {
+			continue
+		}
+		if params, ok := rt.match(segments); ok {
+			return rt.op, params
+		}
+	}
+	return nil, nil
+}
+
+// Methods returns the methods of the routes matching the escaped path, for the Allow header of a 405 response.
+func (router *Router) Methods(escapedPath string) []string {
+	var methods []string
+	segments, ok := router.pathSegments(escapedPath)
+	if !ok {
+		return nil
+	}
+	seen := make(map[string]bool, 0)
+	for _, rt := range router.routes {
+		if _, ok := rt.match(segments); ok && !seen[rt.op.HttpMethod] {
+			seen[rt.op.HttpMethod] = true
+			methods = append(methods, rt.op.HttpMethod)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+func (router *Router) pathSegments(escapedPath string) ([]string, bool) {
+	if router.base != "" {
+		if escapedPath != router.base && !strings.HasPrefix(escapedPath, router.base+"/") {
+			return nil, false
+		}
+		escapedPath = escapedPath[len(router.base):]
+	}
+	return splitPath(escapedPath), true
+}
+
+func (rt *route) match(segments []string) (map[string]string, bool) {
+	params := make(map[string]string, 0)
+	for i, seg := range rt.segments {
+		if !isLabel(seg) {
+			if i >= len(segments) || segments[i] != seg {
+				return nil, false
+			}
+			continue
+		}
+		name := seg[1 : len(seg)-1]
+		if strings.HasSuffix(name, "+") {
+			//a greedy label takes the rest of the path, and must be the last segment of the template
+			if i >= len(segments) || i != len(rt.segments)-1 {
+				return nil, false
+			}
+			value, err := url.PathUnescape(strings.Join(segments[i:], "/"))
+			if err != nil {
+				return nil, false
+			}
+			params[name[:len(name)-1]] = value
+			return params, true
+		}
+		if i >= len(segments) {
+			return nil, false
+		}
+		value, err := url.PathUnescape(segments[i])
+		if err != nil || value == "" {
+			return nil, false
+		}
+		params[name] = value
+	}
+	return params, len(segments) == len(rt.segments)
+}
@@ -90,6 +90,15 @@ type StringList []string
 
 type AbsoluteIdentifierList []AbsoluteIdentifier
 
+// Annotations - traits (or other annotations) that have no specific
+// representation in the model, keyed by their absolute id, i.e.
+// "com.acme#owner". The values are arbitrary data.
+type Annotations map[AbsoluteIdentifier]any
+
+// Metadata - model-wide metadata, as found in Smithy "metadata" statements.
+// The values are arbitrary data.
+type Metadata map[string]any
+
 type FieldDefList []*FieldDef
 
 type EnumElementList []*EnumElement
@@ -99,52 +108,56 @@ type EnumElementList []*EnumElement
 // members in aggregate types. TypeDef could more properly be defined as a Union
 // of various types, but this structure is more convenient.
 type TypeDef struct {
-	Comment  string             `json:"comment,omitempty"`
-	Tags     StringList         `json:"tags,omitempty"`
-	MinValue *data.Decimal      `json:"minValue,omitempty"`
-	MaxValue *data.Decimal      `json:"maxValue,omitempty"`
-	MinSize  int64              `json:"minSize,omitempty"`
-	MaxSize  int64              `json:"maxSize,omitempty"`
-	Required bool               `json:"required,omitempty"`
-	Pattern  string             `json:"pattern,omitempty"`
-	Items    AbsoluteIdentifier `json:"items,omitempty"`
-	Keys     AbsoluteIdentifier `json:"keys,omitempty"`
-	Fields   FieldDefList       `json:"fields,omitempty"`
-	Elements EnumElementList    `json:"elements,omitempty"`
-	Id       AbsoluteIdentifier `json:"id"`
-	Base     BaseType           `json:"base"`
+	Comment     string             `json:"comment,omitempty"`
+	Tags        StringList         `json:"tags,omitempty"`
+	Annotations Annotations        `json:"annotations,omitempty"`
+	MinValue    *data.Decimal      `json:"minValue,omitempty"`
+	MaxValue    *data.Decimal      `json:"maxValue,omitempty"`
+	MinSize     int64              `json:"minSize,omitempty"`
+	MaxSize     int64              `json:"maxSize,omitempty"`
+	Required    bool               `json:"required,omitempty"`
+	Pattern     string             `json:"pattern,omitempty"`
+	Items       AbsoluteIdentifier `json:"items,omitempty"`
+	Keys        AbsoluteIdentifier `json:"keys,omitempty"`
+	Fields      FieldDefList       `json:"fields,omitempty"`
+	Elements    EnumElementList    `json:"elements,omitempty"`
+	Id          AbsoluteIdentifier `json:"id"`
+	Base        BaseType           `json:"base"`
 }
 
 // Field - describes each field in a structure or union.
 type FieldDef struct {
-	Comment  string             `json:"comment,omitempty"`
-	Tags     StringList         `json:"tags,omitempty"`
-	MinValue *data.Decimal      `json:"minValue,omitempty"`
-	MaxValue *data.Decimal      `json:"maxValue,omitempty"`
-	MinSize  int64              `json:"minSize,omitempty"`
-	MaxSize  int64              `json:"maxSize,omitempty"`
-	Required bool               `json:"required,omitempty"`
-	Pattern  string             `json:"pattern,omitempty"`
-	Items    AbsoluteIdentifier `json:"items,omitempty"`
-	Keys     AbsoluteIdentifier `json:"keys,omitempty"`
-	Fields   FieldDefList       `json:"fields,omitempty"`
-	Elements EnumElementList    `json:"elements,omitempty"`
-	Name     Identifier         `json:"name"`
-	Type     AbsoluteIdentifier `json:"type"`
+	Comment     string             `json:"comment,omitempty"`
+	Tags        StringList         `json:"tags,omitempty"`
+	Annotations Annotations        `json:"annotations,omitempty"`
+	MinValue    *data.Decimal      `json:"minValue,omitempty"`
+	MaxValue    *data.Decimal      `json:"maxValue,omitempty"`
+	MinSize     int64              `json:"minSize,omitempty"`
+	MaxSize     int64              `json:"maxSize,omitempty"`
+	Required    bool               `json:"required,omitempty"`
+	Pattern     string             `json:"pattern,omitempty"`
+	Items       AbsoluteIdentifier `json:"items,omitempty"`
+	Keys        AbsoluteIdentifier `json:"keys,omitempty"`
+	Fields      FieldDefList       `json:"fields,omitempty"`
+	Elements    EnumElementList    `json:"elements,omitempty"`
+	Name        Identifier         `json:"name"`
+	Type        AbsoluteIdentifier `json:"type"`
 }
 
 // Element - describes each element of an Enum type
 type EnumElement struct {
-	Comment string     `json:"comment,omitempty"`
-	Tags    StringList `json:"tags,omitempty"`
-	Symbol  Identifier `json:"symbol"`
-	Value   string     `json:"value,omitempty"`
+	Comment     string      `json:"comment,omitempty"`
+	Tags        StringList  `json:"tags,omitempty"`
+	Annotations Annotations `json:"annotations,omitempty"`
+	Symbol      Identifier  `json:"symbol"`
+	Value       string      `json:"value,omitempty"`
 }
 
 // ResourceDef - describes a resource, and its operations and sub-resources
 type ResourceDef struct {
 	Comment              string                 `json:"comment,omitempty"`
 	Tags                 StringList             `json:"tags,omitempty"`
+	Annotations          Annotations            `json:"annotations,omitempty"`
 	Id                   AbsoluteIdentifier     `json:"id"`
 	Create               AbsoluteIdentifier     `json:"create,omitempty"`
 	Read                 AbsoluteIdentifier     `json:"read,omitempty"`
@@ -159,15 +172,16 @@ type ResourceDef struct {
 
 // OperationDef - describes an operation, including its HTTP bindings
 type OperationDef struct {
-	Comment    string                 `json:"comment,omitempty"`
-	Tags       StringList             `json:"tags,omitempty"`
-	Id         AbsoluteIdentifier     `json:"id"`
-	HttpMethod string                 `json:"httpMethod,omitempty"`
-	HttpUri    string                 `json:"httpUri,omitempty"`
-	Input      *OperationInput        `json:"input,omitempty"`
-	Output     *OperationOutput       `json:"output,omitempty"`
-	Exceptions AbsoluteIdentifierList `json:"exceptions,omitempty"`
-	Examples   OperationExampleList   `json:"examples,omitempty"`
+	Comment     string                 `json:"comment,omitempty"`
+	Tags        StringList             `json:"tags,omitempty"`
+	Annotations Annotations            `json:"annotations,omitempty"`
+	Id          AbsoluteIdentifier     `json:"id"`
+	HttpMethod  string                 `json:"httpMethod,omitempty"`
+	HttpUri     string                 `json:"httpUri,omitempty"`
+	Input       *OperationInput        `json:"input,omitempty"`
+	Output      *OperationOutput       `json:"output,omitempty"`
+	Exceptions  AbsoluteIdentifierList `json:"exceptions,omitempty"`
+	Examples    OperationExampleList   `json:"examples,omitempty"`
 }
 
 type OperationOutputList []*OperationOutput
@@ -189,10 +203,11 @@ type OperationErrorExample struct {
 // OperationInput - the description of an operation input. It is similar to a
 // Struct definition, but with HTTP bindings.
 type OperationInput struct {
-	Comment string                  `json:"comment,omitempty"`
-	Tags    StringList              `json:"tags,omitempty"`
-	Id      AbsoluteIdentifier      `json:"id,omitempty"`
-	Fields  OperationInputFieldList `json:"fields,omitempty"`
+	Comment     string                  `json:"comment,omitempty"`
+	Tags        StringList              `json:"tags,omitempty"`
+	Annotations Annotations             `json:"annotations,omitempty"`
+	Id          AbsoluteIdentifier      `json:"id,omitempty"`
+	Fields      OperationInputFieldList `json:"fields,omitempty"`
 }
 
 type OperationInputFieldList []*OperationInputField
@@ -201,6 +216,7 @@ type OperationInputFieldList []*OperationInputField
 type OperationInputField struct {
 	Comment     string             `json:"comment,omitempty"`
 	Tags        StringList         `json:"tags,omitempty"`
+	Annotations Annotations        `json:"annotations,omitempty"`
 	MinValue    *data.Decimal      `json:"minValue,omitempty"`
 	MaxValue    *data.Decimal      `json:"maxValue,omitempty"`
 	MinSize     int64              `json:"minSize,omitempty"`
@@ -223,11 +239,12 @@ type OperationInputField struct {
 // OperationOutput - the description of an operation output. Similar to a Struct
 // definition, but with HTTP bindings. Also used for OperationExceptions.
 type OperationOutput struct {
-	Comment    string                   `json:"comment,omitempty"`
-	Tags       StringList               `json:"tags,omitempty"`
-	Id         AbsoluteIdentifier       `json:"id,omitempty"`
-	HttpStatus int32                    `json:"httpStatus,omitempty"`
-	Fields     OperationOutputFieldList `json:"fields,omitempty"`
+	Comment     string                   `json:"comment,omitempty"`
+	Tags        StringList               `json:"tags,omitempty"`
+	Annotations Annotations              `json:"annotations,omitempty"`
+	Id          AbsoluteIdentifier       `json:"id,omitempty"`
+	HttpStatus  int32                    `json:"httpStatus,omitempty"`
+	Fields      OperationOutputFieldList `json:"fields,omitempty"`
 }
 
 type OperationOutputFieldList []*OperationOutputField
@@ -236,6 +253,7 @@ type OperationOutputFieldList []*OperationOutputField
 type OperationOutputField struct {
 	Comment     string             `json:"comment,omitempty"`
 	Tags        StringList         `json:"tags,omitempty"`
+	Annotations Annotations        `json:"annotations,omitempty"`
 	MinValue    *data.Decimal      `json:"minValue,omitempty"`
 	MaxValue    *data.Decimal      `json:"maxValue,omitempty"`
 	MinSize     int64              `json:"minSize,omitempty"`
@@ -260,13 +278,15 @@ type ResourceDefList []*ResourceDef
 
 // ServiceDef - the definition of a service, consisting of Types and Operations
 type ServiceDef struct {
-	Comment    string              `json:"comment,omitempty"`
-	Tags       StringList          `json:"tags,omitempty"`
-	Id         AbsoluteIdentifier  `json:"id"`
-	Version    string              `json:"version,omitempty"`
-	Base       string              `json:"base,omitempty"`
-	Types      TypeDefList         `json:"types,omitempty"`
-	Resources  ResourceDefList     `json:"resources,omitempty"`
-	Operations OperationDefList    `json:"operations,omitempty"`
-	Exceptions OperationOutputList `json:"exceptions,omitempty"`
+	Comment     string              `json:"comment,omitempty"`
+	Tags        StringList          `json:"tags,omitempty"`
+	Annotations Annotations         `json:"annotations,omitempty"`
+	Id          AbsoluteIdentifier  `json:"id"`
+	Version     string              `json:"version,omitempty"`
+	Base        string              `json:"base,omitempty"`
+	Metadata    Metadata            `json:"metadata,omitempty"`
+	Types       TypeDefList         `json:"types,omitempty"`
+	Resources   ResourceDefList     `json:"resources,omitempty"`
+	Operations  OperationDefList    `json:"operations,omitempty"`
+	Exceptions  OperationOutputList `json:"exceptions,omitempty"`
 }
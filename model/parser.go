@@ -981,6 +981,10 @@ func (p *Parser) ExpectIdentifierList() ([]string, error) {
 		if tok.Type == CLOSE_BRACKET {
 			return lst, nil
 		}
+		if tok.Type == COMMA {
+			//the api generator separates the identifiers with commas
+			continue
+		}
 		if tok.Type != SYMBOL {
 			//		s, err := p.ExpectIdentifier()
 			//		if err != nil {
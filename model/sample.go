@@ -0,0 +1,371 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package model
+
+import (
+	"encoding/base64"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"regexp/syntax"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultSampleDepth is the depth of nested structs, lists, and maps below which a sample has its optional fields, and
+// beyond which a sample has only what its type requires.
+const DefaultSampleDepth = 6
+
+// The timestamps of samples are in the year after sampleEpoch.
+var sampleEpoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Sampler synthesises example values for the types of a model, as they would be decoded from JSON: numbers are float64,
+// objects are map[string]any, and lists are []any. The values conform to the types, and to the constraints of the
+// fields referring to them: strings match their pattern and size, numbers are in their range, lists and maps have
+// their sizes, enums are one of their elements, and unions are one of their variants. Strings are made realistic by the
+// names of their fields, i.e. an "email" is an email address.
+//
+// The samples are determined by the seed: each sample of a type, input, or output is the same for the same seed, no
+// matter which samples were taken before it. A Sampler is not safe for concurrent use.
+type Sampler struct {
+	Schema   *Schema
+	Seed     int64
+	MaxDepth int
+	rand     *rand.Rand
+}
+
+func NewSampler(schema *Schema, seed int64) *Sampler {
+	return &Sampler{Schema: schema, Seed: seed, MaxDepth: DefaultSampleDepth}
+}
+
+// Sample returns a sample value of the type.
+func (s *Sampler) Sample(tid AbsoluteIdentifier) any {
+	s.reseed(string(tid))
+	return s.value(tid, valueConstraints{}, StripNamespace(tid), 0)
+}
+
+// SampleInput returns a sample input of the operation: a value for every field, in the form of an example's input.
+func (s *Sampler) SampleInput(in *OperationInput) map[string]any {
+	input := make(map[string]any, 0)
+	if in == nil {
+		return input
+	}
+	s.reseed(string(in.Id))
+	for _, f := range in.Fields {
+		c := valueConstraints{minValue: f.MinValue, maxValue: f.MaxValue, minSize: f.MinSize, maxSize: f.MaxSize, pattern: f.Pattern}
+		input[string(f.Name)] = s.value(f.Type, c, string(f.Name), 1)
+	}
+	return input
+}
+
+// SampleOutput returns a sample output (or exception) of an operation: a value for every field, in the form of an
+// example's output.
+func (s *Sampler) SampleOutput(out *OperationOutput) map[string]any {
+	output := make(map[string]any, 0)
+	if out == nil {
+		return output
+	}
+	s.reseed(string(out.Id))
+	for _, f := range out.Fields {
+		c := valueConstraints{minValue: f.MinValue, maxValue: f.MaxValue, minSize: f.MinSize, maxSize: f.MaxSize, pattern: f.Pattern}
+		output[string(f.Name)] = s.value(f.Type, c, string(f.Name), 1)
+	}
+	return output
+}
+
+// SampleExample returns an example of the operation made of a sample input and output, for an operation without
+// examples.
+func (s *Sampler) SampleExample(op *OperationDef) *OperationExample {
+	return &OperationExample{
+		Title:  "Sample " + StripNamespace(op.Id),
+		Input:  s.SampleInput(op.Input),
+		Output: s.SampleOutput(op.Output),
+	}
+}
+
+func (s *Sampler) reseed(key string) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	s.rand = rand.New(rand.NewSource(s.Seed ^ int64(h.Sum64())))
+}
+
+func (s *Sampler) value(tid AbsoluteIdentifier, c valueConstraints, name string, depth int) any {
+	td := s.Schema.GetTypeDef(tid)
+	if td != nil {
+		c = c.merge(valueConstraints{minValue: td.MinValue, maxValue: td.MaxValue, minSize: td.MinSize, maxSize: td.MaxSize, pattern: td.Pattern})
+	}
+	bt := s.Schema.BaseType(tid)
+	switch bt {
+	case BaseType_Bool:
+		return s.rand.Intn(2) == 1
+	case BaseType_Int8, BaseType_Int16, BaseType_Int32, BaseType_Int64, BaseType_Integer:
+		lo, hi := s.numberRange(bt, c)
+		lo, hi = math.Ceil(lo), math.Floor(hi)
+		if hi < lo {
+			return lo
+		}
+		return lo + float64(s.rand.Int63n(int64(hi-lo)+1))
+	case BaseType_Float32, BaseType_Float64, BaseType_Decimal:
+		lo, hi := s.numberRange(bt, c)
+		n := math.Round((lo+s.rand.Float64()*(hi-lo))*100) / 100
+		return math.Max(lo, math.Min(hi, n))
+	case BaseType_String:
+		return s.stringValue(c, name)
+	case BaseType_Timestamp:
+		t := sampleEpoch.Add(time.Duration(s.rand.Int63n(365*24*3600)) * time.Second)
+		return t.Format(time.RFC3339)
+	case BaseType_Blob:
+		b := make([]byte, 8+s.rand.Intn(9))
+		s.rand.Read(b)
+		return base64.StdEncoding.EncodeToString(b)
+	case BaseType_Enum:
+		if td == nil || len(td.Elements) == 0 {
+			return name
+		}
+		el := td.Elements[s.rand.Intn(len(td.Elements))]
+		if el.Value != "" {
+			return el.Value
+		}
+		return string(el.Symbol)
+	case BaseType_List:
+		items := make([]any, 0)
+		if td == nil {
+			return items
+		}
+		for i, n := 0, s.size(c, depth); i < n; i++ {
+			items = append(items, s.value(td.Items, valueConstraints{}, name, depth+1))
+		}
+		return items
+	case BaseType_Map:
+		m := make(map[string]any, 0)
+		if td == nil {
+			return m
+		}
+		n := s.size(c, depth)
+		if kd := s.Schema.GetTypeDef(td.Keys); kd != nil && kd.Base == BaseType_Enum {
+			//distinct elements, as many as there are
+			for _, i := range s.rand.Perm(len(kd.Elements)) {
+				if len(m) == n {
+					break
+				}
+				key := kd.Elements[i].Value
+				if key == "" {
+					key = string(kd.Elements[i].Symbol)
+				}
+				m[key] = s.value(td.Items, valueConstraints{}, name, depth+1)
+			}
+			return m
+		}
+		for i := 0; len(m) < n && i < 10*n; i++ {
+			key := fmt.Sprint(s.value(td.Keys, valueConstraints{}, "key", depth+1))
+			if _, ok := m[key]; !ok {
+				m[key] = s.value(td.Items, valueConstraints{}, name, depth+1)
+			}
+		}
+		return m
+	case BaseType_Struct:
+		obj := make(map[string]any, 0)
+		if td == nil {
+			return obj
+		}
+		for _, f := range td.Fields {
+			if f.Required || depth < s.MaxDepth-1 {
+				fc := valueConstraints{minValue: f.MinValue, maxValue: f.MaxValue, minSize: f.MinSize, maxSize: f.MaxSize, pattern: f.Pattern}
+				obj[string(f.Name)] = s.value(f.Type, fc, string(f.Name), depth+1)
+			}
+		}
+		return obj
+	case BaseType_Union:
+		if td == nil || len(td.Fields) == 0 {
+			return map[string]any{}
+		}
+		//the first variant ends a recursive union
+		f := td.Fields[0]
+		if depth < s.MaxDepth {
+			f = td.Fields[s.rand.Intn(len(td.Fields))]
+		}
+		return map[string]any{string(f.Name): s.value(f.Type, valueConstraints{}, string(f.Name), depth+1)}
+	}
+	return name
+}
+
+// numberRange returns the range of the samples of a number: its constraints, within the range of its type, or a range
+// of realistic quantities when it is unconstrained.
+func (s *Sampler) numberRange(bt BaseType, c valueConstraints) (float64, float64) {
+	lo, hi := 0.0, 1000.0
+	if c.minValue != nil {
+		lo = c.minValue.AsFloat64()
+		if c.maxValue == nil {
+			hi = lo + 1000
+		}
+	}
+	if c.maxValue != nil {
+		hi = c.maxValue.AsFloat64()
+		if c.minValue == nil && hi < 0 {
+			lo = hi - 1000
+		}
+	}
+	if r, ok := integerRanges[bt]; ok {
+		lo, hi = math.Max(lo, r[0]), math.Min(hi, r[1])
+	}
+	if hi-lo > 1e6 {
+		//a wide range, like that of a type's minimum and maximum, is narrowed to realistic quantities
+		if lo <= 0 && hi >= 0 {
+			lo, hi = 0, math.Min(hi, 1000)
+		} else {
+			hi = lo + 1e6
+		}
+	}
+	if hi < lo {
+		hi = lo
+	}
+	return lo, hi
+}
+
+// size returns the number of items of a list or map: between its minimum and maximum size, and the fewest allowed at
+// the maximum depth.
+func (s *Sampler) size(c valueConstraints, depth int) int {
+	lo, hi := int(c.minSize), int(c.maxSize)
+	if depth >= s.MaxDepth {
+		return lo
+	}
+	if lo == 0 {
+		lo = 1
+	}
+	if hi == 0 || hi > lo+2 {
+		hi = lo + 2
+	}
+	if hi < lo {
+		return hi
+	}
+	return lo + s.rand.Intn(hi-lo+1)
+}
+
+// stringValue returns a string matching the pattern, or a realistic one for the name, of the size required.
+func (s *Sampler) stringValue(c valueConstraints, name string) string {
+	if c.pattern != "" {
+		if re, err := syntax.Parse(c.pattern, syntax.Perl); err == nil {
+			compiled := compiledPattern(c.pattern)
+			var str string
+			for i := 0; i < 10; i++ {
+				var sb strings.Builder
+				s.generate(re, &sb)
+				str = sb.String()
+				n := int64(utf8.RuneCountInString(str))
+				if (compiled == nil || compiled.MatchString(str)) && (c.minSize == 0 || n >= c.minSize) && (c.maxSize == 0 || n <= c.maxSize) {
+					break
+				}
+			}
+			return str
+		}
+	}
+	str := s.realisticString(name)
+	for c.minSize > 0 && int64(utf8.RuneCountInString(str)) < c.minSize {
+		str += string(rune('a' + s.rand.Intn(26)))
+	}
+	if c.maxSize > 0 && int64(utf8.RuneCountInString(str)) > c.maxSize {
+		str = string([]rune(str)[:c.maxSize])
+	}
+	return str
+}
+
+var sampleWords = []string{"alpha", "bravo", "cedar", "delta", "ember", "falcon", "granite", "harbor", "indigo", "juniper", "kestrel", "lumen", "maple", "nova", "orchid", "pixel", "quartz", "raven", "summit", "tundra"}
+var sampleNames = []string{"Ada Lovelace", "Alan Turing", "Grace Hopper", "Edsger Dijkstra", "Barbara Liskov", "Donald Knuth", "Frances Allen", "Ken Thompson"}
+
+// realisticString returns a string of the kind its name suggests: an id, an email address, a URL, a person's name, or
+// a phrase.
+func (s *Sampler) realisticString(name string) string {
+	word := sampleWords[s.rand.Intn(len(sampleWords))]
+	lower := strings.ToLower(name)
+	switch {
+	case strings.Contains(lower, "email"):
+		return word + "@example.com"
+	case strings.Contains(lower, "url") || strings.Contains(lower, "uri") || strings.Contains(lower, "href") || strings.Contains(lower, "link"):
+		return "https://example.com/" + word
+	case lower == "id" || strings.HasSuffix(name, "Id") || strings.HasSuffix(lower, "_id"):
+		return fmt.Sprintf("%s-%04d", word, s.rand.Intn(10000))
+	case strings.Contains(lower, "phone"):
+		return fmt.Sprintf("+1-555-01%02d", s.rand.Intn(100))
+	case lower == "name" || strings.HasSuffix(lower, "name") && !strings.Contains(lower, "file"):
+		return sampleNames[s.rand.Intn(len(sampleNames))]
+	case strings.Contains(lower, "description") || strings.Contains(lower, "comment") || strings.Contains(lower, "message") || strings.Contains(lower, "summary"):
+		return "The " + word + " " + sampleWords[s.rand.Intn(len(sampleWords))] + " is ready"
+	}
+	return word
+}
+
+// generate writes a random string matching the regular expression. Repetitions are bounded, and the characters of a
+// class are printable ASCII when the class has some.
+func (s *Sampler) generate(re *syntax.Regexp, sb *strings.Builder) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			sb.WriteRune(r)
+		}
+	case syntax.OpCharClass:
+		sb.WriteRune(s.classRune(re.Rune))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		sb.WriteRune(rune('a' + s.rand.Intn(26)))
+	case syntax.OpCapture:
+		s.generate(re.Sub[0], sb)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			s.generate(sub, sb)
+		}
+	case syntax.OpAlternate:
+		s.generate(re.Sub[s.rand.Intn(len(re.Sub))], sb)
+	case syntax.OpStar:
+		s.repeat(re.Sub[0], 0, 3, sb)
+	case syntax.OpPlus:
+		s.repeat(re.Sub[0], 1, 3, sb)
+	case syntax.OpQuest:
+		s.repeat(re.Sub[0], 0, 1, sb)
+	case syntax.OpRepeat:
+		max := re.Max
+		if max < 0 {
+			max = re.Min + 3
+		}
+		s.repeat(re.Sub[0], re.Min, max, sb)
+	}
+	//anchors, word boundaries, and empty matches have no characters
+}
+
+func (s *Sampler) repeat(re *syntax.Regexp, min, max int, sb *strings.Builder) {
+	for i, n := 0, min+s.rand.Intn(max-min+1); i < n; i++ {
+		s.generate(re, sb)
+	}
+}
+
+// classRune returns a rune of a character class, given as pairs of the bounds of its ranges.
+func (s *Sampler) classRune(ranges []rune) rune {
+	var printable []rune
+	for i := 0; i+1 < len(ranges); i += 2 {
+		for r := ranges[i]; r <= ranges[i+1] && r <= '~'; r++ {
+			if r >= ' ' {
+				printable = append(printable, r)
+			}
+		}
+	}
+	if len(printable) > 0 {
+		return printable[s.rand.Intn(len(printable))]
+	}
+	if len(ranges) >= 2 {
+		return ranges[0] + rune(s.rand.Intn(int(ranges[1]-ranges[0])+1))
+	}
+	return 'x'
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package model
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+// blobSchema has a struct with a field for each of the ids of the blob type: base#Blob, and base#Bytes as the Smithy,
+// protobuf, and GraphQL importers name it.
+func blobSchema(t *testing.T) *Schema {
+	schema := NewSchema()
+	err := schema.AddTypeDef(&TypeDef{
+		Id:   "example#Attachment",
+		Base: BaseType_Struct,
+		Fields: FieldDefList{
+			{Name: "name", Type: "base#String", Required: true},
+			{Name: "content", Type: "base#Bytes", Required: true},
+			{Name: "thumbnail", Type: "base#Blob", Required: true},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func TestSampleBlob(t *testing.T) {
+	sampler := NewSampler(blobSchema(t), 1)
+	sample, ok := sampler.Sample("example#Attachment").(map[string]any)
+	if !ok {
+		t.Fatalf("expected an object, got %#v", sampler.Sample("example#Attachment"))
+	}
+	for _, name := range []string{"content", "thumbnail"} {
+		s, ok := sample[name].(string)
+		if !ok {
+			t.Errorf("%s: expected a base64 string, got %#v", name, sample[name])
+			continue
+		}
+		if _, err := base64.StdEncoding.DecodeString(s); err != nil {
+			t.Errorf("%s: expected base64, got %q: %v", name, s, err)
+		}
+	}
+}
+
+func TestSampleIsSeeded(t *testing.T) {
+	schema := blobSchema(t)
+	a := Pretty(NewSampler(schema, 7).Sample("example#Attachment"))
+	b := Pretty(NewSampler(schema, 7).Sample("example#Attachment"))
+	if a != b {
+		t.Errorf("expected the same sample for the same seed, got %s and %s", a, b)
+	}
+}
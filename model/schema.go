@@ -128,6 +128,8 @@ func (schema *Schema) GetTypeDef(id AbsoluteIdentifier) *TypeDef {
 	return schema.typeIndex[id]
 }
 
+// GetExceptionDef returns the definition of an exception. Clients decode the fields of an exception leniently: the body
+// of an error response may not be what the model says, i.e. when it comes from a proxy.
 func (schema *Schema) GetExceptionDef(id AbsoluteIdentifier) *OperationOutput {
 	if schema.excIndex == nil {
 		schema.excIndex = make(map[AbsoluteIdentifier]*OperationOutput, 0)
@@ -174,7 +176,7 @@ func (out *OperationOutput) String() string {
 
 func (schema *Schema) BaseType(id AbsoluteIdentifier) BaseType {
 	switch id {
-	case "base#Blob":
+	case "base#Blob", "base#Bytes":
 		return BaseType_Blob
 	case "base#Bool":
 		return BaseType_Bool
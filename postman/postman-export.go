@@ -0,0 +1,383 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package postman
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/boynton/api/httptrace"
+	"github.com/boynton/api/model"
+	"github.com/boynton/data"
+)
+
+const CollectionSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+const CollectionSuffix = ".postman_collection.json"
+const EnvironmentSuffix = ".postman_environment.json"
+
+// BaseUrlVariable is the variable of the collection and the environment that the URL of every request starts with.
+const BaseUrlVariable = "baseUrl"
+
+// DefaultBaseUrl is the base URL of a service without one, the address of "api serve".
+const DefaultBaseUrl = "http://localhost:8080"
+
+// Generator writes a Postman (v2.1) collection of the operations of the service, with a folder for each resource, and
+// an environment with the base URL of the service. The request of each operation is filled in from its first example,
+// and every example is a saved response of the request.
+type Generator struct {
+	model.BaseGenerator
+	ns string
+}
+
+func (gen *Generator) GenerateResource(rez *model.ResourceDef) error {
+	return nil
+}
+
+func (gen *Generator) GenerateOperation(op *model.OperationDef) error {
+	return nil
+}
+
+func (gen *Generator) GenerateException(op *model.OperationOutput) error {
+	return nil
+}
+
+func (gen *Generator) GenerateType(td *model.TypeDef) error {
+	return nil
+}
+
+func (gen *Generator) Generate(schema *model.Schema, config *data.Object) error {
+	err := gen.Configure(schema, config)
+	if err != nil {
+		return err
+	}
+	gen.ns = config.GetString("namespace")
+	if gen.ns == "" {
+		gen.ns = string(schema.ServiceNamespace())
+		if gen.ns == "" {
+			gen.ns = string(schema.Namespace)
+		}
+	}
+	name := string(schema.ServiceName())
+	if name == "" {
+		name = gen.ns
+	}
+	baseUrl := schema.Base
+	if baseUrl == "" {
+		baseUrl = DefaultBaseUrl
+	}
+	baseUrl = strings.TrimSuffix(baseUrl, "/")
+	coll, err := gen.Collection(name, baseUrl)
+	if err != nil {
+		return err
+	}
+	fname := gen.FileName(gen.ns, CollectionSuffix)
+	err = gen.Write(model.Pretty(coll), fname, "\n\n------------------"+fname+"\n")
+	if err != nil {
+		return err
+	}
+	env := &Environment{
+		Name:   name,
+		Values: []*EnvironmentValue{{Key: BaseUrlVariable, Value: baseUrl, Type: "default", Enabled: true}},
+		Scope:  "environment",
+	}
+	fname = gen.FileName(gen.ns, EnvironmentSuffix)
+	return gen.Write(model.Pretty(env), fname, "\n\n------------------"+fname+"\n")
+}
+
+type Collection struct {
+	Info     *Info       `json:"info"`
+	Item     []*Item     `json:"item"`
+	Variable []*KeyValue `json:"variable,omitempty"`
+}
+
+type Info struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+// Item is a folder, with items, or a request, with its saved responses.
+type Item struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Item        []*Item     `json:"item,omitempty"`
+	Request     *Request    `json:"request,omitempty"`
+	Response    []*Response `json:"response,omitempty"`
+}
+
+type Request struct {
+	Method      string      `json:"method"`
+	Header      []*KeyValue `json:"header"`
+	Body        *Body       `json:"body,omitempty"`
+	Url         *Url        `json:"url"`
+	Description string      `json:"description,omitempty"`
+}
+
+type KeyValue struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+	Disabled    bool   `json:"disabled,omitempty"`
+}
+
+type Body struct {
+	Mode    string       `json:"mode"`
+	Raw     string       `json:"raw"`
+	Options *BodyOptions `json:"options,omitempty"`
+}
+
+type BodyOptions struct {
+	Raw struct {
+		Language string `json:"language"`
+	} `json:"raw"`
+}
+
+type Url struct {
+	Raw      string      `json:"raw"`
+	Host     []string    `json:"host"`
+	Path     []string    `json:"path,omitempty"`
+	Query    []*KeyValue `json:"query,omitempty"`
+	Variable []*KeyValue `json:"variable,omitempty"`
+}
+
+type Response struct {
+	Name            string      `json:"name"`
+	OriginalRequest *Request    `json:"originalRequest"`
+	Status          string      `json:"status"`
+	Code            int         `json:"code"`
+	PreviewLanguage string      `json:"_postman_previewlanguage,omitempty"`
+	Header          []*KeyValue `json:"header"`
+	Body            string      `json:"body"`
+}
+
+type Environment struct {
+	Name   string              `json:"name"`
+	Values []*EnvironmentValue `json:"values"`
+	Scope  string              `json:"_postman_variable_scope"`
+}
+
+type EnvironmentValue struct {
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Type    string `json:"type"`
+	Enabled bool   `json:"enabled"`
+}
+
+// Collection returns the collection of the operations. The operations of each resource are in its folder, named for
+// the resource, and the others follow the folders.
+func (gen *Generator) Collection(name string, baseUrl string) (*Collection, error) {
+	coll := &Collection{
+		Info:     &Info{Name: name, Description: gen.Schema.Comment, Schema: CollectionSchema},
+		Item:     []*Item{},
+		Variable: []*KeyValue{{Key: BaseUrlVariable, Value: baseUrl}},
+	}
+	foldered := make(map[model.AbsoluteIdentifier]bool, 0)
+	for _, rez := range gen.Schema.Resources {
+		folder := &Item{Name: resourceName(rez), Description: rez.Comment, Item: []*Item{}}
+		for _, oid := range resourceOperations(gen.Schema, rez) {
+			op := gen.Schema.GetOperationDef(oid)
+			if op == nil || foldered[oid] || op.HttpMethod == "" {
+				continue
+			}
+			foldered[oid] = true
+			item, err := gen.RequestItem(op)
+			if err != nil {
+				return nil, err
+			}
+			folder.Item = append(folder.Item, item)
+		}
+		if len(folder.Item) > 0 {
+			coll.Item = append(coll.Item, folder)
+		}
+	}
+	for _, op := range gen.Operations() {
+		if foldered[op.Id] || op.HttpMethod == "" {
+			continue
+		}
+		item, err := gen.RequestItem(op)
+		if err != nil {
+			return nil, err
+		}
+		coll.Item = append(coll.Item, item)
+	}
+	return coll, nil
+}
+
+// resourceOperations are all operations of the resource, in the order of its lifecycle.
+func resourceOperations(schema *model.Schema, rez *model.ResourceDef) []model.AbsoluteIdentifier {
+	ops := schema.ResourceOperations(rez)
+	if rez.Put != "" {
+		ops = append(ops, rez.Put)
+	}
+	return append(ops, rez.CollectionOperations...)
+}
+
+func resourceName(rez *model.ResourceDef) string {
+	name := model.StripNamespace(rez.Id)
+	if trimmed := strings.TrimSuffix(name, "Resource"); trimmed != "" {
+		return trimmed
+	}
+	return name
+}
+
+// RequestItem returns the request of the operation, filled in from its first example, and a saved response for each
+// example.
+func (gen *Generator) RequestItem(op *model.OperationDef) (*Item, error) {
+	item := &Item{Name: model.StripNamespace(op.Id), Response: []*Response{}}
+	var first *model.OperationExample
+	if len(op.Examples) > 0 {
+		first = op.Examples[0]
+	}
+	item.Request = gen.Request(op, first)
+	item.Request.Description = op.Comment
+	for _, example := range op.Examples {
+		res, err := gen.Response(op, example)
+		if err != nil {
+			return nil, err
+		}
+		item.Response = append(item.Response, res)
+	}
+	return item, nil
+}
+
+// Request returns the request of the operation with the values of the example, which may be nil. The query parameters
+// and headers without a value in the example are included, disabled, for the user to fill in.
+func (gen *Generator) Request(op *model.OperationDef, example *model.OperationExample) *Request {
+	input := data.NewObject()
+	if example != nil {
+		input = data.AsObject(example.Input)
+		if input == nil {
+			input = data.NewObject()
+		}
+	}
+	req := &Request{Method: op.HttpMethod, Header: []*KeyValue{}, Url: &Url{Host: []string{"{{" + BaseUrlVariable + "}}"}}}
+	path := op.HttpUri
+	if n := strings.Index(path, "?"); n >= 0 {
+		path = path[:n]
+	}
+	for _, seg := range strings.Split(strings.Trim(path, "/"), "/") {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			seg = ":" + strings.TrimSuffix(strings.Trim(seg, "{}"), "+")
+		}
+		if seg != "" {
+			req.Url.Path = append(req.Url.Path, seg)
+		}
+	}
+	var query []string
+	var members *data.Object
+	if op.Input != nil {
+		for _, f := range op.Input.Fields {
+			name := string(f.Name)
+			v := input.Get(name)
+			switch {
+			case f.HttpPath:
+				req.Url.Variable = append(req.Url.Variable, &KeyValue{Key: name, Value: stringValue(v), Description: f.Comment})
+			case f.HttpQuery != "":
+				key := string(f.HttpQuery)
+				if !input.Has(name) {
+					req.Url.Query = append(req.Url.Query, &KeyValue{Key: key, Description: f.Comment, Disabled: true})
+					continue
+				}
+				//a list is the repeated parameter
+				for _, sv := range stringValues(v) {
+					req.Url.Query = append(req.Url.Query, &KeyValue{Key: key, Value: sv, Description: f.Comment})
+					query = append(query, key+"="+sv)
+				}
+			case f.HttpHeader != "":
+				req.Header = append(req.Header, &KeyValue{Key: f.HttpHeader, Value: strings.Join(stringValues(v), ", "), Description: f.Comment, Disabled: !input.Has(name)})
+			case f.HttpPayload:
+				if input.Has(name) {
+					req.Body = jsonBody(v)
+				}
+			default:
+				if input.Has(name) {
+					if members == nil {
+						members = data.NewObject()
+					}
+					members.Put(name, v)
+				}
+			}
+		}
+	}
+	if req.Body == nil && members != nil {
+		req.Body = jsonBody(members)
+	}
+	req.Header = append(req.Header, &KeyValue{Key: "Accept", Value: "application/json"})
+	if req.Body != nil {
+		req.Header = append(req.Header, &KeyValue{Key: "Content-Type", Value: "application/json"})
+	}
+	req.Url.Raw = "{{" + BaseUrlVariable + "}}"
+	if len(req.Url.Path) > 0 {
+		req.Url.Raw += "/" + strings.Join(req.Url.Path, "/")
+	}
+	if len(query) > 0 {
+		req.Url.Raw += "?" + strings.Join(query, "&")
+	}
+	return req
+}
+
+// Response returns the saved response of the example, with the request of its input.
+func (gen *Generator) Response(op *model.OperationDef, example *model.OperationExample) (*Response, error) {
+	ex, err := httptrace.ExampleExchange(gen.Schema, op, example)
+	if err != nil {
+		return nil, err
+	}
+	res := &Response{
+		Name:            example.Title,
+		OriginalRequest: gen.Request(op, example),
+		Status:          http.StatusText(ex.Status),
+		Code:            ex.Status,
+		Header:          []*KeyValue{},
+	}
+	for _, h := range ex.ResponseHeaders {
+		res.Header = append(res.Header, &KeyValue{Key: h.Name, Value: h.Value})
+	}
+	if ex.ResponseBody != nil {
+		res.Header = append(res.Header, &KeyValue{Key: "Content-Type", Value: "application/json"})
+		res.PreviewLanguage = "json"
+		res.Body = strings.TrimSuffix(model.Pretty(ex.ResponseBody), "\n")
+	}
+	return res, nil
+}
+
+func jsonBody(v any) *Body {
+	body := &Body{Mode: "raw", Raw: strings.TrimSuffix(model.Pretty(v), "\n"), Options: &BodyOptions{}}
+	body.Options.Raw.Language = "json"
+	return body
+}
+
+func stringValues(v any) []string {
+	if v == nil {
+		return nil
+	}
+	if items, ok := v.([]any); ok {
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			values = append(values, stringValue(item))
+		}
+		return values
+	}
+	return []string{stringValue(v)}
+}
+
+func stringValue(v any) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,533 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package postman
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/boynton/api/model"
+	"github.com/boynton/api/traffic"
+	"github.com/boynton/data"
+)
+
+// IsCollectionFile is true if the JSON file is a Postman collection, identified by the schema of its info.
+func IsCollectionFile(path string) bool {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var raw struct {
+		Info *struct {
+			Schema string `json:"schema"`
+		} `json:"info"`
+	}
+	if json.Unmarshal(b, &raw) != nil {
+		return false
+	}
+	return raw.Info != nil && strings.Contains(raw.Info.Schema, "schema.getpostman.com")
+}
+
+// Import builds a model from Postman (v2.1) collections. Each request is an operation named for it, with the path
+// parameters of its ":name" and "{{name}}" path segments, and its query parameters and headers, including the disabled
+// ones. The types of the bodies and parameters are inferred from the values of the request and its saved responses,
+// as the traffic importer does, and the saved responses are the examples of the operation. Each folder is a resource
+// of its operations. Variables are resolved from the collection's variables, so that "{{baseUrl}}" becomes the base of
+// the service. The service is named by "postman.service", or for the first collection.
+func Import(paths []string, tags []string, ns string, conf *data.Object) (*model.Schema, error) {
+	imp := &importer{
+		mb:     traffic.NewModelBuilder(ns),
+		ns:     ns,
+		rezIds: make(map[string]bool, 0),
+	}
+	name := conf.GetString("postman.service")
+	comment := ""
+	for _, path := range paths {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var coll importCollection
+		if err := json.Unmarshal(b, &coll); err != nil {
+			return nil, fmt.Errorf("Cannot parse Postman collection %s: %v", path, err)
+		}
+		if name == "" {
+			name = symbol(coll.Info.Name)
+		}
+		if comment == "" {
+			comment = description(coll.Info.Description)
+		}
+		imp.vars = make(map[string]string, 0)
+		for _, v := range coll.Variable {
+			if !v.Disabled {
+				imp.vars[v.Key] = stringValue(v.Value)
+			}
+		}
+		if err := imp.items(coll.Item, path, &folder{}); err != nil {
+			return nil, err
+		}
+	}
+	if len(imp.ops) == 0 {
+		return nil, fmt.Errorf("No requests found in %s", strings.Join(paths, ", "))
+	}
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "Service" + name
+	}
+	if err := imp.mb.Build(name); err != nil {
+		return nil, err
+	}
+	schema := imp.mb.Schema()
+	schema.Comment = comment
+	for _, f := range imp.folders {
+		rez := &model.ResourceDef{Id: f.id, Comment: f.comment}
+		for _, op := range f.ops {
+			rez.Operations = append(rez.Operations, op.Id)
+		}
+		for _, sub := range f.folders {
+			rez.Resources = append(rez.Resources, sub.id)
+		}
+		if err := schema.AddResourceDef(rez); err != nil {
+			return nil, err
+		}
+	}
+	if len(tags) > 0 {
+		schema.Filter(tags)
+	}
+	return schema, nil
+}
+
+// The collection as it is read: the descriptions may be strings or objects with content, URLs may be strings or
+// objects, and values may be of any JSON type.
+type importCollection struct {
+	Info struct {
+		Name        string `json:"name"`
+		Description any    `json:"description"`
+	} `json:"info"`
+	Item     []*importItem     `json:"item"`
+	Variable []*importKeyValue `json:"variable"`
+}
+
+type importItem struct {
+	Name        string            `json:"name"`
+	Description any               `json:"description"`
+	Item        []*importItem     `json:"item"`
+	Request     *importRequest    `json:"request"`
+	Response    []*importResponse `json:"response"`
+}
+
+type importRequest struct {
+	Method      string            `json:"method"`
+	Header      []*importKeyValue `json:"header"`
+	Body        *Body             `json:"body"`
+	Url         any               `json:"url"`
+	Description any               `json:"description"`
+}
+
+type importResponse struct {
+	Name            string            `json:"name"`
+	OriginalRequest *importRequest    `json:"originalRequest"`
+	Code            int               `json:"code"`
+	Header          []*importKeyValue `json:"header"`
+	Body            string            `json:"body"`
+}
+
+type importKeyValue struct {
+	Key      string `json:"key"`
+	Value    any    `json:"value"`
+	Disabled bool   `json:"disabled"`
+}
+
+type importUrl struct {
+	Raw      string            `json:"raw"`
+	Protocol string            `json:"protocol"`
+	Host     any               `json:"host"`
+	Port     string            `json:"port"`
+	Path     any               `json:"path"`
+	Query    []*importKeyValue `json:"query"`
+	Variable []*importKeyValue `json:"variable"`
+}
+
+type folder struct {
+	id      model.AbsoluteIdentifier
+	comment string
+	ops     []*traffic.Operation
+	folders []*folder
+}
+
+type importer struct {
+	mb      *traffic.ModelBuilder
+	ns      string
+	vars    map[string]string
+	rezIds  map[string]bool
+	ops     []*traffic.Operation
+	folders []*folder
+}
+
+// items adds the operations of the requests, and a resource for each folder, to the folder of the items.
+func (imp *importer) items(items []*importItem, path string, parent *folder) error {
+	for _, item := range items {
+		if item.Request == nil {
+			name := symbol(item.Name)
+			if name == "" {
+				name = "Folder"
+			}
+			unique := name + "Resource"
+			for i := 2; imp.rezIds[unique]; i++ {
+				unique = fmt.Sprintf("%s%dResource", name, i)
+			}
+			imp.rezIds[unique] = true
+			f := &folder{id: model.AbsoluteIdentifier(imp.ns + "#" + unique), comment: description(item.Description)}
+			imp.folders = append(imp.folders, f)
+			parent.folders = append(parent.folders, f)
+			if err := imp.items(item.Item, path, f); err != nil {
+				return err
+			}
+			continue
+		}
+		op, err := imp.operation(item, path)
+		if err != nil {
+			return err
+		}
+		imp.mb.AddOperation(op)
+		imp.ops = append(imp.ops, op)
+		parent.ops = append(parent.ops, op)
+	}
+	return nil
+}
+
+// operation returns the operation of the request item, with an exchange for each saved response, or one of the
+// request alone if there are none and its path variables all have values.
+func (imp *importer) operation(item *importItem, path string) (*traffic.Operation, error) {
+	req := item.Request
+	op := &traffic.Operation{
+		Name:    symbol(item.Name),
+		Comment: description(req.Description),
+		Method:  strings.ToUpper(req.Method),
+	}
+	if op.Method == "" {
+		op.Method = "GET"
+	}
+	if op.Name == "" {
+		op.Name = symbol(strings.ToLower(op.Method))
+	}
+	if op.Comment == "" {
+		op.Comment = description(item.Description)
+	}
+	template, err := imp.template(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: request %q: %v", path, item.Name, err)
+	}
+	op.Path = template
+	u := imp.url(req.Url)
+	for _, q := range u.Query {
+		if q.Disabled && q.Key != "" {
+			op.Query = append(op.Query, q.Key)
+		}
+	}
+	for _, h := range req.Header {
+		if h.Disabled && h.Key != "" {
+			op.Headers = append(op.Headers, h.Key)
+		}
+	}
+	if len(item.Response) == 0 && imp.resolved(req) {
+		x, err := imp.exchange(item.Name, req)
+		if err != nil {
+			return nil, fmt.Errorf("%s: request %q: %v", path, item.Name, err)
+		}
+		op.Exchanges = append(op.Exchanges, x)
+	}
+	for _, res := range item.Response {
+		original := res.OriginalRequest
+		if original == nil {
+			original = req
+		}
+		x, err := imp.exchange(res.Name, original)
+		if err != nil {
+			return nil, fmt.Errorf("%s: response %q of request %q: %v", path, res.Name, item.Name, err)
+		}
+		x.Status = res.Code
+		x.ResponseHeader = headers(res.Header, imp.vars)
+		x.ResponseBody = []byte(res.Body)
+		op.Exchanges = append(op.Exchanges, x)
+	}
+	return op, nil
+}
+
+// template returns the path template of the request, where ":name" and "{{name}}" segments are "{name}" parameters.
+func (imp *importer) template(req *importRequest) (string, error) {
+	_, prefix := imp.base(imp.url(req.Url))
+	segments := prefix
+	for _, seg := range imp.segments(req.Url) {
+		if name := pathVariable(seg); name != "" {
+			if !model.IsSymbol(name) {
+				return "", fmt.Errorf("bad path variable %q", name)
+			}
+			seg = "{" + name + "}"
+		} else {
+			seg = url.PathEscape(imp.resolve(seg))
+		}
+		segments = append(segments, seg)
+	}
+	return "/" + strings.Join(segments, "/"), nil
+}
+
+// exchange returns the exchange of the request, with the values of its variables. A variable without a value is left
+// as it is written.
+func (imp *importer) exchange(title string, req *importRequest) (*traffic.Exchange, error) {
+	u := imp.url(req.Url)
+	vars := make(map[string]string, 0)
+	for k, v := range imp.vars {
+		vars[k] = v
+	}
+	for _, v := range u.Variable {
+		vars[v.Key] = stringValue(v.Value)
+	}
+	origin, prefix := imp.base(u)
+	var path strings.Builder
+	for _, seg := range prefix {
+		path.WriteString("/" + seg)
+	}
+	for _, seg := range imp.segments(req.Url) {
+		if name := pathVariable(seg); name != "" {
+			if v := vars[name]; v != "" {
+				seg = v
+			}
+		} else {
+			seg = imp.resolve(seg)
+		}
+		path.WriteString("/" + url.PathEscape(seg))
+	}
+	var query []string
+	for _, q := range u.Query {
+		if !q.Disabled && q.Key != "" {
+			query = append(query, url.QueryEscape(imp.resolve(q.Key))+"="+url.QueryEscape(imp.resolve(stringValue(q.Value))))
+		}
+	}
+	//an unresolved base makes the URL relative
+	raw := origin + path.String()
+	if len(query) > 0 {
+		raw += "?" + strings.Join(query, "&")
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("bad URL %q", raw)
+	}
+	x := &traffic.Exchange{
+		Title:          title,
+		Method:         strings.ToUpper(req.Method),
+		Url:            parsed,
+		RequestHeader:  headers(req.Header, imp.vars),
+		ResponseHeader: make(http.Header, 0),
+	}
+	if x.Method == "" {
+		x.Method = "GET"
+	}
+	if req.Body != nil && req.Body.Mode == "raw" {
+		x.RequestBody = []byte(imp.resolve(req.Body.Raw))
+	}
+	return x, nil
+}
+
+// resolved is true if all path variables of the request have values.
+func (imp *importer) resolved(req *importRequest) bool {
+	vars := make(map[string]string, 0)
+	for _, v := range imp.url(req.Url).Variable {
+		vars[v.Key] = stringValue(v.Value)
+	}
+	for _, seg := range imp.segments(req.Url) {
+		if name := pathVariable(seg); name != "" && vars[name] == "" && imp.vars[name] == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// url returns the URL of the request as an object, parsing the raw URL of the string form.
+func (imp *importer) url(v any) *importUrl {
+	u := &importUrl{}
+	switch val := v.(type) {
+	case string:
+		u.Raw = val
+	case map[string]any:
+		b, _ := json.Marshal(val)
+		json.Unmarshal(b, u)
+	}
+	if u.Host == nil && u.Path == nil && u.Raw != "" {
+		raw := u.Raw
+		if n := strings.Index(raw, "?"); n >= 0 {
+			for _, pair := range strings.Split(raw[n+1:], "&") {
+				if pair != "" {
+					kv := strings.SplitN(pair, "=", 2)
+					q := &importKeyValue{Key: kv[0]}
+					if len(kv) > 1 {
+						q.Value = kv[1]
+					}
+					u.Query = append(u.Query, q)
+				}
+			}
+			raw = raw[:n]
+		}
+		if n := strings.Index(raw, "://"); n >= 0 {
+			u.Protocol = raw[:n]
+			raw = raw[n+3:]
+		}
+		parts := strings.SplitN(raw, "/", 2)
+		u.Host = parts[0]
+		if len(parts) > 1 {
+			u.Path = parts[1]
+		}
+	}
+	return u
+}
+
+// segments are the path segments of the URL of the request, as they are written.
+func (imp *importer) segments(v any) []string {
+	var segments []string
+	switch path := imp.url(v).Path.(type) {
+	case string:
+		for _, seg := range strings.Split(strings.Trim(path, "/"), "/") {
+			if seg != "" {
+				segments = append(segments, seg)
+			}
+		}
+	case []any:
+		for _, seg := range path {
+			switch s := seg.(type) {
+			case string:
+				if s != "" {
+					segments = append(segments, s)
+				}
+			case map[string]any:
+				//a segment object, with its value
+				segments = append(segments, stringValue(s["value"]))
+			}
+		}
+	}
+	return segments
+}
+
+// base returns the scheme and host of the URL, with its variables resolved, and the escaped segments of the path that
+// the variable a collection's host usually is, like "{{baseUrl}}", may have. The scheme and host are empty if they
+// cannot be resolved.
+func (imp *importer) base(u *importUrl) (string, []string) {
+	host := ""
+	switch h := u.Host.(type) {
+	case string:
+		host = h
+	case []any:
+		var parts []string
+		for _, part := range h {
+			parts = append(parts, stringValue(part))
+		}
+		host = strings.Join(parts, ".")
+	}
+	host = imp.resolve(host)
+	if u.Port != "" {
+		host += ":" + u.Port
+	}
+	if strings.Contains(host, "{{") {
+		return "", nil
+	}
+	if !strings.Contains(host, "://") {
+		protocol := u.Protocol
+		if protocol == "" {
+			protocol = "http"
+		}
+		host = protocol + "://" + host
+	}
+	b, err := url.Parse(host)
+	if err != nil || b.Host == "" {
+		return "", nil
+	}
+	var prefix []string
+	for _, seg := range strings.Split(strings.Trim(b.EscapedPath(), "/"), "/") {
+		if seg != "" {
+			prefix = append(prefix, seg)
+		}
+	}
+	return b.Scheme + "://" + b.Host, prefix
+}
+
+var variable = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+
+// resolve replaces the variables of the collection with their values.
+func (imp *importer) resolve(s string) string {
+	return resolve(s, imp.vars)
+}
+
+func resolve(s string, vars map[string]string) string {
+	return variable.ReplaceAllStringFunc(s, func(ref string) string {
+		if v, ok := vars[strings.TrimSpace(ref[2:len(ref)-2])]; ok {
+			return v
+		}
+		return ref
+	})
+}
+
+// pathVariable returns the name of a path segment that is a variable, i.e. ":itemId" or "{{itemId}}".
+func pathVariable(seg string) string {
+	if strings.HasPrefix(seg, ":") {
+		return seg[1:]
+	}
+	if m := variable.FindStringSubmatch(seg); m != nil && m[0] == seg {
+		return strings.TrimSpace(m[1])
+	}
+	return ""
+}
+
+func headers(kvs []*importKeyValue, vars map[string]string) http.Header {
+	h := make(http.Header, 0)
+	for _, kv := range kvs {
+		if !kv.Disabled && kv.Key != "" {
+			h.Add(kv.Key, resolve(stringValue(kv.Value), vars))
+		}
+	}
+	return h
+}
+
+// description returns the text of a description, which may be an object with its content.
+func description(v any) string {
+	switch d := v.(type) {
+	case string:
+		return d
+	case map[string]any:
+		return stringValue(d["content"])
+	}
+	return ""
+}
+
+// symbol makes a name for a model element of the name of a Postman item, i.e. "Get an item" becomes "GetAnItem".
+func symbol(name string) string {
+	var sb strings.Builder
+	upper := true
+	for _, ch := range name {
+		if !(ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9') {
+			upper = true
+			continue
+		}
+		if upper && ch >= 'a' && ch <= 'z' {
+			ch = ch - 'a' + 'A'
+		}
+		upper = false
+		sb.WriteRune(ch)
+	}
+	return sb.String()
+}
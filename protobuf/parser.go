@@ -0,0 +1,974 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package protobuf
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// File is the parsed form of a single .proto file. Options are keyed by their name, with the parentheses of custom
+// options removed (i.e. "google.api.http"), and options set by field, like "(google.api.http).get", are nested. Option
+// values are strings, float64, bool, or, for aggregate values, map[string]interface{} (a repeated key in an aggregate
+// produces a []interface{}).
+type File struct {
+	Path     string
+	Syntax   string
+	Package  string
+	Imports  []string
+	Options  map[string]interface{}
+	Messages []*Message
+	Enums    []*Enum
+	Services []*Service
+}
+
+type Message struct {
+	Name     string
+	FullName string
+	Comment  string
+	Fields   []*Field
+	Oneofs   []*Oneof
+	Messages []*Message
+	Enums    []*Enum
+	Options  map[string]interface{}
+}
+
+// Field - a message field. For map fields KeyType is set and Type is the value type. Label is "repeated", "optional",
+// "required", or empty.
+type Field struct {
+	Name    string
+	Type    string
+	KeyType string
+	Label   string
+	Number  int
+	Comment string
+	Oneof   string
+	Options map[string]interface{}
+}
+
+type Oneof struct {
+	Name    string
+	Comment string
+	Fields  []*Field
+}
+
+type Enum struct {
+	Name     string
+	FullName string
+	Comment  string
+	Values   []*EnumValue
+	Options  map[string]interface{}
+}
+
+type EnumValue struct {
+	Name    string
+	Number  int
+	Comment string
+	Options map[string]interface{}
+}
+
+type Service struct {
+	Name     string
+	FullName string
+	Comment  string
+	Methods  []*Method
+	Options  map[string]interface{}
+}
+
+type Method struct {
+	Name            string
+	Comment         string
+	InputType       string
+	OutputType      string
+	ClientStreaming bool
+	ServerStreaming bool
+	Options         map[string]interface{}
+}
+
+type Parser struct {
+	scanner   *Scanner
+	file      *File
+	ungotten  *Token
+	comment   string
+	lastLine  int
+	lastField *Field
+}
+
+func ParseFile(path string) (*File, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(path, string(b))
+}
+
+func Parse(path string, text string) (*File, error) {
+	p := &Parser{
+		scanner: NewScanner(path, text),
+		file: &File{
+			Path:    path,
+			Syntax:  "proto2",
+			Options: make(map[string]interface{}, 0),
+		},
+	}
+	err := p.parseFile()
+	if err != nil {
+		return nil, err
+	}
+	return p.file, nil
+}
+
+// GetToken returns the next significant token. Comments on lines of their own accumulate as the leading comment of
+// the next declaration; a comment on the same line as a field declaration becomes that field's comment, if it has none.
+func (p *Parser) GetToken() (Token, error) {
+	if p.ungotten != nil {
+		tok := *p.ungotten
+		p.ungotten = nil
+		return tok, nil
+	}
+	for {
+		tok, err := p.scanner.Scan()
+		if err != nil {
+			return tok, err
+		}
+		if tok.Type == LINE_COMMENT || tok.Type == BLOCK_COMMENT {
+			text := tok.Text
+			if tok.Type == BLOCK_COMMENT {
+				text = cleanBlockComment(text)
+			}
+			if tok.Line == p.lastLine && p.lastField != nil {
+				if p.lastField.Comment == "" {
+					p.lastField.Comment = strings.TrimSpace(text)
+				}
+				continue
+			}
+			if p.comment != "" {
+				p.comment = p.comment + "\n" + text
+			} else {
+				p.comment = text
+			}
+			continue
+		}
+		if p.lastLine != tok.Line {
+			p.lastField = nil
+		}
+		p.lastLine = tok.Line
+		return tok, nil
+	}
+}
+
+func (p *Parser) UngetToken(tok Token) {
+	p.ungotten = &tok
+}
+
+// takeComment returns the leading comment accumulated so far, and resets it.
+func (p *Parser) takeComment() string {
+	c := p.comment
+	p.comment = ""
+	return trimComment(c)
+}
+
+func trimComment(c string) string {
+	lines := strings.Split(c, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(strings.TrimRight(line, " \t"), " ")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+func cleanBlockComment(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, "*")
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (p *Parser) Error(tok Token, msg string) error {
+	return p.scanner.error(tok, msg)
+}
+
+func (p *Parser) SyntaxError(tok Token) error {
+	if tok.Type == EOF {
+		return p.Error(tok, "Unexpected end of file")
+	}
+	return p.Error(tok, fmt.Sprintf("Syntax error at %q", tok.Text))
+}
+
+func (p *Parser) expect(tt TokenType) (Token, error) {
+	tok, err := p.GetToken()
+	if err != nil {
+		return tok, err
+	}
+	if tok.Type != tt {
+		return tok, p.Error(tok, fmt.Sprintf("Expected %v, found %q", tt, tok.Text))
+	}
+	return tok, nil
+}
+
+func (p *Parser) expectSymbol() (string, error) {
+	tok, err := p.expect(SYMBOL)
+	return tok.Text, err
+}
+
+func (p *Parser) expectString() (string, error) {
+	tok, err := p.expect(STRING)
+	if err != nil {
+		return "", err
+	}
+	s := tok.Text
+	//adjacent string literals are concatenated
+	for {
+		next, err := p.GetToken()
+		if err != nil {
+			return "", err
+		}
+		if next.Type != STRING {
+			p.UngetToken(next)
+			return s, nil
+		}
+		s += next.Text
+	}
+}
+
+func (p *Parser) expectInt() (int, error) {
+	tok, err := p.expect(NUMBER)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(tok.Text, 0, 64)
+	if err != nil {
+		return 0, p.Error(tok, "Expected an integer, found "+tok.Text)
+	}
+	return int(n), nil
+}
+
+// skipOptional consumes the next token if it has the given type.
+func (p *Parser) skipOptional(tt TokenType) (bool, error) {
+	tok, err := p.GetToken()
+	if err != nil {
+		return false, err
+	}
+	if tok.Type == tt {
+		return true, nil
+	}
+	p.UngetToken(tok)
+	return false, nil
+}
+
+func (p *Parser) parseFile() error {
+	for {
+		tok, err := p.GetToken()
+		if err != nil {
+			return err
+		}
+		if tok.Type == EOF {
+			return nil
+		}
+		if tok.Type == SEMICOLON {
+			continue
+		}
+		if tok.Type != SYMBOL {
+			return p.SyntaxError(tok)
+		}
+		switch tok.Text {
+		case "syntax", "edition":
+			_, err = p.expect(EQUALS)
+			if err == nil {
+				p.file.Syntax, err = p.expectString()
+			}
+			if err == nil {
+				_, err = p.expect(SEMICOLON)
+			}
+			p.takeComment()
+		case "package":
+			p.file.Package, err = p.expectSymbol()
+			if err == nil {
+				_, err = p.expect(SEMICOLON)
+			}
+			p.takeComment()
+		case "import":
+			next, err2 := p.GetToken()
+			if err2 != nil {
+				return err2
+			}
+			if next.Type == SYMBOL && (next.Text == "public" || next.Text == "weak") {
+				next, err2 = p.GetToken()
+				if err2 != nil {
+					return err2
+				}
+			}
+			if next.Type != STRING {
+				return p.SyntaxError(next)
+			}
+			p.file.Imports = append(p.file.Imports, next.Text)
+			_, err = p.expect(SEMICOLON)
+			p.takeComment()
+		case "option":
+			err = p.parseOption(p.file.Options)
+			p.takeComment()
+		case "message":
+			var msg *Message
+			msg, err = p.parseMessage(p.file.Package)
+			if err == nil {
+				p.file.Messages = append(p.file.Messages, msg)
+			}
+		case "enum":
+			var en *Enum
+			en, err = p.parseEnum(p.file.Package)
+			if err == nil {
+				p.file.Enums = append(p.file.Enums, en)
+			}
+		case "service":
+			var svc *Service
+			svc, err = p.parseService()
+			if err == nil {
+				p.file.Services = append(p.file.Services, svc)
+			}
+		case "extend":
+			p.takeComment()
+			err = p.skipBlock()
+		default:
+			return p.SyntaxError(tok)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func qualifiedName(scope string, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+func (p *Parser) parseMessage(scope string) (*Message, error) {
+	comment := p.takeComment()
+	name, err := p.expectSymbol()
+	if err != nil {
+		return nil, err
+	}
+	msg := &Message{
+		Name:     name,
+		FullName: qualifiedName(scope, name),
+		Comment:  comment,
+		Options:  make(map[string]interface{}, 0),
+	}
+	_, err = p.expect(OPEN_BRACE)
+	if err != nil {
+		return nil, err
+	}
+	err = p.parseMessageBody(msg, nil)
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// parseMessageBody parses the declarations of a message, or of a oneof within it (in which case oneof is not nil).
+func (p *Parser) parseMessageBody(msg *Message, oneof *Oneof) error {
+	for {
+		tok, err := p.GetToken()
+		if err != nil {
+			return err
+		}
+		switch tok.Type {
+		case CLOSE_BRACE:
+			p.takeComment()
+			return nil
+		case SEMICOLON:
+			continue
+		case SYMBOL:
+		default:
+			return p.SyntaxError(tok)
+		}
+		switch tok.Text {
+		case "message":
+			if oneof != nil {
+				return p.SyntaxError(tok)
+			}
+			nested, err := p.parseMessage(msg.FullName)
+			if err != nil {
+				return err
+			}
+			msg.Messages = append(msg.Messages, nested)
+			continue
+		case "enum":
+			if oneof != nil {
+				return p.SyntaxError(tok)
+			}
+			en, err := p.parseEnum(msg.FullName)
+			if err != nil {
+				return err
+			}
+			msg.Enums = append(msg.Enums, en)
+			continue
+		case "oneof":
+			if oneof != nil {
+				return p.SyntaxError(tok)
+			}
+			o := &Oneof{Comment: p.takeComment()}
+			o.Name, err = p.expectSymbol()
+			if err != nil {
+				return err
+			}
+			_, err = p.expect(OPEN_BRACE)
+			if err != nil {
+				return err
+			}
+			err = p.parseMessageBody(msg, o)
+			if err != nil {
+				return err
+			}
+			msg.Oneofs = append(msg.Oneofs, o)
+			continue
+		case "option":
+			p.takeComment()
+			err = p.parseOption(msg.Options)
+			if err != nil {
+				return err
+			}
+			continue
+		case "reserved", "extensions":
+			p.takeComment()
+			err = p.skipStatement()
+			if err != nil {
+				return err
+			}
+			continue
+		case "extend":
+			p.takeComment()
+			err = p.skipBlock()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		field, err := p.parseField(tok)
+		if err != nil {
+			return err
+		}
+		if oneof != nil {
+			field.Oneof = oneof.Name
+			oneof.Fields = append(oneof.Fields, field)
+		}
+		msg.Fields = append(msg.Fields, field)
+		p.lastField = field
+	}
+}
+
+func (p *Parser) parseField(tok Token) (*Field, error) {
+	field := &Field{
+		Comment: p.takeComment(),
+		Options: make(map[string]interface{}, 0),
+	}
+	var err error
+	switch tok.Text {
+	case "repeated", "optional", "required":
+		field.Label = tok.Text
+		tok, err = p.expect(SYMBOL)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if tok.Text == "group" {
+		return nil, p.Error(tok, "Proto2 groups are not supported")
+	}
+	if tok.Text == "map" {
+		_, err = p.expect(OPEN_ANGLE)
+		if err == nil {
+			field.KeyType, err = p.expectSymbol()
+		}
+		if err == nil {
+			_, err = p.expect(COMMA)
+		}
+		if err == nil {
+			field.Type, err = p.expectSymbol()
+		}
+		if err == nil {
+			_, err = p.expect(CLOSE_ANGLE)
+		}
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		field.Type = tok.Text
+	}
+	field.Name, err = p.expectSymbol()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.expect(EQUALS)
+	if err != nil {
+		return nil, err
+	}
+	field.Number, err = p.expectInt()
+	if err != nil {
+		return nil, err
+	}
+	err = p.parseInlineOptions(field.Options)
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.expect(SEMICOLON)
+	return field, err
+}
+
+func (p *Parser) parseEnum(scope string) (*Enum, error) {
+	comment := p.takeComment()
+	name, err := p.expectSymbol()
+	if err != nil {
+		return nil, err
+	}
+	en := &Enum{
+		Name:     name,
+		FullName: qualifiedName(scope, name),
+		Comment:  comment,
+		Options:  make(map[string]interface{}, 0),
+	}
+	_, err = p.expect(OPEN_BRACE)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, err := p.GetToken()
+		if err != nil {
+			return nil, err
+		}
+		switch tok.Type {
+		case CLOSE_BRACE:
+			p.takeComment()
+			return en, nil
+		case SEMICOLON:
+			continue
+		case SYMBOL:
+		default:
+			return nil, p.SyntaxError(tok)
+		}
+		switch tok.Text {
+		case "option":
+			p.takeComment()
+			err = p.parseOption(en.Options)
+		case "reserved":
+			p.takeComment()
+			err = p.skipStatement()
+		default:
+			val := &EnumValue{
+				Name:    tok.Text,
+				Comment: p.takeComment(),
+				Options: make(map[string]interface{}, 0),
+			}
+			_, err = p.expect(EQUALS)
+			if err == nil {
+				val.Number, err = p.expectInt()
+			}
+			if err == nil {
+				err = p.parseInlineOptions(val.Options)
+			}
+			if err == nil {
+				_, err = p.expect(SEMICOLON)
+			}
+			en.Values = append(en.Values, val)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *Parser) parseService() (*Service, error) {
+	comment := p.takeComment()
+	name, err := p.expectSymbol()
+	if err != nil {
+		return nil, err
+	}
+	svc := &Service{
+		Name:     name,
+		FullName: qualifiedName(p.file.Package, name),
+		Comment:  comment,
+		Options:  make(map[string]interface{}, 0),
+	}
+	_, err = p.expect(OPEN_BRACE)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, err := p.GetToken()
+		if err != nil {
+			return nil, err
+		}
+		switch tok.Type {
+		case CLOSE_BRACE:
+			p.takeComment()
+			return svc, nil
+		case SEMICOLON:
+			continue
+		case SYMBOL:
+		default:
+			return nil, p.SyntaxError(tok)
+		}
+		switch tok.Text {
+		case "option":
+			p.takeComment()
+			err = p.parseOption(svc.Options)
+		case "rpc":
+			var m *Method
+			m, err = p.parseMethod()
+			if err == nil {
+				svc.Methods = append(svc.Methods, m)
+			}
+		default:
+			return nil, p.SyntaxError(tok)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *Parser) parseMethod() (*Method, error) {
+	m := &Method{
+		Comment: p.takeComment(),
+		Options: make(map[string]interface{}, 0),
+	}
+	var err error
+	m.Name, err = p.expectSymbol()
+	if err != nil {
+		return nil, err
+	}
+	m.InputType, m.ClientStreaming, err = p.parseMethodType()
+	if err != nil {
+		return nil, err
+	}
+	tok, err := p.expect(SYMBOL)
+	if err != nil {
+		return nil, err
+	}
+	if tok.Text != "returns" {
+		return nil, p.SyntaxError(tok)
+	}
+	m.OutputType, m.ServerStreaming, err = p.parseMethodType()
+	if err != nil {
+		return nil, err
+	}
+	tok, err = p.GetToken()
+	if err != nil {
+		return nil, err
+	}
+	if tok.Type == SEMICOLON {
+		return m, nil
+	}
+	if tok.Type != OPEN_BRACE {
+		return nil, p.SyntaxError(tok)
+	}
+	for {
+		tok, err = p.GetToken()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case tok.Type == CLOSE_BRACE:
+			p.takeComment()
+			return m, nil
+		case tok.Type == SEMICOLON:
+		case tok.Type == SYMBOL && tok.Text == "option":
+			p.takeComment()
+			err = p.parseOption(m.Options)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, p.SyntaxError(tok)
+		}
+	}
+}
+
+func (p *Parser) parseMethodType() (string, bool, error) {
+	_, err := p.expect(OPEN_PAREN)
+	if err != nil {
+		return "", false, err
+	}
+	name, err := p.expectSymbol()
+	if err != nil {
+		return "", false, err
+	}
+	streaming := false
+	if name == "stream" {
+		tok, err := p.GetToken()
+		if err != nil {
+			return "", false, err
+		}
+		if tok.Type == SYMBOL {
+			streaming = true
+			name = tok.Text
+		} else {
+			p.UngetToken(tok)
+		}
+	}
+	_, err = p.expect(CLOSE_PAREN)
+	return name, streaming, err
+}
+
+// parseOption parses the rest of an "option name = value;" statement into the options map.
+func (p *Parser) parseOption(options map[string]interface{}) error {
+	name, err := p.parseOptionName()
+	if err != nil {
+		return err
+	}
+	_, err = p.expect(EQUALS)
+	if err != nil {
+		return err
+	}
+	val, err := p.parseOptionValue()
+	if err != nil {
+		return err
+	}
+	setOption(options, name, val)
+	_, err = p.expect(SEMICOLON)
+	return err
+}
+
+// parseInlineOptions parses the optional "[name = value, ...]" following a field or enum value.
+func (p *Parser) parseInlineOptions(options map[string]interface{}) error {
+	found, err := p.skipOptional(OPEN_BRACKET)
+	if err != nil || !found {
+		return err
+	}
+	for {
+		name, err := p.parseOptionName()
+		if err != nil {
+			return err
+		}
+		_, err = p.expect(EQUALS)
+		if err != nil {
+			return err
+		}
+		val, err := p.parseOptionValue()
+		if err != nil {
+			return err
+		}
+		setOption(options, name, val)
+		tok, err := p.GetToken()
+		if err != nil {
+			return err
+		}
+		if tok.Type == CLOSE_BRACKET {
+			return nil
+		}
+		if tok.Type != COMMA {
+			return p.SyntaxError(tok)
+		}
+	}
+}
+
+// parseOptionName returns the path of an option name. A custom option is a single element, without its parentheses,
+// and any field names that follow it are further elements, i.e. "(google.api.http).get" is [google.api.http get].
+func (p *Parser) parseOptionName() ([]string, error) {
+	var path []string
+	for {
+		tok, err := p.GetToken()
+		if err != nil {
+			return nil, err
+		}
+		switch tok.Type {
+		case OPEN_PAREN:
+			ext, err := p.expectSymbol()
+			if err != nil {
+				return nil, err
+			}
+			_, err = p.expect(CLOSE_PAREN)
+			if err != nil {
+				return nil, err
+			}
+			path = append(path, strings.TrimPrefix(ext, "."))
+		case SYMBOL:
+			for _, name := range strings.Split(tok.Text, ".") {
+				if name != "" {
+					path = append(path, name)
+				}
+			}
+		default:
+			if len(path) == 0 {
+				return nil, p.SyntaxError(tok)
+			}
+			p.UngetToken(tok)
+			return path, nil
+		}
+	}
+}
+
+func setOption(options map[string]interface{}, path []string, val interface{}) {
+	for _, name := range path[:len(path)-1] {
+		sub, ok := options[name].(map[string]interface{})
+		if !ok {
+			sub = make(map[string]interface{}, 0)
+			options[name] = sub
+		}
+		options = sub
+	}
+	options[path[len(path)-1]] = val
+}
+
+func (p *Parser) parseOptionValue() (interface{}, error) {
+	tok, err := p.GetToken()
+	if err != nil {
+		return nil, err
+	}
+	switch tok.Type {
+	case STRING:
+		p.UngetToken(tok)
+		return p.expectString()
+	case NUMBER:
+		n, err := strconv.ParseFloat(tok.Text, 64)
+		if err != nil {
+			i, err2 := strconv.ParseInt(tok.Text, 0, 64)
+			if err2 != nil {
+				return nil, p.Error(tok, "Bad number: "+tok.Text)
+			}
+			n = float64(i)
+		}
+		return n, nil
+	case SYMBOL:
+		switch tok.Text {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return tok.Text, nil
+	case OPEN_BRACE:
+		return p.parseAggregate(CLOSE_BRACE)
+	case OPEN_ANGLE:
+		return p.parseAggregate(CLOSE_ANGLE)
+	case OPEN_BRACKET:
+		var lst []interface{}
+		for {
+			tok, err := p.GetToken()
+			if err != nil {
+				return nil, err
+			}
+			if tok.Type == CLOSE_BRACKET {
+				return lst, nil
+			}
+			if tok.Type == COMMA {
+				continue
+			}
+			p.UngetToken(tok)
+			v, err := p.parseOptionValue()
+			if err != nil {
+				return nil, err
+			}
+			lst = append(lst, v)
+		}
+	}
+	return nil, p.SyntaxError(tok)
+}
+
+// parseAggregate parses a protobuf text format message literal, as used for the values of options like google.api.http.
+func (p *Parser) parseAggregate(end TokenType) (map[string]interface{}, error) {
+	agg := make(map[string]interface{}, 0)
+	for {
+		tok, err := p.GetToken()
+		if err != nil {
+			return nil, err
+		}
+		switch tok.Type {
+		case end:
+			return agg, nil
+		case COMMA, SEMICOLON:
+			continue
+		case SYMBOL:
+		case OPEN_BRACKET:
+			//extension or Any type URL field names
+			name := ""
+			for {
+				t, err := p.GetToken()
+				if err != nil {
+					return nil, err
+				}
+				if t.Type == CLOSE_BRACKET {
+					break
+				}
+				name += t.Text
+			}
+			tok.Text = name
+		default:
+			return nil, p.SyntaxError(tok)
+		}
+		key := tok.Text
+		_, err = p.skipOptional(COLON)
+		if err != nil {
+			return nil, err
+		}
+		val, err := p.parseOptionValue()
+		if err != nil {
+			return nil, err
+		}
+		if prev, ok := agg[key]; ok {
+			if lst, ok := prev.([]interface{}); ok {
+				agg[key] = append(lst, val)
+			} else {
+				agg[key] = []interface{}{prev, val}
+			}
+		} else {
+			agg[key] = val
+		}
+	}
+}
+
+// skipStatement skips tokens through the next semicolon.
+func (p *Parser) skipStatement() error {
+	for {
+		tok, err := p.GetToken()
+		if err != nil {
+			return err
+		}
+		if tok.Type == SEMICOLON {
+			return nil
+		}
+		if tok.Type == EOF {
+			return p.SyntaxError(tok)
+		}
+	}
+}
+
+// skipBlock skips a braced block, such as an "extend" declaration.
+func (p *Parser) skipBlock() error {
+	depth := 0
+	for {
+		tok, err := p.GetToken()
+		if err != nil {
+			return err
+		}
+		switch tok.Type {
+		case OPEN_BRACE:
+			depth++
+		case CLOSE_BRACE:
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		case EOF:
+			return p.SyntaxError(tok)
+		}
+	}
+}
@@ -0,0 +1,772 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package protobuf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/boynton/api/model"
+	"github.com/boynton/data"
+)
+
+const IndentAmount = "  "
+
+// Generator emits a proto3 file for the model. Field and enum value numbers must never change once published, so they
+// are taken from a "protoIndex" annotation (i.e. the Smithy trait @com.acme#protoIndex(3)) if present, otherwise from
+// the lock file written by the previous run. New fields are numbered after the highest number ever used in their
+// message, and fields that have been removed from the model are emitted as reserved.
+type Generator struct {
+	model.BaseGenerator
+	ns        model.Namespace
+	pkg       string
+	lockPath  string
+	lock      *FieldNumberLock
+	imports   map[string]bool
+	wrappers  map[string]*model.TypeDef
+	enumNames map[string]string
+	body      strings.Builder
+}
+
+// FieldNumberLock is the content of the lock file, recording the number assigned to each field of each message, and
+// each value of each enum.
+type FieldNumberLock struct {
+	Messages map[string]map[string]int `json:"messages"`
+	Enums    map[string]map[string]int `json:"enums"`
+}
+
+type protoField struct {
+	name        string
+	typ         model.AbsoluteIdentifier
+	comment     string
+	required    bool
+	annotations model.Annotations
+}
+
+func (gen *Generator) GenerateResource(rez *model.ResourceDef) error {
+	return nil
+}
+
+func (gen *Generator) GenerateOperation(op *model.OperationDef) error {
+	return nil
+}
+
+func (gen *Generator) GenerateException(op *model.OperationOutput) error {
+	return nil
+}
+
+func (gen *Generator) GenerateType(td *model.TypeDef) error {
+	return nil
+}
+
+func (gen *Generator) Generate(schema *model.Schema, config *data.Object) error {
+	err := gen.Configure(schema, config)
+	if err != nil {
+		return err
+	}
+	gen.ns = model.Namespace(config.GetString("namespace"))
+	if gen.ns == "" {
+		gen.ns = schema.ServiceNamespace()
+		if gen.ns == "" {
+			gen.ns = schema.Namespace
+		}
+	}
+	gen.pkg = config.GetString("proto.package")
+	if gen.pkg == "" {
+		gen.pkg = string(gen.ns)
+	}
+	fname := gen.FileName(gen.pkg, ".proto")
+	gen.lockPath = config.GetString("proto.lockFile")
+	if gen.lockPath == "" && gen.OutDir != "" {
+		gen.lockPath = filepath.Join(gen.OutDir, gen.FileName(gen.pkg, ".proto.lock.json"))
+	}
+	gen.lock, err = LoadFieldNumberLock(gen.lockPath)
+	if err != nil {
+		return err
+	}
+	gen.imports = make(map[string]bool, 0)
+	gen.wrappers = make(map[string]*model.TypeDef, 0)
+	gen.assignEnumNames()
+
+	err = gen.GenerateService()
+	if err != nil {
+		return err
+	}
+	for _, td := range gen.Types() {
+		err = gen.generateTypeDef(td)
+		if err != nil {
+			return err
+		}
+	}
+	for _, edef := range gen.Exceptions() {
+		err = gen.generateMessage(model.StripNamespace(edef.Id), edef.Comment, outputFields(edef.Fields), false)
+		if err != nil {
+			return err
+		}
+	}
+	gen.generateWrappers()
+
+	gen.Begin()
+	gen.Emitf("syntax = \"proto3\";\n\npackage %s;\n", gen.pkg)
+	if len(gen.imports) > 0 {
+		var imports []string
+		for imp := range gen.imports {
+			imports = append(imports, imp)
+		}
+		sort.Strings(imports)
+		gen.Emit("\n")
+		for _, imp := range imports {
+			gen.Emitf("import %q;\n", imp)
+		}
+	}
+	if goPackage := gen.Config.GetString("proto.goPackage"); goPackage != "" {
+		gen.Emitf("\noption go_package = %q;\n", goPackage)
+	}
+	gen.Emit(gen.body.String())
+	err = gen.Write(gen.End(), fname, "")
+	if err != nil {
+		return err
+	}
+	if gen.lockPath != "" {
+		return gen.lock.Save(gen.lockPath)
+	}
+	return nil
+}
+
+func LoadFieldNumberLock(path string) (*FieldNumberLock, error) {
+	lock := &FieldNumberLock{
+		Messages: make(map[string]map[string]int, 0),
+		Enums:    make(map[string]map[string]int, 0),
+	}
+	if path == "" {
+		return lock, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		//no lock file yet
+		return lock, nil
+	}
+	err = json.Unmarshal(b, lock)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot parse proto lock file %s: %v", path, err)
+	}
+	if lock.Messages == nil {
+		lock.Messages = make(map[string]map[string]int, 0)
+	}
+	if lock.Enums == nil {
+		lock.Enums = make(map[string]map[string]int, 0)
+	}
+	return lock, nil
+}
+
+func (lock *FieldNumberLock) Save(path string) error {
+	b, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(b, '\n'), 0644)
+}
+
+func (gen *Generator) emit(s string) {
+	gen.body.WriteString(s)
+}
+
+func (gen *Generator) emitf(format string, args ...interface{}) {
+	gen.body.WriteString(fmt.Sprintf(format, args...))
+}
+
+func (gen *Generator) emitComment(comment string, indent string) {
+	if comment == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimRight(comment, "\n"), "\n") {
+		gen.emitf("%s// %s\n", indent, strings.TrimRight(line, " "))
+	}
+}
+
+func (gen *Generator) GenerateService() error {
+	if len(gen.Schema.Operations) == 0 {
+		return nil
+	}
+	name := string(gen.Schema.ServiceName())
+	if name == "" {
+		name = model.Capitalize(gen.pkg[strings.LastIndex(gen.pkg, ".")+1:]) + "Service"
+	}
+	var messages []func() error
+	gen.emit("\n")
+	gen.emitComment(gen.Schema.Comment, "")
+	gen.emitf("service %s {\n", name)
+	for i, op := range gen.Operations() {
+		if i > 0 {
+			gen.emit("\n")
+		}
+		opName := model.StripNamespace(op.Id)
+		reqType, body, reqMessage := gen.requestMessage(op)
+		if reqMessage != nil {
+			messages = append(messages, reqMessage)
+		}
+		respType, responseBody, respMessage := gen.responseMessage(op)
+		if respMessage != nil {
+			messages = append(messages, respMessage)
+		}
+		comment := op.Comment
+		if len(op.Exceptions) > 0 {
+			var errs []string
+			for _, eid := range op.Exceptions {
+				e := gen.Schema.GetExceptionDef(eid)
+				if e != nil {
+					errs = append(errs, fmt.Sprintf("%s (%d)", model.StripNamespace(eid), e.HttpStatus))
+				}
+			}
+			if comment != "" {
+				comment += "\n"
+			}
+			comment += "Errors: " + strings.Join(errs, ", ")
+		}
+		gen.emitComment(comment, IndentAmount)
+		gen.emitf("%srpc %s(%s) returns (%s) {\n", IndentAmount, opName, reqType, respType)
+		if op.HttpMethod != "" {
+			gen.imports["google/api/annotations.proto"] = true
+			gen.emitf("%s%soption (google.api.http) = {\n", IndentAmount, IndentAmount)
+			indent := IndentAmount + IndentAmount + IndentAmount
+			path := gen.pathTemplate(gen.Schema.Base + op.HttpUri)
+			switch method := strings.ToLower(op.HttpMethod); method {
+			case "get", "put", "post", "delete", "patch":
+				gen.emitf("%s%s: %q\n", indent, method, path)
+			default:
+				gen.emitf("%scustom: {\n%s%skind: %q\n%s%spath: %q\n%s}\n", indent, indent, IndentAmount, op.HttpMethod, indent, IndentAmount, path, indent)
+			}
+			if body != "" {
+				gen.emitf("%sbody: %q\n", indent, body)
+			}
+			if responseBody != "" {
+				gen.emitf("%sresponse_body: %q\n", indent, responseBody)
+			}
+			gen.emitf("%s%s};\n", IndentAmount, IndentAmount)
+		}
+		gen.emitf("%s}\n", IndentAmount)
+	}
+	gen.emit("}\n")
+	for _, m := range messages {
+		err := m()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pathTemplate converts the model's path template to the google.api.http form: variables name fields by their proto
+// names, and a greedy label like {key+} matches multiple segments.
+func (gen *Generator) pathTemplate(uri string) string {
+	var sb strings.Builder
+	for {
+		i := strings.Index(uri, "{")
+		j := strings.Index(uri, "}")
+		if i < 0 || j < i {
+			sb.WriteString(uri)
+			return sb.String()
+		}
+		sb.WriteString(uri[:i])
+		name := uri[i+1 : j]
+		if strings.HasSuffix(name, "+") {
+			sb.WriteString("{" + snakeCase(strings.TrimSuffix(name, "+")) + "=**}")
+		} else {
+			sb.WriteString("{" + snakeCase(name) + "}")
+		}
+		uri = uri[j+1:]
+	}
+}
+
+func (gen *Generator) messageName(preferred string, fallback model.AbsoluteIdentifier) string {
+	if gen.Schema.GetTypeDef(gen.Schema.Namespaced(preferred)) == nil || fallback == "" {
+		return preferred
+	}
+	return model.StripNamespace(fallback)
+}
+
+// requestMessage determines the request message of an operation. When the input is just a structure payload, that
+// structure is the request, otherwise a request message holding all input fields is defined.
+func (gen *Generator) requestMessage(op *model.OperationDef) (string, string, func() error) {
+	if op.Input == nil || len(op.Input.Fields) == 0 {
+		gen.imports["google/protobuf/empty.proto"] = true
+		return "google.protobuf.Empty", "", nil
+	}
+	if len(op.Input.Fields) == 1 && op.Input.Fields[0].HttpPayload && gen.isMessageType(op.Input.Fields[0].Type) {
+		return model.StripNamespace(op.Input.Fields[0].Type), "*", nil
+	}
+	name := gen.messageName(model.StripNamespace(op.Id)+"Request", op.Input.Id)
+	var fields []*protoField
+	body := ""
+	for _, f := range op.Input.Fields {
+		comment := f.Comment
+		if f.HttpHeader != "" {
+			if comment != "" {
+				comment += "\n"
+			}
+			comment += "Bound to the HTTP header " + f.HttpHeader
+		}
+		if f.HttpPayload {
+			body = snakeCase(string(f.Name))
+		}
+		fields = append(fields, &protoField{
+			name:        string(f.Name),
+			typ:         f.Type,
+			comment:     comment,
+			required:    f.Required,
+			annotations: f.Annotations,
+		})
+	}
+	return name, body, func() error {
+		return gen.generateMessage(name, op.Input.Comment, fields, false)
+	}
+}
+
+// responseMessage determines the response message of an operation. When the output is just a structure payload, that
+// structure is the response, otherwise a response message holding all output fields is defined.
+func (gen *Generator) responseMessage(op *model.OperationDef) (string, string, func() error) {
+	if op.Output == nil || len(op.Output.Fields) == 0 {
+		gen.imports["google/protobuf/empty.proto"] = true
+		return "google.protobuf.Empty", "", nil
+	}
+	if len(op.Output.Fields) == 1 && op.Output.Fields[0].HttpPayload && gen.isMessageType(op.Output.Fields[0].Type) {
+		return model.StripNamespace(op.Output.Fields[0].Type), "", nil
+	}
+	name := gen.messageName(model.StripNamespace(op.Id)+"Response", op.Output.Id)
+	responseBody := ""
+	for _, f := range op.Output.Fields {
+		if f.HttpPayload && len(op.Output.Fields) > 1 {
+			responseBody = snakeCase(string(f.Name))
+		}
+	}
+	fields := outputFields(op.Output.Fields)
+	return name, responseBody, func() error {
+		return gen.generateMessage(name, op.Output.Comment, fields, false)
+	}
+}
+
+func outputFields(fields []*model.OperationOutputField) []*protoField {
+	var result []*protoField
+	for _, f := range fields {
+		comment := f.Comment
+		if f.HttpHeader != "" {
+			if comment != "" {
+				comment += "\n"
+			}
+			comment += "Bound to the HTTP header " + f.HttpHeader
+		}
+		result = append(result, &protoField{
+			name:        string(f.Name),
+			typ:         f.Type,
+			comment:     comment,
+			required:    f.Required,
+			annotations: f.Annotations,
+		})
+	}
+	return result
+}
+
+func (gen *Generator) isMessageType(id model.AbsoluteIdentifier) bool {
+	td := gen.Schema.GetTypeDef(id)
+	return td != nil && (td.Base == model.BaseType_Struct || td.Base == model.BaseType_Union)
+}
+
+func (gen *Generator) generateTypeDef(td *model.TypeDef) error {
+	name := model.StripNamespace(td.Id)
+	switch td.Base {
+	case model.BaseType_Struct, model.BaseType_Union:
+		var fields []*protoField
+		for _, f := range td.Fields {
+			fields = append(fields, &protoField{
+				name:        string(f.Name),
+				typ:         f.Type,
+				comment:     f.Comment,
+				required:    f.Required,
+				annotations: f.Annotations,
+			})
+		}
+		return gen.generateMessage(name, td.Comment, fields, td.Base == model.BaseType_Union)
+	case model.BaseType_Enum:
+		return gen.generateEnum(td)
+	}
+	//lists, maps, and primitive types are declared inline where they are used
+	return nil
+}
+
+// generateMessage emits a message. The fields of a union are all in a single oneof.
+func (gen *Generator) generateMessage(name string, comment string, fields []*protoField, union bool) error {
+	numbers, reservedNumbers, reservedNames, err := gen.fieldNumbers(name, fields)
+	if err != nil {
+		return err
+	}
+	gen.emit("\n")
+	gen.emitComment(comment, "")
+	gen.emitf("message %s {\n", name)
+	indent := IndentAmount
+	if len(reservedNumbers) > 0 {
+		var nums []string
+		for _, n := range reservedNumbers {
+			nums = append(nums, fmt.Sprint(n))
+		}
+		gen.emitf("%sreserved %s;\n", indent, strings.Join(nums, ", "))
+		gen.emitf("%sreserved \"%s\";\n\n", indent, strings.Join(reservedNames, "\", \""))
+	}
+	if union {
+		gen.emitf("%soneof value {\n", indent)
+		indent += IndentAmount
+	}
+	for _, f := range fields {
+		gen.emitComment(f.comment, indent)
+		fname := snakeCase(f.name)
+		var opts []string
+		if lowerCamelCase(fname) != f.name {
+			opts = append(opts, fmt.Sprintf("json_name = %q", f.name))
+		}
+		if f.required && !union {
+			gen.imports["google/api/field_behavior.proto"] = true
+			opts = append(opts, "(google.api.field_behavior) = REQUIRED")
+		}
+		options := ""
+		if len(opts) > 0 {
+			options = " [" + strings.Join(opts, ", ") + "]"
+		}
+		gen.emitf("%s%s %s = %d%s;\n", indent, gen.fieldSpec(f.typ, !union), fname, numbers[fname], options)
+	}
+	if union {
+		gen.emitf("%s}\n", IndentAmount)
+	}
+	gen.emit("}\n")
+	return nil
+}
+
+// fieldNumbers assigns the number of each field of a message, updating the lock. It also returns the numbers and
+// names of fields that have been removed since they were locked.
+func (gen *Generator) fieldNumbers(message string, fields []*protoField) (map[string]int, []int, []string, error) {
+	locked := gen.lock.Messages[message]
+	if locked == nil {
+		locked = make(map[string]int, 0)
+		gen.lock.Messages[message] = locked
+	}
+	numbers := make(map[string]int, 0)
+	used := make(map[int]string, 0)
+	for _, f := range fields {
+		if n, ok := protoIndex(f.annotations); ok {
+			fname := snakeCase(f.name)
+			if other, ok := used[n]; ok {
+				return nil, nil, nil, fmt.Errorf("Fields %q and %q of %s both have the field number %d", other, fname, message, n)
+			}
+			numbers[fname] = n
+			used[n] = fname
+		}
+	}
+	for _, f := range fields {
+		fname := snakeCase(f.name)
+		if _, ok := numbers[fname]; ok {
+			continue
+		}
+		if n, ok := locked[fname]; ok {
+			if _, taken := used[n]; !taken {
+				numbers[fname] = n
+				used[n] = fname
+			}
+		}
+	}
+	next := 1
+	for _, n := range locked {
+		if n >= next {
+			next = n + 1
+		}
+	}
+	for n := range used {
+		if n >= next {
+			next = n + 1
+		}
+	}
+	for _, f := range fields {
+		fname := snakeCase(f.name)
+		if _, ok := numbers[fname]; ok {
+			continue
+		}
+		if next >= 19000 && next <= 19999 {
+			//reserved for the protobuf implementation
+			next = 20000
+		}
+		numbers[fname] = next
+		used[next] = fname
+		next++
+	}
+	var reservedNumbers []int
+	var reservedNames []string
+	for fname, n := range locked {
+		if _, ok := numbers[fname]; !ok {
+			if _, ok := used[n]; !ok {
+				reservedNumbers = append(reservedNumbers, n)
+			}
+			reservedNames = append(reservedNames, fname)
+		}
+	}
+	for fname, n := range numbers {
+		locked[fname] = n
+	}
+	sort.Ints(reservedNumbers)
+	sort.Strings(reservedNames)
+	return numbers, reservedNumbers, reservedNames, nil
+}
+
+// protoIndex finds the field number given by an annotation named "protoIndex", in any namespace.
+func protoIndex(annotations model.Annotations) (int, bool) {
+	for k, v := range annotations {
+		if model.StripNamespace(k) != "protoIndex" {
+			continue
+		}
+		switch n := v.(type) {
+		case float64:
+			return int(n), true
+		case int:
+			return n, true
+		case int64:
+			return int(n), true
+		case json.Number:
+			i, err := n.Int64()
+			return int(i), err == nil
+		}
+	}
+	return 0, false
+}
+
+// assignEnumNames determines the name of each enum value. Enum values are scoped to the package in protobuf, so when
+// the same symbol is used by more than one enum, it is prefixed with the enum name.
+func (gen *Generator) assignEnumNames() {
+	gen.enumNames = make(map[string]string, 0)
+	counts := make(map[string]int, 0)
+	for _, td := range gen.Schema.Types {
+		if td.Base == model.BaseType_Enum {
+			for _, el := range td.Elements {
+				counts[string(el.Symbol)]++
+			}
+		}
+	}
+	for _, td := range gen.Schema.Types {
+		if td.Base != model.BaseType_Enum {
+			continue
+		}
+		prefix := strings.ToUpper(snakeCase(model.StripNamespace(td.Id))) + "_"
+		for _, el := range td.Elements {
+			sym := string(el.Symbol)
+			name := sym
+			if counts[sym] > 1 {
+				name = prefix + strings.ToUpper(sym)
+			}
+			gen.enumNames[string(td.Id)+"$"+sym] = name
+		}
+	}
+}
+
+// generateEnum emits an enum. Proto3 enums must have a zero value, so if the model does not provide one, an
+// unspecified value is added.
+func (gen *Generator) generateEnum(td *model.TypeDef) error {
+	name := model.StripNamespace(td.Id)
+	locked := gen.lock.Enums[name]
+	if locked == nil {
+		locked = make(map[string]int, 0)
+		gen.lock.Enums[name] = locked
+	}
+	type enumValue struct {
+		name    string
+		comment string
+		number  int
+	}
+	var values []*enumValue
+	used := make(map[int]bool, 0)
+	for _, el := range td.Elements {
+		v := &enumValue{name: gen.enumNames[string(td.Id)+"$"+string(el.Symbol)], comment: el.Comment, number: -1}
+		if n, ok := protoIndex(el.Annotations); ok {
+			v.number = n
+		} else if n, ok := locked[v.name]; ok {
+			v.number = n
+		}
+		if v.number >= 0 {
+			if used[v.number] {
+				return fmt.Errorf("Enum %s has more than one value numbered %d", name, v.number)
+			}
+			used[v.number] = true
+		}
+		values = append(values, v)
+	}
+	next := 0
+	for _, n := range locked {
+		if n >= next {
+			next = n + 1
+		}
+	}
+	for n := range used {
+		if n >= next {
+			next = n + 1
+		}
+	}
+	if !used[0] && len(values) > 0 && values[0].number < 0 && (next == 0 || isUnspecified(values[0].name)) {
+		values[0].number = 0
+		used[0] = true
+		if next == 0 {
+			next = 1
+		}
+	}
+	if !used[0] {
+		zero := &enumValue{name: strings.ToUpper(snakeCase(name)) + "_UNSPECIFIED", number: 0}
+		values = append([]*enumValue{zero}, values...)
+		if next == 0 {
+			next = 1
+		}
+	}
+	for _, v := range values {
+		if v.number < 0 {
+			v.number = next
+			next++
+		}
+		locked[v.name] = v.number
+	}
+	gen.emit("\n")
+	gen.emitComment(td.Comment, "")
+	gen.emitf("enum %s {\n", name)
+	for _, v := range values {
+		gen.emitComment(v.comment, IndentAmount)
+		gen.emitf("%s%s = %d;\n", IndentAmount, v.name, v.number)
+	}
+	gen.emit("}\n")
+	return nil
+}
+
+func isUnspecified(name string) bool {
+	return strings.HasSuffix(name, "UNSPECIFIED") || strings.HasSuffix(name, "UNKNOWN")
+}
+
+var protoScalarTypes = map[model.AbsoluteIdentifier]string{
+	"base#Bool":    "bool",
+	"base#Int8":    "int32",
+	"base#Int16":   "int32",
+	"base#Int32":   "int32",
+	"base#Int64":   "int64",
+	"base#Float32": "float",
+	"base#Float64": "double",
+	"base#Integer": "string",
+	"base#Decimal": "string",
+	"base#Bytes":   "bytes",
+	"base#Blob":    "bytes",
+	"base#String":  "string",
+}
+
+// fieldSpec returns the type of a field as declared in a message, i.e. "string", "repeated Book", or
+// "map<string, Book>". Where repeated and map fields are not allowed (in a oneof, or as list items or map values),
+// a list or map type is instead wrapped in a message of the same name.
+func (gen *Generator) fieldSpec(id model.AbsoluteIdentifier, allowRepeated bool) string {
+	if s, ok := protoScalarTypes[id]; ok {
+		return s
+	}
+	switch id {
+	case "base#Timestamp":
+		gen.imports["google/protobuf/timestamp.proto"] = true
+		return "google.protobuf.Timestamp"
+	case "base#Any":
+		gen.imports["google/protobuf/struct.proto"] = true
+		return "google.protobuf.Value"
+	case "base#List":
+		gen.imports["google/protobuf/struct.proto"] = true
+		return "google.protobuf.ListValue"
+	case "base#Map", "base#Struct":
+		gen.imports["google/protobuf/struct.proto"] = true
+		return "google.protobuf.Struct"
+	}
+	td := gen.Schema.GetTypeDef(id)
+	if td == nil {
+		model.Warning("Undefined type %s, using google.protobuf.Value\n", id)
+		gen.imports["google/protobuf/struct.proto"] = true
+		return "google.protobuf.Value"
+	}
+	switch td.Base {
+	case model.BaseType_Struct, model.BaseType_Union, model.BaseType_Enum:
+		return model.StripNamespace(td.Id)
+	case model.BaseType_List, model.BaseType_Map:
+		if !allowRepeated {
+			gen.wrappers[model.StripNamespace(td.Id)] = td
+			return model.StripNamespace(td.Id)
+		}
+		if td.Base == model.BaseType_List {
+			return "repeated " + gen.fieldSpec(td.Items, false)
+		}
+		return "map<" + gen.mapKeySpec(td.Keys) + ", " + gen.fieldSpec(td.Items, false) + ">"
+	}
+	return gen.fieldSpec(model.AbsoluteIdentifier("base#"+td.Base.String()), allowRepeated)
+}
+
+// mapKeySpec returns the type of a map key. Only integral and string types are allowed as keys in protobuf, so other
+// key types (including enums) are represented as strings.
+func (gen *Generator) mapKeySpec(id model.AbsoluteIdentifier) string {
+	if td := gen.Schema.GetTypeDef(id); td != nil {
+		id = model.AbsoluteIdentifier("base#" + td.Base.String())
+	}
+	switch s := protoScalarTypes[id]; s {
+	case "int32", "int64", "string", "bool":
+		return s
+	}
+	return "string"
+}
+
+func (gen *Generator) generateWrappers() {
+	emitted := make(map[string]bool, 0)
+	for len(emitted) < len(gen.wrappers) {
+		var names []string
+		for name := range gen.wrappers {
+			if !emitted[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			emitted[name] = true
+			td := gen.wrappers[name]
+			gen.emit("\n")
+			gen.emitComment(td.Comment, "")
+			gen.emitf("message %s {\n", name)
+			if td.Base == model.BaseType_List {
+				gen.emitf("%srepeated %s items = 1;\n", IndentAmount, gen.fieldSpec(td.Items, false))
+			} else {
+				gen.emitf("%smap<%s, %s> entries = 1;\n", IndentAmount, gen.mapKeySpec(td.Keys), gen.fieldSpec(td.Items, false))
+			}
+			gen.emit("}\n")
+		}
+	}
+}
+
+// snakeCase converts a lowerCamelCase name to the snake_case conventional for protobuf field names.
+func snakeCase(s string) string {
+	var sb strings.Builder
+	runes := []rune(s)
+	for i, ch := range runes {
+		if ch >= 'A' && ch <= 'Z' {
+			if i > 0 && (runes[i-1] >= 'a' && runes[i-1] <= 'z' || runes[i-1] >= '0' && runes[i-1] <= '9' ||
+				i+1 < len(runes) && runes[i+1] >= 'a' && runes[i+1] <= 'z' && runes[i-1] != '_') {
+				sb.WriteRune('_')
+			}
+			ch = ch - 'A' + 'a'
+		}
+		sb.WriteRune(ch)
+	}
+	return sb.String()
+}
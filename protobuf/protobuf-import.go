@@ -0,0 +1,757 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package protobuf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/boynton/api/model"
+)
+
+// Import parses the .proto files and builds a model from them. Imported files are also read, if they can be found
+// relative to the importing file, but only their types are used. Services are mapped to HTTP operations using their
+// google.api.http annotations, falling back to the gRPC transcoding default (POST to /package.Service/Method).
+func Import(paths []string, tags []string, ns string) (*model.Schema, error) {
+	mb := &ModelBuilder{
+		schema:   model.NewSchema(),
+		ns:       ns,
+		types:    make(map[string]*protoType, 0),
+		typeDefs: make(map[model.AbsoluteIdentifier]*model.TypeDef, 0),
+		loaded:   make(map[string]bool, 0),
+	}
+	for _, path := range paths {
+		err := mb.load(path, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := mb.Build()
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) > 0 {
+		mb.schema.Filter(tags)
+	}
+	return mb.schema, nil
+}
+
+type ModelBuilder struct {
+	schema   *model.Schema
+	ns       string
+	files    []*File
+	services []*File
+	types    map[string]*protoType
+	typeDefs map[model.AbsoluteIdentifier]*model.TypeDef
+	loaded   map[string]bool
+}
+
+// protoType is a message or enum declaration, indexed by its fully qualified protobuf name.
+type protoType struct {
+	id      model.AbsoluteIdentifier
+	message *Message
+	enum    *Enum
+}
+
+var wellKnownTypes = map[string]model.AbsoluteIdentifier{
+	"google.protobuf.Timestamp":   "base#Timestamp",
+	"google.protobuf.Duration":    "base#String",
+	"google.protobuf.FieldMask":   "base#String",
+	"google.protobuf.Struct":      "base#Any",
+	"google.protobuf.Value":       "base#Any",
+	"google.protobuf.ListValue":   "base#Any",
+	"google.protobuf.Any":         "base#Any",
+	"google.protobuf.Empty":       "base#Any",
+	"google.protobuf.DoubleValue": "base#Float64",
+	"google.protobuf.FloatValue":  "base#Float32",
+	"google.protobuf.Int64Value":  "base#Int64",
+	"google.protobuf.UInt64Value": "base#Integer",
+	"google.protobuf.Int32Value":  "base#Int32",
+	"google.protobuf.UInt32Value": "base#Int64",
+	"google.protobuf.BoolValue":   "base#Bool",
+	"google.protobuf.StringValue": "base#String",
+	"google.protobuf.BytesValue":  "base#Bytes",
+}
+
+var scalarTypes = map[string]model.AbsoluteIdentifier{
+	"double":   "base#Float64",
+	"float":    "base#Float32",
+	"int32":    "base#Int32",
+	"sint32":   "base#Int32",
+	"sfixed32": "base#Int32",
+	"uint32":   "base#Int64",
+	"fixed32":  "base#Int64",
+	"int64":    "base#Int64",
+	"sint64":   "base#Int64",
+	"sfixed64": "base#Int64",
+	"uint64":   "base#Integer",
+	"fixed64":  "base#Integer",
+	"bool":     "base#Bool",
+	"string":   "base#String",
+	"bytes":    "base#Bytes",
+}
+
+const emptyType = "google.protobuf.Empty"
+
+func (mb *ModelBuilder) load(path string, primary bool) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if mb.loaded[abs] {
+		return nil
+	}
+	mb.loaded[abs] = true
+	f, err := ParseFile(path)
+	if err != nil {
+		return err
+	}
+	mb.files = append(mb.files, f)
+	if primary {
+		mb.services = append(mb.services, f)
+	}
+	for _, imp := range f.Imports {
+		if strings.HasPrefix(imp, "google/protobuf/") || strings.HasPrefix(imp, "google/api/") || strings.HasPrefix(imp, "google/rpc/") {
+			continue
+		}
+		found := findImport(filepath.Dir(path), imp)
+		if found == "" {
+			model.Warning("Cannot find imported proto file %q, its types will be undefined\n", imp)
+			continue
+		}
+		err = mb.load(found, false)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findImport looks for an imported file relative to the directory of the importing file and each of its ancestors,
+// since import paths are relative to an include root that is not otherwise known.
+func findImport(dir string, imp string) string {
+	for {
+		candidate := filepath.Join(dir, imp)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func (mb *ModelBuilder) namespace(f *File) model.Namespace {
+	if f.Package != "" {
+		return model.Namespace(f.Package)
+	}
+	return model.Namespace(mb.ns)
+}
+
+func (mb *ModelBuilder) Build() error {
+	for _, f := range mb.files {
+		ns := mb.namespace(f)
+		for _, msg := range f.Messages {
+			mb.registerMessage(ns, "", msg)
+		}
+		for _, en := range f.Enums {
+			mb.types[en.FullName] = &protoType{id: typeId(ns, en.Name), enum: en}
+		}
+	}
+	if len(mb.services) > 0 {
+		mb.schema.Namespace = mb.namespace(mb.services[0])
+	}
+	for _, f := range mb.files {
+		ns := mb.namespace(f)
+		for _, msg := range f.Messages {
+			err := mb.importMessage(ns, msg)
+			if err != nil {
+				return err
+			}
+		}
+		for _, en := range f.Enums {
+			err := mb.importEnum(ns, en)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for _, f := range mb.services {
+		for _, svc := range f.Services {
+			err := mb.importService(f, svc)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Nested messages and enums are named by concatenating the names of their enclosing messages, i.e. Outer.Inner
+// becomes OuterInner.
+func (mb *ModelBuilder) registerMessage(ns model.Namespace, prefix string, msg *Message) {
+	name := prefix + msg.Name
+	mb.types[msg.FullName] = &protoType{id: typeId(ns, name), message: msg}
+	for _, nested := range msg.Messages {
+		mb.registerMessage(ns, name, nested)
+	}
+	for _, en := range msg.Enums {
+		mb.types[en.FullName] = &protoType{id: typeId(ns, name+en.Name), enum: en}
+	}
+}
+
+func typeId(ns model.Namespace, name string) model.AbsoluteIdentifier {
+	return model.AbsoluteIdentifier(string(ns) + "#" + name)
+}
+
+// resolve finds the declaration a type reference refers to, following the protobuf scoping rules: the name is looked
+// up in the innermost scope first, then in each enclosing scope.
+func (mb *ModelBuilder) resolve(scope string, name string) (string, *protoType) {
+	if strings.HasPrefix(name, ".") {
+		name = name[1:]
+		return name, mb.types[name]
+	}
+	for {
+		candidate := qualifiedName(scope, name)
+		if t, ok := mb.types[candidate]; ok {
+			return candidate, t
+		}
+		if _, ok := wellKnownTypes[candidate]; ok {
+			return candidate, nil
+		}
+		if scope == "" {
+			return name, nil
+		}
+		i := strings.LastIndex(scope, ".")
+		if i < 0 {
+			scope = ""
+		} else {
+			scope = scope[:i]
+		}
+	}
+}
+
+func (mb *ModelBuilder) typeRef(scope string, name string) model.AbsoluteIdentifier {
+	if id, ok := scalarTypes[name]; ok {
+		return id
+	}
+	fullName, t := mb.resolve(scope, name)
+	if t != nil {
+		return t.id
+	}
+	if id, ok := wellKnownTypes[fullName]; ok {
+		return id
+	}
+	model.Warning("Undefined protobuf type %q referenced from %s\n", name, scope)
+	return "base#Any"
+}
+
+func (mb *ModelBuilder) addTypeDef(td *model.TypeDef) error {
+	if _, ok := mb.typeDefs[td.Id]; ok {
+		return nil
+	}
+	mb.typeDefs[td.Id] = td
+	return mb.schema.AddTypeDef(td)
+}
+
+func (mb *ModelBuilder) importMessage(ns model.Namespace, msg *Message) error {
+	id := mb.types[msg.FullName].id
+	td := &model.TypeDef{
+		Id:      id,
+		Base:    model.BaseType_Struct,
+		Comment: msg.Comment,
+	}
+	fields, err := mb.importFields(ns, msg, id)
+	if err != nil {
+		return err
+	}
+	td.Fields = fields
+	err = mb.addTypeDef(td)
+	if err != nil {
+		return err
+	}
+	for _, nested := range msg.Messages {
+		if nested.Options["map_entry"] == true {
+			continue
+		}
+		err = mb.importMessage(ns, nested)
+		if err != nil {
+			return err
+		}
+	}
+	for _, en := range msg.Enums {
+		err = mb.importEnum(ns, en)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importFields converts the fields of a message. The fields of each oneof are replaced by a single field, whose type
+// is a union of them.
+func (mb *ModelBuilder) importFields(ns model.Namespace, msg *Message, id model.AbsoluteIdentifier) ([]*model.FieldDef, error) {
+	var fields []*model.FieldDef
+	oneofsDone := make(map[string]bool, 0)
+	for _, field := range msg.Fields {
+		if field.Oneof == "" {
+			fd, err := mb.importField(ns, msg.FullName, field)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, fd)
+			continue
+		}
+		if oneofsDone[field.Oneof] {
+			continue
+		}
+		oneofsDone[field.Oneof] = true
+		for _, oneof := range msg.Oneofs {
+			if oneof.Name != field.Oneof {
+				continue
+			}
+			union := &model.TypeDef{
+				Id:      model.AbsoluteIdentifier(string(id) + pascalCase(oneof.Name)),
+				Base:    model.BaseType_Union,
+				Comment: oneof.Comment,
+			}
+			for _, of := range oneof.Fields {
+				fd, err := mb.importField(ns, msg.FullName, of)
+				if err != nil {
+					return nil, err
+				}
+				fd.Required = false
+				union.Fields = append(union.Fields, fd)
+			}
+			err := mb.addTypeDef(union)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, &model.FieldDef{
+				Name:    model.Identifier(lowerCamelCase(oneof.Name)),
+				Type:    union.Id,
+				Comment: oneof.Comment,
+			})
+		}
+	}
+	return fields, nil
+}
+
+func (mb *ModelBuilder) importField(ns model.Namespace, scope string, field *Field) (*model.FieldDef, error) {
+	ftype, err := mb.fieldType(ns, scope, field)
+	if err != nil {
+		return nil, err
+	}
+	return &model.FieldDef{
+		Name:     model.Identifier(fieldName(field)),
+		Type:     ftype,
+		Comment:  field.Comment,
+		Required: isRequired(field),
+	}, nil
+}
+
+// fieldType returns the type of the field, defining the list or map type it needs, if any. These are named for their
+// element types, i.e. "repeated Book" is BookList, and "map<string,Book>" is StringBookMap.
+func (mb *ModelBuilder) fieldType(ns model.Namespace, scope string, field *Field) (model.AbsoluteIdentifier, error) {
+	items := mb.typeRef(scope, field.Type)
+	if field.KeyType != "" {
+		keys := mb.typeRef(scope, field.KeyType)
+		td := &model.TypeDef{
+			Id:    typeId(ns, model.StripNamespace(keys)+model.StripNamespace(items)+"Map"),
+			Base:  model.BaseType_Map,
+			Keys:  keys,
+			Items: items,
+		}
+		return td.Id, mb.addTypeDef(td)
+	}
+	if field.Label == "repeated" {
+		td := &model.TypeDef{
+			Id:    typeId(ns, model.StripNamespace(items)+"List"),
+			Base:  model.BaseType_List,
+			Items: items,
+		}
+		return td.Id, mb.addTypeDef(td)
+	}
+	return items, nil
+}
+
+// fieldName is the name the field has in the proto3 JSON mapping.
+func fieldName(field *Field) string {
+	if jn, ok := field.Options["json_name"].(string); ok {
+		return jn
+	}
+	return lowerCamelCase(field.Name)
+}
+
+func isRequired(field *Field) bool {
+	if field.Label == "required" {
+		return true
+	}
+	switch fb := field.Options["google.api.field_behavior"].(type) {
+	case string:
+		return fb == "REQUIRED"
+	case []interface{}:
+		for _, v := range fb {
+			if v == "REQUIRED" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (mb *ModelBuilder) importEnum(ns model.Namespace, en *Enum) error {
+	td := &model.TypeDef{
+		Id:      mb.types[en.FullName].id,
+		Base:    model.BaseType_Enum,
+		Comment: en.Comment,
+	}
+	for _, v := range en.Values {
+		td.Elements = append(td.Elements, &model.EnumElement{
+			Symbol:  model.Identifier(v.Name),
+			Comment: v.Comment,
+		})
+	}
+	return mb.addTypeDef(td)
+}
+
+var serviceVersionPattern = regexp.MustCompile(`^v[0-9]+([a-z]+[0-9]*)?$`)
+
+func (mb *ModelBuilder) importService(f *File, svc *Service) error {
+	ns := mb.namespace(f)
+	if mb.schema.Id == "" {
+		mb.schema.Id = typeId(ns, svc.Name)
+		mb.schema.Comment = svc.Comment
+		segments := strings.Split(f.Package, ".")
+		if last := segments[len(segments)-1]; serviceVersionPattern.MatchString(last) {
+			mb.schema.Version = last
+		}
+	} else {
+		model.Warning("Only one service is supported per model, the methods of %s are added to %s\n", svc.FullName, mb.schema.Id)
+	}
+	for _, m := range svc.Methods {
+		err := mb.importMethod(f, svc, m)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// httpRule is the primary binding of a google.api.http annotation.
+type httpRule struct {
+	method       string
+	path         string
+	body         string
+	responseBody string
+}
+
+func methodHttpRule(svc *Service, m *Method) *httpRule {
+	opt, ok := m.Options["google.api.http"].(map[string]interface{})
+	if !ok {
+		return &httpRule{method: "POST", path: "/" + svc.FullName + "/" + m.Name, body: "*"}
+	}
+	rule := &httpRule{}
+	for _, method := range []string{"get", "put", "post", "delete", "patch"} {
+		if path, ok := opt[method].(string); ok {
+			rule.method = strings.ToUpper(method)
+			rule.path = path
+		}
+	}
+	if custom, ok := opt["custom"].(map[string]interface{}); ok {
+		kind, _ := custom["kind"].(string)
+		rule.method = strings.ToUpper(kind)
+		rule.path, _ = custom["path"].(string)
+	}
+	rule.body, _ = opt["body"].(string)
+	rule.responseBody, _ = opt["response_body"].(string)
+	if _, ok := opt["additional_bindings"]; ok {
+		model.Warning("Additional HTTP bindings of %s.%s are ignored\n", svc.Name, m.Name)
+	}
+	return rule
+}
+
+var pathVariablePattern = regexp.MustCompile(`\{([a-zA-Z0-9_.]+)(=[^}]*)?\}`)
+
+// pathTemplate converts a google.api.http path template to the model's form, and returns the field paths of its
+// variables. A variable matching a single segment, like {name} or {name=*}, becomes {name}. A variable matching
+// several segments, like {name=shelves/*/books/*} or {name=**}, becomes the greedy label {name+}: its value is the
+// whole resource name, literal segments included, as in gRPC transcoding. The model allows only one greedy label, so
+// any other such variable matches a single segment, with a warning.
+func pathTemplate(path string) (string, []string) {
+	var vars []string
+	greedy := false
+	uri := pathVariablePattern.ReplaceAllStringFunc(path, func(s string) string {
+		m := pathVariablePattern.FindStringSubmatch(s)
+		vars = append(vars, m[1])
+		name := lowerCamelCase(strings.ReplaceAll(m[1], ".", "_"))
+		pattern := strings.TrimPrefix(m[2], "=")
+		if pattern == "" || pattern == "*" {
+			return "{" + name + "}"
+		}
+		if !greedy {
+			greedy = true
+			return "{" + name + "+}"
+		}
+		model.Warning("Only one path variable may span several segments, %s matches a single one\n", m[1])
+		return "{" + name + "}"
+	})
+	return uri, vars
+}
+
+func (mb *ModelBuilder) importMethod(f *File, svc *Service, m *Method) error {
+	ns := mb.namespace(f)
+	if m.ClientStreaming || m.ServerStreaming {
+		model.Warning("Streaming is not supported, %s.%s is imported as a unary operation\n", svc.Name, m.Name)
+	}
+	rule := methodHttpRule(svc, m)
+	if rule.method == "" || rule.path == "" {
+		return fmt.Errorf("Bad google.api.http annotation on %s.%s", svc.Name, m.Name)
+	}
+	uri, pathVars := pathTemplate(rule.path)
+	opId := typeId(ns, m.Name)
+	op := &model.OperationDef{
+		Id:         opId,
+		Comment:    m.Comment,
+		HttpMethod: rule.method,
+		HttpUri:    uri,
+	}
+	input, err := mb.methodInput(f, m, opId, rule, pathVars)
+	if err != nil {
+		return err
+	}
+	op.Input = input
+	output, err := mb.methodOutput(f, m, opId, rule)
+	if err != nil {
+		return err
+	}
+	op.Output = output
+	return mb.schema.AddOperationDef(op)
+}
+
+// requestMessage resolves the message type of a method's request or response, which is nil for google.protobuf.Empty.
+func (mb *ModelBuilder) requestMessage(f *File, name string) (*protoType, error) {
+	fullName, t := mb.resolve(f.Package, name)
+	if fullName == emptyType {
+		return nil, nil
+	}
+	if t == nil || t.message == nil {
+		return nil, fmt.Errorf("RPC message type not defined: %s", name)
+	}
+	return t, nil
+}
+
+func (mb *ModelBuilder) methodInput(f *File, m *Method, opId model.AbsoluteIdentifier, rule *httpRule, pathVars []string) (*model.OperationInput, error) {
+	ns := mb.namespace(f)
+	req, err := mb.requestMessage(f, m.InputType)
+	if err != nil {
+		return nil, err
+	}
+	if req == nil {
+		if len(pathVars) > 0 {
+			return nil, fmt.Errorf("Path variables of %s have no request fields to bind to", m.Name)
+		}
+		return nil, nil
+	}
+	msg := req.message
+	input := &model.OperationInput{
+		Id: model.AbsoluteIdentifier(string(opId) + "Input"),
+	}
+	bound := make(map[string]bool, 0)
+	for _, v := range pathVars {
+		field, scope := mb.lookupFieldPath(msg, v)
+		in := &model.OperationInputField{
+			Name:     model.Identifier(lowerCamelCase(strings.ReplaceAll(v, ".", "_"))),
+			Type:     "base#String",
+			Required: true,
+			HttpPath: true,
+		}
+		if field != nil {
+			in.Type = mb.typeRef(scope, field.Type)
+			in.Comment = field.Comment
+		}
+		input.Fields = append(input.Fields, in)
+		if strings.Index(v, ".") < 0 {
+			bound[lowerCamelCase(v)] = true
+		}
+	}
+	fields, err := mb.importFields(ns, msg, req.id)
+	if err != nil {
+		return nil, err
+	}
+	var rest []*model.FieldDef
+	for _, fd := range fields {
+		if !bound[string(fd.Name)] {
+			rest = append(rest, fd)
+		}
+	}
+	switch rule.body {
+	case "*":
+		if len(rest) == 0 {
+			break
+		}
+		payloadType := req.id
+		if len(rest) != len(fields) {
+			//the path variables are not part of the body
+			td := &model.TypeDef{
+				Id:     model.AbsoluteIdentifier(string(opId) + "RequestBody"),
+				Base:   model.BaseType_Struct,
+				Fields: rest,
+			}
+			err = mb.addTypeDef(td)
+			if err != nil {
+				return nil, err
+			}
+			payloadType = td.Id
+		}
+		input.Fields = append(input.Fields, &model.OperationInputField{
+			Name:        model.Identifier(model.Uncapitalize(model.StripNamespace(payloadType))),
+			Type:        payloadType,
+			Comment:     msg.Comment,
+			Required:    true,
+			HttpPayload: true,
+		})
+	default:
+		for _, fd := range rest {
+			in := &model.OperationInputField{
+				Name:     fd.Name,
+				Type:     fd.Type,
+				Comment:  fd.Comment,
+				Required: fd.Required,
+			}
+			if rule.body != "" && string(fd.Name) == lowerCamelCase(rule.body) {
+				in.HttpPayload = true
+			} else if mb.isQueryable(fd.Type) {
+				in.HttpQuery = fd.Name
+			} else {
+				model.Warning("Field %q of %s cannot be bound to a query parameter, and is omitted from %s\n", fd.Name, msg.FullName, m.Name)
+				continue
+			}
+			input.Fields = append(input.Fields, in)
+		}
+	}
+	return input, nil
+}
+
+// lookupFieldPath finds the field for a path variable like "book.name", returning it and the scope its type is
+// declared in.
+func (mb *ModelBuilder) lookupFieldPath(msg *Message, path string) (*Field, string) {
+	segments := strings.Split(path, ".")
+	for i, seg := range segments {
+		var found *Field
+		for _, field := range msg.Fields {
+			if field.Name == seg {
+				found = field
+			}
+		}
+		if found == nil {
+			return nil, ""
+		}
+		if i == len(segments)-1 {
+			return found, msg.FullName
+		}
+		_, t := mb.resolve(msg.FullName, found.Type)
+		if t == nil || t.message == nil {
+			return nil, ""
+		}
+		msg = t.message
+	}
+	return nil, ""
+}
+
+// isQueryable is true for the types grpc-gateway accepts as query parameters: scalars, enums, and lists of them.
+func (mb *ModelBuilder) isQueryable(id model.AbsoluteIdentifier) bool {
+	if id == "base#Any" {
+		return false
+	}
+	if mb.schema.IsBaseType(id) {
+		return true
+	}
+	td := mb.typeDefs[id]
+	if td == nil {
+		return false
+	}
+	switch td.Base {
+	case model.BaseType_Enum:
+		return true
+	case model.BaseType_List:
+		return td.Items != "base#Any" && (mb.schema.IsBaseType(td.Items) || mb.typeDefs[td.Items] != nil && mb.typeDefs[td.Items].Base == model.BaseType_Enum)
+	}
+	return false
+}
+
+func (mb *ModelBuilder) methodOutput(f *File, m *Method, opId model.AbsoluteIdentifier, rule *httpRule) (*model.OperationOutput, error) {
+	resp, err := mb.requestMessage(f, m.OutputType)
+	if err != nil {
+		return nil, err
+	}
+	output := &model.OperationOutput{
+		Id:         model.AbsoluteIdentifier(string(opId) + "Output"),
+		HttpStatus: 200,
+	}
+	if resp == nil {
+		output.HttpStatus = 204
+		return output, nil
+	}
+	payload := &model.OperationOutputField{
+		Name:        model.Identifier(model.Uncapitalize(model.StripNamespace(resp.id))),
+		Type:        resp.id,
+		Comment:     resp.message.Comment,
+		HttpPayload: true,
+	}
+	if rule.responseBody != "" {
+		field, scope := mb.lookupFieldPath(resp.message, rule.responseBody)
+		if field == nil {
+			return nil, fmt.Errorf("The response_body of %s is not a field of %s", m.Name, resp.message.FullName)
+		}
+		ftype, err := mb.fieldType(mb.namespace(f), scope, field)
+		if err != nil {
+			return nil, err
+		}
+		payload.Name = model.Identifier(fieldName(field))
+		payload.Type = ftype
+		payload.Comment = field.Comment
+	}
+	output.Fields = append(output.Fields, payload)
+	return output, nil
+}
+
+func pascalCase(s string) string {
+	return model.Capitalize(lowerCamelCase(s))
+}
+
+// lowerCamelCase converts a snake_case protobuf name to lowerCamelCase, as the proto3 JSON mapping does.
+func lowerCamelCase(s string) string {
+	var sb strings.Builder
+	upper := false
+	for i, ch := range s {
+		if ch == '_' {
+			upper = i > 0
+			continue
+		}
+		if upper && ch >= 'a' && ch <= 'z' {
+			ch = ch - 'a' + 'A'
+		}
+		upper = false
+		sb.WriteRune(ch)
+	}
+	return sb.String()
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package protobuf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPathTemplate(t *testing.T) {
+	tests := []struct {
+		path string
+		uri  string
+		vars []string
+	}{
+		{"/v1/things/{name}", "/v1/things/{name}", []string{"name"}},
+		{"/v1/things/{name=*}", "/v1/things/{name}", []string{"name"}},
+		{"/v1/things/{name=things/*}", "/v1/things/{name+}", []string{"name"}},
+		{"/v1/{name=shelves/*/books/*}:publish", "/v1/{name+}:publish", []string{"name"}},
+		{"/v1/files/{path=**}", "/v1/files/{path+}", []string{"path"}},
+		{"/v1/{book.shelf_id}/books", "/v1/{bookShelfId}/books", []string{"book.shelf_id"}},
+	}
+	for _, test := range tests {
+		uri, vars := pathTemplate(test.path)
+		if uri != test.uri {
+			t.Errorf("%s: expected %s, got %s", test.path, test.uri, uri)
+		}
+		if len(vars) != len(test.vars) || vars[0] != test.vars[0] {
+			t.Errorf("%s: expected the variables %v, got %v", test.path, test.vars, vars)
+		}
+	}
+}
+
+func TestImportResourceNamePath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "things.proto")
+	src := `syntax = "proto3";
+package example.v1;
+
+import "google/api/annotations.proto";
+
+service ThingService {
+  rpc GetThing(GetThingRequest) returns (Thing) {
+    option (google.api.http) = { get: "/v1/things/{name=things/*}" };
+  }
+}
+
+message GetThingRequest {
+  string name = 1;
+}
+
+message Thing {
+  string name = 1;
+}
+`
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	schema, err := Import([]string{path}, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema.Operations) != 1 {
+		t.Fatalf("expected one operation, got %d", len(schema.Operations))
+	}
+	op := schema.Operations[0]
+	if op.HttpUri != "/v1/things/{name+}" {
+		t.Errorf("expected the path /v1/things/{name+}, got %s", op.HttpUri)
+	}
+	if op.Input == nil || len(op.Input.Fields) != 1 || !op.Input.Fields[0].HttpPath || op.Input.Fields[0].Name != "name" {
+		t.Errorf("expected the name field to be bound to the path, got %v", op.Input)
+	}
+}
@@ -0,0 +1,302 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package protobuf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type TokenType int
+
+const (
+	UNDEFINED TokenType = iota
+	EOF
+	LINE_COMMENT
+	BLOCK_COMMENT
+	SYMBOL
+	NUMBER
+	STRING
+	SEMICOLON
+	COMMA
+	COLON
+	EQUALS
+	OPEN_BRACE
+	CLOSE_BRACE
+	OPEN_BRACKET
+	CLOSE_BRACKET
+	OPEN_PAREN
+	CLOSE_PAREN
+	OPEN_ANGLE
+	CLOSE_ANGLE
+)
+
+type Token struct {
+	Type  TokenType
+	Text  string
+	Line  int
+	Start int
+}
+
+func (tokenType TokenType) String() string {
+	switch tokenType {
+	case EOF:
+		return "EOF"
+	case LINE_COMMENT:
+		return "LINE_COMMENT"
+	case BLOCK_COMMENT:
+		return "BLOCK_COMMENT"
+	case SYMBOL:
+		return "SYMBOL"
+	case NUMBER:
+		return "NUMBER"
+	case STRING:
+		return "STRING"
+	case SEMICOLON:
+		return "SEMICOLON"
+	case COMMA:
+		return "COMMA"
+	case COLON:
+		return "COLON"
+	case EQUALS:
+		return "EQUALS"
+	case OPEN_BRACE:
+		return "OPEN_BRACE"
+	case CLOSE_BRACE:
+		return "CLOSE_BRACE"
+	case OPEN_BRACKET:
+		return "OPEN_BRACKET"
+	case CLOSE_BRACKET:
+		return "CLOSE_BRACKET"
+	case OPEN_PAREN:
+		return "OPEN_PAREN"
+	case CLOSE_PAREN:
+		return "CLOSE_PAREN"
+	case OPEN_ANGLE:
+		return "OPEN_ANGLE"
+	case CLOSE_ANGLE:
+		return "CLOSE_ANGLE"
+	}
+	return "UNDEFINED"
+}
+
+func (tok Token) String() string {
+	if tok.Type == EOF {
+		return "EOF"
+	}
+	return fmt.Sprintf("<%v %q %d:%d>", tok.Type, tok.Text, tok.Line, tok.Start)
+}
+
+var punctuation = map[rune]TokenType{
+	';': SEMICOLON,
+	',': COMMA,
+	':': COLON,
+	'=': EQUALS,
+	'{': OPEN_BRACE,
+	'}': CLOSE_BRACE,
+	'[': OPEN_BRACKET,
+	']': CLOSE_BRACKET,
+	'(': OPEN_PAREN,
+	')': CLOSE_PAREN,
+	'<': OPEN_ANGLE,
+	'>': CLOSE_ANGLE,
+}
+
+// Scanner tokenizes a .proto file. Full identifiers (i.e. "google.protobuf.Timestamp" or ".foo.Bar") are returned as a
+// single SYMBOL token, string literals are returned unescaped, and comments are returned so the parser can attach
+// them to declarations.
+type Scanner struct {
+	path   string
+	src    []rune
+	pos    int
+	line   int
+	column int
+}
+
+func NewScanner(path string, text string) *Scanner {
+	return &Scanner{path: path, src: []rune(text), line: 1, column: 1}
+}
+
+func (s *Scanner) peek(offset int) rune {
+	if s.pos+offset < len(s.src) {
+		return s.src[s.pos+offset]
+	}
+	return 0
+}
+
+func (s *Scanner) advance() rune {
+	ch := s.src[s.pos]
+	s.pos++
+	if ch == '\n' {
+		s.line++
+		s.column = 1
+	} else {
+		s.column++
+	}
+	return ch
+}
+
+func (s *Scanner) Scan() (Token, error) {
+	for s.pos < len(s.src) {
+		ch := s.peek(0)
+		if ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == '\f' || ch == '\v' {
+			s.advance()
+			continue
+		}
+		tok := Token{Line: s.line, Start: s.column}
+		switch {
+		case ch == '/' && s.peek(1) == '/':
+			s.advance()
+			s.advance()
+			start := s.pos
+			for s.pos < len(s.src) && s.peek(0) != '\n' {
+				s.advance()
+			}
+			tok.Type = LINE_COMMENT
+			tok.Text = strings.TrimRight(string(s.src[start:s.pos]), "\r")
+		case ch == '/' && s.peek(1) == '*':
+			s.advance()
+			s.advance()
+			start := s.pos
+			for s.pos < len(s.src) && !(s.peek(0) == '*' && s.peek(1) == '/') {
+				s.advance()
+			}
+			if s.pos >= len(s.src) {
+				return tok, s.error(tok, "Unterminated comment")
+			}
+			tok.Type = BLOCK_COMMENT
+			tok.Text = string(s.src[start:s.pos])
+			s.advance()
+			s.advance()
+		case ch == '"' || ch == '\'':
+			text, err := s.scanString(tok)
+			if err != nil {
+				return tok, err
+			}
+			tok.Type = STRING
+			tok.Text = text
+		case isDigit(ch) || (ch == '-' || ch == '+' || ch == '.') && isDigit(s.peek(1)):
+			start := s.pos
+			s.advance()
+			for s.pos < len(s.src) && (isLetter(s.peek(0)) || isDigit(s.peek(0)) || s.peek(0) == '.' ||
+				(s.peek(0) == '-' || s.peek(0) == '+') && (s.src[s.pos-1] == 'e' || s.src[s.pos-1] == 'E')) {
+				s.advance()
+			}
+			tok.Type = NUMBER
+			tok.Text = string(s.src[start:s.pos])
+		case isLetter(ch) || ch == '_' || ch == '.' && isLetter(s.peek(1)):
+			start := s.pos
+			s.advance()
+			for s.pos < len(s.src) && (isLetter(s.peek(0)) || isDigit(s.peek(0)) || s.peek(0) == '_' || s.peek(0) == '.') {
+				s.advance()
+			}
+			tok.Type = SYMBOL
+			tok.Text = string(s.src[start:s.pos])
+		default:
+			if tt, ok := punctuation[ch]; ok {
+				s.advance()
+				tok.Type = tt
+				tok.Text = string(ch)
+			} else {
+				return tok, s.error(tok, fmt.Sprintf("Unexpected character %q", ch))
+			}
+		}
+		return tok, nil
+	}
+	return Token{Type: EOF, Line: s.line, Start: s.column}, nil
+}
+
+func (s *Scanner) scanString(tok Token) (string, error) {
+	quote := s.advance()
+	var sb strings.Builder
+	for {
+		if s.pos >= len(s.src) || s.peek(0) == '\n' {
+			return "", s.error(tok, "Unterminated string")
+		}
+		ch := s.advance()
+		if ch == quote {
+			return sb.String(), nil
+		}
+		if ch != '\\' {
+			sb.WriteRune(ch)
+			continue
+		}
+		if s.pos >= len(s.src) {
+			return "", s.error(tok, "Unterminated string")
+		}
+		esc := s.advance()
+		switch esc {
+		case 'n':
+			sb.WriteRune('\n')
+		case 't':
+			sb.WriteRune('\t')
+		case 'r':
+			sb.WriteRune('\r')
+		case 'a':
+			sb.WriteRune('\a')
+		case 'b':
+			sb.WriteRune('\b')
+		case 'f':
+			sb.WriteRune('\f')
+		case 'v':
+			sb.WriteRune('\v')
+		case 'x', 'X', 'u', 'U':
+			n := 2
+			if esc == 'u' {
+				n = 4
+			} else if esc == 'U' {
+				n = 8
+			}
+			digits := ""
+			for i := 0; i < n && isHexDigit(s.peek(0)); i++ {
+				digits += string(s.advance())
+			}
+			v, err := strconv.ParseUint(digits, 16, 32)
+			if err != nil {
+				return "", s.error(tok, "Bad escape in string")
+			}
+			sb.WriteRune(rune(v))
+		default:
+			if esc >= '0' && esc <= '7' {
+				digits := string(esc)
+				for i := 0; i < 2 && s.peek(0) >= '0' && s.peek(0) <= '7'; i++ {
+					digits += string(s.advance())
+				}
+				v, _ := strconv.ParseUint(digits, 8, 32)
+				sb.WriteRune(rune(v))
+			} else {
+				sb.WriteRune(esc)
+			}
+		}
+	}
+}
+
+func (s *Scanner) error(tok Token, msg string) error {
+	return fmt.Errorf("*** %s:%d:%d: %s", s.path, tok.Line, tok.Start, msg)
+}
+
+func isDigit(ch rune) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || ch >= 'a' && ch <= 'f' || ch >= 'A' && ch <= 'F'
+}
+
+func isLetter(ch rune) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
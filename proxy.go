@@ -0,0 +1,83 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/boynton/api/model"
+	"github.com/boynton/api/proxy"
+	"github.com/boynton/data"
+)
+
+// Proxy implements "api proxy": it assembles the model from the files, and runs a reverse proxy to the target that
+// validates the traffic against the model, until interrupted. It returns the exit code of the tool.
+func Proxy(args []string) int {
+	flags := flag.NewFlagSet("proxy", flag.ExitOnError)
+	pTarget := flags.String("target", "", "The URL of the service to forward to")
+	pAddr := flags.String("addr", "localhost:8080", "The address to listen on")
+	pOut := flags.String("o", "", "The file to append the violation records to (defaults to stdout)")
+	pAll := flags.Bool("all", false, "Log a record for every exchange, not only those with violations")
+	pNoValidate := flags.Bool("v", false, "Suppress validation of the assembled model")
+	pQuiet := flags.Bool("q", false, "Quiet tool output, make it less verbose")
+	pNs := flags.String("ns", "", "The namespace to force if absent")
+	var tags Tags
+	flags.Var(&tags, "t", "Tag of entities to include. Prefix tag with '-' to exclude that tag")
+	flags.Parse(args)
+	files := flags.Args()
+	if len(files) == 0 || *pTarget == "" {
+		fmt.Println("usage: api proxy -target url [-addr host:port] [-o file] [-all] [-vq] [-ns namespace] [-t tag]* file ...")
+		flags.PrintDefaults()
+		return 1
+	}
+	target, err := url.Parse(*pTarget)
+	if err != nil || target.Host == "" {
+		fmt.Printf("*** Bad target URL: %q\n", *pTarget)
+		return 1
+	}
+	model.MinimizeOutput = *pQuiet
+	schema, err := AssembleModel(files, tags, *pNs, false, *pNoValidate, data.NewObject())
+	if err != nil {
+		fmt.Printf("*** %v\n", err)
+		return 4
+	}
+	var log io.Writer = os.Stdout
+	if *pOut != "" {
+		f, err := os.OpenFile(*pOut, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			fmt.Printf("*** %v\n", err)
+			return 4
+		}
+		defer f.Close()
+		log = f
+	}
+	p := proxy.NewProxy(schema, target, log)
+	p.LogAll = *pAll
+	if !*pQuiet {
+		fmt.Fprintf(os.Stderr, "Proxying %s to %s at http://%s\n", schema.Id, target, *pAddr)
+	}
+	err = http.ListenAndServe(*pAddr, p)
+	if err != nil {
+		fmt.Printf("*** %v\n", err)
+		return 4
+	}
+	return 0
+}
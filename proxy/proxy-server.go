@@ -0,0 +1,271 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package proxy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/boynton/api/model"
+)
+
+// Record is the log record of an exchange through the proxy. The path of each violation starts with "request" or
+// "response", i.e. "request.query.limit" or "response.body.items[0].id".
+type Record struct {
+	Time       string             `json:"time"`
+	Method     string             `json:"method"`
+	Url        string             `json:"url"`
+	Operation  string             `json:"operation,omitempty"`
+	Status     int                `json:"status,omitempty"`
+	Violations []*model.Violation `json:"violations,omitempty"`
+}
+
+func (rec *Record) violate(path string, format string, args ...any) {
+	rec.Violations = append(rec.Violations, &model.Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Proxy is a reverse proxy to an implementation of a model's service. It forwards every request and response
+// unchanged, and checks each against the model: the route matches an operation, the required inputs are present, the
+// inputs and outputs conform to their types, and the status is the output's or one of the operation's exceptions'.
+type Proxy struct {
+	Schema *model.Schema
+	Router *model.Router
+	// Log receives a Record, as a line of JSON, for each exchange that violates the model
+	Log io.Writer
+	// LogAll logs the records of the exchanges without violations, too
+	LogAll bool
+	proxy  *httputil.ReverseProxy
+	lock   sync.Mutex
+}
+
+type recordKey struct{}
+
+type exchange struct {
+	op     *model.OperationDef
+	record *Record
+}
+
+func NewProxy(schema *model.Schema, target *url.URL, log io.Writer) *Proxy {
+	p := &Proxy{Schema: schema, Router: model.NewRouter(schema), Log: log}
+	p.proxy = httputil.NewSingleHostReverseProxy(target)
+	director := p.proxy.Director
+	p.proxy.Director = func(r *http.Request) {
+		director(r)
+		r.Host = target.Host
+	}
+	p.proxy.ModifyResponse = p.checkResponse
+	p.proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		if x, ok := r.Context().Value(recordKey{}).(*exchange); ok {
+			x.record.Status = http.StatusBadGateway
+			x.record.violate("response", "the target failed: %v", err)
+			p.log(x.record)
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	return p
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec := &Record{Time: time.Now().UTC().Format(time.RFC3339Nano), Method: r.Method, Url: r.URL.String()}
+	op, params := p.Router.Match(r.Method, r.URL.EscapedPath())
+	if op == nil {
+		rec.violate("request", "no operation matches %s %s", r.Method, r.URL.Path)
+	} else {
+		rec.Operation = model.StripNamespace(op.Id)
+		if err := p.checkRequest(rec, op, r, params); err != nil {
+			rec.violate("request", "cannot read the request: %v", err)
+			p.log(rec)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+	ctx := context.WithValue(r.Context(), recordKey{}, &exchange{op: op, record: rec})
+	p.proxy.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// checkRequest checks the inputs of the request. The body is read, and replaced for forwarding.
+func (p *Proxy) checkRequest(rec *Record, op *model.OperationDef, r *http.Request, params map[string]string) error {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return err
+		}
+		r.Body.Close()
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	defer func() {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}()
+	input, err := model.RequestInput(p.Schema, op, r, params)
+	if err != nil {
+		rec.violate("request", "%v", err)
+		return nil
+	}
+	if op.Input == nil {
+		return nil
+	}
+	for _, f := range op.Input.Fields {
+		var path string
+		switch {
+		case f.HttpPath:
+			path = "request.path." + string(f.Name)
+		case f.HttpQuery != "":
+			path = "request.query." + string(f.HttpQuery)
+		case f.HttpHeader != "":
+			path = "request.header." + f.HttpHeader
+		case f.HttpPayload:
+			path = "request.body"
+		default:
+			path = "request.body." + string(f.Name)
+		}
+		v, ok := input[string(f.Name)]
+		if !ok || v == nil {
+			if f.Required {
+				rec.violate(path, "missing required input %q", f.Name)
+			}
+			continue
+		}
+		rec.Violations = append(rec.Violations, p.Schema.ConformInput(f, v, path)...)
+	}
+	return nil
+}
+
+// checkResponse checks the status and the outputs of the response, and logs the record of the exchange. The body is
+// read, and replaced for forwarding.
+func (p *Proxy) checkResponse(res *http.Response) error {
+	x, ok := res.Request.Context().Value(recordKey{}).(*exchange)
+	if !ok {
+		return nil
+	}
+	rec := x.record
+	rec.Status = res.StatusCode
+	defer p.log(rec)
+	if x.op == nil {
+		return nil
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		rec.violate("response", "cannot read the response: %v", err)
+		return nil
+	}
+	if strings.EqualFold(res.Header.Get("Content-Encoding"), "gzip") && len(body) > 0 {
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		if err == nil {
+			body, err = io.ReadAll(zr)
+		}
+		if err != nil {
+			rec.violate("response.body", "cannot decompress the response: %v", err)
+			return nil
+		}
+	}
+	out := p.declaredOutput(x.op, res.StatusCode)
+	if out == nil {
+		rec.violate("response.status", "%d is not a status of %s (expected %s)", res.StatusCode, rec.Operation, p.declaredStatuses(x.op))
+		return nil
+	}
+	output, err := model.ResponseOutput(p.Schema, out, res.Header, body)
+	if err != nil {
+		rec.violate("response", "%v", err)
+		return nil
+	}
+	for _, f := range out.Fields {
+		path := "response.body." + string(f.Name)
+		if f.HttpHeader != "" {
+			path = "response.header." + f.HttpHeader
+		} else if f.HttpPayload {
+			path = "response.body"
+		}
+		v, ok := output[string(f.Name)]
+		if !ok || v == nil {
+			if f.Required {
+				rec.violate(path, "missing required output %q", f.Name)
+			}
+			continue
+		}
+		rec.Violations = append(rec.Violations, p.Schema.ConformOutput(f, v, path)...)
+	}
+	return nil
+}
+
+// declaredOutput returns the output or exception of the operation with the status, or nil if it has none.
+func (p *Proxy) declaredOutput(op *model.OperationDef, status int) *model.OperationOutput {
+	if op.Output != nil && outputStatus(op.Output) == status {
+		return op.Output
+	}
+	if op.Output == nil && status == http.StatusOK {
+		return &model.OperationOutput{}
+	}
+	for _, eid := range op.Exceptions {
+		if edef := p.Schema.GetExceptionDef(eid); edef != nil && outputStatus(edef) == status {
+			return edef
+		}
+	}
+	return nil
+}
+
+func (p *Proxy) declaredStatuses(op *model.OperationDef) string {
+	statuses := []int{http.StatusOK}
+	if op.Output != nil {
+		statuses[0] = outputStatus(op.Output)
+	}
+	for _, eid := range op.Exceptions {
+		if edef := p.Schema.GetExceptionDef(eid); edef != nil {
+			statuses = append(statuses, outputStatus(edef))
+		}
+	}
+	sort.Ints(statuses)
+	var s []string
+	for i, status := range statuses {
+		if i == 0 || status != statuses[i-1] {
+			s = append(s, fmt.Sprint(status))
+		}
+	}
+	return strings.Join(s, ", ")
+}
+
+func outputStatus(out *model.OperationOutput) int {
+	if out.HttpStatus == 0 {
+		return http.StatusOK
+	}
+	return int(out.HttpStatus)
+}
+
+func (p *Proxy) log(rec *Record) {
+	if p.Log == nil || (len(rec.Violations) == 0 && !p.LogAll) {
+		return
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.Log.Write(append(b, '\n'))
+}
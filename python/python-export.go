@@ -0,0 +1,752 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package python
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/boynton/api/model"
+	"github.com/boynton/data"
+)
+
+const IndentAmount = "    "
+
+// Generator emits a single Python 3.10 module with a class for each struct, union, and enum of the model, and for a
+// service the operation outputs, an exception class for each exception, and two clients: one using urllib, and an
+// async one using httpx. Attributes are the snake_case form of the field names, from_dict and to_dict convert to and
+// from the JSON representation, with its field names, timestamps, blobs, and decimals.
+type Generator struct {
+	model.BaseGenerator
+	ns       model.Namespace
+	pydantic bool
+}
+
+func (gen *Generator) GenerateResource(rez *model.ResourceDef) error {
+	return nil
+}
+
+func (gen *Generator) GenerateOperation(op *model.OperationDef) error {
+	return nil
+}
+
+func (gen *Generator) GenerateException(op *model.OperationOutput) error {
+	return nil
+}
+
+func (gen *Generator) GenerateType(td *model.TypeDef) error {
+	return nil
+}
+
+func (gen *Generator) Generate(schema *model.Schema, config *data.Object) error {
+	err := gen.Configure(schema, config)
+	if err != nil {
+		return err
+	}
+	gen.ns = model.Namespace(config.GetString("namespace"))
+	if gen.ns == "" {
+		gen.ns = schema.ServiceNamespace()
+		if gen.ns == "" {
+			gen.ns = schema.Namespace
+		}
+	}
+	switch config.GetString("python.models") {
+	case "", "dataclass":
+		gen.pydantic = false
+	case "pydantic":
+		gen.pydantic = true
+	default:
+		return fmt.Errorf("python: unsupported models %q, expected dataclass or pydantic", config.GetString("python.models"))
+	}
+	hasService := len(schema.Operations) > 0
+
+	gen.Begin()
+	gen.Emit("# Generated\n")
+	if schema.Comment != "" {
+		gen.Emit("\n")
+		gen.Emitf("\"\"\"%s\"\"\"\n", docString(schema.Comment))
+	}
+	gen.Emit("\n")
+	gen.Emit("from __future__ import annotations\n\n")
+	imports := []string{"import base64"}
+	if hasService {
+		imports = append(imports, "import json", "import urllib.error", "import urllib.parse", "import urllib.request")
+	}
+	if !gen.pydantic {
+		imports = append(imports, "from dataclasses import dataclass")
+	}
+	imports = append(imports, "from datetime import datetime, timezone", "from decimal import Decimal", "from enum import Enum", "from typing import Any, Callable")
+	if gen.pydantic {
+		imports = append(imports, "\nfrom pydantic import BaseModel")
+	}
+	gen.Emit(strings.Join(imports, "\n") + "\n")
+	var models []string
+	for _, td := range gen.Types() {
+		switch td.Base {
+		case model.BaseType_Struct, model.BaseType_Union:
+			gen.Emit("\n\n")
+			gen.generateModel(td.Id, td.Comment, modelFields(td.Fields), td.Base == model.BaseType_Union)
+			models = append(models, model.StripNamespace(td.Id))
+		case model.BaseType_Enum:
+			gen.Emit("\n\n")
+			gen.generateEnum(td)
+		}
+	}
+	if hasService {
+		for _, op := range gen.Operations() {
+			if hasOutput(op) {
+				var fields []*modelField
+				for _, f := range op.Output.Fields {
+					fields = append(fields, &modelField{name: string(f.Name), tid: f.Type, required: f.Required, comment: f.Comment})
+				}
+				gen.Emit("\n\n")
+				gen.generateModel(op.Output.Id, op.Output.Comment, fields, false)
+				models = append(models, model.StripNamespace(op.Output.Id))
+			}
+		}
+		gen.Emit("\n\n")
+		gen.Emit(apiErrorSource)
+		for _, edef := range gen.Exceptions() {
+			gen.Emit("\n\n")
+			gen.generateException(edef)
+		}
+		for _, op := range gen.Operations() {
+			gen.Emit("\n\n")
+			gen.generateRequest(op)
+			gen.Emit("\n\n")
+			gen.generateResponse(op)
+		}
+		gen.Emit("\n\n")
+		gen.generateClient(false)
+		gen.Emit("\n\n")
+		gen.generateClient(true)
+		gen.Emit("\n\n")
+		gen.Emit(responseSource)
+	}
+	gen.Emit("\n\n")
+	gen.Emit(codecSource)
+	if gen.pydantic && len(models) > 0 {
+		//the annotations refer to classes defined later in the module
+		gen.Emit("\n\n")
+		for _, name := range models {
+			gen.Emitf("%s.model_rebuild()\n", name)
+		}
+	}
+	fname := strings.ReplaceAll(string(gen.ns), ".", "_") + ".py"
+	return gen.Write(gen.End(), fname, "")
+}
+
+// modelField is an attribute of a generated class.
+type modelField struct {
+	name     string
+	tid      model.AbsoluteIdentifier
+	required bool
+	comment  string
+}
+
+func modelFields(fields model.FieldDefList) []*modelField {
+	var result []*modelField
+	for _, f := range fields {
+		result = append(result, &modelField{name: string(f.Name), tid: f.Type, required: f.Required, comment: f.Comment})
+	}
+	return result
+}
+
+func docString(comment string) string {
+	comment = strings.TrimSpace(comment)
+	comment = strings.ReplaceAll(comment, "\\", "\\\\")
+	return strings.ReplaceAll(comment, "\"\"\"", "\\\"\\\"\\\"")
+}
+
+func (gen *Generator) emitDocString(indent, comment string) {
+	if strings.TrimSpace(comment) == "" {
+		return
+	}
+	comment = docString(comment)
+	if !strings.Contains(comment, "\n") && len(indent)+len(comment) < 100 {
+		gen.Emitf("%s\"\"\"%s\"\"\"\n", indent, comment)
+		return
+	}
+	gen.Emitf("%s\"\"\"\n", indent)
+	gen.Emit(model.FormatComment(indent, "", comment, 100, false))
+	gen.Emitf("%s\"\"\"\n", indent)
+}
+
+func (gen *Generator) emitComment(indent, comment string) {
+	if strings.TrimSpace(comment) == "" {
+		return
+	}
+	gen.Emit(model.FormatComment(indent, "# ", strings.TrimSpace(comment), 100, false))
+}
+
+var reservedNames = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true, "async": true, "await": true,
+	"break": true, "class": true, "continue": true, "def": true, "del": true, "elif": true, "else": true,
+	"except": true, "finally": true, "for": true, "from": true, "global": true, "if": true, "import": true, "in": true,
+	"is": true, "lambda": true, "nonlocal": true, "not": true, "or": true, "pass": true, "raise": true,
+	"return": true, "try": true, "while": true, "with": true, "yield": true, "self": true,
+	//methods of the generated classes, and of pydantic's BaseModel
+	"from_dict": true, "to_dict": true, "dict": true, "json": true, "copy": true, "schema": true, "validate": true,
+	"construct": true, "fields": true,
+}
+
+// snakeCase returns the Python form of a name, i.e. "itemId" becomes "item_id", and "HTTPStatus" "http_status".
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteRune('_')
+			}
+		}
+		if r == '-' || r == '.' || r == ' ' {
+			r = '_'
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// pythonName returns the attribute or parameter name for a field.
+func pythonName(name string) string {
+	s := snakeCase(name)
+	if reservedNames[s] {
+		return s + "_"
+	}
+	return s
+}
+
+// TypeHint returns the type annotation for the given type. Named primitive types, lists, and maps have no class of
+// their own, they are referred to as the Python type they are represented with.
+func (gen *Generator) TypeHint(id model.AbsoluteIdentifier) string {
+	switch id {
+	case "base#Bool":
+		return "bool"
+	case "base#Int8", "base#Int16", "base#Int32", "base#Int64", "base#Integer":
+		return "int"
+	case "base#Float32", "base#Float64":
+		return "float"
+	case "base#Decimal":
+		return "Decimal"
+	case "base#String":
+		return "str"
+	case "base#Timestamp":
+		return "datetime"
+	case "base#Bytes", "base#Blob":
+		return "bytes"
+	case "base#Any", "base#Struct":
+		return "Any"
+	case "base#List":
+		return "list[Any]"
+	case "base#Map":
+		return "dict[str, Any]"
+	}
+	td := gen.Schema.GetTypeDef(id)
+	if td == nil {
+		return model.StripNamespace(id)
+	}
+	switch td.Base {
+	case model.BaseType_Struct, model.BaseType_Union, model.BaseType_Enum:
+		return model.StripNamespace(id)
+	case model.BaseType_List:
+		return "list[" + gen.TypeHint(td.Items) + "]"
+	case model.BaseType_Map:
+		//enum keys are kept as the strings they are in JSON
+		return "dict[str, " + gen.TypeHint(td.Items) + "]"
+	}
+	return gen.TypeHint(model.AbsoluteIdentifier("base#" + td.Base.String()))
+}
+
+// decoder returns the function converting the JSON representation of a value of the given type to its Python
+// representation, or "" if they are the same.
+func (gen *Generator) decoder(id model.AbsoluteIdentifier) string {
+	if id == "base#Bytes" {
+		return "base64.b64decode"
+	}
+	td := gen.Schema.GetTypeDef(id)
+	switch gen.Schema.BaseType(id) {
+	case model.BaseType_Struct, model.BaseType_Union:
+		if td != nil {
+			return model.StripNamespace(id) + ".from_dict"
+		}
+	case model.BaseType_Enum:
+		if td != nil {
+			return model.StripNamespace(id)
+		}
+	case model.BaseType_Timestamp:
+		return "_parse_timestamp"
+	case model.BaseType_Blob:
+		return "base64.b64decode"
+	case model.BaseType_Decimal:
+		return "_parse_decimal"
+	case model.BaseType_List:
+		if td != nil {
+			if item := gen.decoder(td.Items); item != "" {
+				return "_list_of(" + item + ")"
+			}
+		}
+	case model.BaseType_Map:
+		if td != nil {
+			if item := gen.decoder(td.Items); item != "" {
+				return "_dict_of(" + item + ")"
+			}
+		}
+	}
+	return ""
+}
+
+// paramDecoder returns the function converting a header value of the given type to its Python representation.
+func (gen *Generator) paramDecoder(id model.AbsoluteIdentifier) string {
+	switch gen.Schema.BaseType(id) {
+	case model.BaseType_Bool:
+		return "_parse_bool"
+	case model.BaseType_Int8, model.BaseType_Int16, model.BaseType_Int32, model.BaseType_Int64, model.BaseType_Integer:
+		return "int"
+	case model.BaseType_Float32, model.BaseType_Float64:
+		return "float"
+	}
+	return gen.decoder(id)
+}
+
+func decode(expr, decoder string) string {
+	if decoder == "" {
+		return expr
+	}
+	return fmt.Sprintf("_decode(%s, %s)", expr, decoder)
+}
+
+// generateModel emits a class with the given fields. The fields of a union are all optional, exactly one of them is
+// set in a valid value.
+func (gen *Generator) generateModel(id model.AbsoluteIdentifier, comment string, fields []*modelField, union bool) {
+	name := model.StripNamespace(id)
+	i1 := IndentAmount
+	i2 := i1 + IndentAmount
+	i3 := i2 + IndentAmount
+	if gen.pydantic {
+		gen.Emitf("class %s(BaseModel):\n", name)
+	} else {
+		gen.Emit("@dataclass(kw_only=True)\n")
+		gen.Emitf("class %s:\n", name)
+	}
+	gen.emitDocString(i1, comment)
+	if comment != "" && len(fields) > 0 {
+		gen.Emit("\n")
+	}
+	for _, f := range fields {
+		gen.emitComment(i1, f.comment)
+		if f.required && !union {
+			gen.Emitf("%s%s: %s\n", i1, pythonName(f.name), gen.TypeHint(f.tid))
+		} else {
+			gen.Emitf("%s%s: %s | None = None\n", i1, pythonName(f.name), gen.TypeHint(f.tid))
+		}
+	}
+	if comment != "" || len(fields) > 0 {
+		gen.Emit("\n")
+	}
+	gen.Emitf("%s@classmethod\n", i1)
+	gen.Emitf("%sdef from_dict(cls, d: dict[str, Any]) -> %s:\n", i1, name)
+	if len(fields) == 0 {
+		gen.Emitf("%sreturn cls()\n", i2)
+	} else {
+		gen.Emitf("%sreturn cls(\n", i2)
+		for _, f := range fields {
+			gen.Emitf("%s%s=%s,\n", i3, pythonName(f.name), decode(fmt.Sprintf("d.get(%q)", f.name), gen.decoder(f.tid)))
+		}
+		gen.Emitf("%s)\n", i2)
+	}
+	gen.Emit("\n")
+	gen.Emitf("%sdef to_dict(self) -> dict[str, Any]:\n", i1)
+	gen.Emitf("%sd: dict[str, Any] = {}\n", i2)
+	for _, f := range fields {
+		pname := pythonName(f.name)
+		gen.Emitf("%sif self.%s is not None:\n", i2, pname)
+		gen.Emitf("%sd[%q] = _encode(self.%s)\n", i3, f.name, pname)
+	}
+	gen.Emitf("%sreturn d\n", i2)
+}
+
+// generateEnum emits an enum whose values are the JSON of each element, or its symbol if it has none.
+func (gen *Generator) generateEnum(td *model.TypeDef) {
+	gen.Emitf("class %s(str, Enum):\n", model.StripNamespace(td.Id))
+	gen.emitDocString(IndentAmount, td.Comment)
+	if td.Comment != "" {
+		gen.Emit("\n")
+	}
+	for _, el := range td.Elements {
+		value := el.Value
+		if value == "" {
+			value = string(el.Symbol)
+		}
+		gen.emitComment(IndentAmount, el.Comment)
+		symbol := string(el.Symbol)
+		if reservedNames[symbol] {
+			symbol += "_"
+		}
+		gen.Emitf("%s%s = %q\n", IndentAmount, symbol, value)
+	}
+	if len(td.Elements) == 0 {
+		gen.Emitf("%spass\n", IndentAmount)
+	}
+}
+
+func hasOutput(op *model.OperationDef) bool {
+	return op.Output != nil && len(op.Output.Fields) > 0
+}
+
+// outputValue returns the expression decoding an output or exception field from the response.
+func (gen *Generator) outputValue(f *model.OperationOutputField) string {
+	if f.HttpPayload {
+		return decode("response.json()", gen.decoder(f.Type))
+	}
+	if f.HttpHeader != "" {
+		return decode(fmt.Sprintf("response.header(%q)", f.HttpHeader), gen.paramDecoder(f.Type))
+	}
+	return decode(fmt.Sprintf("response.member(%q)", f.Name), gen.decoder(f.Type))
+}
+
+func (gen *Generator) generateException(edef *model.OperationOutput) {
+	i1 := IndentAmount
+	i2 := i1 + IndentAmount
+	gen.Emitf("class %s(ApiError):\n", model.StripNamespace(edef.Id))
+	gen.emitDocString(i1, edef.Comment)
+	if edef.Comment != "" {
+		gen.Emit("\n")
+	}
+	gen.Emitf("%sdef __init__(self, response: _Response):\n", i1)
+	gen.Emitf("%ssuper().__init__(response)\n", i2)
+	for _, f := range edef.Fields {
+		gen.emitComment(i2, f.Comment)
+		gen.Emitf("%sself.%s: %s | None = %s\n", i2, pythonName(string(f.Name)), gen.TypeHint(f.Type), gen.outputValue(f))
+	}
+}
+
+func operationName(op *model.OperationDef) string {
+	return pythonName(model.StripNamespace(op.Id))
+}
+
+// inputParams returns the keyword-only parameters of the methods for an operation, and the arguments passing them on.
+func (gen *Generator) inputParams(op *model.OperationDef) ([]string, []string) {
+	var params []string
+	var args []string
+	if op.Input == nil {
+		return params, args
+	}
+	for _, f := range op.Input.Fields {
+		pname := pythonName(string(f.Name))
+		if f.Required {
+			params = append(params, fmt.Sprintf("%s: %s", pname, gen.TypeHint(f.Type)))
+		} else {
+			params = append(params, fmt.Sprintf("%s: %s | None = None", pname, gen.TypeHint(f.Type)))
+		}
+		args = append(args, pname)
+	}
+	return params, args
+}
+
+// generateRequest emits the function building the request of an operation, shared by both clients.
+func (gen *Generator) generateRequest(op *model.OperationDef) {
+	i1 := IndentAmount
+	i2 := i1 + IndentAmount
+	i3 := i2 + IndentAmount
+	var params []string
+	if op.Input != nil {
+		for _, f := range op.Input.Fields {
+			params = append(params, fmt.Sprintf("%s: %s | None", pythonName(string(f.Name)), gen.TypeHint(f.Type)))
+		}
+	}
+	gen.Emitf("def _%s_request(%s) -> _Request:\n", operationName(op), strings.Join(params, ", "))
+	gen.Emitf("%s_query: list[tuple[str, str]] = []\n", i1)
+	gen.Emitf("%s_headers: dict[str, str] = {}\n", i1)
+	binding := model.HttpInputBinding(op)
+	for _, f := range binding.Query {
+		pname := pythonName(string(f.Name))
+		gen.Emitf("%sif %s is not None:\n", i1, pname)
+		if gen.Schema.BaseType(f.Type) == model.BaseType_List {
+			gen.Emitf("%sfor _value in %s:\n", i2, pname)
+			gen.Emitf("%s_query.append((%q, _param(_value)))\n", i3, f.HttpQuery)
+		} else {
+			gen.Emitf("%s_query.append((%q, _param(%s)))\n", i2, f.HttpQuery, pname)
+		}
+	}
+	for _, f := range binding.Headers {
+		pname := pythonName(string(f.Name))
+		gen.Emitf("%sif %s is not None:\n", i1, pname)
+		gen.Emitf("%s_headers[%q] = _param(%s)\n", i2, f.HttpHeader, pname)
+	}
+	body := "None"
+	if binding.Payload != nil {
+		body = fmt.Sprintf("_encode(%s)", pythonName(string(binding.Payload.Name)))
+	} else if len(binding.Members) > 0 {
+		body = "_body"
+		gen.Emitf("%s_body: dict[str, Any] = {}\n", i1)
+		for _, f := range binding.Members {
+			pname := pythonName(string(f.Name))
+			gen.Emitf("%sif %s is not None:\n", i1, pname)
+			gen.Emitf("%s_body[%q] = _encode(%s)\n", i2, f.Name, pname)
+		}
+	}
+	gen.Emitf("%sreturn _Request(%q, %s, _query, _headers, %s)\n", i1, op.HttpMethod, gen.pathExpression(binding), body)
+}
+
+// generateResponse emits the function decoding the response of an operation, shared by both clients.
+func (gen *Generator) generateResponse(op *model.OperationDef) {
+	i1 := IndentAmount
+	i2 := i1 + IndentAmount
+	result := "None"
+	if hasOutput(op) {
+		result = model.StripNamespace(op.Output.Id)
+	}
+	gen.Emitf("def _%s_response(response: _Response) -> %s:\n", operationName(op), result)
+	var exceptions []string
+	statuses := make(map[int32]bool, 0)
+	for _, eid := range op.Exceptions {
+		edef := gen.Schema.GetExceptionDef(eid)
+		if edef == nil || statuses[edef.HttpStatus] {
+			continue
+		}
+		statuses[edef.HttpStatus] = true
+		exceptions = append(exceptions, fmt.Sprintf("%d: %s", edef.HttpStatus, model.StripNamespace(eid)))
+	}
+	gen.Emitf("%sif response.status // 100 != 2:\n", i1)
+	gen.Emitf("%sraise _error(response, {%s})\n", i2, strings.Join(exceptions, ", "))
+	if hasOutput(op) {
+		gen.Emitf("%sreturn %s(\n", i1, result)
+		for _, f := range op.Output.Fields {
+			gen.Emitf("%s%s=%s,\n", i2, pythonName(string(f.Name)), gen.outputValue(f))
+		}
+		gen.Emitf("%s)\n", i1)
+	} else {
+		gen.Emitf("%sreturn None\n", i1)
+	}
+}
+
+// pathExpression returns a Python expression for the operation's URI, substituting its path fields.
+func (gen *Generator) pathExpression(binding *model.InputBinding) string {
+	var parts []string
+	for _, seg := range binding.Path {
+		if seg.Label == "" {
+			parts = append(parts, fmt.Sprintf("%q", seg.Literal))
+		} else if seg.Greedy {
+			parts = append(parts, fmt.Sprintf("urllib.parse.quote(_param(%s), safe=\"/\")", pythonName(seg.Label)))
+		} else {
+			parts = append(parts, fmt.Sprintf("urllib.parse.quote(_param(%s), safe=\"\")", pythonName(seg.Label)))
+		}
+	}
+	return strings.Join(parts, " + ")
+}
+
+func (gen *Generator) generateClient(async bool) {
+	serviceName := string(gen.Schema.ServiceName())
+	if serviceName == "" {
+		serviceName = model.Capitalize(strings.ReplaceAll(string(gen.ns), ".", "_"))
+	}
+	i1 := IndentAmount
+	i2 := i1 + IndentAmount
+	if async {
+		gen.Emit(strings.ReplaceAll(asyncClientSource, "CLIENT", "Async"+serviceName+"Client"))
+	} else {
+		gen.Emit(strings.ReplaceAll(clientSource, "CLIENT", serviceName+"Client"))
+	}
+	for _, op := range gen.Operations() {
+		params, args := gen.inputParams(op)
+		result := "None"
+		if hasOutput(op) {
+			result = model.StripNamespace(op.Output.Id)
+		}
+		sig := "self"
+		if len(params) > 0 {
+			sig = "self, *, " + strings.Join(params, ", ")
+		}
+		gen.Emit("\n")
+		if async {
+			gen.Emitf("%sasync def %s(%s) -> %s:\n", i1, operationName(op), sig, result)
+		} else {
+			gen.Emitf("%sdef %s(%s) -> %s:\n", i1, operationName(op), sig, result)
+		}
+		gen.emitDocString(i2, op.Comment)
+		send := fmt.Sprintf("self._send(_%s_request(%s))", operationName(op), strings.Join(args, ", "))
+		if async {
+			send = "await " + send
+		}
+		gen.Emitf("%sreturn _%s_response(%s)\n", i2, operationName(op), send)
+	}
+}
+
+var apiErrorSource = `class ApiError(Exception):
+    """The base of all errors raised by the clients. The body is the parsed JSON of the response, if any."""
+
+    def __init__(self, response: _Response):
+        super().__init__(f"{type(self).__name__} (HTTP status {response.status})")
+        self.status = response.status
+        self.headers = response.headers
+        self.body = response.json()
+`
+
+var clientSource = `class CLIENT:
+    """A client using urllib. The headers are sent with every request, i.e. Authorization."""
+
+    def __init__(self, base_url: str, headers: dict[str, str] | None = None, timeout: float | None = None):
+        self.base_url = base_url.rstrip("/")
+        self.headers = dict(headers or {})
+        self.timeout = timeout
+
+    def _send(self, request: _Request) -> _Response:
+        url, headers, data = request.prepare(self.base_url, self.headers)
+        r = urllib.request.Request(url, data=data, headers=headers, method=request.method)
+        kwargs: dict[str, Any] = {} if self.timeout is None else {"timeout": self.timeout}
+        try:
+            with urllib.request.urlopen(r, **kwargs) as response:
+                return _Response(response.status, response.headers, response.read())
+        except urllib.error.HTTPError as e:
+            return _Response(e.code, e.headers, e.read())
+`
+
+var asyncClientSource = `class CLIENT:
+    """
+    An async client using httpx, which must be installed. The headers are sent with every request, i.e.
+    Authorization. It should be closed with aclose, or used as an async context manager.
+    """
+
+    def __init__(self, base_url: str, headers: dict[str, str] | None = None, timeout: float | None = None, client: Any = None):
+        import httpx
+
+        self.base_url = base_url.rstrip("/")
+        self.headers = dict(headers or {})
+        self.client = client if client is not None else httpx.AsyncClient(timeout=timeout)
+
+    async def aclose(self) -> None:
+        await self.client.aclose()
+
+    async def __aenter__(self) -> CLIENT:
+        return self
+
+    async def __aexit__(self, *args: Any) -> None:
+        await self.aclose()
+
+    async def _send(self, request: _Request) -> _Response:
+        url, headers, data = request.prepare(self.base_url, self.headers)
+        response = await self.client.request(request.method, url, headers=headers, content=data)
+        return _Response(response.status_code, response.headers, response.content)
+`
+
+var responseSource = `class _Request:
+    def __init__(self, method: str, path: str, query: list[tuple[str, str]], headers: dict[str, str], body: Any):
+        self.method = method
+        self.path = path
+        self.query = query
+        self.headers = headers
+        self.body = body
+
+    def prepare(self, base_url: str, headers: dict[str, str]) -> tuple[str, dict[str, str], bytes | None]:
+        url = base_url + self.path
+        if self.query:
+            url += "?" + urllib.parse.urlencode(self.query, quote_via=urllib.parse.quote)
+        h = {"Accept": "application/json", **headers, **self.headers}
+        data = None
+        if self.body is not None:
+            h["Content-Type"] = "application/json"
+            data = json.dumps(self.body).encode("utf-8")
+        return url, h, data
+
+
+class _Response:
+    def __init__(self, status: int, headers: Any, data: bytes):
+        self.status = status
+        self.headers = headers
+        self.data = data
+
+    def json(self) -> Any:
+        if not self.data:
+            return None
+        try:
+            return json.loads(self.data)
+        except ValueError:
+            return self.data.decode("utf-8", "replace")
+
+    def member(self, name: str) -> Any:
+        body = self.json()
+        return body.get(name) if isinstance(body, dict) else None
+
+    def header(self, name: str) -> str | None:
+        return self.headers.get(name)
+
+
+def _error(response: _Response, exceptions: dict[int, type]) -> ApiError:
+    return exceptions.get(response.status, ApiError)(response)
+
+
+def _param(value: Any) -> str:
+    if isinstance(value, bool):
+        return "true" if value else "false"
+    if isinstance(value, Enum):
+        return value.value
+    if isinstance(value, datetime):
+        return _format_timestamp(value)
+    return str(value)
+
+
+def _parse_bool(value: str) -> bool:
+    return value.lower() == "true"
+`
+
+var codecSource = `def _encode(value: Any) -> Any:
+    """Returns the JSON representation of a value."""
+    if value is None or isinstance(value, (bool, int, float, str)):
+        return value.value if isinstance(value, Enum) else value
+    if hasattr(value, "to_dict"):
+        return value.to_dict()
+    if isinstance(value, datetime):
+        return _format_timestamp(value)
+    if isinstance(value, Decimal):
+        return int(value) if value == value.to_integral_value() else float(value)
+    if isinstance(value, (bytes, bytearray)):
+        return base64.b64encode(value).decode("ascii")
+    if isinstance(value, dict):
+        return {str(_encode(k)): _encode(v) for k, v in value.items()}
+    if isinstance(value, (list, tuple)):
+        return [_encode(v) for v in value]
+    return value
+
+
+def _decode(value: Any, decoder: Callable[[Any], Any]) -> Any:
+    return None if value is None else decoder(value)
+
+
+def _list_of(decoder: Callable[[Any], Any]) -> Callable[[Any], Any]:
+    return lambda values: [_decode(v, decoder) for v in values]
+
+
+def _dict_of(decoder: Callable[[Any], Any]) -> Callable[[Any], Any]:
+    return lambda values: {k: _decode(v, decoder) for k, v in values.items()}
+
+
+def _parse_decimal(value: Any) -> Decimal:
+    return Decimal(str(value))
+
+
+def _parse_timestamp(value: str) -> datetime:
+    return datetime.fromisoformat(value.replace("Z", "+00:00"))
+
+
+def _format_timestamp(value: datetime) -> str:
+    if value.tzinfo is None:
+        value = value.replace(tzinfo=timezone.utc)
+    return value.astimezone(timezone.utc).isoformat().replace("+00:00", "Z")
+`
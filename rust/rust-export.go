@@ -0,0 +1,836 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package rust
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/boynton/api/model"
+	"github.com/boynton/data"
+)
+
+const IndentAmount = "    "
+
+// Generator emits a single Rust module with serde types for the model and, for a service, an ApiError enum and an
+// async client using reqwest. The crates used for timestamps, decimals and big integers are configurable.
+type Generator struct {
+	model.BaseGenerator
+	ns        model.Namespace
+	timestamp string
+	decimal   string
+	integer   string
+	used      map[string]bool
+}
+
+// the Rust types for the supported crates of each configurable base type
+var timestampTypes = map[string]string{"chrono": "chrono::DateTime<chrono::Utc>", "string": "String"}
+var decimalTypes = map[string]string{"rust_decimal": "rust_decimal::Decimal", "bigdecimal": "bigdecimal::BigDecimal", "f64": "f64", "string": "String"}
+var integerTypes = map[string]string{"num-bigint": "num_bigint::BigInt", "i128": "i128", "string": "String"}
+
+func (gen *Generator) GenerateResource(rez *model.ResourceDef) error {
+	return nil
+}
+
+func (gen *Generator) GenerateOperation(op *model.OperationDef) error {
+	return nil
+}
+
+func (gen *Generator) GenerateException(op *model.OperationOutput) error {
+	return nil
+}
+
+func (gen *Generator) GenerateType(td *model.TypeDef) error {
+	return nil
+}
+
+func configured(config *data.Object, key string, dflt string, choices map[string]string) (string, error) {
+	name := config.GetString(key)
+	if name == "" {
+		name = dflt
+	}
+	if _, ok := choices[name]; !ok {
+		var names []string
+		for n := range choices {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("rust: unsupported %s %q, expected one of %s", key, name, strings.Join(names, ", "))
+	}
+	return name, nil
+}
+
+func (gen *Generator) Generate(schema *model.Schema, config *data.Object) error {
+	err := gen.Configure(schema, config)
+	if err != nil {
+		return err
+	}
+	gen.ns = model.Namespace(config.GetString("namespace"))
+	if gen.ns == "" {
+		gen.ns = schema.ServiceNamespace()
+		if gen.ns == "" {
+			gen.ns = schema.Namespace
+		}
+	}
+	if gen.timestamp, err = configured(config, "rust.timestamp", "chrono", timestampTypes); err != nil {
+		return err
+	}
+	if gen.decimal, err = configured(config, "rust.decimal", "rust_decimal", decimalTypes); err != nil {
+		return err
+	}
+	if gen.integer, err = configured(config, "rust.integer", "num-bigint", integerTypes); err != nil {
+		return err
+	}
+	gen.used = make(map[string]bool, 0)
+	hasService := len(schema.Operations) > 0
+
+	//the body is rendered first, the header lists the crates it turned out to use
+	gen.Begin()
+	for _, td := range gen.Types() {
+		gen.Emit("\n")
+		gen.generateTypeDef(td)
+	}
+	if hasService {
+		for _, op := range gen.Operations() {
+			gen.generateOperationTypes(op)
+		}
+		for _, edef := range gen.Exceptions() {
+			gen.Emit("\n")
+			gen.generateException(edef)
+		}
+		gen.Emit("\n")
+		gen.generateApiError()
+		gen.Emit("\n")
+		gen.generateClient()
+		gen.Emit("\n")
+		gen.Emit(clientUtilSource)
+	}
+	if gen.used["base64"] {
+		gen.Emit("\n")
+		gen.Emit(bytesSource)
+	}
+	body := gen.End()
+
+	gen.Begin()
+	gen.Emit("// Generated\n")
+	if schema.Comment != "" {
+		gen.Emit("//\n")
+		gen.Emit(model.FormatComment("", "// ", strings.TrimSpace(schema.Comment), 100, false))
+	}
+	gen.Emit("//\n// Dependencies:\n")
+	for _, dep := range gen.dependencies(hasService) {
+		gen.Emitf("//   %s\n", dep)
+	}
+	gen.Emit("\n")
+	gen.Emit("#![allow(dead_code, clippy::large_enum_variant)]\n\n")
+	gen.Emit("use serde::{Deserialize, Serialize};\n")
+	if gen.used["HashMap"] {
+		gen.Emit("use std::collections::HashMap;\n")
+	}
+	if hasService || gen.used["fmt"] {
+		gen.Emit("use std::fmt;\n")
+	}
+	gen.Emit(body)
+	fname := strings.ReplaceAll(string(gen.ns), ".", "_") + ".rs"
+	return gen.Write(gen.End(), fname, "")
+}
+
+// dependencies returns the Cargo.toml lines for the crates used by the generated code.
+func (gen *Generator) dependencies(hasService bool) []string {
+	deps := []string{`serde = { version = "1", features = ["derive"] }`}
+	if hasService || gen.used["serde_json"] {
+		deps = append(deps, `serde_json = "1"`)
+	}
+	if gen.used["chrono"] {
+		deps = append(deps, `chrono = { version = "0.4", features = ["serde"] }`)
+	}
+	if gen.used["rust_decimal"] {
+		deps = append(deps, `rust_decimal = { version = "1", features = ["serde-with-arbitrary-precision"] }`)
+	}
+	if gen.used["bigdecimal"] {
+		deps = append(deps, `bigdecimal = { version = "0.4", features = ["serde-json"] }`)
+	}
+	if gen.used["num-bigint"] {
+		deps = append(deps, `num-bigint = { version = "0.4", features = ["serde"] }`)
+	}
+	if gen.used["base64"] {
+		deps = append(deps, `base64 = "0.22"`)
+	}
+	if hasService {
+		deps = append(deps, `reqwest = { version = "0.12", features = ["json"] }`)
+	}
+	return deps
+}
+
+func (gen *Generator) emitComment(indent, comment string) {
+	comment = strings.TrimSpace(comment)
+	if comment == "" {
+		return
+	}
+	gen.Emit(model.FormatComment(indent, "/// ", comment, 100, false))
+}
+
+var reservedNames = map[string]bool{
+	"as": true, "break": true, "const": true, "continue": true, "crate": true, "else": true, "enum": true,
+	"extern": true, "false": true, "fn": true, "for": true, "if": true, "impl": true, "in": true, "let": true,
+	"loop": true, "match": true, "mod": true, "move": true, "mut": true, "pub": true, "ref": true, "return": true,
+	"self": true, "Self": true, "static": true, "struct": true, "super": true, "trait": true, "true": true,
+	"type": true, "unsafe": true, "use": true, "where": true, "while": true, "async": true, "await": true,
+	"dyn": true, "abstract": true, "become": true, "box": true, "do": true, "final": true, "macro": true,
+	"override": true, "priv": true, "typeof": true, "unsized": true, "virtual": true, "yield": true, "try": true,
+}
+
+// snakeCase returns the Rust form of a field name, i.e. "itemId" becomes "item_id", and "HTTPStatus" "http_status".
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteRune('_')
+			}
+		}
+		if r == '-' || r == '.' || r == ' ' {
+			r = '_'
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// pascalCase returns the Rust form of an enum variant name, i.e. "IN_PROGRESS" and "inProgress" become "InProgress".
+func pascalCase(name string) string {
+	var b strings.Builder
+	for _, word := range strings.Split(snakeCase(name), "_") {
+		b.WriteString(model.Capitalize(word))
+	}
+	return b.String()
+}
+
+// fieldName returns the Rust identifier for a field. Keywords are used as raw identifiers where allowed.
+func fieldName(name string) string {
+	s := snakeCase(name)
+	switch s {
+	case "self", "Self", "super", "crate":
+		return s + "_"
+	}
+	if reservedNames[s] {
+		return "r#" + s
+	}
+	return s
+}
+
+// serdeName returns the name serde uses for a Rust identifier.
+func serdeName(ident string) string {
+	return strings.TrimPrefix(ident, "r#")
+}
+
+// TypeRef returns the Rust type used to refer to the given type.
+func (gen *Generator) TypeRef(id model.AbsoluteIdentifier) string {
+	switch id {
+	case "base#Bool":
+		return "bool"
+	case "base#Int8":
+		return "i8"
+	case "base#Int16":
+		return "i16"
+	case "base#Int32":
+		return "i32"
+	case "base#Int64":
+		return "i64"
+	case "base#Float32":
+		return "f32"
+	case "base#Float64":
+		return "f64"
+	case "base#Integer":
+		gen.used[gen.integer] = true
+		return integerTypes[gen.integer]
+	case "base#Decimal":
+		gen.used[gen.decimal] = true
+		return decimalTypes[gen.decimal]
+	case "base#String":
+		return "String"
+	case "base#Timestamp":
+		gen.used[gen.timestamp] = true
+		return timestampTypes[gen.timestamp]
+	case "base#Bytes", "base#Blob":
+		gen.used["base64"] = true
+		return "Bytes"
+	case "base#Any", "base#Struct":
+		gen.used["serde_json"] = true
+		return "serde_json::Value"
+	case "base#List":
+		gen.used["serde_json"] = true
+		return "Vec<serde_json::Value>"
+	case "base#Map":
+		gen.used["serde_json"] = true
+		gen.used["HashMap"] = true
+		return "HashMap<String, serde_json::Value>"
+	}
+	td := gen.Schema.GetTypeDef(id)
+	if td != nil {
+		//the aliases and the types they refer to are marked as used as they are declared
+		gen.TypeDecl(td)
+	}
+	return model.StripNamespace(id)
+}
+
+// TypeDecl returns the Rust type a type alias is declared as, for the types that are not structs, unions or enums.
+func (gen *Generator) TypeDecl(td *model.TypeDef) string {
+	switch td.Base {
+	case model.BaseType_Struct, model.BaseType_Union, model.BaseType_Enum:
+		return ""
+	case model.BaseType_List:
+		return "Vec<" + gen.TypeRef(td.Items) + ">"
+	case model.BaseType_Map:
+		gen.used["HashMap"] = true
+		if gen.Schema.BaseType(td.Keys) == model.BaseType_Enum {
+			return "HashMap<" + gen.TypeRef(td.Keys) + ", " + gen.TypeRef(td.Items) + ">"
+		}
+		return "HashMap<String, " + gen.TypeRef(td.Items) + ">"
+	}
+	return gen.TypeRef(model.AbsoluteIdentifier("base#" + td.Base.String()))
+}
+
+// reaches returns true if a value of the type from contains a value of type to directly, not through a list or a
+// map. A field of a struct that reaches the struct itself must be boxed.
+func (gen *Generator) reaches(from, to model.AbsoluteIdentifier, seen map[model.AbsoluteIdentifier]bool) bool {
+	if from == to {
+		return true
+	}
+	if seen[from] {
+		return false
+	}
+	seen[from] = true
+	td := gen.Schema.GetTypeDef(from)
+	if td == nil || (td.Base != model.BaseType_Struct && td.Base != model.BaseType_Union) {
+		return false
+	}
+	for _, f := range td.Fields {
+		if gen.reaches(f.Type, to, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+func (gen *Generator) fieldType(container, tid model.AbsoluteIdentifier) string {
+	tref := gen.TypeRef(tid)
+	if container != "" && gen.reaches(tid, container, make(map[model.AbsoluteIdentifier]bool, 0)) {
+		return "Box<" + tref + ">"
+	}
+	return tref
+}
+
+func (gen *Generator) generateTypeDef(td *model.TypeDef) {
+	name := model.StripNamespace(td.Id)
+	gen.emitComment("", td.Comment)
+	switch td.Base {
+	case model.BaseType_Struct:
+		gen.Emit("#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]\n")
+		gen.Emitf("pub struct %s {\n", name)
+		for _, f := range td.Fields {
+			gen.emitField(td.Id, string(f.Name), f.Type, f.Required, f.Comment)
+		}
+		gen.Emit("}\n")
+	case model.BaseType_Union:
+		//serde's externally tagged representation is the JSON of a union: an object with exactly one property
+		gen.Emit("#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]\n")
+		gen.Emitf("pub enum %s {\n", name)
+		for _, f := range td.Fields {
+			gen.emitComment(IndentAmount, f.Comment)
+			gen.Emitf("%s#[serde(rename = %q)]\n", IndentAmount, f.Name)
+			gen.Emitf("%s%s(%s),\n", IndentAmount, pascalCase(string(f.Name)), gen.fieldType(td.Id, f.Type))
+		}
+		gen.Emit("}\n")
+	case model.BaseType_Enum:
+		gen.generateEnum(td)
+	default:
+		gen.Emitf("pub type %s = %s;\n", name, gen.TypeDecl(td))
+	}
+}
+
+func (gen *Generator) emitField(container model.AbsoluteIdentifier, name string, tid model.AbsoluteIdentifier, required bool, comment string) {
+	gen.emitComment(IndentAmount, comment)
+	ident := fieldName(name)
+	tref := gen.fieldType(container, tid)
+	var attrs []string
+	if serdeName(ident) != name {
+		attrs = append(attrs, fmt.Sprintf("rename = %q", name))
+	}
+	if !required {
+		attrs = append(attrs, `default, skip_serializing_if = "Option::is_none"`)
+		tref = "Option<" + tref + ">"
+	}
+	if len(attrs) > 0 {
+		gen.Emitf("%s#[serde(%s)]\n", IndentAmount, strings.Join(attrs, ", "))
+	}
+	gen.Emitf("%spub %s: %s,\n", IndentAmount, ident, tref)
+}
+
+// generateEnum emits an enum whose JSON is the value of each element, or its symbol if it has none. Display and
+// FromStr use the same strings, for path, query, and header parameters.
+func (gen *Generator) generateEnum(td *model.TypeDef) {
+	gen.used["fmt"] = true
+	name := model.StripNamespace(td.Id)
+	i1 := IndentAmount
+	i2 := i1 + IndentAmount
+	i3 := i2 + IndentAmount
+	gen.Emit("#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash, PartialOrd, Ord, Serialize, Deserialize)]\n")
+	gen.Emitf("pub enum %s {\n", name)
+	var variants, values []string
+	for _, el := range td.Elements {
+		value := el.Value
+		if value == "" {
+			value = string(el.Symbol)
+		}
+		variants = append(variants, pascalCase(string(el.Symbol)))
+		values = append(values, value)
+		gen.emitComment(i1, el.Comment)
+		gen.Emitf("%s#[serde(rename = %q)]\n", i1, value)
+		gen.Emitf("%s%s,\n", i1, pascalCase(string(el.Symbol)))
+	}
+	gen.Emit("}\n\n")
+	gen.Emitf("impl %s {\n", name)
+	gen.Emitf("%spub fn as_str(&self) -> &'static str {\n", i1)
+	gen.Emitf("%smatch self {\n", i2)
+	for i, v := range variants {
+		gen.Emitf("%s%s::%s => %q,\n", i3, name, v, values[i])
+	}
+	gen.Emitf("%s}\n", i2)
+	gen.Emitf("%s}\n", i1)
+	gen.Emit("}\n\n")
+	gen.Emitf("impl fmt::Display for %s {\n", name)
+	gen.Emitf("%sfn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {\n", i1)
+	gen.Emitf("%sf.write_str(self.as_str())\n", i2)
+	gen.Emitf("%s}\n", i1)
+	gen.Emit("}\n\n")
+	gen.Emitf("impl std::str::FromStr for %s {\n", name)
+	gen.Emitf("%stype Err = String;\n\n", i1)
+	gen.Emitf("%sfn from_str(s: &str) -> Result<Self, Self::Err> {\n", i1)
+	gen.Emitf("%smatch s {\n", i2)
+	for i, v := range variants {
+		gen.Emitf("%s%q => Ok(%s::%s),\n", i3, values[i], name, v)
+	}
+	gen.Emitf("%s_ => Err(format!(\"not a valid %s: {}\", s)),\n", i3, name)
+	gen.Emitf("%s}\n", i2)
+	gen.Emitf("%s}\n", i1)
+	gen.Emit("}\n")
+}
+
+func hasOutput(op *model.OperationDef) bool {
+	return op.Output != nil && len(op.Output.Fields) > 0
+}
+
+func hasInput(op *model.OperationDef) bool {
+	return op.Input != nil && len(op.Input.Fields) > 0
+}
+
+// generateOperationTypes emits the input and output structs of an operation. They are built by the caller and by the
+// client, not serialized as a whole.
+func (gen *Generator) generateOperationTypes(op *model.OperationDef) {
+	if hasInput(op) {
+		gen.Emit("\n")
+		gen.emitComment("", op.Input.Comment)
+		gen.Emit("#[derive(Debug, Clone, PartialEq)]\n")
+		gen.Emitf("pub struct %s {\n", model.StripNamespace(op.Input.Id))
+		for _, f := range op.Input.Fields {
+			gen.emitPlainField(string(f.Name), f.Type, f.Required, f.Comment)
+		}
+		gen.Emit("}\n")
+	}
+	if hasOutput(op) {
+		gen.Emit("\n")
+		gen.emitComment("", op.Output.Comment)
+		gen.Emit("#[derive(Debug, Clone, PartialEq)]\n")
+		gen.Emitf("pub struct %s {\n", model.StripNamespace(op.Output.Id))
+		for _, f := range op.Output.Fields {
+			gen.emitPlainField(string(f.Name), f.Type, f.Required, f.Comment)
+		}
+		gen.Emit("}\n")
+	}
+}
+
+func (gen *Generator) emitPlainField(name string, tid model.AbsoluteIdentifier, required bool, comment string) {
+	gen.emitComment(IndentAmount, comment)
+	tref := gen.TypeRef(tid)
+	if !required {
+		tref = "Option<" + tref + ">"
+	}
+	gen.Emitf("%spub %s: %s,\n", IndentAmount, fieldName(name), tref)
+}
+
+// generateException emits a struct for an exception, built from an error response. Every field is optional, as the
+// fields of an exception are decoded leniently (see model.Schema.GetExceptionDef).
+func (gen *Generator) generateException(edef *model.OperationOutput) {
+	name := model.StripNamespace(edef.Id)
+	i1 := IndentAmount
+	i2 := i1 + IndentAmount
+	i3 := i2 + IndentAmount
+	gen.emitComment("", edef.Comment)
+	gen.Emit("#[derive(Debug, Clone, PartialEq)]\n")
+	gen.Emitf("pub struct %s {\n", name)
+	for _, f := range edef.Fields {
+		gen.emitPlainField(string(f.Name), f.Type, false, f.Comment)
+	}
+	gen.Emit("}\n\n")
+	gen.Emitf("impl %s {\n", name)
+	headers, body := "headers", "body"
+	if usesHeaders, usesBody := decodes(edef.Fields); !usesHeaders || !usesBody {
+		if !usesHeaders {
+			headers = "_headers"
+		}
+		if !usesBody {
+			body = "_body"
+		}
+	}
+	gen.Emitf("%sfn from_response(%s: &reqwest::header::HeaderMap, %s: &[u8]) -> Self {\n", i1, headers, body)
+	gen.Emitf("%s%s {\n", i2, name)
+	for _, f := range edef.Fields {
+		gen.Emitf("%s%s: %s.ok().flatten(),\n", i3, fieldName(string(f.Name)), gen.outputValue(f))
+	}
+	gen.Emitf("%s}\n", i2)
+	gen.Emitf("%s}\n", i1)
+	gen.Emit("}\n")
+}
+
+// decodes tells whether any of the fields is decoded from the headers, or from the body, of a response.
+func decodes(fields []*model.OperationOutputField) (bool, bool) {
+	usesHeaders, usesBody := false, false
+	for _, f := range fields {
+		if f.HttpHeader != "" {
+			usesHeaders = true
+		} else {
+			usesBody = true
+		}
+	}
+	return usesHeaders, usesBody
+}
+
+// outputValue returns the expression decoding an output or exception field from the response, as a
+// Result<Option<T>, ApiError>.
+func (gen *Generator) outputValue(f *model.OperationOutputField) string {
+	if f.HttpPayload {
+		return "payload(body)"
+	}
+	if f.HttpHeader != "" {
+		if gen.Schema.BaseType(f.Type) == model.BaseType_List {
+			return fmt.Sprintf("header_list(headers, %q)", f.HttpHeader)
+		}
+		return fmt.Sprintf("header(headers, %q)", f.HttpHeader)
+	}
+	return fmt.Sprintf("member(body, %q)", f.Name)
+}
+
+func (gen *Generator) generateApiError() {
+	i1 := IndentAmount
+	i2 := i1 + IndentAmount
+	i3 := i2 + IndentAmount
+	gen.Emit("/// The errors returned by the client: an exception of the service, an unexpected response, or a failure to send the\n")
+	gen.Emit("/// request or to decode the response.\n")
+	gen.Emit("#[derive(Debug)]\n")
+	gen.Emit("pub enum ApiError {\n")
+	for _, edef := range gen.Exceptions() {
+		name := model.StripNamespace(edef.Id)
+		gen.Emitf("%s%s(%s),\n", i1, name, name)
+	}
+	gen.Emitf("%sStatus { status: u16, body: String },\n", i1)
+	gen.Emitf("%sRequest(reqwest::Error),\n", i1)
+	gen.Emitf("%sDecode(String),\n", i1)
+	gen.Emit("}\n\n")
+	gen.Emit("impl fmt::Display for ApiError {\n")
+	gen.Emitf("%sfn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {\n", i1)
+	gen.Emitf("%smatch self {\n", i2)
+	for _, edef := range gen.Exceptions() {
+		name := model.StripNamespace(edef.Id)
+		gen.Emitf("%sApiError::%s(_) => write!(f, \"%s (HTTP status %d)\"),\n", i3, name, name, edef.HttpStatus)
+	}
+	gen.Emitf("%sApiError::Status { status, .. } => write!(f, \"HTTP status {}\", status),\n", i3)
+	gen.Emitf("%sApiError::Request(e) => write!(f, \"request failed: {}\", e),\n", i3)
+	gen.Emitf("%sApiError::Decode(message) => write!(f, \"cannot decode the response: {}\", message),\n", i3)
+	gen.Emitf("%s}\n", i2)
+	gen.Emitf("%s}\n", i1)
+	gen.Emit("}\n\n")
+	gen.Emit("impl std::error::Error for ApiError {}\n")
+}
+
+func (gen *Generator) generateClient() {
+	schema := gen.Schema
+	serviceName := string(schema.ServiceName())
+	if serviceName == "" {
+		serviceName = pascalCase(strings.ReplaceAll(string(gen.ns), ".", "_"))
+	}
+	className := serviceName + "Client"
+	i1 := IndentAmount
+	i2 := i1 + IndentAmount
+	i3 := i2 + IndentAmount
+	i4 := i3 + IndentAmount
+	gen.emitComment("", schema.Comment)
+	gen.Emit(strings.ReplaceAll(clientSource, "CLIENT", className))
+	for _, op := range gen.Operations() {
+		gen.Emit("\n")
+		gen.emitComment(i1, op.Comment)
+		param := ""
+		if hasInput(op) {
+			param = ", input: &" + model.StripNamespace(op.Input.Id)
+		}
+		result := "()"
+		if hasOutput(op) {
+			result = model.StripNamespace(op.Output.Id)
+		}
+		gen.Emitf("%spub async fn %s(&self%s) -> Result<%s, ApiError> {\n", i1, fieldName(model.StripNamespace(op.Id)), param, result)
+		binding := model.HttpInputBinding(op)
+		gen.Emitf("%slet path = %s;\n", i2, gen.pathExpression(binding))
+		mut := ""
+		if len(binding.Query) > 0 || len(binding.Headers) > 0 || binding.Payload != nil || len(binding.Members) > 0 {
+			mut = "mut "
+		}
+		method := strings.ToUpper(op.HttpMethod)
+		switch method {
+		case "GET", "POST", "PUT", "DELETE", "HEAD", "OPTIONS", "PATCH":
+			gen.Emitf("%slet %srequest = self.http.request(reqwest::Method::%s, format!(\"{}{}\", self.base_url, path));\n", i2, mut, method)
+		default:
+			gen.Emitf("%slet method = reqwest::Method::from_bytes(b%q).expect(\"valid method\");\n", i2, method)
+			gen.Emitf("%slet %srequest = self.http.request(method, format!(\"{}{}\", self.base_url, path));\n", i2, mut)
+		}
+		for _, f := range binding.Headers {
+			gen.emitOptional(i2, f, func(indent, value string) {
+				if gen.Schema.BaseType(f.Type) == model.BaseType_List {
+					//the items of a list are sent as a comma-separated value
+					td := gen.Schema.GetTypeDef(f.Type)
+					items := fmt.Sprintf("%s.iter().map(|item| %s).collect::<Vec<_>>().join(\",\")", value, gen.param("item", td.Items))
+					gen.Emitf("%srequest = request.header(%q, %s);\n", indent, f.HttpHeader, items)
+				} else {
+					gen.Emitf("%srequest = request.header(%q, %s);\n", indent, f.HttpHeader, gen.param(value, f.Type))
+				}
+			})
+		}
+		if len(binding.Query) > 0 {
+			gen.Emitf("%slet mut query: Vec<(&str, String)> = Vec::new();\n", i2)
+			for _, f := range binding.Query {
+				gen.emitOptional(i2, f, func(indent, value string) {
+					if gen.Schema.BaseType(f.Type) == model.BaseType_List {
+						td := gen.Schema.GetTypeDef(f.Type)
+						gen.Emitf("%sfor item in %s {\n", indent, value)
+						gen.Emitf("%s%squery.push((%q, %s));\n", indent, IndentAmount, f.HttpQuery, gen.param("item", td.Items))
+						gen.Emitf("%s}\n", indent)
+					} else {
+						gen.Emitf("%squery.push((%q, %s));\n", indent, f.HttpQuery, gen.param(value, f.Type))
+					}
+				})
+			}
+			gen.Emitf("%srequest = request.query(&query);\n", i2)
+		}
+		if binding.Payload != nil {
+			gen.emitOptional(i2, binding.Payload, func(indent, value string) {
+				gen.Emitf("%srequest = request.json(%s);\n", indent, value)
+			})
+		} else if len(binding.Members) > 0 {
+			gen.Emitf("%slet mut body = serde_json::Map::new();\n", i2)
+			for _, f := range binding.Members {
+				gen.emitOptional(i2, f, func(indent, value string) {
+					gen.Emitf("%sbody.insert(%q.to_string(), serde_json::to_value(%s).map_err(|e| ApiError::Decode(e.to_string()))?);\n", indent, f.Name, value)
+				})
+			}
+			gen.Emitf("%srequest = request.json(&body);\n", i2)
+		}
+		gen.Emitf("%slet response = request.send().await.map_err(ApiError::Request)?;\n", i2)
+		gen.Emitf("%slet status = response.status().as_u16();\n", i2)
+		usesHeaders, usesBody := false, false
+		if hasOutput(op) {
+			usesHeaders, usesBody = decodes(op.Output.Fields)
+		}
+		if usesHeaders || len(op.Exceptions) > 0 {
+			gen.Emitf("%slet headers = response.headers().clone();\n", i2)
+		}
+		gen.Emitf("%slet body = response.bytes().await.map_err(ApiError::Request)?;\n", i2)
+		gen.Emitf("%sif !(200..300).contains(&status) {\n", i2)
+		gen.Emitf("%sreturn Err(match status {\n", i3)
+		statuses := make(map[int32]bool, 0)
+		for _, eid := range op.Exceptions {
+			edef := schema.GetExceptionDef(eid)
+			if edef == nil || statuses[edef.HttpStatus] {
+				continue
+			}
+			statuses[edef.HttpStatus] = true
+			name := model.StripNamespace(eid)
+			gen.Emitf("%s%d => ApiError::%s(%s::from_response(&headers, &body)),\n", i4, edef.HttpStatus, name, name)
+		}
+		gen.Emitf("%s_ => ApiError::Status { status, body: String::from_utf8_lossy(&body).into_owned() },\n", i4)
+		gen.Emitf("%s});\n", i3)
+		gen.Emitf("%s}\n", i2)
+		if hasOutput(op) {
+			if usesHeaders {
+				gen.Emitf("%slet headers = &headers;\n", i2)
+			}
+			if usesBody {
+				gen.Emitf("%slet body = &body[..];\n", i2)
+			}
+			gen.Emitf("%sOk(%s {\n", i2, result)
+			for _, f := range op.Output.Fields {
+				value := gen.outputValue(f) + "?"
+				if f.Required {
+					value = fmt.Sprintf("required(%s, %q)?", value, f.Name)
+				}
+				gen.Emitf("%s%s: %s,\n", i3, fieldName(string(f.Name)), value)
+			}
+			gen.Emitf("%s})\n", i2)
+		} else {
+			gen.Emitf("%sOk(())\n", i2)
+		}
+		gen.Emitf("%s}\n", i1)
+	}
+	gen.Emit("}\n")
+}
+
+// emitOptional emits the code using the value of an input field, only if it is present when it is optional.
+func (gen *Generator) emitOptional(indent string, f *model.OperationInputField, emit func(indent, value string)) {
+	expr := "&input." + fieldName(string(f.Name))
+	if f.Required {
+		emit(indent, expr)
+		return
+	}
+	gen.Emitf("%sif let Some(value) = %s {\n", indent, expr)
+	emit(indent+IndentAmount, "value")
+	gen.Emitf("%s}\n", indent)
+}
+
+// param returns the expression formatting a value for a path, query, or header parameter.
+func (gen *Generator) param(expr string, tid model.AbsoluteIdentifier) string {
+	if gen.Schema.BaseType(tid) == model.BaseType_Timestamp && gen.timestamp == "chrono" {
+		return expr + ".to_rfc3339_opts(chrono::SecondsFormat::AutoSi, true)"
+	}
+	return expr + ".to_string()"
+}
+
+// pathExpression returns a Rust expression for the operation's URI, substituting its path fields.
+func (gen *Generator) pathExpression(binding *model.InputBinding) string {
+	var format strings.Builder
+	var args []string
+	for _, seg := range binding.Path {
+		if seg.Label == "" {
+			format.WriteString(seg.Literal)
+			continue
+		}
+		format.WriteString("{}")
+		var tid model.AbsoluteIdentifier = "base#String"
+		if seg.Field != nil {
+			tid = seg.Field.Type
+		}
+		args = append(args, fmt.Sprintf("encode(&%s, %v)", gen.param("input."+fieldName(seg.Label), tid), seg.Greedy))
+	}
+	if len(args) == 0 {
+		return fmt.Sprintf("%q", format.String())
+	}
+	return fmt.Sprintf("format!(%q, %s)", format.String(), strings.Join(args, ", "))
+}
+
+var clientSource = `#[derive(Debug, Clone)]
+pub struct CLIENT {
+    base_url: String,
+    http: reqwest::Client,
+}
+
+impl CLIENT {
+    /// Creates a client for the service at the given URL, i.e. "https://api.example.com/v1".
+    pub fn new(base_url: impl Into<String>) -> Self {
+        Self::with_client(base_url, reqwest::Client::new())
+    }
+
+    /// Creates a client using the given reqwest client, i.e. one built with default headers for authorization.
+    pub fn with_client(base_url: impl Into<String>, http: reqwest::Client) -> Self {
+        let base_url = base_url.into().trim_end_matches('/').to_string();
+        Self { base_url, http }
+    }
+`
+
+var clientUtilSource = `fn encode(value: &str, keep_slashes: bool) -> String {
+    let mut s = String::with_capacity(value.len());
+    for b in value.bytes() {
+        match b {
+            b'A'..=b'Z' | b'a'..=b'z' | b'0'..=b'9' | b'-' | b'.' | b'_' | b'~' => s.push(b as char),
+            b'/' if keep_slashes => s.push('/'),
+            _ => s.push_str(&format!("%{:02X}", b)),
+        }
+    }
+    s
+}
+
+fn required<T>(value: Option<T>, name: &str) -> Result<T, ApiError> {
+    value.ok_or_else(|| ApiError::Decode(format!("missing {}", name)))
+}
+
+fn payload<T: serde::de::DeserializeOwned>(body: &[u8]) -> Result<Option<T>, ApiError> {
+    if body.is_empty() {
+        return Ok(None);
+    }
+    serde_json::from_slice(body).map(Some).map_err(|e| ApiError::Decode(e.to_string()))
+}
+
+fn member<T: serde::de::DeserializeOwned>(body: &[u8], name: &str) -> Result<Option<T>, ApiError> {
+    let value: Option<serde_json::Value> = payload(body)?;
+    match value.as_ref().and_then(|v| v.get(name)) {
+        None | Some(serde_json::Value::Null) => Ok(None),
+        Some(v) => T::deserialize(v).map(Some).map_err(|e| ApiError::Decode(format!("{}: {}", name, e))),
+    }
+}
+
+fn header<T: std::str::FromStr>(headers: &reqwest::header::HeaderMap, name: &str) -> Result<Option<T>, ApiError> {
+    match headers.get(name) {
+        None => Ok(None),
+        Some(value) => {
+            let s = value.to_str().map_err(|e| ApiError::Decode(format!("{}: {}", name, e)))?;
+            s.trim().parse().map(Some).map_err(|_| ApiError::Decode(format!("{}: cannot parse {:?}", name, s)))
+        }
+    }
+}
+
+fn header_list<T: std::str::FromStr>(headers: &reqwest::header::HeaderMap, name: &str) -> Result<Option<Vec<T>>, ApiError> {
+    let s: Option<String> = header(headers, name)?;
+    match s {
+        None => Ok(None),
+        Some(s) => s
+            .split(',')
+            .map(|item| item.trim().parse().map_err(|_| ApiError::Decode(format!("{}: cannot parse {:?}", name, item))))
+            .collect::<Result<Vec<T>, ApiError>>()
+            .map(Some),
+    }
+}
+`
+
+var bytesSource = `/// A blob, represented in JSON as a base64 string.
+#[derive(Debug, Clone, PartialEq, Eq, Default)]
+pub struct Bytes(pub Vec<u8>);
+
+impl Serialize for Bytes {
+    fn serialize<S: serde::Serializer>(&self, serializer: S) -> Result<S::Ok, S::Error> {
+        use base64::Engine;
+        serializer.serialize_str(&base64::engine::general_purpose::STANDARD.encode(&self.0))
+    }
+}
+
+impl<'de> Deserialize<'de> for Bytes {
+    fn deserialize<D: serde::Deserializer<'de>>(deserializer: D) -> Result<Self, D::Error> {
+        use base64::Engine;
+        let s = String::deserialize(deserializer)?;
+        base64::engine::general_purpose::STANDARD.decode(s).map(Bytes).map_err(serde::de::Error::custom)
+    }
+}
+`
@@ -0,0 +1,98 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package samples
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/boynton/api/model"
+	"github.com/boynton/data"
+)
+
+const FileSuffix = ".samples.json"
+
+// Generator writes sample data for the model: a value of every type, and an input and output of every operation and
+// exception, synthesised from the types and their constraints. The samples are the same for the same "samples.seed".
+type Generator struct {
+	model.BaseGenerator
+	sampler *model.Sampler
+	types   *data.Object
+	ops     *data.Object
+	excs    *data.Object
+}
+
+func (gen *Generator) Generate(schema *model.Schema, config *data.Object) error {
+	err := gen.Configure(schema, config)
+	if err != nil {
+		return err
+	}
+	var seed int64
+	if s := config.GetString("samples.seed"); s != "" {
+		if seed, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return fmt.Errorf("Bad samples.seed %q: %v", s, err)
+		}
+	}
+	gen.sampler = model.NewSampler(schema, seed)
+	gen.types, gen.ops, gen.excs = data.NewObject(), data.NewObject(), data.NewObject()
+	for _, op := range gen.Operations() {
+		if err := gen.GenerateOperation(op); err != nil {
+			return err
+		}
+	}
+	for _, out := range gen.Exceptions() {
+		if err := gen.GenerateException(out); err != nil {
+			return err
+		}
+	}
+	for _, td := range gen.Types() {
+		if err := gen.GenerateType(td); err != nil {
+			return err
+		}
+	}
+	samples := data.NewObject()
+	samples.Put("operations", gen.ops)
+	samples.Put("exceptions", gen.excs)
+	samples.Put("types", gen.types)
+	ns := string(schema.ServiceNamespace())
+	if ns == "" {
+		ns = string(schema.Namespace)
+	}
+	fname := gen.FileName(ns, FileSuffix)
+	return gen.Write(model.Pretty(samples), fname, "")
+}
+
+func (gen *Generator) GenerateResource(rez *model.ResourceDef) error {
+	return nil
+}
+
+func (gen *Generator) GenerateOperation(op *model.OperationDef) error {
+	sample := data.NewObject()
+	sample.Put("input", gen.sampler.SampleInput(op.Input))
+	sample.Put("output", gen.sampler.SampleOutput(op.Output))
+	gen.ops.Put(model.StripNamespace(op.Id), sample)
+	return nil
+}
+
+func (gen *Generator) GenerateException(out *model.OperationOutput) error {
+	gen.excs.Put(model.StripNamespace(out.Id), gen.sampler.SampleOutput(out))
+	return nil
+}
+
+func (gen *Generator) GenerateType(td *model.TypeDef) error {
+	gen.types.Put(model.StripNamespace(td.Id), gen.sampler.Sample(td.Id))
+	return nil
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+
+	"github.com/boynton/api/mock"
+	"github.com/boynton/api/model"
+	"github.com/boynton/data"
+)
+
+// Serve implements "api serve": it assembles the model from the files, and runs a mock server for its operations until
+// interrupted. It returns the exit code of the tool.
+func Serve(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	pAddr := flags.String("addr", "localhost:8080", "The address to listen on")
+	pNoValidate := flags.Bool("v", false, "Suppress validation of the assembled model")
+	pQuiet := flags.Bool("q", false, "Do not log each request")
+	pNs := flags.String("ns", "", "The namespace to force if absent")
+	pSeed := flags.Int64("seed", 0, "The seed of the responses synthesised for requests matching no example")
+	var tags Tags
+	flags.Var(&tags, "t", "Tag of entities to include. Prefix tag with '-' to exclude that tag")
+	flags.Parse(args)
+	files := flags.Args()
+	if len(files) == 0 {
+		fmt.Println("usage: api serve [-addr host:port] [-vq] [-ns namespace] [-seed n] [-t tag]* file ...")
+		flags.PrintDefaults()
+		return 1
+	}
+	model.MinimizeOutput = *pQuiet
+	schema, err := AssembleModel(files, tags, *pNs, false, *pNoValidate, data.NewObject())
+	if err != nil {
+		fmt.Printf("*** %v\n", err)
+		return 4
+	}
+	server := mock.NewServer(schema)
+	server.Quiet = *pQuiet
+	server.Seed = *pSeed
+	if !*pQuiet {
+		fmt.Printf("Serving %d operations of %s at http://%s\n", len(schema.Operations), schema.Id, *pAddr)
+		if schema.Base != "" {
+			fmt.Printf("The operations are under the path of the base %q\n", schema.Base)
+		}
+	}
+	err = http.ListenAndServe(*pAddr, server)
+	if err != nil {
+		fmt.Printf("*** %v\n", err)
+		return 4
+	}
+	return 0
+}
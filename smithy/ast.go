@@ -808,12 +808,14 @@ func (ast *AST) Merge(src *AST) error {
 				v := src.Metadata.Get(k)
 				prev := ast.Metadata.Get(k)
 				if prev != nil {
-					err := ast.mergeConflict(k, prev, v)
+					merged, err := ast.mergeConflict(k, prev.RawValue(), v.RawValue())
 					if err != nil {
 						return err
 					}
+					ast.Metadata.Put(k, merged)
+				} else {
+					ast.Metadata.Put(k, v)
 				}
-				ast.Metadata.Put(k, v)
 			}
 		}
 	}
@@ -869,10 +871,20 @@ func (ast *AST) mergeShape(key string, shape1 *Shape, shape2 *Shape) error {
 	return nil
 }
 
-func (ast *AST) mergeConflict(k string, v1 interface{}, v2 interface{}) error {
-	//todo: if values are identical, accept one of them
-	//todo: concat list values
-	return fmt.Errorf("Conflict when merging models (not yet handled): %s\n", k)
+// mergeConflict resolves a metadata key defined in more than one model file, as the Smithy spec does: list values
+// are concatenated, identical values are accepted, and anything else is an error.
+func (ast *AST) mergeConflict(k string, v1 interface{}, v2 interface{}) (interface{}, error) {
+	l1, ok1 := v1.([]interface{})
+	l2, ok2 := v2.([]interface{})
+	if ok1 && ok2 {
+		merged := make([]interface{}, 0, len(l1)+len(l2))
+		merged = append(merged, l1...)
+		return append(merged, l2...), nil
+	}
+	if jsonEncode(v1) == jsonEncode(v2) {
+		return v1, nil
+	}
+	return nil, fmt.Errorf("Conflicting metadata values when merging models: %s\n", k)
 }
 
 var mixinSeq int
@@ -0,0 +1,537 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package smithy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/boynton/api/model"
+)
+
+const BuildConfigFileName = "smithy-build.json"
+
+// the "source" projection is implicit in smithy-build, it is the model with no transforms applied.
+const SourceProjection = "source"
+
+// BuildConfig - the subset of smithy-build.json that this tool understands. Plugins are ignored, the
+// generator selected on the command line is used for every projection instead.
+type BuildConfig struct {
+	Version     string            `json:"version"`
+	Sources     []string          `json:"sources,omitempty"`
+	Imports     []string          `json:"imports,omitempty"`
+	Projections *Map[*Projection] `json:"projections,omitempty"`
+	dir         string
+}
+
+type Projection struct {
+	Abstract   bool         `json:"abstract,omitempty"`
+	Imports    []string     `json:"imports,omitempty"`
+	Transforms []*Transform `json:"transforms,omitempty"`
+}
+
+type Transform struct {
+	Name string     `json:"name"`
+	Args *NodeValue `json:"args,omitempty"`
+}
+
+func LoadBuildConfig(path string) (*BuildConfig, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot read smithy build file: %v", err)
+	}
+	var conf *BuildConfig
+	err = json.Unmarshal(b, &conf)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot parse smithy build file: %v", err)
+	}
+	if conf.Version == "" {
+		return nil, fmt.Errorf("Cannot parse smithy build file: missing 'version'")
+	}
+	conf.dir = filepath.Dir(path)
+	return conf, nil
+}
+
+// FindBuildConfig looks for a smithy-build.json next to the given source paths, then in the current directory.
+func FindBuildConfig(paths []string) string {
+	var dirs []string
+	for _, path := range paths {
+		if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+			dirs = append(dirs, path, filepath.Dir(path))
+		} else {
+			dirs = append(dirs, filepath.Dir(path))
+		}
+	}
+	dirs = append(dirs, ".")
+	for _, dir := range dirs {
+		path := filepath.Join(dir, BuildConfigFileName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+func (conf *BuildConfig) resolve(paths []string) []string {
+	var result []string
+	for _, p := range paths {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(conf.dir, p)
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+// ProjectionNames returns the names of the non-abstract projections, in the order declared.
+func (conf *BuildConfig) ProjectionNames() []string {
+	names := []string{SourceProjection}
+	for _, name := range conf.Projections.Keys() {
+		if name != SourceProjection && !conf.Projections.Get(name).Abstract {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (conf *BuildConfig) GetProjection(name string) (*Projection, error) {
+	if conf.Projections.Has(name) {
+		proj := conf.Projections.Get(name)
+		if proj.Abstract {
+			return nil, fmt.Errorf("Cannot build an abstract projection: %s", name)
+		}
+		return proj, nil
+	}
+	if name == SourceProjection {
+		return &Projection{}, nil
+	}
+	return nil, fmt.Errorf("No such projection in %s: %s", BuildConfigFileName, name)
+}
+
+// SourcePaths returns the sources and imports of the config, plus the imports of the named projection,
+// resolved relative to the location of the config file.
+func (conf *BuildConfig) SourcePaths(name string) []string {
+	paths := conf.resolve(conf.Sources)
+	paths = append(paths, conf.resolve(conf.Imports)...)
+	if conf.Projections.Has(name) {
+		paths = append(paths, conf.resolve(conf.Projections.Get(name).Imports)...)
+	}
+	return paths
+}
+
+// ImportProjection assembles the given smithy files, applies the projection's transforms, and imports the result.
+func ImportProjection(paths []string, tags []string, proj *Projection, noValidate bool) (*model.Schema, error) {
+	ast, err := Assemble(paths)
+	if err != nil {
+		return nil, err
+	}
+	err = ast.Project(proj)
+	if err != nil {
+		return nil, err
+	}
+	return ImportAST(ast, tags, noValidate)
+}
+
+// Project applies the transforms of the projection to the AST, in order.
+func (ast *AST) Project(proj *Projection) error {
+	for _, t := range proj.Transforms {
+		err := ast.ApplyTransform(t)
+		if err != nil {
+			return fmt.Errorf("Transform %q failed: %v", t.Name, err)
+		}
+	}
+	return nil
+}
+
+func (ast *AST) ApplyTransform(t *Transform) error {
+	args := t.Args
+	if args == nil {
+		args = NewNodeValue()
+	}
+	switch t.Name {
+	case "excludeShapesByTag":
+		return ast.ExcludeShapesByTag(nodeStrings(args.Get("tags")))
+	case "includeShapesByTag":
+		return ast.IncludeShapesByTag(nodeStrings(args.Get("tags")))
+	case "excludeTraits":
+		return ast.ExcludeTraits(nodeStrings(args.Get("traits")))
+	case "renameShapes":
+		renamed := make(map[string]string, 0)
+		if r := args.Get("renamed"); r != nil && r.IsObject() {
+			for _, k := range r.Keys() {
+				renamed[k] = r.GetString(k)
+			}
+		}
+		return ast.RenameShapes(renamed)
+	case "flattenNamespaces":
+		return ast.FlattenNamespaces(args.GetString("namespace"), args.GetString("service"), nodeStrings(args.Get("includeTagged")))
+	case "removeUnusedShapes":
+		return ast.RemoveUnusedShapes(nodeStrings(args.Get("exportTagged")))
+	case "includeServices":
+		return ast.IncludeServices(nodeStrings(args.Get("services")))
+	default:
+		return fmt.Errorf("Unsupported transform: %s", t.Name)
+	}
+}
+
+func nodeStrings(node *NodeValue) []string {
+	if node == nil {
+		return nil
+	}
+	var result []string
+	for _, v := range AsSlice(node.RawValue()) {
+		if s, ok := v.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func traitTags(traits *NodeValue) []string {
+	if traits == nil {
+		return nil
+	}
+	return nodeStrings(traits.Get("smithy.api#tags"))
+}
+
+func hasAnyTag(traits *NodeValue, tags []string) bool {
+	for _, t := range traitTags(traits) {
+		if containsString(tags, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ast *AST) ExcludeShapesByTag(tags []string) error {
+	removed := make(map[string]bool, 0)
+	for _, id := range ast.Shapes.Keys() {
+		shape := ast.GetShape(id)
+		if hasAnyTag(shape.Traits, tags) {
+			removed[id] = true
+			continue
+		}
+		if shape.Members != nil {
+			for _, k := range shape.Members.Keys() {
+				if hasAnyTag(shape.Members.Get(k).Traits, tags) {
+					shape.Members.Delete(k)
+				}
+			}
+		}
+	}
+	ast.removeShapes(removed)
+	return nil
+}
+
+func (ast *AST) IncludeShapesByTag(tags []string) error {
+	removed := make(map[string]bool, 0)
+	for _, id := range ast.Shapes.Keys() {
+		if !hasAnyTag(ast.GetShape(id).Traits, tags) {
+			removed[id] = true
+		}
+	}
+	ast.removeShapes(removed)
+	return nil
+}
+
+// ExcludeTraits removes the given traits from every shape and member, along with the definitions of the
+// traits in the model. Relative trait ids refer to the prelude, and an id ending with '#' removes every trait
+// in that namespace.
+func (ast *AST) ExcludeTraits(traits []string) error {
+	var ids, namespaces []string
+	for _, t := range traits {
+		if strings.HasSuffix(t, "#") {
+			namespaces = append(namespaces, t)
+		} else if strings.Index(t, "#") < 0 {
+			ids = append(ids, "smithy.api#"+t)
+		} else {
+			ids = append(ids, t)
+		}
+	}
+	excluded := func(tid string) bool {
+		if containsString(ids, tid) {
+			return true
+		}
+		for _, ns := range namespaces {
+			if strings.HasPrefix(tid, ns) {
+				return true
+			}
+		}
+		return false
+	}
+	strip := func(tr *NodeValue) {
+		if tr == nil || !tr.IsObject() {
+			return
+		}
+		for _, k := range tr.Keys() {
+			if excluded(k) {
+				if m, ok := tr.value.(map[string]interface{}); ok {
+					delete(m, k)
+				}
+			}
+		}
+	}
+	removed := make(map[string]bool, 0)
+	for _, id := range ast.Shapes.Keys() {
+		shape := ast.GetShape(id)
+		if excluded(id) && shape.Traits != nil && shape.Traits.Has("smithy.api#trait") {
+			removed[id] = true
+			continue
+		}
+		strip(shape.Traits)
+		for _, mem := range shapeMembers(shape) {
+			strip(mem.Traits)
+		}
+	}
+	ast.removeShapes(removed)
+	return nil
+}
+
+func (ast *AST) RenameShapes(renamed map[string]string) error {
+	for from, to := range renamed {
+		if !ast.Shapes.Has(from) {
+			return fmt.Errorf("Cannot rename %s: shape not found", from)
+		}
+		if ast.Shapes.Has(to) && renamed[to] == "" {
+			return fmt.Errorf("Cannot rename %s to %s: shape already exists", from, to)
+		}
+	}
+	newShapes := NewMap[*Shape]()
+	for _, id := range ast.Shapes.Keys() {
+		shape := ast.GetShape(id)
+		if to, ok := renamed[id]; ok {
+			id = to
+		}
+		newShapes.Put(id, shape)
+	}
+	ast.Shapes = newShapes
+	ast.mapReferences(func(target string) string {
+		if to, ok := renamed[target]; ok {
+			return to
+		}
+		return target
+	})
+	return nil
+}
+
+// FlattenNamespaces moves the closure of the service (and any shapes with the given tags) into a single
+// namespace. All other shapes are removed.
+func (ast *AST) FlattenNamespaces(ns string, service string, includeTagged []string) error {
+	if ns == "" || service == "" {
+		return fmt.Errorf("both 'namespace' and 'service' arguments are required")
+	}
+	if !ast.Shapes.Has(service) {
+		return fmt.Errorf("service not found: %s", service)
+	}
+	roots := []string{service}
+	for _, id := range ast.Shapes.Keys() {
+		if len(includeTagged) > 0 && hasAnyTag(ast.GetShape(id).Traits, includeTagged) {
+			roots = append(roots, id)
+		}
+	}
+	ast.FilterDependencies(roots, nil)
+	renamed := make(map[string]string, 0)
+	seen := make(map[string]string, 0)
+	for _, id := range ast.Shapes.Keys() {
+		to := ns + "#" + stripNamespace(id)
+		if prev, ok := seen[to]; ok {
+			return fmt.Errorf("conflicting shape names after flattening: %s and %s", prev, id)
+		}
+		seen[to] = id
+		if to != id {
+			renamed[id] = to
+		}
+	}
+	return ast.RenameShapes(renamed)
+}
+
+// RemoveUnusedShapes removes shapes not connected to a service, unless they are tagged with one of the
+// exportTagged tags. Trait definitions are kept only if some remaining shape or member uses them.
+func (ast *AST) RemoveUnusedShapes(exportTagged []string) error {
+	var roots []string
+	for _, id := range ast.Shapes.Keys() {
+		shape := ast.GetShape(id)
+		if shape.Type == "service" || (len(exportTagged) > 0 && hasAnyTag(shape.Traits, exportTagged)) {
+			roots = append(roots, id)
+		}
+	}
+	included := NewMap[bool]()
+	for _, k := range roots {
+		ast.noteDependencies(included, k)
+	}
+	for _, id := range included.Keys() {
+		if shape := ast.GetShape(id); shape != nil {
+			for _, mem := range shapeMembers(shape) {
+				if mem.Traits != nil && mem.Traits.IsObject() {
+					for _, tk := range mem.Traits.Keys() {
+						ast.noteDependencies(included, tk)
+					}
+				}
+			}
+		}
+	}
+	removed := make(map[string]bool, 0)
+	for _, id := range ast.Shapes.Keys() {
+		if !included.Has(id) {
+			removed[id] = true
+		}
+	}
+	ast.removeShapes(removed)
+	return nil
+}
+
+func (ast *AST) IncludeServices(services []string) error {
+	removed := make(map[string]bool, 0)
+	for _, id := range ast.Shapes.Keys() {
+		if ast.GetShape(id).Type == "service" && !containsString(services, id) {
+			removed[id] = true
+		}
+	}
+	ast.removeShapes(removed)
+	return nil
+}
+
+func shapeMembers(shape *Shape) []*Member {
+	var mems []*Member
+	for _, m := range []*Member{shape.Member, shape.Key, shape.Value} {
+		if m != nil {
+			mems = append(mems, m)
+		}
+	}
+	if shape.Members != nil {
+		for _, k := range shape.Members.Keys() {
+			mems = append(mems, shape.Members.Get(k))
+		}
+	}
+	return mems
+}
+
+// removeShapes deletes the shapes, and any references to them. Lists and maps whose member is removed
+// are removed as well, since they cannot exist without it.
+func (ast *AST) removeShapes(removed map[string]bool) {
+	for len(removed) > 0 {
+		for id := range removed {
+			ast.Shapes.Delete(id)
+		}
+		cascade := make(map[string]bool, 0)
+		for _, id := range ast.Shapes.Keys() {
+			shape := ast.GetShape(id)
+			for _, m := range []*Member{shape.Member, shape.Key, shape.Value} {
+				if m != nil && removed[m.Target] {
+					cascade[id] = true
+				}
+			}
+		}
+		ast.mapReferences(func(target string) string {
+			if removed[target] {
+				return ""
+			}
+			return target
+		})
+		removed = cascade
+	}
+}
+
+// mapReferences rewrites every shape reference in the AST with fn. If fn returns "", the reference is dropped.
+func (ast *AST) mapReferences(fn func(target string) string) {
+	mapRef := func(ref *ShapeRef) *ShapeRef {
+		if ref == nil {
+			return nil
+		}
+		t := fn(ref.Target)
+		if t == "" {
+			return nil
+		}
+		ref.Target = t
+		return ref
+	}
+	mapRefs := func(refs []*ShapeRef) []*ShapeRef {
+		var result []*ShapeRef
+		for _, ref := range refs {
+			if r := mapRef(ref); r != nil {
+				result = append(result, r)
+			}
+		}
+		return result
+	}
+	mapTraits := func(traits *NodeValue) *NodeValue {
+		if traits == nil || !traits.IsObject() {
+			return traits
+		}
+		newTraits := NewNodeValue()
+		for _, k := range traits.Keys() {
+			tk := k
+			if !strings.HasPrefix(k, "smithy.api#") {
+				tk = fn(k)
+			}
+			if tk != "" {
+				newTraits.Put(tk, traits.Get(k))
+			}
+		}
+		return newTraits
+	}
+	for _, id := range ast.Shapes.Keys() {
+		shape := ast.GetShape(id)
+		shape.Traits = mapTraits(shape.Traits)
+		for _, m := range []*Member{shape.Member, shape.Key, shape.Value} {
+			if m != nil {
+				if t := fn(m.Target); t != "" {
+					m.Target = t
+				}
+				m.Traits = mapTraits(m.Traits)
+			}
+		}
+		if shape.Members != nil {
+			for _, k := range shape.Members.Keys() {
+				m := shape.Members.Get(k)
+				if m.Target == "smithy.api#Unit" {
+					continue
+				}
+				t := fn(m.Target)
+				if t == "" {
+					shape.Members.Delete(k)
+				} else {
+					m.Target = t
+					m.Traits = mapTraits(m.Traits)
+				}
+			}
+		}
+		if shape.Identifiers != nil {
+			for _, k := range shape.Identifiers.Keys() {
+				if mapRef(shape.Identifiers.Get(k)) == nil {
+					shape.Identifiers.Delete(k)
+				}
+			}
+		}
+		shape.Mixins = mapRefs(shape.Mixins)
+		shape.Create = mapRef(shape.Create)
+		shape.Put = mapRef(shape.Put)
+		shape.Read = mapRef(shape.Read)
+		shape.Update = mapRef(shape.Update)
+		shape.Delete = mapRef(shape.Delete)
+		shape.List = mapRef(shape.List)
+		shape.CollectionOperations = mapRefs(shape.CollectionOperations)
+		shape.Operations = mapRefs(shape.Operations)
+		shape.Resources = mapRefs(shape.Resources)
+		shape.Input = mapRef(shape.Input)
+		shape.Output = mapRef(shape.Output)
+		shape.Errors = mapRefs(shape.Errors)
+	}
+}
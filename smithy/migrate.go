@@ -0,0 +1,911 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package smithy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/boynton/api/model"
+	"github.com/boynton/data"
+)
+
+// Migrator rewrites Smithy IDL 1.0 source files as IDL 2.0. Unlike the IdlGenerator, it edits the original source
+// text in place, so comments and the layout of each file are preserved. The following changes are made:
+//
+//   - the $version control statement is set to "2.0"
+//   - set shapes become list shapes with the @uniqueItems trait
+//   - @box is removed, and the implicit zero value of unboxed primitives becomes an explicit default
+//   - string shapes with the @enum trait become enum shapes
+//   - operation input and output structures used nowhere else are inlined with the := syntax
+type Migrator struct {
+	model.BaseGenerator
+	files    []*migrationFile
+	refs     map[string]int
+	unboxed  map[string]string
+	inlined  map[string]bool
+	assembly *AST
+}
+
+type migrationFile struct {
+	path      string
+	src       []rune
+	tokens    []*migrationToken
+	version   *migrationToken
+	namespace string
+	use       map[string]string
+	applied   map[string]bool
+	shapes    []*migrationShape
+	edits     []*migrationEdit
+}
+
+type migrationToken struct {
+	Token
+	offset int
+	end    int
+}
+
+type migrationTrait struct {
+	name     string
+	start    int
+	end      int
+	argStart int
+	argEnd   int
+}
+
+type migrationShape struct {
+	keyword   *migrationToken
+	name      string
+	nameEnd   int
+	start     int
+	docs      []*migrationToken
+	traits    []*migrationTrait
+	open      int //token index of the body's open brace, or -1
+	close     int //token index of the body's close brace, or -1
+	members   []*migrationMember
+	input     *migrationMember
+	output    *migrationMember
+	separator *migrationToken //the colon of an operation's input or output
+}
+
+type migrationMember struct {
+	name        string
+	traits      []*migrationTrait
+	colon       *migrationToken
+	target      string
+	targetStart int
+	targetEnd   int
+	hasDefault  bool
+}
+
+type migrationEdit struct {
+	start int
+	end   int
+	text  string
+}
+
+// Migrate rewrites each of the .smithy files in paths, writing the results to the configured output directory
+// with the same file names, or to stdout.
+func (gen *Migrator) Migrate(paths []string, config *data.Object) error {
+	err := gen.Configure(nil, config)
+	if err != nil {
+		return err
+	}
+	gen.assembly, err = Assemble(paths)
+	if err != nil {
+		return err
+	}
+	gen.refs = make(map[string]int, 0)
+	gen.assembly.mapReferences(func(target string) string {
+		gen.refs[target] = gen.refs[target] + 1
+		return target
+	})
+	gen.unboxed = make(map[string]string, 0)
+	gen.inlined = make(map[string]bool, 0)
+	for _, path := range paths {
+		if filepath.Ext(path) != ".smithy" {
+			continue
+		}
+		file, err := gen.scanFile(path)
+		if err != nil {
+			return err
+		}
+		gen.files = append(gen.files, file)
+	}
+	for _, file := range gen.files {
+		if file.isVersion1() {
+			file.noteUnboxed(gen.unboxed)
+		}
+	}
+	for i, file := range gen.files {
+		text := string(file.src)
+		if file.isVersion1() {
+			text, err = gen.migrateFile(file)
+			if err != nil {
+				return err
+			}
+		} else {
+			model.Warning("%s is already Smithy IDL 2, not modified\n", file.path)
+		}
+		separator := ""
+		if i > 0 {
+			separator = "\n"
+		}
+		gen.Write(text, filepath.Base(file.path), separator)
+	}
+	return gen.Err
+}
+
+func (gen *Migrator) scanFile(path string) (*migrationFile, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := &migrationFile{
+		path:    path,
+		src:     []rune(string(b)),
+		use:     make(map[string]string, 0),
+		applied: make(map[string]bool, 0),
+	}
+	lineStarts := []int{0}
+	for i, ch := range file.src {
+		if ch == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	scanner := NewScanner(strings.NewReader(string(b)))
+	for {
+		tok := scanner.Scan()
+		if tok.Type == EOF {
+			break
+		}
+		if tok.Type == UNDEFINED {
+			return nil, fmt.Errorf("%s:%d:%d: %s", path, tok.Line, tok.Start, tok.Text)
+		}
+		mtok := &migrationToken{Token: tok}
+		mtok.offset = lineStarts[tok.Line-1] + tok.Start - 1
+		mtok.end = file.tokenEnd(mtok)
+		file.tokens = append(file.tokens, mtok)
+	}
+	err = file.scanStatements()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return file, nil
+}
+
+func (file *migrationFile) tokenEnd(tok *migrationToken) int {
+	src := file.src
+	switch tok.Type {
+	case SYMBOL, NUMBER:
+		return tok.offset + len([]rune(tok.Text))
+	case LINE_COMMENT, NEWLINE:
+		i := tok.offset
+		for i < len(src) && src[i] != '\n' {
+			i++
+		}
+		if tok.Type == NEWLINE && i < len(src) {
+			i++
+		}
+		return i
+	case BLOCK_COMMENT:
+		i := tok.offset + 2
+		for i+1 < len(src) && !(src[i] == '*' && src[i+1] == '/') {
+			i++
+		}
+		return i + 2
+	case STRING:
+		if strings.HasPrefix(string(src[tok.offset:]), `"""`) {
+			n := strings.Index(string(src[tok.offset+3:]), `"""`)
+			return tok.offset + 3 + len([]rune(string(src[tok.offset+3:])[:n])) + 3
+		}
+		i := tok.offset + 1
+		for i < len(src) && src[i] != '"' {
+			if src[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		return i + 1
+	}
+	return tok.offset + 1
+}
+
+func (file *migrationFile) isVersion1() bool {
+	return file.version == nil || strings.HasPrefix(file.version.Text, "1")
+}
+
+// skipNewlines returns the index of the next token that is not a newline or comment.
+func (file *migrationFile) skipNewlines(i int) int {
+	for i < len(file.tokens) {
+		switch file.tokens[i].Type {
+		case NEWLINE, LINE_COMMENT, BLOCK_COMMENT:
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// matching returns the index of the token closing the bracket at index i.
+func (file *migrationFile) matching(i int) (int, error) {
+	open := file.tokens[i].Type
+	var close TokenType
+	switch open {
+	case OPEN_BRACE:
+		close = CLOSE_BRACE
+	case OPEN_BRACKET:
+		close = CLOSE_BRACKET
+	case OPEN_PAREN:
+		close = CLOSE_PAREN
+	}
+	depth := 0
+	for j := i; j < len(file.tokens); j++ {
+		switch file.tokens[j].Type {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return j, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("line %d: unbalanced %q", file.tokens[i].Line, file.tokens[i].Text)
+}
+
+// shapeId reads a possibly namespaced shape id starting at index i, returning its text and the index following it.
+func (file *migrationFile) shapeId(i int) (string, int, error) {
+	i = file.skipNewlines(i)
+	if i >= len(file.tokens) || file.tokens[i].Type != SYMBOL {
+		return "", i, file.syntaxError(i)
+	}
+	id := file.tokens[i].Text
+	i++
+	for i+1 < len(file.tokens) {
+		t := file.tokens[i].Type
+		if (t == DOT || t == HASH || t == DOLLAR) && file.tokens[i+1].Type == SYMBOL && file.tokens[i].offset == file.tokens[i-1].end {
+			id = id + file.tokens[i].Text + file.tokens[i+1].Text
+			i += 2
+		} else {
+			break
+		}
+	}
+	return id, i, nil
+}
+
+// skipValue returns the index following the node value or shape reference at index i.
+func (file *migrationFile) skipValue(i int) (int, error) {
+	i = file.skipNewlines(i)
+	if i >= len(file.tokens) {
+		return i, file.syntaxError(i)
+	}
+	switch file.tokens[i].Type {
+	case OPEN_BRACE, OPEN_BRACKET:
+		j, err := file.matching(i)
+		return j + 1, err
+	case SYMBOL:
+		_, j, err := file.shapeId(i)
+		return j, err
+	}
+	return i + 1, nil
+}
+
+func (file *migrationFile) syntaxError(i int) error {
+	if i >= len(file.tokens) {
+		return fmt.Errorf("Unexpected end of file")
+	}
+	tok := file.tokens[i]
+	return fmt.Errorf("line %d: syntax error at %q", tok.Line, tok.Text)
+}
+
+// trait reads the trait whose @ is at index i.
+func (file *migrationFile) trait(i int) (*migrationTrait, int, error) {
+	tr := &migrationTrait{
+		start:    file.tokens[i].offset,
+		argStart: -1,
+	}
+	name, j, err := file.shapeId(i + 1)
+	if err != nil {
+		return nil, j, err
+	}
+	tr.name = name
+	tr.end = file.tokens[j-1].end
+	if j < len(file.tokens) && file.tokens[j].Type == OPEN_PAREN {
+		k, err := file.matching(j)
+		if err != nil {
+			return nil, k, err
+		}
+		tr.argStart = file.tokens[j].end
+		tr.argEnd = file.tokens[k].offset
+		tr.end = file.tokens[k].end
+		j = k + 1
+	}
+	return tr, j, nil
+}
+
+func (file *migrationFile) scanStatements() error {
+	var docs []*migrationToken
+	var traits []*migrationTrait
+	start := -1
+	for i := 0; i < len(file.tokens); {
+		tok := file.tokens[i]
+		switch tok.Type {
+		case NEWLINE, SEMICOLON, BLOCK_COMMENT:
+			i++
+		case LINE_COMMENT:
+			if strings.HasPrefix(tok.Text, "/") {
+				if start < 0 {
+					start = tok.offset
+				}
+				docs = append(docs, tok)
+			}
+			i++
+		case AT:
+			tr, j, err := file.trait(i)
+			if err != nil {
+				return err
+			}
+			if start < 0 {
+				start = tok.offset
+			}
+			traits = append(traits, tr)
+			i = j
+		case DOLLAR:
+			name, j, err := file.shapeId(i + 1)
+			if err != nil {
+				return err
+			}
+			j = file.skipNewlines(j)
+			if j >= len(file.tokens) || file.tokens[j].Type != COLON {
+				return file.syntaxError(j)
+			}
+			j = file.skipNewlines(j + 1)
+			if name == "version" && j < len(file.tokens) && file.tokens[j].Type == STRING {
+				file.version = file.tokens[j]
+			}
+			i, err = file.skipValue(j)
+			if err != nil {
+				return err
+			}
+		case SYMBOL:
+			var err error
+			i, err = file.scanStatement(i, start, docs, traits)
+			if err != nil {
+				return err
+			}
+			docs = nil
+			traits = nil
+			start = -1
+		default:
+			return file.syntaxError(i)
+		}
+	}
+	return nil
+}
+
+func (file *migrationFile) scanStatement(i int, start int, docs []*migrationToken, traits []*migrationTrait) (int, error) {
+	tok := file.tokens[i]
+	switch tok.Text {
+	case "namespace":
+		ns, j, err := file.shapeId(i + 1)
+		file.namespace = ns
+		return j, err
+	case "use":
+		id, j, err := file.shapeId(i + 1)
+		file.use[StripNamespace(id)] = id
+		return j, err
+	case "metadata":
+		_, j, err := file.shapeId(i + 1)
+		if err != nil {
+			return j, err
+		}
+		j = file.skipNewlines(j)
+		if j >= len(file.tokens) || file.tokens[j].Type != EQUALS {
+			return j, file.syntaxError(j)
+		}
+		return file.skipValue(j + 1)
+	case "apply":
+		id, j, err := file.shapeId(i + 1)
+		if err != nil {
+			return j, err
+		}
+		file.applied[file.absoluteId(id)] = true
+		j = file.skipNewlines(j)
+		if j < len(file.tokens) && file.tokens[j].Type == OPEN_BRACE {
+			return file.skipValue(j)
+		}
+		if j >= len(file.tokens) || file.tokens[j].Type != AT {
+			return j, file.syntaxError(j)
+		}
+		_, j, err = file.trait(j)
+		return j, err
+	}
+	name, j, err := file.shapeId(i + 1)
+	if err != nil {
+		return j, err
+	}
+	if start < 0 {
+		start = tok.offset
+	}
+	shape := &migrationShape{
+		keyword: tok,
+		name:    name,
+		nameEnd: file.tokens[j-1].end,
+		start:   start,
+		docs:    docs,
+		traits:  traits,
+		open:    -1,
+		close:   -1,
+	}
+	file.shapes = append(file.shapes, shape)
+	k := file.skipNewlines(j)
+	if k < len(file.tokens) && file.tokens[k].Type == SYMBOL && (file.tokens[k].Text == "with" || file.tokens[k].Text == "for") {
+		k, err = file.skipValue(k + 1)
+		if err != nil {
+			return k, err
+		}
+		k = file.skipNewlines(k)
+	}
+	if k >= len(file.tokens) || file.tokens[k].Type != OPEN_BRACE {
+		return j, nil
+	}
+	shape.open = k
+	shape.close, err = file.matching(k)
+	if err != nil {
+		return k, err
+	}
+	switch tok.Text {
+	case "structure", "union":
+		err = file.scanMembers(shape)
+	case "operation":
+		err = file.scanOperation(shape)
+	}
+	return shape.close + 1, err
+}
+
+func (file *migrationFile) scanMembers(shape *migrationShape) error {
+	var traits []*migrationTrait
+	for i := shape.open + 1; i < shape.close; {
+		tok := file.tokens[i]
+		switch tok.Type {
+		case AT:
+			tr, j, err := file.trait(i)
+			if err != nil {
+				return err
+			}
+			traits = append(traits, tr)
+			i = j
+		case SYMBOL:
+			mem := &migrationMember{
+				name:   tok.Text,
+				traits: traits,
+			}
+			traits = nil
+			j := file.skipNewlines(i + 1)
+			if j >= shape.close || file.tokens[j].Type != COLON {
+				return file.syntaxError(j)
+			}
+			mem.colon = file.tokens[j]
+			target, k, err := file.shapeId(j + 1)
+			if err != nil {
+				return err
+			}
+			mem.target = target
+			mem.targetStart = file.tokens[file.skipNewlines(j+1)].offset
+			mem.targetEnd = file.tokens[k-1].end
+			if k < shape.close && file.tokens[k].Type == EQUALS {
+				mem.hasDefault = true
+				k, err = file.skipValue(k + 1)
+				if err != nil {
+					return err
+				}
+			}
+			shape.members = append(shape.members, mem)
+			i = k
+		default:
+			i++
+		}
+	}
+	return nil
+}
+
+func (file *migrationFile) scanOperation(shape *migrationShape) error {
+	for i := shape.open + 1; i < shape.close; {
+		tok := file.tokens[i]
+		if tok.Type != SYMBOL {
+			i++
+			continue
+		}
+		j := file.skipNewlines(i + 1)
+		if j >= shape.close || file.tokens[j].Type != COLON {
+			return file.syntaxError(j)
+		}
+		k := file.skipNewlines(j + 1)
+		if k < shape.close && file.tokens[k].Type == EQUALS {
+			//an IDL 2 inline structure, possibly with traits and mixins
+			k = file.skipNewlines(k + 1)
+			for k < shape.close && file.tokens[k].Type != OPEN_BRACE {
+				k++
+			}
+		}
+		if (tok.Text == "input" || tok.Text == "output") && file.tokens[k].Type == SYMBOL {
+			target, next, err := file.shapeId(k)
+			if err != nil {
+				return err
+			}
+			mem := &migrationMember{
+				name:        tok.Text,
+				colon:       file.tokens[j],
+				target:      target,
+				targetStart: file.tokens[k].offset,
+				targetEnd:   file.tokens[next-1].end,
+			}
+			if tok.Text == "input" {
+				shape.input = mem
+			} else {
+				shape.output = mem
+			}
+			i = next
+			continue
+		}
+		var err error
+		i, err = file.skipValue(k)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (file *migrationFile) absoluteId(name string) string {
+	if strings.Index(name, "#") >= 0 {
+		return name
+	}
+	if id, ok := file.use[name]; ok {
+		return id
+	}
+	if IsPreludeType(name) || strings.HasPrefix(name, "Primitive") {
+		return "smithy.api#" + name
+	}
+	return file.namespace + "#" + name
+}
+
+func (shape *migrationShape) trait(name string) *migrationTrait {
+	return findTrait(shape.traits, name)
+}
+
+func (mem *migrationMember) trait(name string) *migrationTrait {
+	return findTrait(mem.traits, name)
+}
+
+func findTrait(traits []*migrationTrait, name string) *migrationTrait {
+	for _, tr := range traits {
+		if tr.name == name || tr.name == "smithy.api#"+name {
+			return tr
+		}
+	}
+	return nil
+}
+
+func primitiveDefault(shapeType string) string {
+	switch shapeType {
+	case "boolean":
+		return "false"
+	case "byte", "short", "integer", "long", "float", "double":
+		return "0"
+	}
+	return ""
+}
+
+// noteUnboxed records the primitive shapes of a 1.0 file that have an implicit zero value.
+func (file *migrationFile) noteUnboxed(unboxed map[string]string) {
+	for _, shape := range file.shapes {
+		dflt := primitiveDefault(shape.keyword.Text)
+		if dflt != "" && shape.trait("box") == nil && shape.trait("enum") == nil {
+			unboxed[file.absoluteId(shape.name)] = dflt
+		}
+	}
+}
+
+func (file *migrationFile) edit(start int, end int, text string) {
+	file.edits = append(file.edits, &migrationEdit{start: start, end: end, text: text})
+}
+
+func (file *migrationFile) lineStart(offset int) int {
+	for offset > 0 && file.src[offset-1] != '\n' {
+		offset--
+	}
+	return offset
+}
+
+func (file *migrationFile) lineEnd(offset int) int {
+	for offset < len(file.src) && file.src[offset] != '\n' {
+		offset++
+	}
+	if offset < len(file.src) {
+		offset++
+	}
+	return offset
+}
+
+func (file *migrationFile) indentation(offset int) string {
+	start := file.lineStart(offset)
+	end := start
+	for end < offset && (file.src[end] == ' ' || file.src[end] == '\t') {
+		end++
+	}
+	return string(file.src[start:end])
+}
+
+func (file *migrationFile) isBlank(start int, end int) bool {
+	return strings.TrimSpace(string(file.src[start:end])) == ""
+}
+
+// removeTrait deletes a trait, along with its line if nothing else is on it.
+func (file *migrationFile) removeTrait(tr *migrationTrait) {
+	lstart := file.lineStart(tr.start)
+	lend := file.lineEnd(tr.end)
+	if file.isBlank(lstart, tr.start) && file.isBlank(tr.end, lend) {
+		file.edit(lstart, lend, "")
+		return
+	}
+	end := tr.end
+	for end < len(file.src) && file.src[end] == ' ' {
+		end++
+	}
+	file.edit(tr.start, end, "")
+}
+
+// replaceTrait replaces a trait with the given text, preserving its position.
+func (file *migrationFile) replaceTrait(tr *migrationTrait, text string) {
+	file.edit(tr.start, tr.end, text)
+}
+
+// apply returns the source text in the given range with the edits within it applied. Edits that overlap
+// an earlier edit, such as those inside a shape that has been removed, are skipped.
+func (file *migrationFile) apply(start int, end int) string {
+	edits := make([]*migrationEdit, 0, len(file.edits))
+	for _, e := range file.edits {
+		if e.start >= start && e.end <= end {
+			edits = append(edits, e)
+		}
+	}
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].start < edits[j].start
+	})
+	var buf strings.Builder
+	cursor := start
+	for _, e := range edits {
+		if e.start < cursor {
+			continue
+		}
+		buf.WriteString(string(file.src[cursor:e.start]))
+		buf.WriteString(e.text)
+		cursor = e.end
+	}
+	buf.WriteString(string(file.src[cursor:end]))
+	return buf.String()
+}
+
+func (gen *Migrator) migrateFile(file *migrationFile) (string, error) {
+	if file.version != nil {
+		file.edit(file.version.offset, file.version.end, `"2.0"`)
+	} else {
+		file.edit(0, 0, "$version: \"2.0\"\n\n")
+	}
+	for _, shape := range file.shapes {
+		err := gen.migrateShape(file, shape)
+		if err != nil {
+			return "", fmt.Errorf("%s: %s: %v", file.path, shape.name, err)
+		}
+	}
+	byName := make(map[string]*migrationShape, 0)
+	for _, shape := range file.shapes {
+		byName[file.absoluteId(shape.name)] = shape
+	}
+	for _, shape := range file.shapes {
+		if shape.keyword.Text == "operation" {
+			gen.inlineStructure(file, shape, shape.input, "Input", byName)
+			gen.inlineStructure(file, shape, shape.output, "Output", byName)
+		}
+	}
+	return file.apply(0, len(file.src)), nil
+}
+
+func (gen *Migrator) migrateShape(file *migrationFile, shape *migrationShape) error {
+	kw := shape.keyword
+	indent := file.indentation(kw.offset)
+	if tr := shape.trait("box"); tr != nil {
+		file.removeTrait(tr)
+	}
+	switch kw.Text {
+	case "set":
+		file.edit(kw.offset, kw.end, "@uniqueItems\n"+indent+"list")
+	case "string":
+		if tr := shape.trait("enum"); tr != nil {
+			text, err := gen.enumShape(file, shape, tr, indent)
+			if err != nil {
+				return err
+			}
+			file.removeTrait(tr)
+			file.edit(kw.offset, shape.nameEnd, text)
+		}
+	case "structure", "union":
+		for _, mem := range shape.members {
+			gen.migrateMember(file, mem, kw.Text == "union")
+		}
+	default:
+		if dflt, ok := gen.unboxed[file.absoluteId(shape.name)]; ok && shape.trait("default") == nil {
+			file.edit(kw.offset, kw.offset, "@default("+dflt+")\n"+indent)
+		}
+	}
+	return nil
+}
+
+func (gen *Migrator) migrateMember(file *migrationFile, mem *migrationMember, isUnion bool) {
+	target := file.absoluteId(mem.target)
+	boxed := mem.trait("box")
+	dflt := ""
+	if strings.HasPrefix(target, "smithy.api#Primitive") {
+		//the deprecated prelude primitives are replaced by their boxed equivalents with an explicit default
+		name := strings.TrimPrefix(StripNamespace(target), "Primitive")
+		file.edit(mem.targetStart, mem.targetEnd, name)
+		dflt = primitiveDefault(Uncapitalize(name))
+		if boxed != nil {
+			file.removeTrait(boxed)
+			dflt = ""
+		}
+	} else if d, ok := gen.unboxed[target]; ok {
+		dflt = d
+		if boxed != nil {
+			file.replaceTrait(boxed, "@default(null)")
+			dflt = ""
+		}
+	} else if boxed != nil {
+		file.removeTrait(boxed)
+	}
+	if dflt != "" && !isUnion && !mem.hasDefault && mem.trait("required") == nil && mem.trait("default") == nil {
+		file.edit(mem.targetEnd, mem.targetEnd, " = "+dflt)
+	}
+}
+
+func (gen *Migrator) enumShape(file *migrationFile, shape *migrationShape, tr *migrationTrait, indent string) (string, error) {
+	if tr.argStart < 0 {
+		return "", fmt.Errorf("@enum trait has no values")
+	}
+	p := &Parser{
+		scanner: NewScanner(strings.NewReader(string(file.src[tr.argStart:tr.argEnd]))),
+		path:    file.path,
+	}
+	v, err := p.parseLiteralValue()
+	if err != nil {
+		return "", err
+	}
+	items, ok := v.([]interface{})
+	if !ok {
+		return "", fmt.Errorf("@enum trait value is not a list")
+	}
+	var buf strings.Builder
+	buf.WriteString("enum " + shape.name + " {\n")
+	mindent := indent + IndentAmount
+	for _, item := range items {
+		e := AsNodeValue(item)
+		value := e.GetString("value")
+		name := e.GetString("name")
+		if name == "" {
+			name = enumElementName(value)
+		}
+		if doc := e.GetString("documentation"); doc != "" {
+			for _, line := range strings.Split(doc, "\n") {
+				buf.WriteString(strings.TrimRight(mindent+"/// "+line, " ") + "\n")
+			}
+		}
+		if tags := e.GetStringSlice("tags"); len(tags) > 0 {
+			buf.WriteString(mindent + "@tags([" + strings.Join(quoteStrings(tags), ", ") + "])\n")
+		}
+		if e.GetBool("deprecated") {
+			buf.WriteString(mindent + "@deprecated\n")
+		}
+		if name == value {
+			buf.WriteString(mindent + name + "\n")
+		} else {
+			buf.WriteString(fmt.Sprintf("%s%s = %q\n", mindent, name, value))
+		}
+	}
+	buf.WriteString(indent + "}")
+	return buf.String(), nil
+}
+
+// enumElementName derives an enum member name for an @enum value that has none.
+func enumElementName(value string) string {
+	var buf strings.Builder
+	for i, ch := range value {
+		if IsLetter(ch) || (i > 0 && IsDigit(ch)) || (i > 0 && ch == '_') {
+			buf.WriteRune(ch)
+		} else {
+			if i == 0 && IsDigit(ch) {
+				buf.WriteString("_")
+				buf.WriteRune(ch)
+			} else {
+				buf.WriteString("_")
+			}
+		}
+	}
+	name := buf.String()
+	if name == value {
+		return name
+	}
+	return strings.ToUpper(name)
+}
+
+func quoteStrings(lst []string) []string {
+	var result []string
+	for _, s := range lst {
+		result = append(result, fmt.Sprintf("%q", s))
+	}
+	return result
+}
+
+// inlineStructure replaces an operation's reference to its input or output structure with the structure itself,
+// if that structure is defined in the same file, named by the IDL 2 convention, and used nowhere else.
+func (gen *Migrator) inlineStructure(file *migrationFile, op *migrationShape, ref *migrationMember, suffix string, byName map[string]*migrationShape) {
+	if ref == nil {
+		return
+	}
+	target := file.absoluteId(ref.target)
+	if target != file.absoluteId(op.name)+suffix || gen.refs[target] != 1 || file.applied[target] || gen.inlined[target] {
+		return
+	}
+	shape, ok := byName[target]
+	if !ok || shape.keyword.Text != "structure" || shape.open < 0 {
+		return
+	}
+	for _, tr := range shape.traits {
+		if tr.name != "input" && tr.name != "output" && tr.name != "smithy.api#input" && tr.name != "smithy.api#output" {
+			return
+		}
+	}
+	gen.inlined[target] = true
+	indent := file.indentation(ref.colon.offset)
+	body := strings.TrimLeft(file.apply(shape.nameEnd, file.tokens[shape.close].end), " \t")
+	lines := strings.Split(body, "\n")
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != "" {
+			lines[i] = indent + lines[i]
+		}
+	}
+	file.edit(ref.colon.offset, ref.targetEnd, " := "+strings.Join(lines, "\n"))
+	if len(shape.docs) > 0 {
+		//doc comments are not permitted on inline structures, keep them as ordinary comments
+		var buf strings.Builder
+		for _, doc := range shape.docs {
+			buf.WriteString(indent + "//" + doc.Text[1:] + "\n")
+		}
+		lstart := file.lineStart(ref.colon.offset)
+		file.edit(lstart, lstart, buf.String())
+	}
+	start := file.lineStart(shape.start)
+	end := file.lineEnd(file.tokens[shape.close].end)
+	if end < len(file.src) && file.isBlank(end, file.lineEnd(end)) && (start == 0 || file.isBlank(file.lineStart(start-1), start)) {
+		end = file.lineEnd(end)
+	}
+	file.edit(start, end, "")
+}
@@ -0,0 +1,693 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package smithy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Selector is a compiled Smithy selector. Only a subset of the selector language is supported: shape type
+// selectors (including number, simpleType and collection), attribute selectors on id, service and trait
+// values, the :not, :is and :test functions, and the >, ~> and -[rel]-> neighbor traversals. Variables and
+// scoped attribute selectors are not supported.
+type Selector struct {
+	text  string
+	steps []*selectorStep
+}
+
+type selectorStep struct {
+	filter    func(idx *shapeIndex, node *shapeNode) bool
+	rels      []string
+	recursive bool
+}
+
+// shapeIndex presents the shapes of an AST, and their members, as the nodes of a graph that selectors traverse.
+type shapeIndex struct {
+	ast   *AST
+	nodes []*shapeNode
+	byId  map[string]*shapeNode
+}
+
+type shapeNode struct {
+	id     string
+	typ    string
+	traits *NodeValue
+	shape  *Shape
+	member *Member
+}
+
+type shapeRel struct {
+	rel  string
+	node *shapeNode
+}
+
+func CompileSelector(text string) (*Selector, error) {
+	p := &selectorParser{src: []rune(text)}
+	steps, err := p.parseSelector()
+	if err == nil && p.pos < len(p.src) {
+		err = p.error("unexpected character")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Selector{text: text, steps: steps}, nil
+}
+
+func (sel *Selector) String() string {
+	return sel.text
+}
+
+// Select returns the ids of the shapes and members in the AST that match the selector.
+func (sel *Selector) Select(ast *AST) []string {
+	idx := newShapeIndex(ast)
+	var ids []string
+	for _, node := range idx.eval(sel.steps, idx.nodes) {
+		ids = append(ids, node.id)
+	}
+	return ids
+}
+
+func newShapeIndex(ast *AST) *shapeIndex {
+	idx := &shapeIndex{
+		ast:  ast,
+		byId: make(map[string]*shapeNode, 0),
+	}
+	for _, id := range ast.Shapes.Keys() {
+		shape := ast.GetShape(id)
+		if shape == nil || shape.Type == "apply" {
+			continue
+		}
+		node := &shapeNode{id: id, typ: shape.Type, traits: shape.Traits, shape: shape}
+		idx.add(node)
+		for _, name := range shapeMemberNames(shape) {
+			mem := shapeMember(shape, name)
+			idx.add(&shapeNode{id: id + "$" + name, typ: "member", traits: mem.Traits, member: mem})
+		}
+	}
+	return idx
+}
+
+func shapeMemberNames(shape *Shape) []string {
+	var names []string
+	if shape.Member != nil {
+		names = append(names, "member")
+	}
+	if shape.Key != nil {
+		names = append(names, "key")
+	}
+	if shape.Value != nil {
+		names = append(names, "value")
+	}
+	if shape.Members != nil {
+		names = append(names, shape.Members.Keys()...)
+	}
+	return names
+}
+
+func shapeMember(shape *Shape, name string) *Member {
+	if shape.Members != nil && shape.Members.Has(name) {
+		return shape.Members.Get(name)
+	}
+	switch name {
+	case "member":
+		return shape.Member
+	case "key":
+		return shape.Key
+	case "value":
+		return shape.Value
+	}
+	return nil
+}
+
+func (idx *shapeIndex) add(node *shapeNode) {
+	idx.nodes = append(idx.nodes, node)
+	idx.byId[node.id] = node
+}
+
+// lookup finds the node for a shape id. Prelude shapes are not in the AST, so nodes are made for them as needed.
+func (idx *shapeIndex) lookup(id string) *shapeNode {
+	if node, ok := idx.byId[id]; ok {
+		return node
+	}
+	if !strings.HasPrefix(id, "smithy.api#") {
+		return nil
+	}
+	name := strings.TrimPrefix(StripNamespace(id), "Primitive")
+	typ := Uncapitalize(name)
+	switch name {
+	case "BigInteger", "BigDecimal":
+		typ = "big" + name[3:]
+	case "Unit":
+		typ = "structure"
+	}
+	node := &shapeNode{id: id, typ: typ}
+	idx.byId[id] = node
+	return node
+}
+
+func (idx *shapeIndex) neighbors(node *shapeNode) []shapeRel {
+	var rels []shapeRel
+	add := func(rel string, target string) {
+		if target == "" {
+			return
+		}
+		if n := idx.lookup(target); n != nil {
+			rels = append(rels, shapeRel{rel: rel, node: n})
+		}
+	}
+	addRef := func(rel string, ref *ShapeRef) {
+		if ref != nil && ref.Target != "smithy.api#Unit" {
+			add(rel, ref.Target)
+		}
+	}
+	addRefs := func(rel string, refs []*ShapeRef) {
+		for _, ref := range refs {
+			addRef(rel, ref)
+		}
+	}
+	if node.member != nil {
+		add("", node.member.Target)
+		return rels
+	}
+	shape := node.shape
+	if shape == nil {
+		return nil
+	}
+	for _, name := range shapeMemberNames(shape) {
+		add("member", node.id+"$"+name)
+	}
+	addRefs("mixin", shape.Mixins)
+	switch shape.Type {
+	case "operation":
+		addRef("input", shape.Input)
+		addRef("output", shape.Output)
+		addRefs("error", shape.Errors)
+	case "service":
+		addRefs("operation", shape.Operations)
+		addRefs("resource", shape.Resources)
+		addRefs("error", shape.Errors)
+	case "resource":
+		if shape.Identifiers != nil {
+			for _, k := range shape.Identifiers.Keys() {
+				addRef("identifier", shape.Identifiers.Get(k))
+			}
+		}
+		addRef("create", shape.Create)
+		addRef("put", shape.Put)
+		addRef("read", shape.Read)
+		addRef("update", shape.Update)
+		addRef("delete", shape.Delete)
+		addRef("list", shape.List)
+		addRefs("operation", shape.Operations)
+		addRefs("collectionOperation", shape.CollectionOperations)
+		addRefs("resource", shape.Resources)
+	}
+	return rels
+}
+
+func (idx *shapeIndex) eval(steps []*selectorStep, start []*shapeNode) []*shapeNode {
+	current := start
+	for _, step := range steps {
+		var next []*shapeNode
+		seen := make(map[*shapeNode]bool, 0)
+		include := func(node *shapeNode) {
+			if !seen[node] {
+				seen[node] = true
+				next = append(next, node)
+			}
+		}
+		if step.filter != nil {
+			for _, node := range current {
+				if step.filter(idx, node) {
+					include(node)
+				}
+			}
+		} else {
+			for _, node := range current {
+				idx.traverse(node, step, include, make(map[*shapeNode]bool, 0))
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+func (idx *shapeIndex) traverse(node *shapeNode, step *selectorStep, include func(*shapeNode), visited map[*shapeNode]bool) {
+	for _, r := range idx.neighbors(node) {
+		if len(step.rels) > 0 && !containsString(step.rels, r.rel) {
+			continue
+		}
+		include(r.node)
+		if step.recursive && !visited[r.node] {
+			visited[r.node] = true
+			idx.traverse(r.node, step, include, visited)
+		}
+	}
+}
+
+// attribute returns the values of an attribute path for the node, and whether the attribute exists at all.
+func (idx *shapeIndex) attribute(node *shapeNode, path []string) ([]string, bool) {
+	switch path[0] {
+	case "id":
+		if len(path) == 1 {
+			return []string{node.id}, true
+		}
+		id := node.id
+		member := ""
+		if i := strings.Index(id, "$"); i >= 0 {
+			member = id[i+1:]
+			id = id[:i]
+		}
+		switch path[1] {
+		case "name":
+			return []string{StripNamespace(id)}, true
+		case "namespace":
+			return []string{shapeIdNamespace(id)}, true
+		case "member":
+			return []string{member}, member != ""
+		}
+	case "service":
+		if node.shape != nil && node.shape.Type == "service" {
+			if len(path) == 1 {
+				return []string{node.id}, true
+			}
+			if path[1] == "version" {
+				return []string{node.shape.Version}, true
+			}
+		}
+	case "trait":
+		v := node.traits.Get(absoluteTraitId(path[1]))
+		if v == nil {
+			return nil, false
+		}
+		values := []*NodeValue{v}
+		for _, key := range path[2:] {
+			var next []*NodeValue
+			for _, val := range values {
+				switch key {
+				case "(keys)":
+					if val.IsObject() {
+						for _, k := range val.Keys() {
+							next = append(next, AsNodeValue(k))
+						}
+					}
+				case "(values)":
+					if val.IsObject() {
+						for _, k := range val.Keys() {
+							next = append(next, val.Get(k))
+						}
+					} else if lst, ok := val.RawValue().([]interface{}); ok {
+						for _, e := range lst {
+							next = append(next, AsNodeValue(e))
+						}
+					}
+				default:
+					if e := val.Get(key); e != nil {
+						next = append(next, e)
+					}
+				}
+			}
+			values = next
+		}
+		if len(values) == 0 {
+			return nil, false
+		}
+		var result []string
+		for _, val := range values {
+			result = append(result, nodeValueString(val))
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+func absoluteTraitId(name string) string {
+	if strings.Index(name, "#") < 0 {
+		return "smithy.api#" + name
+	}
+	return name
+}
+
+func nodeValueString(v *NodeValue) string {
+	switch raw := v.RawValue().(type) {
+	case string:
+		return raw
+	case nil:
+		return ""
+	case bool, float64, int, int64:
+		return fmt.Sprint(raw)
+	}
+	if v.IsObject() {
+		return ""
+	}
+	return jsonEncode(v)
+}
+
+var selectorShapeTypes = map[string][]string{
+	"blob":       {"blob"},
+	"boolean":    {"boolean"},
+	"document":   {"document"},
+	"string":     {"string", "enum"},
+	"byte":       {"byte"},
+	"short":      {"short"},
+	"integer":    {"integer", "intEnum"},
+	"long":       {"long"},
+	"float":      {"float"},
+	"double":     {"double"},
+	"bigInteger": {"bigInteger"},
+	"bigDecimal": {"bigDecimal"},
+	"timestamp":  {"timestamp"},
+	"enum":       {"enum"},
+	"intEnum":    {"intEnum"},
+	"list":       {"list", "set"},
+	"set":        {"set"},
+	"map":        {"map"},
+	"structure":  {"structure"},
+	"union":      {"union"},
+	"service":    {"service"},
+	"operation":  {"operation"},
+	"resource":   {"resource"},
+	"member":     {"member"},
+	"number":     {"byte", "short", "integer", "intEnum", "long", "float", "double", "bigInteger", "bigDecimal"},
+	"simpleType": {"blob", "boolean", "document", "string", "enum", "byte", "short", "integer", "intEnum", "long", "float", "double", "bigInteger", "bigDecimal", "timestamp"},
+	"collection": {"list", "set"},
+}
+
+type selectorParser struct {
+	src []rune
+	pos int
+}
+
+func (p *selectorParser) error(msg string) error {
+	return fmt.Errorf("Bad selector %q at position %d: %s", string(p.src), p.pos, msg)
+}
+
+func (p *selectorParser) skipWhitespace() {
+	for p.pos < len(p.src) {
+		ch := p.src[p.pos]
+		if ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' {
+			p.pos++
+		} else if ch == '/' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '/' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		} else {
+			return
+		}
+	}
+}
+
+func (p *selectorParser) lookingAt(s string) bool {
+	return strings.HasPrefix(string(p.src[p.pos:]), s)
+}
+
+func (p *selectorParser) identifier() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		ch := p.src[p.pos]
+		if IsLetter(ch) || IsDigit(ch) || ch == '_' || ch == '.' || ch == '#' || ch == '$' || ch == '-' && p.pos > start && !p.lookingAt("-[") {
+			p.pos++
+		} else {
+			break
+		}
+	}
+	return string(p.src[start:p.pos])
+}
+
+func (p *selectorParser) parseSelector() ([]*selectorStep, error) {
+	var steps []*selectorStep
+	for {
+		p.skipWhitespace()
+		if p.pos >= len(p.src) || p.src[p.pos] == ')' || p.src[p.pos] == ',' {
+			break
+		}
+		switch {
+		case p.lookingAt(">"):
+			p.pos++
+			steps = append(steps, &selectorStep{})
+		case p.lookingAt("~>"):
+			p.pos += 2
+			steps = append(steps, &selectorStep{recursive: true})
+		case p.lookingAt("-["):
+			p.pos += 2
+			end := strings.Index(string(p.src[p.pos:]), "]->")
+			if end < 0 {
+				return nil, p.error("unterminated relationship")
+			}
+			var rels []string
+			for _, rel := range strings.Split(string(p.src[p.pos:p.pos+end]), ",") {
+				rels = append(rels, strings.TrimSpace(rel))
+			}
+			p.pos += end + 3
+			steps = append(steps, &selectorStep{rels: rels})
+		case p.lookingAt("*"):
+			p.pos++
+			steps = append(steps, &selectorStep{filter: func(idx *shapeIndex, node *shapeNode) bool {
+				return true
+			}})
+		case p.lookingAt("["):
+			step, err := p.parseAttribute()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step)
+		case p.lookingAt(":"):
+			step, err := p.parseFunction()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step)
+		default:
+			name := p.identifier()
+			types, ok := selectorShapeTypes[name]
+			if !ok {
+				return nil, p.error("unsupported shape type " + fmt.Sprintf("%q", name))
+			}
+			steps = append(steps, &selectorStep{filter: func(idx *shapeIndex, node *shapeNode) bool {
+				return containsString(types, node.typ)
+			}})
+		}
+	}
+	return steps, nil
+}
+
+func (p *selectorParser) parseFunction() (*selectorStep, error) {
+	p.pos++
+	name := p.identifier()
+	p.skipWhitespace()
+	if !p.lookingAt("(") {
+		return nil, p.error("expected '('")
+	}
+	p.pos++
+	var args [][]*selectorStep
+	for {
+		steps, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, steps)
+		p.skipWhitespace()
+		if p.lookingAt(",") {
+			p.pos++
+			continue
+		}
+		if p.lookingAt(")") {
+			p.pos++
+			break
+		}
+		return nil, p.error("expected ')'")
+	}
+	matchesAny := func(idx *shapeIndex, node *shapeNode) bool {
+		for _, steps := range args {
+			if len(idx.eval(steps, []*shapeNode{node})) > 0 {
+				return true
+			}
+		}
+		return false
+	}
+	switch name {
+	case "is", "test":
+		return &selectorStep{filter: matchesAny}, nil
+	case "not":
+		return &selectorStep{filter: func(idx *shapeIndex, node *shapeNode) bool {
+			return !matchesAny(idx, node)
+		}}, nil
+	}
+	return nil, p.error("unsupported function :" + name)
+}
+
+func (p *selectorParser) parseAttribute() (*selectorStep, error) {
+	p.pos++
+	p.skipWhitespace()
+	var path []string
+	for {
+		var segment string
+		if p.lookingAt("'") || p.lookingAt("\"") {
+			s, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			segment = s
+		} else if p.lookingAt("(") {
+			end := strings.Index(string(p.src[p.pos:]), ")")
+			if end < 0 {
+				return nil, p.error("unterminated function property")
+			}
+			segment = string(p.src[p.pos : p.pos+end+1])
+			p.pos += end + 1
+		} else {
+			segment = p.identifier()
+		}
+		if segment == "" {
+			return nil, p.error("expected attribute")
+		}
+		path = append(path, segment)
+		if !p.lookingAt("|") {
+			break
+		}
+		p.pos++
+	}
+	switch path[0] {
+	case "id", "service":
+	case "trait":
+		if len(path) < 2 {
+			return nil, p.error("expected a trait name")
+		}
+	default:
+		return nil, p.error("unsupported attribute " + path[0])
+	}
+	p.skipWhitespace()
+	if p.lookingAt("]") {
+		p.pos++
+		return &selectorStep{filter: func(idx *shapeIndex, node *shapeNode) bool {
+			_, exists := idx.attribute(node, path)
+			return exists
+		}}, nil
+	}
+	var comparator string
+	for _, c := range []string{"!=", "^=", "$=", "*=", "?=", ">=", "<=", "=", ">", "<"} {
+		if p.lookingAt(c) {
+			comparator = c
+			p.pos += len(c)
+			break
+		}
+	}
+	if comparator == "" {
+		return nil, p.error("expected a comparator")
+	}
+	var values []string
+	for {
+		p.skipWhitespace()
+		var v string
+		if p.lookingAt("'") || p.lookingAt("\"") {
+			s, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			v = s
+		} else {
+			v = p.identifier()
+			if v == "" {
+				return nil, p.error("expected a value")
+			}
+		}
+		values = append(values, v)
+		p.skipWhitespace()
+		if !p.lookingAt(",") {
+			break
+		}
+		p.pos++
+	}
+	caseInsensitive := false
+	if p.lookingAt("i") {
+		caseInsensitive = true
+		p.pos++
+		p.skipWhitespace()
+	}
+	if !p.lookingAt("]") {
+		return nil, p.error("expected ']'")
+	}
+	p.pos++
+	return &selectorStep{filter: func(idx *shapeIndex, node *shapeNode) bool {
+		actual, exists := idx.attribute(node, path)
+		if comparator == "?=" {
+			return fmt.Sprint(exists) == values[0]
+		}
+		if !exists {
+			return false
+		}
+		for _, a := range actual {
+			for _, v := range values {
+				if compareAttribute(comparator, a, v, caseInsensitive) {
+					return true
+				}
+			}
+		}
+		return false
+	}}, nil
+}
+
+func (p *selectorParser) quoted() (string, error) {
+	quote := p.src[p.pos]
+	p.pos++
+	start := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] != quote {
+		p.pos++
+	}
+	if p.pos >= len(p.src) {
+		return "", p.error("unterminated string")
+	}
+	s := string(p.src[start:p.pos])
+	p.pos++
+	return s, nil
+}
+
+func compareAttribute(comparator, actual, expected string, caseInsensitive bool) bool {
+	if caseInsensitive {
+		actual = strings.ToLower(actual)
+		expected = strings.ToLower(expected)
+	}
+	switch comparator {
+	case "=":
+		return actual == expected
+	case "!=":
+		return actual != expected
+	case "^=":
+		return strings.HasPrefix(actual, expected)
+	case "$=":
+		return strings.HasSuffix(actual, expected)
+	case "*=":
+		return strings.Contains(actual, expected)
+	}
+	a, err1 := strconv.ParseFloat(actual, 64)
+	e, err2 := strconv.ParseFloat(expected, 64)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	switch comparator {
+	case ">":
+		return a > e
+	case ">=":
+		return a >= e
+	case "<":
+		return a < e
+	case "<=":
+		return a <= e
+	}
+	return false
+}
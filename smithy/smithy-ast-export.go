@@ -59,6 +59,7 @@ func (gen *AstGenerator) GenerateResources() (map[string]*Shape, map[model.Absol
 		if rez.Comment != "" {
 			ensureShapeTraits(shape).Put("smithy.api#documentation", rez.Comment)
 		}
+		shape.Traits = withAnnotations(shape.Traits, rez.Annotations)
 		resources[string(rez.Id)] = shape
 		if rez.Create != "" {
 			operations[rez.Create] = true
@@ -133,7 +134,12 @@ func (gen *AstGenerator) EnsureNamespaced(name string) string {
 func (gen *AstGenerator) ToAST() (*AST, error) {
 	ast := &AST{
 		Smithy: "2",
-		//		Metadata: NewNodeValue(),
+	}
+	if len(gen.Schema.Metadata) > 0 {
+		ast.Metadata = NewNodeValue()
+		for k, v := range gen.Schema.Metadata {
+			ast.Metadata.Put(k, clone(v))
+		}
 	}
 	resources, resourceOps, err := gen.GenerateResources()
 	if err != nil {
@@ -159,6 +165,7 @@ func (gen *AstGenerator) ToAST() (*AST, error) {
 		if gen.Schema.Comment != "" {
 			ensureShapeTraits(shape).Put("smithy.api#documentation", gen.Schema.Comment)
 		}
+		shape.Traits = withAnnotations(shape.Traits, gen.Schema.Annotations)
 		for _, k := range resourceKeys {
 			ref := &ShapeRef{
 				Target: gen.EnsureNamespaced(k),
@@ -227,6 +234,7 @@ func (gen *AstGenerator) AddShapesFromOperation(ast *AST, op *model.OperationDef
 	if op.Comment != "" {
 		ensureShapeTraits(shape).Put("smithy.api#documentation", op.Comment)
 	}
+	shape.Traits = withAnnotations(shape.Traits, op.Annotations)
 	switch op.HttpMethod {
 	case "GET":
 		ensureShapeTraits(shape).Put("smithy.api#readonly", NewNodeValue())
@@ -376,11 +384,13 @@ func (gen *AstGenerator) shapeFromOpInput(input *model.OperationInput) (*Shape,
 		if fd.Pattern != "" {
 			ensureMemberTraits(member).Put("smithy.api#pattern", fd.Pattern)
 		}
+		member.Traits = withAnnotations(member.Traits, fd.Annotations)
 		members.Put(string(fd.Name), member)
 	}
 	shape.Members = members
 	ensureShapeTraits(shape).Put("smithy.api#documentation", input.Comment)
 	ensureShapeTraits(shape).Put("smithy.api#input", NewNodeValue())
+	shape.Traits = withAnnotations(shape.Traits, input.Annotations)
 	return shape, nil
 }
 
@@ -408,6 +418,7 @@ func (gen *AstGenerator) shapeFromOpOutput(output *model.OperationOutput, isExce
 		} else if fd.HttpPayload {
 			ensureMemberTraits(member).Put("smithy.api#httpPayload", NewNodeValue())
 		}
+		member.Traits = withAnnotations(member.Traits, fd.Annotations)
 		shape.Members.Put(string(fd.Name), member)
 	}
 	if isException {
@@ -421,6 +432,7 @@ func (gen *AstGenerator) shapeFromOpOutput(output *model.OperationOutput, isExce
 		ensureShapeTraits(shape).Put("smithy.api#output", NewNodeValue())
 	}
 	ensureShapeTraits(shape).Put("smithy.api#documentation", output.Comment)
+	shape.Traits = withAnnotations(shape.Traits, output.Annotations)
 	return shape, nil
 }
 
@@ -455,6 +467,7 @@ func (gen *AstGenerator) ShapeFromType(td *model.TypeDef) (string, *Shape, error
 	if td.Comment != "" {
 		ensureShapeTraits(shape).Put("smithy.api#documentation", td.Comment)
 	}
+	shape.Traits = withAnnotations(shape.Traits, td.Annotations)
 	return id, shape, err
 }
 
@@ -529,6 +542,19 @@ func ensureMemberTraits(member *Member) *NodeValue {
 	return member.Traits
 }
 
+// withAnnotations adds the annotations of a model definition back as traits, in a stable order.
+func withAnnotations(traits *NodeValue, annotations model.Annotations) *NodeValue {
+	var keys []string
+	for k := range annotations {
+		keys = append(keys, string(k))
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		traits = withTrait(traits, k, annotations[model.AbsoluteIdentifier(k)])
+	}
+	return traits
+}
+
 func rangeTrait(min *data.Decimal, max *data.Decimal) *NodeValue {
 	if min == nil && max == nil {
 		return nil
@@ -583,6 +609,8 @@ func typeReference(name string) string {
 		return "smithy.api#List"
 	case "base#Map":
 		return "smithy.api#Map"
+	case "base#Any":
+		return "smithy.api#Document"
 	default:
 		return name
 	}
@@ -600,6 +628,7 @@ func (gen *AstGenerator) ShapeFromEnum(td *model.TypeDef) (string, *Shape, error
 		if el.Value != "" {
 			ensureMemberTraits(mem).Put("smithy.api#enumValue", el.Value)
 		}
+		mem.Traits = withAnnotations(mem.Traits, el.Annotations)
 		shape.Members.Put(string(el.Symbol), mem)
 	}
 	return string(td.Id), shape, nil
@@ -620,9 +649,11 @@ func (gen *AstGenerator) ShapeFromMap(td *model.TypeDef) (string, *Shape, error)
 	shape := &Shape{
 		Type: "map",
 	}
-	itype := typeReference(string(td.Items))
-	shape.Member = &Member{
-		Target: itype,
+	shape.Key = &Member{
+		Target: typeReference(string(td.Keys)),
+	}
+	shape.Value = &Member{
+		Target: typeReference(string(td.Items)),
 	}
 	return string(td.Id), shape, nil
 }
@@ -653,6 +684,7 @@ func (gen *AstGenerator) ShapeFromStruct(td *model.TypeDef) (string, *Shape, err
 		if fd.Required {
 			ensureMemberTraits(member).Put("smithy.api#required", NewNodeValue())
 		}
+		member.Traits = withAnnotations(member.Traits, fd.Annotations)
 		members.Put(string(fd.Name), member)
 	}
 	shape.Members = members
@@ -669,6 +701,7 @@ func (gen *AstGenerator) ShapeFromUnion(td *model.TypeDef) (string, *Shape, erro
 		member := &Member{
 			Target: ftype,
 		}
+		member.Traits = withAnnotations(member.Traits, fd.Annotations)
 		members.Put(string(fd.Name), member)
 	}
 	shape.Members = members
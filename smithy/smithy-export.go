@@ -72,7 +72,6 @@ func (gen *IdlGenerator) Generate(schema *model.Schema, config *data.Object) err
 		return err
 	}
 
-	//fixme: preserve smithy metadata.
 	needsSep := len(ast.Namespaces()) != 1
 	for _, ns := range ast.Namespaces() {
 		fname := gen.FileName(ns, ".smithy")
@@ -11,7 +11,7 @@ func Warning(format string, a ...any) {
 	model.Warning(format, a...)
 }
 
-func Import(paths []string, tags []string, parseOnly bool) (*model.Schema, error) {
+func Import(paths []string, tags []string, parseOnly bool, noValidate bool) (*model.Schema, error) {
 	ast, err := Assemble(paths)
 	if err != nil {
 		return nil, err
@@ -23,7 +23,7 @@ func Import(paths []string, tags []string, parseOnly bool) (*model.Schema, error
 		fmt.Println(model.Pretty(ast))
 		return nil, nil
 	}
-	return ImportAST(ast, tags)
+	return ImportAST(ast, tags, noValidate)
 }
 
 func isTagged(shape *Shape, tags []string) bool {
@@ -41,8 +41,13 @@ func isTagged(shape *Shape, tags []string) bool {
 	return false
 }
 
-func ImportAST(ast *AST, tags []string) (*model.Schema, error) {
-	var err error
+func ImportAST(ast *AST, tags []string, noValidate bool) (*model.Schema, error) {
+	if !noValidate {
+		err := ast.RunValidators()
+		if err != nil {
+			return nil, err
+		}
+	}
 	schema := model.NewSchema()
 	if len(tags) > 0 {
 		ast.Filter(tags)
@@ -58,8 +63,14 @@ func ImportAST(ast *AST, tags []string) (*model.Schema, error) {
 		if base != "" {
 			schema.Base = base
 		}
+		if ast.Metadata.Length() > 0 {
+			schema.Metadata = make(model.Metadata, 0)
+			for _, k := range ast.Metadata.Keys() {
+				schema.Metadata[k] = clone(ast.Metadata.Get(k).RawValue())
+			}
+		}
 	}
-	err = ast.ForAllShapes(func(shapeId string, shape *Shape) error {
+	err := ast.ForAllShapes(func(shapeId string, shape *Shape) error {
 		return importShape(schema, ast, shapeId, shape)
 	})
 	return schema, err
@@ -211,6 +222,7 @@ func addResource(schema *model.Schema, ast *AST, shapeId string, shape *Shape) e
 		List:                 shapeRefToIdentifier(shape.List),
 		Operations:           shapeRefsToIdentifiers(shape.Operations),
 		CollectionOperations: shapeRefsToIdentifiers(shape.CollectionOperations),
+		Annotations:          importAnnotations(shape.Traits),
 	}
 	schema.AddResourceDef(rez)
 	return nil
@@ -223,6 +235,7 @@ func addService(schema *model.Schema, ast *AST, shapeId string, shape *Shape) er
 	schema.Id = model.AbsoluteIdentifier(shapeId)
 	schema.Version = shape.Version
 	schema.Comment = shape.Traits.GetString("smithy.api#documentation")
+	schema.Annotations = importAnnotations(shape.Traits)
 	//TBD: other metadata
 	for _, ref := range shape.Operations { //xxx
 		//		err := addOperationFromRef(schema, ast, ref, "", "")
@@ -248,8 +261,9 @@ func toOpInput(schema *model.Schema, ast *AST, shapeId string) *model.OperationI
 	}
 	//shape.Traits.GetBool("smithy.api#input") should be true
 	ti := &model.OperationInput{
-		Id:      model.AbsoluteIdentifier(shapeId),
-		Comment: shape.Traits.GetString("smithy.api#documentation"),
+		Id:          model.AbsoluteIdentifier(shapeId),
+		Comment:     shape.Traits.GetString("smithy.api#documentation"),
+		Annotations: importAnnotations(shape.Traits),
 	}
 	var payloadContentFields []*model.FieldDef
 	hasPayload := false
@@ -267,17 +281,19 @@ func toOpInput(schema *model.Schema, ast *AST, shapeId string) *model.OperationI
 		}
 		if query == "" && header == "" && !path && !payload {
 			structField := &model.FieldDef{
-				Comment:  "",
-				Name:     model.Identifier(k),
-				Type:     toCanonicalTypeName(mem.Target),
-				Required: mem.Traits.GetBool("smithy.api#required"),
+				Comment:     "",
+				Name:        model.Identifier(k),
+				Type:        toCanonicalTypeName(mem.Target),
+				Required:    mem.Traits.GetBool("smithy.api#required"),
+				Annotations: importAnnotations(mem.Traits),
 			}
 			payloadContentFields = append(payloadContentFields, structField)
 		} else {
 			f := &model.OperationInputField{
-				Name:     model.Identifier(k),
-				Type:     toCanonicalTypeName(mem.Target),
-				Required: mem.Traits.GetBool("smithy.api#required"),
+				Name:        model.Identifier(k),
+				Type:        toCanonicalTypeName(mem.Target),
+				Required:    mem.Traits.GetBool("smithy.api#required"),
+				Annotations: importAnnotations(mem.Traits),
 			}
 			if query != "" {
 				f.HttpQuery = model.Identifier(query)
@@ -347,7 +363,8 @@ func toOpOutput(schema *model.Schema, ast *AST, shapeId string) *model.Operation
 	}
 	//shape.Traits.GetBool("smithy.api#output") should be true
 	to := &model.OperationOutput{
-		Id: model.AbsoluteIdentifier(shapeId),
+		Id:          model.AbsoluteIdentifier(shapeId),
+		Annotations: importAnnotations(shape.Traits),
 	}
 	if shape.Traits != nil {
 		to.Comment = shape.Traits.GetString("smithy.api#documentation")
@@ -363,16 +380,18 @@ func toOpOutput(schema *model.Schema, ast *AST, shapeId string) *model.Operation
 		}
 		if header == "" && !payload {
 			structField := &model.FieldDef{
-				Comment:  "",
-				Name:     model.Identifier(k),
-				Type:     toCanonicalTypeName(mem.Target),
-				Required: mem.Traits.GetBool("smithy.api#required"),
+				Comment:     "",
+				Name:        model.Identifier(k),
+				Type:        toCanonicalTypeName(mem.Target),
+				Required:    mem.Traits.GetBool("smithy.api#required"),
+				Annotations: importAnnotations(mem.Traits),
 			}
 			payloadContentFields = append(payloadContentFields, structField)
 		} else {
 			f := &model.OperationOutputField{
-				Name: model.Identifier(k),
-				Type: toCanonicalTypeName(mem.Target),
+				Name:        model.Identifier(k),
+				Type:        toCanonicalTypeName(mem.Target),
+				Annotations: importAnnotations(mem.Traits),
 			}
 			f.HttpHeader = header
 			f.HttpPayload = payload
@@ -432,8 +451,9 @@ func addOperation(schema *model.Schema, ast *AST, shapeId string, shape *Shape)
 		return nil
 	}
 	op := model.OperationDef{
-		Id:      id,
-		Comment: shape.GetStringTrait("smithy.api#documentation"),
+		Id:          id,
+		Comment:     shape.GetStringTrait("smithy.api#documentation"),
+		Annotations: importAnnotations(shape.Traits),
 	}
 	typesConsumed := make(map[model.AbsoluteIdentifier]bool, 0)
 	if shape.Input != nil && shape.Input.Target != "smithy.api#Unit" {
@@ -526,8 +546,9 @@ func importShape(schema *model.Schema, ast *AST, shapeId string, shape *Shape) e
 		return nil
 	}
 	td := &model.TypeDef{
-		Id:      toCanonicalAbsoluteId(shapeId),
-		Comment: shape.GetStringTrait("smithy.api#documentation"),
+		Id:          toCanonicalAbsoluteId(shapeId),
+		Comment:     shape.GetStringTrait("smithy.api#documentation"),
+		Annotations: importAnnotations(shape.Traits),
 	}
 	number := false
 	switch shape.Type {
@@ -568,10 +589,11 @@ func importShape(schema *model.Schema, ast *AST, shapeId string, shape *Shape) e
 	case "union":
 		td.Base = model.BaseType_Union
 		for _, name := range shape.Members.Keys() {
+			v := shape.Members.Get(name)
 			fd := &model.FieldDef{
-				Name: model.Identifier(name),
+				Name:        model.Identifier(name),
+				Annotations: importAnnotations(v.Traits),
 			}
-			v := shape.Members.Get(name)
 			fd.Type = toCanonicalTypeName(v.Target)
 			if v.Traits != nil {
 				comment := v.GetStringTrait("smithy.api#documentation")
@@ -595,10 +617,11 @@ func importShape(schema *model.Schema, ast *AST, shapeId string, shape *Shape) e
 		} else {
 			td.Base = model.BaseType_Struct
 			for _, name := range shape.Members.Keys() {
+				v := shape.Members.Get(name)
 				fd := &model.FieldDef{
-					Name: model.Identifier(name),
+					Name:        model.Identifier(name),
+					Annotations: importAnnotations(v.Traits),
 				}
-				v := shape.Members.Get(name)
 				if v.Target != "" {
 					fd.Type = toCanonicalTypeName(v.Target)
 				}
@@ -643,10 +666,11 @@ func importShape(schema *model.Schema, ast *AST, shapeId string, shape *Shape) e
 	case "enum":
 		td.Base = model.BaseType_Enum
 		for _, sym := range shape.Members.Keys() {
+			v := shape.Members.Get(sym)
 			el := &model.EnumElement{
-				Symbol: model.Identifier(sym),
+				Symbol:      model.Identifier(sym),
+				Annotations: importAnnotations(v.Traits),
 			}
-			v := shape.Members.Get(sym)
 			if v.Traits != nil {
 				val := v.Traits.GetString("smithy.api#enumValue")
 				if val != "" {
@@ -684,6 +708,25 @@ func importShape(schema *model.Schema, ast *AST, shapeId string, shape *Shape) e
 	return schema.AddTypeDef(td)
 }
 
+// importAnnotations keeps the traits that have no representation in the model, i.e. those outside of the
+// smithy.api namespace, so they can be emitted again on export. The @trait trait is kept as well, so that
+// the shapes defining those traits survive the round trip.
+func importAnnotations(traits *NodeValue) model.Annotations {
+	if traits == nil || !traits.IsObject() {
+		return nil
+	}
+	var annotations model.Annotations
+	for _, k := range traits.Keys() {
+		if k == "smithy.api#trait" || !strings.HasPrefix(k, "smithy.api#") {
+			if annotations == nil {
+				annotations = make(model.Annotations, 0)
+			}
+			annotations[model.AbsoluteIdentifier(k)] = clone(traits.Get(k))
+		}
+	}
+	return annotations
+}
+
 func nameFromId(id string) model.Identifier {
 	l := strings.Split(id, "#")
 	if len(l) == 2 {
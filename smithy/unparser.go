@@ -19,6 +19,7 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/boynton/api/model"
@@ -117,11 +118,14 @@ func (ast *AST) IDL(ns string) string {
 	w.Emit("$version: \"%d\"\n", w.version)
 	emitted := make(map[string]bool, 0)
 
-	if ast.Metadata != nil && ast.Metadata.Length() > 0 {
+	//metadata is model-wide, and would be concatenated if emitted into each namespace's file, so emit it just once
+	if mdns, _, _ := ast.NamespaceAndServiceVersion(); ast.Metadata != nil && ast.Metadata.Length() > 0 && mdns == ns {
 		w.Emit("\n")
-		for _, k := range ast.Metadata.Keys() {
+		keys := ast.Metadata.Keys()
+		sort.Strings(keys)
+		for _, k := range keys {
 			v := ast.Metadata.Get(k)
-			w.Emit("metadata %s = %s", k, data.Pretty(v))
+			w.Emit("metadata %s = %s\n", k, data.Pretty(v))
 		}
 	}
 	w.Emit("\nnamespace %s\n", ns)
@@ -403,6 +407,9 @@ func (w *IdlWriter) EmitRangeTrait(v interface{}, indent string) {
 }
 
 func (w *IdlWriter) EmitTraitTrait(v interface{}) {
+	if nv, ok := v.(*NodeValue); ok {
+		v = nv.RawValue()
+	}
 	l := data.AsMap(v)
 	if l != nil {
 		var lst []string
@@ -424,7 +431,7 @@ func (w *IdlWriter) EmitTraitTrait(v interface{}) {
 		}
 		structurallyExclusive := data.GetString(l, "structurallyExclusive")
 		if structurallyExclusive != "" {
-			lst = append(lst, fmt.Sprintf("selector: %q", structurallyExclusive))
+			lst = append(lst, fmt.Sprintf("structurallyExclusive: %q", structurallyExclusive))
 		}
 		if len(lst) > 0 {
 			w.Emit("@trait(%s)\n", strings.Join(lst, ", "))
@@ -654,13 +661,21 @@ func (w *IdlWriter) EmitTraits(traits *NodeValue, indent string) {
 func (w *IdlWriter) EmitCustomTrait(k string, v interface{}, indent string) {
 	args := ""
 	if m, ok := v.(*NodeValue); ok {
-		if m.Length() > 0 {
+		if !m.IsObject() {
+			if m.RawValue() != nil {
+				args = "(" + data.JsonEncode(m) + ")"
+			}
+		} else if m.Length() > 0 {
 			var lst []string
-			for _, ak := range m.Keys() {
+			keys := m.Keys()
+			sort.Strings(keys)
+			for _, ak := range keys {
 				av := m.Get(ak)
 				lst = append(lst, fmt.Sprintf("%s: %s", ak, data.JsonEncode(av)))
 			}
-			args = "(\n    " + strings.Join(lst, ",\n    ") + ")"
+			//the continuation lines are indented relative to the trait, i.e. when it is applied to a member
+			inner := indent + IndentAmount
+			args = "(\n" + inner + strings.Join(lst, ",\n"+inner) + ")"
 		}
 	}
 	w.Emit("%s@%s%s\n", indent, w.stripNamespace(k), args)
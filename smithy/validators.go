@@ -0,0 +1,391 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package smithy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/boynton/api/model"
+)
+
+const (
+	SeverityNote    = "NOTE"
+	SeverityWarning = "WARNING"
+	SeverityDanger  = "DANGER"
+	SeverityError   = "ERROR"
+)
+
+type ValidationEvent struct {
+	Id       string
+	Severity string
+	ShapeId  string
+	Message  string
+}
+
+func (ev *ValidationEvent) String() string {
+	return fmt.Sprintf("[%s] %s: %s | %s", ev.Severity, ev.ShapeId, ev.Message, ev.Id)
+}
+
+// RunValidators evaluates the built-in trait target check, and the validators declared in the "validators" metadata
+// of the model, honoring the "suppressions" metadata and the @suppress trait. NOTE and WARNING events are reported as
+// warnings, DANGER and ERROR events cause an error to be returned. A validator that is not supported, or whose
+// selector cannot be compiled, is ignored with a warning.
+func (ast *AST) RunValidators() error {
+	idx := newShapeIndex(ast)
+	events := ast.validateTraitTargets(idx)
+	if ast.Metadata != nil {
+		for _, v := range ast.Metadata.GetSlice("validators") {
+			events = append(events, ast.runValidator(idx, AsNodeValue(v))...)
+		}
+	}
+	var failures []string
+	for _, ev := range events {
+		if ast.isSuppressed(idx, ev) {
+			continue
+		}
+		switch ev.Severity {
+		case SeverityDanger, SeverityError:
+			failures = append(failures, ev.String())
+		default:
+			model.Warning("%s\n", ev.String())
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("Model validation failed:\n    %s", strings.Join(failures, "\n    "))
+	}
+	return nil
+}
+
+func (ast *AST) runValidator(idx *shapeIndex, def *NodeValue) []*ValidationEvent {
+	name := def.GetString("name")
+	id := def.GetString("id")
+	if id == "" {
+		id = name
+	}
+	conf := def.Get("configuration")
+	if conf == nil {
+		conf = NewNodeValue()
+	}
+	var events []*ValidationEvent
+	var err error
+	switch name {
+	case "EmitEachSelector":
+		events, err = emitEachSelector(idx, id, conf)
+	case "EmitNoneSelector":
+		events, err = emitNoneSelector(idx, id, conf)
+	case "UnreferencedShape":
+		events, err = unreferencedShape(idx, id, conf)
+	case "CamelCase":
+		events = camelCase(idx, id, conf)
+	case "TraitTarget":
+		events, err = traitTarget(idx, id, conf)
+	default:
+		model.Warning("Smithy validator %q is not supported, ignored\n", name)
+		return nil
+	}
+	if err != nil {
+		model.Warning("Smithy validator %q cannot be evaluated, ignored: %v\n", id, err)
+		return nil
+	}
+	var filter map[string]bool
+	if sel := def.GetString("selector"); sel != "" {
+		compiled, err := CompileSelector(sel)
+		if err != nil {
+			model.Warning("Smithy validator %q cannot be evaluated, ignored: %v\n", id, err)
+			return nil
+		}
+		filter = make(map[string]bool, 0)
+		for _, node := range idx.eval(compiled.steps, idx.nodes) {
+			filter[node.id] = true
+		}
+	}
+	namespaces := def.GetStringSlice("namespaces")
+	severity := def.GetString("severity")
+	message := def.GetString("message")
+	var result []*ValidationEvent
+	for _, ev := range events {
+		if filter != nil && !filter[ev.ShapeId] {
+			continue
+		}
+		if len(namespaces) > 0 && !containsString(namespaces, shapeIdNamespace(ev.ShapeId)) {
+			continue
+		}
+		if severity != "" {
+			ev.Severity = severity
+		}
+		if message != "" {
+			ev.Message = strings.ReplaceAll(message, "{super}", ev.Message)
+		}
+		result = append(result, ev)
+	}
+	return result
+}
+
+func (ast *AST) isSuppressed(idx *shapeIndex, ev *ValidationEvent) bool {
+	if ev.Severity == SeverityError {
+		return false
+	}
+	if node := idx.lookup(ev.ShapeId); node != nil && node.traits != nil {
+		for _, sid := range node.traits.GetStringSlice("smithy.api#suppress") {
+			if suppressionMatches(sid, ev.Id) {
+				return true
+			}
+		}
+	}
+	if ast.Metadata != nil {
+		for _, s := range ast.Metadata.GetSlice("suppressions") {
+			sup := AsNodeValue(s)
+			ns := sup.GetString("namespace")
+			if suppressionMatches(sup.GetString("id"), ev.Id) && (ns == "*" || ns == shapeIdNamespace(ev.ShapeId)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// suppressionMatches allows an id like "Foo" to suppress the hierarchical event ids "Foo.Bar".
+func suppressionMatches(sid, eventId string) bool {
+	return sid == eventId || strings.HasPrefix(eventId, sid+".")
+}
+
+func emitEachSelector(idx *shapeIndex, id string, conf *NodeValue) ([]*ValidationEvent, error) {
+	sel, err := CompileSelector(conf.GetString("selector"))
+	if err != nil {
+		return nil, err
+	}
+	bindToTrait := conf.GetString("bindToTrait")
+	template := conf.GetString("messageTemplate")
+	var events []*ValidationEvent
+	for _, node := range idx.eval(sel.steps, idx.nodes) {
+		if bindToTrait != "" && node.traits.Get(absoluteTraitId(bindToTrait)) == nil {
+			continue
+		}
+		msg := "Selector capture matched selector: " + sel.String()
+		if template != "" {
+			msg = idx.expandTemplate(template, node)
+		}
+		events = append(events, &ValidationEvent{Id: id, Severity: SeverityDanger, ShapeId: node.id, Message: msg})
+	}
+	return events, nil
+}
+
+func emitNoneSelector(idx *shapeIndex, id string, conf *NodeValue) ([]*ValidationEvent, error) {
+	sel, err := CompileSelector(conf.GetString("selector"))
+	if err != nil {
+		return nil, err
+	}
+	if len(idx.eval(sel.steps, idx.nodes)) > 0 {
+		return nil, nil
+	}
+	return []*ValidationEvent{{
+		Id:       id,
+		Severity: SeverityDanger,
+		Message:  "Expected at least one shape to match selector: " + sel.String(),
+	}}, nil
+}
+
+func unreferencedShape(idx *shapeIndex, id string, conf *NodeValue) ([]*ValidationEvent, error) {
+	rootSelector := conf.GetString("rootShapeSelector")
+	if rootSelector == "" {
+		rootSelector = "service"
+	}
+	sel, err := CompileSelector(rootSelector)
+	if err != nil {
+		return nil, err
+	}
+	roots := idx.eval(sel.steps, idx.nodes)
+	connected := idx.eval([]*selectorStep{{recursive: true}}, roots)
+	referenced := make(map[*shapeNode]bool, 0)
+	for _, node := range roots {
+		referenced[node] = true
+	}
+	for _, node := range connected {
+		referenced[node] = true
+	}
+	var events []*ValidationEvent
+	for _, node := range idx.nodes {
+		if referenced[node] || node.shape == nil || node.traits.Get("smithy.api#trait") != nil {
+			continue
+		}
+		events = append(events, &ValidationEvent{
+			Id:       id,
+			Severity: SeverityNote,
+			ShapeId:  node.id,
+			Message:  fmt.Sprintf("The %s shape is not connected to from any shape matching the selector `%s`", node.typ, rootSelector),
+		})
+	}
+	return events, nil
+}
+
+// traitTarget restricts the shapes that the configured traits may be applied to, beyond the selector of their own
+// @trait definition: each shape with one of the traits must match the configured selector.
+func traitTarget(idx *shapeIndex, id string, conf *NodeValue) ([]*ValidationEvent, error) {
+	selector := conf.GetString("selector")
+	if selector == "" {
+		return nil, fmt.Errorf("no selector configured")
+	}
+	sel, err := CompileSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	traits := conf.GetStringSlice("traits")
+	if len(traits) == 0 {
+		return nil, fmt.Errorf("no traits configured")
+	}
+	matches := make(map[string]bool, 0)
+	for _, node := range idx.eval(sel.steps, idx.nodes) {
+		matches[node.id] = true
+	}
+	var events []*ValidationEvent
+	for _, node := range idx.nodes {
+		if matches[node.id] {
+			continue
+		}
+		for _, t := range traits {
+			tid := absoluteTraitId(t)
+			if node.traits.Get(tid) != nil {
+				events = append(events, &ValidationEvent{
+					Id:       id,
+					Severity: SeverityDanger,
+					ShapeId:  node.id,
+					Message:  fmt.Sprintf("Trait `%s` cannot be applied to `%s`. This trait may only be applied to shapes that match the following selector: %s", tid, node.id, selector),
+				})
+			}
+		}
+	}
+	return events, nil
+}
+
+func camelCase(idx *shapeIndex, id string, conf *NodeValue) []*ValidationEvent {
+	memberNames := conf.GetString("memberNames")
+	if memberNames == "" {
+		memberNames = "lower"
+	}
+	var events []*ValidationEvent
+	for _, node := range idx.nodes {
+		var name, want string
+		if node.member != nil {
+			name = node.id[strings.Index(node.id, "$")+1:]
+			want = memberNames
+		} else {
+			name = StripNamespace(node.id)
+			want = "upper"
+		}
+		if !isCamelCase(name, want == "upper") {
+			events = append(events, &ValidationEvent{
+				Id:       id,
+				Severity: SeverityWarning,
+				ShapeId:  node.id,
+				Message:  fmt.Sprintf("%q is not %s camel case", name, want),
+			})
+		}
+	}
+	return events
+}
+
+func isCamelCase(name string, upper bool) bool {
+	if name == "" || strings.Contains(name, "_") {
+		return false
+	}
+	first := rune(name[0])
+	if upper {
+		return first >= 'A' && first <= 'Z'
+	}
+	return first >= 'a' && first <= 'z'
+}
+
+// validateTraitTargets checks each applied trait whose definition is in the model against the selector of its @trait.
+func (ast *AST) validateTraitTargets(idx *shapeIndex) []*ValidationEvent {
+	targets := make(map[string]map[string]bool, 0)
+	var events []*ValidationEvent
+	for _, node := range idx.nodes {
+		if node.traits == nil {
+			continue
+		}
+		for _, tid := range node.traits.Keys() {
+			def := ast.GetShape(tid)
+			if def == nil {
+				continue
+			}
+			tdef := def.Traits.Get("smithy.api#trait")
+			if tdef == nil {
+				continue
+			}
+			matches, ok := targets[tid]
+			if !ok {
+				matches = make(map[string]bool, 0)
+				selector := tdef.GetString("selector")
+				if selector == "" {
+					selector = "*"
+				}
+				sel, err := CompileSelector(selector)
+				if err != nil {
+					model.Warning("Cannot check targets of trait %s: %v\n", tid, err)
+					matches = nil
+				} else {
+					for _, n := range idx.eval(sel.steps, idx.nodes) {
+						matches[n.id] = true
+					}
+				}
+				targets[tid] = matches
+			}
+			if matches != nil && !matches[node.id] {
+				events = append(events, &ValidationEvent{
+					Id:       "TraitTarget",
+					Severity: SeverityError,
+					ShapeId:  node.id,
+					Message:  fmt.Sprintf("Trait `%s` cannot be applied to `%s`. This trait may only be applied to shapes that match the following selector: %s", tid, node.id, tdef.GetString("selector")),
+				})
+			}
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].ShapeId == events[j].ShapeId {
+			return events[i].Message < events[j].Message
+		}
+		return events[i].ShapeId < events[j].ShapeId
+	})
+	return events
+}
+
+// expandTemplate replaces each "@{attribute}" in an EmitEachSelector message template with the value of that
+// attribute for the matched shape. "@@" produces a literal "@".
+func (idx *shapeIndex) expandTemplate(template string, node *shapeNode) string {
+	var sb strings.Builder
+	for i := 0; i < len(template); i++ {
+		if template[i] == '@' && i+1 < len(template) {
+			if template[i+1] == '@' {
+				sb.WriteByte('@')
+				i++
+				continue
+			}
+			if template[i+1] == '{' {
+				end := strings.Index(template[i:], "}")
+				if end > 0 {
+					values, _ := idx.attribute(node, strings.Split(template[i+2:i+end], "|"))
+					sb.WriteString(strings.Join(values, ", "))
+					i += end
+					continue
+				}
+			}
+		}
+		sb.WriteByte(template[i])
+	}
+	return sb.String()
+}
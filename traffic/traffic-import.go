@@ -0,0 +1,842 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package traffic
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/boynton/api/model"
+	"github.com/boynton/data"
+)
+
+// Import infers a model from recorded HTTP traffic: HAR files, and httptrace files. Requests are clustered into
+// operations by method and path template, where id-like path segments (numbers, UUIDs, and the like) are parameters.
+// The query parameters and the request headers that are not standard become inputs, and the types of the JSON bodies
+// are inferred by merging all of their samples. Responses with an error status become exceptions, shared by status.
+// Every exchange is kept as an example of its operation. The service is named by "traffic.service", or for the first
+// file.
+func Import(paths []string, tags []string, ns string, conf *data.Object) (*model.Schema, error) {
+	mb := NewModelBuilder(ns)
+	for _, path := range paths {
+		exchanges, err := ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, x := range exchanges {
+			mb.AddExchange(x)
+		}
+	}
+	if len(mb.exchanges) == 0 {
+		return nil, fmt.Errorf("No HTTP exchanges found in %s", strings.Join(paths, ", "))
+	}
+	name := conf.GetString("traffic.service")
+	if name == "" {
+		name = serviceName(paths[0])
+	}
+	if err := mb.Build(name); err != nil {
+		return nil, err
+	}
+	if len(tags) > 0 {
+		mb.schema.Filter(tags)
+	}
+	return mb.schema, nil
+}
+
+// ModelBuilder infers a model from exchanges, which it clusters into operations, and from operations known by other
+// means, like the requests of a Postman collection.
+type ModelBuilder struct {
+	schema    *model.Schema
+	ns        model.Namespace
+	inf       *inferrer
+	exchanges []*Exchange
+	clusters  map[string]*cluster
+	order     []*cluster
+	opNames   map[string]bool
+	errors    map[int]*errorShape
+}
+
+// Operation is an operation known by other means than clustering exchanges: its name, its method, and its path
+// template, with "{name}" parameters. The exchanges must match the template. The query parameters and headers that are
+// declared are inputs even when no exchange has them, except for the standard headers. Its Id is set by Build.
+type Operation struct {
+	Name      string
+	Comment   string
+	Method    string
+	Path      string
+	Query     []string
+	Headers   []string
+	Exchanges []*Exchange
+	Id        model.AbsoluteIdentifier
+}
+
+func NewModelBuilder(ns string) *ModelBuilder {
+	mb := &ModelBuilder{
+		schema:   model.NewSchema(),
+		ns:       model.Namespace(ns),
+		clusters: make(map[string]*cluster, 0),
+		errors:   make(map[int]*errorShape, 0),
+	}
+	mb.inf = newInferrer(mb.schema, mb.ns)
+	return mb
+}
+
+// Schema is the model, once built.
+func (mb *ModelBuilder) Schema() *model.Schema {
+	return mb.schema
+}
+
+// AddExchange adds an exchange to the operation of its method and path template, where id-like segments are
+// parameters.
+func (mb *ModelBuilder) AddExchange(x *Exchange) {
+	mb.exchanges = append(mb.exchanges, x)
+	mb.cluster(x)
+}
+
+// AddOperation adds an operation with its exchanges.
+func (mb *ModelBuilder) AddOperation(op *Operation) {
+	c := &cluster{method: op.Method, name: op.Name, comment: op.Comment, exchanges: op.Exchanges, source: op}
+	for _, seg := range strings.Split(strings.Trim(op.Path, "/"), "/") {
+		switch {
+		case seg == "":
+		case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}"):
+			c.segments = append(c.segments, "")
+			c.params = append(c.params, strings.Trim(seg, "{}"))
+		default:
+			c.segments = append(c.segments, seg)
+			c.params = append(c.params, "")
+		}
+	}
+	mb.exchanges = append(mb.exchanges, op.Exchanges...)
+	mb.order = append(mb.order, c)
+}
+
+// cluster is the exchanges of one operation.
+type cluster struct {
+	source     *Operation
+	comment    string
+	method     string
+	segments   []string //the literal segments of the path template, with "" for the parameters
+	params     []string //the names of the parameters, by segment
+	exchanges  []*Exchange
+	name       string
+	pathShapes map[string]*shape
+	query      *bindings
+	headers    *bindings
+	request    *shape
+	requests   int
+	response   *shape
+	responses  int
+	respHdrs   *bindings
+	status     int
+	errors     map[int]bool
+	op         *model.OperationDef
+}
+
+// bindings are the query parameters or headers of a cluster's requests or responses, by their HTTP name.
+type bindings struct {
+	order   []string
+	values  map[string][][]string
+	shapes  map[string]*shape
+	present map[string]int
+	count   int
+}
+
+// errorShape is the merge of the bodies of the responses with an error status, across all operations.
+type errorShape struct {
+	status int
+	body   *shape
+	id     model.AbsoluteIdentifier
+}
+
+func newBindings() *bindings {
+	return &bindings{values: make(map[string][][]string, 0), shapes: make(map[string]*shape, 0), present: make(map[string]int, 0)}
+}
+
+// Build infers the model of the service named, from all of the exchanges.
+func (mb *ModelBuilder) Build(name string) error {
+	mb.schema.Namespace = mb.ns
+	mb.schema.Id = model.AbsoluteIdentifier(string(mb.ns) + "#" + name)
+	mb.schema.Base = commonBase(mb.exchanges)
+	mb.nameOperations()
+	//all samples are merged before any type is resolved, the same type names are shared by operations
+	for _, c := range mb.order {
+		mb.mergeSamples(c)
+	}
+	mb.defineExceptions()
+	for _, c := range mb.order {
+		if err := mb.defineOperation(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commonBase returns the scheme and host of the requests, if they are absolute and all the same.
+func commonBase(exchanges []*Exchange) string {
+	base := ""
+	for _, x := range exchanges {
+		if x.Url.Host == "" {
+			return ""
+		}
+		b := x.Url.Scheme + "://" + x.Url.Host
+		if base != "" && b != base {
+			return ""
+		}
+		base = b
+	}
+	return base
+}
+
+var uuidSegment = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+var hexSegment = regexp.MustCompile(`^[0-9a-fA-F]{8,}$`)
+var versionSegment = regexp.MustCompile(`^v[0-9]+([a-z]+[0-9]*)?$`)
+var mixedSegment = regexp.MustCompile(`^[A-Za-z_\-]*[0-9][A-Za-z0-9_\-]*$`)
+
+// isIdSegment is true for the path segments that look like ids: numbers, UUIDs, long hex strings, and tokens of five
+// or more characters that mix letters and digits.
+func isIdSegment(seg string) bool {
+	if seg == "" {
+		return false
+	}
+	if _, err := strconv.ParseInt(seg, 10, 64); err == nil {
+		return true
+	}
+	if uuidSegment.MatchString(seg) || hexSegment.MatchString(seg) {
+		return true
+	}
+	if len(seg) >= 5 && mixedSegment.MatchString(seg) && strings.ContainsAny(seg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ") {
+		//version segments, like "v2beta1", are literals
+		return !versionSegment.MatchString(seg)
+	}
+	return false
+}
+
+func (mb *ModelBuilder) cluster(x *Exchange) {
+	var segments []string
+	path := strings.Trim(x.Url.EscapedPath(), "/")
+	if path != "" {
+		segments = strings.Split(path, "/")
+	}
+	var key strings.Builder
+	key.WriteString(x.Method + " ")
+	template := make([]string, len(segments))
+	for i, seg := range segments {
+		if !isIdSegment(seg) {
+			template[i] = seg
+		}
+		key.WriteString("/" + template[i])
+		if template[i] == "" {
+			key.WriteString("{}")
+		}
+	}
+	c, ok := mb.clusters[key.String()]
+	if !ok {
+		c = &cluster{method: x.Method, segments: template}
+		mb.clusters[key.String()] = c
+		mb.order = append(mb.order, c)
+	}
+	c.exchanges = append(c.exchanges, x)
+}
+
+var opVerbs = map[string]string{
+	"POST":   "Create",
+	"PUT":    "Update",
+	"PATCH":  "Patch",
+	"DELETE": "Delete",
+	"HEAD":   "Head",
+}
+
+// nameOperations names the operations for the verb of their method and their last literal path segment, i.e.
+// "GET /items/{itemId}" is GetItem, and "GET /items" is ListItems. An action on an item, like "POST /items/{itemId}/ship"
+// is ShipItem. Names that collide are qualified by earlier segments.
+func (mb *ModelBuilder) nameOperations() {
+	mb.opNames = make(map[string]bool, 0)
+	for _, c := range mb.order {
+		if c.source != nil {
+			name := c.name
+			for i := 2; mb.opNames[name]; i++ {
+				name = fmt.Sprintf("%s%d", c.name, i)
+			}
+			mb.opNames[name] = true
+			c.name = name
+		}
+	}
+	for _, c := range mb.order {
+		if c.source != nil {
+			continue
+		}
+		var literals []string
+		seen := make(map[string]int, 0)
+		c.params = make([]string, len(c.segments))
+		prev := ""
+		for i, seg := range c.segments {
+			if seg != "" {
+				literals = append(literals, seg)
+				prev = seg
+				continue
+			}
+			pname := "id"
+			if prev != "" {
+				pname = model.Uncapitalize(singular(pascal(unescape(prev)))) + "Id"
+			}
+			seen[pname]++
+			if seen[pname] > 1 {
+				pname = fmt.Sprintf("%s%d", pname, seen[pname])
+			}
+			c.params[i] = pname
+		}
+		n := len(c.segments)
+		itemLevel := n > 0 && c.segments[n-1] == ""
+		var verb, noun string
+		if len(literals) == 0 {
+			noun = "Root"
+		} else {
+			noun = pascal(unescape(literals[len(literals)-1]))
+		}
+		switch {
+		case c.method == "GET" && !itemLevel:
+			verb = "List"
+		case c.method == "GET":
+			verb, noun = "Get", singular(noun)
+		case c.method == "POST" && n > 1 && c.segments[n-1] != "" && c.segments[n-2] == "" && len(literals) > 1:
+			verb, noun = noun, singular(pascal(unescape(literals[len(literals)-2])))
+		case opVerbs[c.method] != "":
+			verb, noun = opVerbs[c.method], singular(noun)
+		default:
+			verb = pascal(strings.ToLower(c.method))
+		}
+		name := verb + noun
+		for i := len(literals) - 2; mb.opNames[name] && i >= 0; i-- {
+			name = verb + pascal(unescape(literals[i])) + strings.TrimPrefix(name, verb)
+		}
+		unique := name
+		for i := 2; mb.opNames[unique]; i++ {
+			unique = fmt.Sprintf("%s%d", name, i)
+		}
+		mb.opNames[unique] = true
+		c.name = unique
+	}
+}
+
+// payloadName is the type name for the JSON bodies of an operation's successful responses: the singular resource for
+// an item, and the collection otherwise, whose items are then of the singular resource.
+func (c *cluster) payloadName() string {
+	n := len(c.segments)
+	noun := "Root"
+	for i := n - 1; i >= 0; i-- {
+		if c.segments[i] != "" {
+			noun = pascal(unescape(c.segments[i]))
+			break
+		}
+	}
+	if c.method == "GET" && (n == 0 || c.segments[n-1] != "") {
+		return noun
+	}
+	if c.method == "POST" && n > 1 && c.segments[n-1] != "" && c.segments[n-2] == "" {
+		//an action
+		return c.name + "Result"
+	}
+	return singular(noun)
+}
+
+func (mb *ModelBuilder) mergeSamples(c *cluster) {
+	c.pathShapes = make(map[string]*shape, 0)
+	c.query = newBindings()
+	c.headers = newBindings()
+	c.respHdrs = newBindings()
+	c.request = newShape(c.name + "RequestBody")
+	c.response = newShape(c.payloadName())
+	c.errors = make(map[int]bool, 0)
+	statusCounts := make(map[int]int, 0)
+	for _, x := range c.exchanges {
+		segments := strings.Split(strings.Trim(x.Url.EscapedPath(), "/"), "/")
+		for i, pname := range c.params {
+			if pname != "" && i < len(segments) {
+				sh, ok := c.pathShapes[pname]
+				if !ok {
+					sh = newShape(model.Capitalize(pname))
+					c.pathShapes[pname] = sh
+				}
+				mb.inf.merge(sh, scalarValue(unescape(segments[i])))
+			}
+		}
+		query := x.Url.Query()
+		c.query.add(queryKeys(x.Url.RawQuery), func(key string) []string { return query[key] })
+		c.headers.add(headerKeys(x.RequestHeader), x.RequestHeader.Values)
+		if v, ok := jsonBody(x.RequestBody); ok {
+			mb.inf.merge(c.request, v)
+			c.requests++
+		}
+		switch {
+		case x.Status == 0:
+		case x.Status < 300:
+			statusCounts[x.Status]++
+			c.respHdrs.add(headerKeys(x.ResponseHeader), x.ResponseHeader.Values)
+			if v, ok := jsonBody(x.ResponseBody); ok {
+				mb.inf.merge(c.response, v)
+				c.responses++
+			}
+		default:
+			c.errors[x.Status] = true
+			es, ok := mb.errors[x.Status]
+			if !ok {
+				es = &errorShape{status: x.Status, body: newShape(exceptionName(x.Status) + "Error")}
+				mb.errors[x.Status] = es
+			}
+			if v, ok := jsonBody(x.ResponseBody); ok {
+				mb.inf.merge(es.body, v)
+			}
+		}
+	}
+	if c.source != nil {
+		for _, pname := range c.params {
+			if _, ok := c.pathShapes[pname]; pname != "" && !ok {
+				//a parameter of the template, with no value to infer a type from
+				sh := newShape(model.Capitalize(pname))
+				sh.strs++
+				c.pathShapes[pname] = sh
+			}
+		}
+		c.query.declare(c.source.Query)
+		var headers []string
+		for _, key := range c.source.Headers {
+			if key = http.CanonicalHeaderKey(key); !isStandardHeader(key) {
+				headers = append(headers, key)
+			}
+		}
+		c.headers.declare(headers)
+	}
+	c.query.merge(mb.inf)
+	c.headers.merge(mb.inf)
+	c.respHdrs.merge(mb.inf)
+	c.status = http.StatusOK
+	for status, count := range statusCounts {
+		if count > statusCounts[c.status] || (count == statusCounts[c.status] && status < c.status) {
+			c.status = status
+		}
+	}
+}
+
+// add records the values of the bindings of a request or response.
+func (b *bindings) add(keys []string, values func(string) []string) {
+	b.count++
+	for _, key := range keys {
+		if _, ok := b.values[key]; !ok {
+			b.order = append(b.order, key)
+		}
+		b.values[key] = append(b.values[key], values(key))
+		b.present[key]++
+	}
+}
+
+// declare adds the bindings that are not in any sample.
+func (b *bindings) declare(keys []string) {
+	for _, key := range keys {
+		if _, ok := b.values[key]; !ok {
+			b.order = append(b.order, key)
+			b.values[key] = nil
+		}
+	}
+}
+
+// merge infers the shapes of the bindings from their values. Values are typed as numbers or booleans when they parse as
+// such, and a binding with more than one value in any sample is a list.
+func (b *bindings) merge(inf *inferrer) {
+	for _, key := range b.order {
+		isList := false
+		for _, vals := range b.values[key] {
+			isList = isList || len(vals) > 1
+		}
+		sh := newShape(pascal(key))
+		if len(b.values[key]) == 0 {
+			//declared, with no value to infer a type from
+			sh.strs++
+		}
+		for _, vals := range b.values[key] {
+			if !isList {
+				inf.merge(sh, scalarValue(vals[0]))
+				continue
+			}
+			var list []any
+			for _, v := range vals {
+				list = append(list, scalarValue(v))
+			}
+			inf.merge(sh, list)
+		}
+		b.shapes[key] = sh
+	}
+}
+
+func (mb *ModelBuilder) defineExceptions() {
+	var statuses []int
+	for status := range mb.errors {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	for _, status := range statuses {
+		es := mb.errors[status]
+		name := mb.inf.uniqueName(exceptionName(status))
+		es.id = mb.inf.id(name)
+		edef := &model.OperationOutput{Id: es.id, HttpStatus: int32(status)}
+		if es.body.objs+es.body.arrays+es.body.strs+es.body.ints+es.body.floats+es.body.bools > 0 {
+			edef.Fields = append(edef.Fields, &model.OperationOutputField{
+				Name:        "error",
+				Type:        mb.inf.typeOf(es.body),
+				HttpPayload: true,
+			})
+		}
+		mb.schema.EnsureExceptionDef(edef)
+	}
+}
+
+func (mb *ModelBuilder) defineOperation(c *cluster) error {
+	var path strings.Builder
+	for i, seg := range c.segments {
+		if seg == "" {
+			seg = "{" + c.params[i] + "}"
+		}
+		path.WriteString("/" + seg)
+	}
+	if path.Len() == 0 {
+		path.WriteString("/")
+	}
+	opId := mb.inf.id(c.name)
+	op := &model.OperationDef{
+		Id:         opId,
+		HttpMethod: c.method,
+		HttpUri:    path.String(),
+		Input:      &model.OperationInput{Id: mb.inf.id(c.name + "Input")},
+		Output:     &model.OperationOutput{Id: mb.inf.id(c.name + "Output"), HttpStatus: int32(c.status)},
+	}
+	c.op = op
+	if c.source != nil {
+		op.Comment = c.comment
+		c.source.Id = opId
+	}
+	fieldNames := make(map[string]bool, 0)
+	uniqueField := func(name string) model.Identifier {
+		unique := name
+		for i := 2; fieldNames[unique]; i++ {
+			unique = fmt.Sprintf("%s%d", name, i)
+		}
+		fieldNames[unique] = true
+		return model.Identifier(unique)
+	}
+	for _, pname := range c.params {
+		if pname != "" {
+			op.Input.Fields = append(op.Input.Fields, &model.OperationInputField{
+				Name:     uniqueField(pname),
+				Type:     mb.inf.typeOf(c.pathShapes[pname]),
+				Required: true,
+				HttpPath: true,
+			})
+		}
+	}
+	for _, key := range c.query.order {
+		f := &model.OperationInputField{
+			Name:      uniqueField(fieldName(key)),
+			Type:      mb.inf.typeOf(c.query.shapes[key]),
+			Required:  c.query.present[key] == c.query.count,
+			HttpQuery: model.Identifier(key),
+		}
+		op.Input.Fields = append(op.Input.Fields, f)
+	}
+	for _, key := range c.headers.order {
+		f := &model.OperationInputField{
+			Name:       uniqueField(fieldName(strings.TrimPrefix(key, "X-"))),
+			Type:       mb.inf.typeOf(c.headers.shapes[key]),
+			Required:   c.headers.present[key] == c.headers.count,
+			HttpHeader: key,
+		}
+		op.Input.Fields = append(op.Input.Fields, f)
+	}
+	if c.requests > 0 {
+		tid := mb.inf.typeOf(c.request)
+		op.Input.Fields = append(op.Input.Fields, &model.OperationInputField{
+			Name:        uniqueField("body"),
+			Type:        tid,
+			Required:    c.requests == len(c.exchanges),
+			HttpPayload: true,
+		})
+	}
+	fieldNames = make(map[string]bool, 0)
+	for _, key := range c.respHdrs.order {
+		f := &model.OperationOutputField{
+			Name:       uniqueField(fieldName(strings.TrimPrefix(key, "X-"))),
+			Type:       mb.inf.typeOf(c.respHdrs.shapes[key]),
+			Required:   c.respHdrs.present[key] == c.respHdrs.count,
+			HttpHeader: key,
+		}
+		op.Output.Fields = append(op.Output.Fields, f)
+	}
+	if c.responses > 0 {
+		tid := mb.inf.typeOf(c.response)
+		f := &model.OperationOutputField{
+			Name:        uniqueField(payloadFieldName(tid)),
+			Type:        tid,
+			HttpPayload: true,
+		}
+		op.Output.Fields = append(op.Output.Fields, f)
+	}
+	var statuses []int
+	for status := range c.errors {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	for _, status := range statuses {
+		op.Exceptions = append(op.Exceptions, mb.errors[status].id)
+	}
+	titles := make(map[string]bool, 0)
+	for _, x := range c.exchanges {
+		op.Examples = append(op.Examples, mb.example(c, x, titles))
+	}
+	return mb.schema.AddOperationDef(op)
+}
+
+// example makes an operation example of a recorded exchange, with its values typed as the inferred model's.
+func (mb *ModelBuilder) example(c *cluster, x *Exchange, titles map[string]bool) *model.OperationExample {
+	title := x.Title
+	if title == "" {
+		title = x.Method + " " + x.Url.RequestURI()
+	}
+	unique := title
+	for i := 2; titles[unique]; i++ {
+		unique = fmt.Sprintf("%s (%d)", title, i)
+	}
+	titles[unique] = true
+	ex := &model.OperationExample{Title: unique}
+	input := make(map[string]any, 0)
+	segments := strings.Split(strings.Trim(x.Url.EscapedPath(), "/"), "/")
+	query := x.Url.Query()
+	for _, f := range c.op.Input.Fields {
+		var vals []string
+		switch {
+		case f.HttpPath:
+			for i, pname := range c.params {
+				if pname == string(f.Name) && i < len(segments) {
+					vals = []string{unescape(segments[i])}
+				}
+			}
+		case f.HttpQuery != "":
+			vals = query[string(f.HttpQuery)]
+		case f.HttpHeader != "":
+			vals = x.RequestHeader.Values(f.HttpHeader)
+		case f.HttpPayload:
+			if v, ok := jsonBody(x.RequestBody); ok {
+				input[string(f.Name)] = v
+			}
+		}
+		if len(vals) > 0 {
+			input[string(f.Name)] = mb.typedValue(f.Type, vals)
+		}
+	}
+	if len(input) > 0 {
+		ex.Input = input
+	}
+	if x.Status == 0 {
+		return ex
+	}
+	output := make(map[string]any, 0)
+	if x.Status >= 300 {
+		ex.Error = &model.OperationErrorExample{ShapeId: mb.errors[x.Status].id}
+		if v, ok := jsonBody(x.ResponseBody); ok {
+			output["error"] = v
+			ex.Error.Output = output
+		}
+		return ex
+	}
+	for _, f := range c.op.Output.Fields {
+		if f.HttpHeader != "" {
+			if vals := x.ResponseHeader.Values(f.HttpHeader); len(vals) > 0 {
+				output[string(f.Name)] = mb.typedValue(f.Type, vals)
+			}
+		} else if v, ok := jsonBody(x.ResponseBody); ok {
+			output[string(f.Name)] = v
+		}
+	}
+	if len(output) > 0 {
+		ex.Output = output
+	}
+	return ex
+}
+
+// typedValue is the value of a path parameter, query parameter, or header, as its type.
+func (mb *ModelBuilder) typedValue(tid model.AbsoluteIdentifier, vals []string) any {
+	if mb.schema.BaseType(tid) == model.BaseType_List {
+		var items model.AbsoluteIdentifier
+		if td := mb.schema.GetTypeDef(tid); td != nil {
+			items = td.Items
+		}
+		var list []any
+		for _, v := range vals {
+			list = append(list, mb.typedValue(items, []string{v}))
+		}
+		return list
+	}
+	if len(vals) == 0 {
+		return nil
+	}
+	switch mb.schema.BaseType(tid) {
+	case model.BaseType_Bool, model.BaseType_Int64, model.BaseType_Float64:
+		return scalarValue(vals[0])
+	}
+	return vals[0]
+}
+
+var decimalValue = regexp.MustCompile(`^-?(0|[1-9][0-9]*)\.[0-9]+$`)
+
+// scalarValue is a string from a URL or header as the JSON value it parses as: a number, a boolean, or the string.
+// Numbers with leading zeros, like "007", are strings.
+func scalarValue(s string) any {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil && strconv.FormatInt(i, 10) == s {
+		return float64(i)
+	}
+	if decimalValue.MatchString(s) {
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			return n
+		}
+	}
+	if s == "true" || s == "false" {
+		return s == "true"
+	}
+	return s
+}
+
+func jsonBody(b []byte) (any, bool) {
+	if len(strings.TrimSpace(string(b))) == 0 {
+		return nil, false
+	}
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// queryKeys are the query parameter names, in the order of their first appearance.
+func queryKeys(rawQuery string) []string {
+	var keys []string
+	seen := make(map[string]bool, 0)
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		key := strings.SplitN(pair, "=", 2)[0]
+		if k, err := url.QueryUnescape(key); err == nil {
+			key = k
+		}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// The headers of the protocol, of browsers, of caching, and of credentials, which are not inputs or outputs.
+var standardHeaders = map[string]bool{
+	"Accept": true, "Accept-Charset": true, "Accept-Encoding": true, "Accept-Language": true, "Accept-Ranges": true,
+	"Access-Control-Request-Headers": true, "Access-Control-Request-Method": true, "Age": true, "Alt-Svc": true,
+	"Authorization": true, "Cache-Control": true, "Connection": true, "Content-Encoding": true, "Content-Length": true,
+	"Content-Type": true, "Cookie": true, "Date": true, "Dnt": true, "Expect": true, "Expires": true, "Host": true,
+	"If-Modified-Since": true, "If-None-Match": true, "Keep-Alive": true, "Origin": true, "Pragma": true,
+	"Priority": true, "Referer": true, "Server": true, "Set-Cookie": true, "Strict-Transport-Security": true,
+	"Te": true, "Trailer": true, "Transfer-Encoding": true, "Upgrade": true, "Upgrade-Insecure-Requests": true,
+	"User-Agent": true, "Vary": true, "Via": true, "Www-Authenticate": true, "X-Api-Key": true,
+	"X-Content-Type-Options": true, "X-Forwarded-For": true, "X-Forwarded-Host": true, "X-Forwarded-Proto": true,
+	"X-Frame-Options": true, "X-Powered-By": true, "X-Xss-Protection": true,
+}
+
+func isStandardHeader(name string) bool {
+	return standardHeaders[name] || strings.HasPrefix(name, "Sec-") || strings.HasPrefix(name, "Access-Control-") || strings.HasPrefix(name, "Content-Security-Policy")
+}
+
+func headerKeys(h http.Header) []string {
+	var keys []string
+	for key := range h {
+		if !isStandardHeader(http.CanonicalHeaderKey(key)) {
+			keys = append(keys, http.CanonicalHeaderKey(key))
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func unescape(seg string) string {
+	if s, err := url.PathUnescape(seg); err == nil {
+		return s
+	}
+	return seg
+}
+
+func exceptionName(status int) string {
+	if text := http.StatusText(status); text != "" {
+		return pascal(text)
+	}
+	return fmt.Sprintf("Status%d", status)
+}
+
+func payloadFieldName(tid model.AbsoluteIdentifier) string {
+	if strings.HasPrefix(string(tid), "base#") {
+		return "body"
+	}
+	return model.Uncapitalize(model.StripNamespace(tid))
+}
+
+// fieldName makes a field name of a query parameter or header name, i.e. "page-size" becomes "pageSize".
+func fieldName(key string) string {
+	name := model.Uncapitalize(pascal(key))
+	if !model.IsSymbol(name) {
+		return "param" + pascal(name)
+	}
+	return name
+}
+
+// pascal makes a type name of words separated by anything but letters and digits, i.e. "line-items" becomes
+// "LineItems".
+func pascal(s string) string {
+	var sb strings.Builder
+	upper := true
+	for _, ch := range s {
+		if !(ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9') {
+			upper = true
+			continue
+		}
+		if upper && ch >= 'a' && ch <= 'z' {
+			ch = ch - 'a' + 'A'
+		}
+		upper = false
+		sb.WriteRune(ch)
+	}
+	return sb.String()
+}
+
+// serviceName derives a service name from a file name, i.e. "shop-traffic.har" becomes "ShopTraffic".
+func serviceName(path string) string {
+	name := pascal(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		return "Service" + name
+	}
+	return name
+}
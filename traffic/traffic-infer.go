@@ -0,0 +1,317 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package traffic
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boynton/api/model"
+)
+
+// An enum is inferred for a string field with at most maxEnumValues distinct values, each seen minEnumRepeats times on
+// average, that are all words of one case, like "pending" or "IN_PROGRESS". Names and codes, like "Ann" or "A1", are not.
+const maxEnumValues = 10
+const minEnumRepeats = 2
+const maxTrackedValues = 50
+
+// shape is the merge of the JSON values sampled at one place, i.e. a field of an object or the body of a response.
+type shape struct {
+	name       string //the name of the type inferred for an object (or a list) here
+	nulls      int
+	bools      int
+	ints       int
+	floats     int
+	strs       int
+	timestamps int
+	arrays     int
+	objs       int
+	values     map[string]int //the distinct strings, until there are more than maxTrackedValues
+	obj        *objShape
+	items      *shape
+}
+
+// objShape is the merge of all objects sampled for a type name, wherever they were seen.
+type objShape struct {
+	name    string
+	count   int
+	order   []string
+	fields  map[string]*shape
+	present map[string]int
+	id      model.AbsoluteIdentifier
+}
+
+type inferrer struct {
+	schema *model.Schema
+	ns     model.Namespace
+	objs   map[string]*objShape
+	names  map[string]bool //the type names in use, by structs, lists, and enums
+	enums  map[string]string
+}
+
+func newInferrer(schema *model.Schema, ns model.Namespace) *inferrer {
+	return &inferrer{
+		schema: schema,
+		ns:     ns,
+		objs:   make(map[string]*objShape, 0),
+		names:  make(map[string]bool, 0),
+		enums:  make(map[string]string, 0),
+	}
+}
+
+func newShape(name string) *shape {
+	return &shape{name: name, values: make(map[string]int, 0)}
+}
+
+func (inf *inferrer) merge(sh *shape, v any) {
+	switch val := v.(type) {
+	case nil:
+		sh.nulls++
+	case bool:
+		sh.bools++
+	case float64:
+		if val == math.Trunc(val) && math.Abs(val) < 1e15 {
+			sh.ints++
+		} else {
+			sh.floats++
+		}
+	case string:
+		sh.strs++
+		if _, err := time.Parse(time.RFC3339Nano, val); err == nil {
+			sh.timestamps++
+		}
+		if sh.values != nil {
+			sh.values[val]++
+			if len(sh.values) > maxTrackedValues {
+				sh.values = nil
+			}
+		}
+	case []any:
+		sh.arrays++
+		if sh.items == nil {
+			sh.items = newShape(singular(sh.name))
+		}
+		for _, item := range val {
+			inf.merge(sh.items, item)
+		}
+	case map[string]any:
+		sh.objs++
+		if sh.obj == nil {
+			sh.obj = inf.object(sh.name)
+		}
+		obj := sh.obj
+		obj.count++
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if !model.IsSymbol(k) {
+				continue
+			}
+			fs, ok := obj.fields[k]
+			if !ok {
+				fs = newShape(model.Capitalize(k))
+				obj.fields[k] = fs
+				obj.order = append(obj.order, k)
+			}
+			inf.merge(fs, val[k])
+			if val[k] != nil {
+				obj.present[k]++
+			}
+		}
+	}
+}
+
+// object returns the shape of the objects named by the type name, so that all objects under a field name, or bodies
+// of the same resource, merge into one type.
+func (inf *inferrer) object(name string) *objShape {
+	obj, ok := inf.objs[name]
+	if !ok {
+		obj = &objShape{name: name, fields: make(map[string]*shape, 0), present: make(map[string]int, 0)}
+		inf.objs[name] = obj
+	}
+	return obj
+}
+
+// typeOf resolves the shape to a type, defining the structs, lists, and enums that it needs.
+func (inf *inferrer) typeOf(sh *shape) model.AbsoluteIdentifier {
+	if sh == nil {
+		return "base#Any"
+	}
+	kinds := 0
+	for _, n := range []int{sh.bools, sh.ints + sh.floats, sh.strs, sh.arrays, sh.objs} {
+		if n > 0 {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return "base#Any"
+	}
+	switch {
+	case sh.bools > 0:
+		return "base#Bool"
+	case sh.floats > 0:
+		return "base#Float64"
+	case sh.ints > 0:
+		return "base#Int64"
+	case sh.strs > 0:
+		if sh.timestamps == sh.strs {
+			return "base#Timestamp"
+		}
+		if isEnum(sh) {
+			return inf.enumType(sh)
+		}
+		return "base#String"
+	case sh.arrays > 0:
+		return inf.listType(sh)
+	}
+	return inf.structType(sh.obj)
+}
+
+var enumValue = regexp.MustCompile(`^([a-z]{2,}([_\-][a-z]+)*|[A-Z]{2,}([_\-][A-Z]+)*)$`)
+
+func isEnum(sh *shape) bool {
+	if sh.values == nil || len(sh.values) < 2 || len(sh.values) > maxEnumValues || sh.strs < minEnumRepeats*len(sh.values) {
+		return false
+	}
+	for v := range sh.values {
+		if !enumValue.MatchString(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (inf *inferrer) enumType(sh *shape) model.AbsoluteIdentifier {
+	var values []string
+	for v := range sh.values {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	key := strings.Join(values, ",")
+	for name, k := range inf.enums {
+		if k == key && strings.HasPrefix(name, sh.name) {
+			return inf.id(name)
+		}
+	}
+	name := inf.uniqueName(sh.name)
+	inf.enums[name] = key
+	td := &model.TypeDef{Id: inf.id(name), Base: model.BaseType_Enum}
+	for _, v := range values {
+		el := &model.EnumElement{Symbol: model.Identifier(enumSymbol(v))}
+		if string(el.Symbol) != v {
+			el.Value = v
+		}
+		td.Elements = append(td.Elements, el)
+	}
+	inf.schema.AddTypeDef(td)
+	return td.Id
+}
+
+func (inf *inferrer) listType(sh *shape) model.AbsoluteIdentifier {
+	items := inf.typeOf(sh.items)
+	name := model.StripNamespace(items)
+	if sh.items == nil || items == "base#Any" {
+		name = singular(sh.name)
+	}
+	id := inf.id(name + "List")
+	if td := inf.schema.GetTypeDef(id); td != nil && td.Items == items {
+		return id
+	}
+	td := &model.TypeDef{Id: inf.id(inf.uniqueName(name + "List")), Base: model.BaseType_List, Items: items}
+	inf.schema.AddTypeDef(td)
+	return td.Id
+}
+
+func (inf *inferrer) structType(obj *objShape) model.AbsoluteIdentifier {
+	if obj.id != "" {
+		return obj.id
+	}
+	obj.id = inf.id(inf.uniqueName(obj.name))
+	td := &model.TypeDef{Id: obj.id, Base: model.BaseType_Struct}
+	//added before its fields resolve, for the types that refer to themselves
+	inf.schema.AddTypeDef(td)
+	for _, k := range obj.order {
+		fs := obj.fields[k]
+		fd := &model.FieldDef{
+			Name:     model.Identifier(k),
+			Type:     inf.typeOf(fs),
+			Required: obj.present[k] == obj.count,
+		}
+		td.Fields = append(td.Fields, fd)
+	}
+	return obj.id
+}
+
+func (inf *inferrer) id(name string) model.AbsoluteIdentifier {
+	return model.AbsoluteIdentifier(string(inf.ns) + "#" + name)
+}
+
+func (inf *inferrer) uniqueName(name string) string {
+	unique := name
+	for i := 2; inf.names[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	inf.names[unique] = true
+	return unique
+}
+
+// enumSymbol makes an enum symbol of a value, i.e. "in-progress" becomes "IN_PROGRESS".
+func enumSymbol(value string) string {
+	var sb strings.Builder
+	prevLower := false
+	for _, ch := range value {
+		switch {
+		case ch >= 'a' && ch <= 'z':
+			sb.WriteRune(ch - 'a' + 'A')
+			prevLower = true
+		case ch >= 'A' && ch <= 'Z':
+			if prevLower {
+				sb.WriteRune('_')
+			}
+			sb.WriteRune(ch)
+			prevLower = false
+		case ch >= '0' && ch <= '9':
+			sb.WriteRune(ch)
+		default:
+			sb.WriteRune('_')
+			prevLower = false
+		}
+	}
+	return sb.String()
+}
+
+// singular makes a plural English word singular, well enough for the names of collections.
+func singular(word string) string {
+	lower := strings.ToLower(word)
+	switch {
+	case strings.HasSuffix(lower, "ies") && len(word) > 4:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(lower, "sses"), strings.HasSuffix(lower, "xes"), strings.HasSuffix(lower, "ches"), strings.HasSuffix(lower, "shes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(lower, "ss"), strings.HasSuffix(lower, "us"), strings.HasSuffix(lower, "is"):
+		return word
+	case strings.HasSuffix(lower, "s") && len(word) > 1:
+		return word[:len(word)-1]
+	}
+	return word
+}
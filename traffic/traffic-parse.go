@@ -0,0 +1,266 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package traffic
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Exchange is a recorded HTTP request and its response. The response is absent (a zero Status) in an httptrace file
+// with only requests.
+type Exchange struct {
+	Title          string
+	Method         string
+	Url            *url.URL
+	RequestHeader  http.Header
+	RequestBody    []byte
+	Status         int
+	ResponseHeader http.Header
+	ResponseBody   []byte
+}
+
+// IsHarFile is true if the JSON file is an HTTP Archive, identified by its "log" with "entries".
+func IsHarFile(path string) bool {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var raw struct {
+		Log *struct {
+			Entries []any `json:"entries"`
+		} `json:"log"`
+	}
+	if json.Unmarshal(b, &raw) != nil {
+		return false
+	}
+	return raw.Log != nil && raw.Log.Entries != nil
+}
+
+// ReadFile reads the exchanges of a HAR file (.har or .json), or of an httptrace file (any other extension).
+func ReadFile(path string) ([]*Exchange, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch filepath.Ext(path) {
+	case ".har", ".json":
+		return ParseHar(b, path)
+	}
+	return ParseTrace(string(b), path)
+}
+
+type harFile struct {
+	Log struct {
+		Entries []*harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	Comment string `json:"comment"`
+	Request struct {
+		Method   string       `json:"method"`
+		Url      string       `json:"url"`
+		Headers  []*harHeader `json:"headers"`
+		PostData *struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+		} `json:"postData"`
+	} `json:"request"`
+	Response struct {
+		Status  int          `json:"status"`
+		Headers []*harHeader `json:"headers"`
+		Content struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+			Encoding string `json:"encoding"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+type harHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ParseHar returns the exchanges of an HTTP Archive (HAR 1.2) that are API calls. Entries for documents, scripts,
+// stylesheets, images, and fonts, as a browser records them, are skipped.
+func ParseHar(b []byte, path string) ([]*Exchange, error) {
+	var har harFile
+	if err := json.Unmarshal(b, &har); err != nil {
+		return nil, fmt.Errorf("Cannot parse HAR file %s: %v", path, err)
+	}
+	var exchanges []*Exchange
+	for i, entry := range har.Log.Entries {
+		req, res := entry.Request, entry.Response
+		u, err := url.Parse(req.Url)
+		if err != nil {
+			return nil, fmt.Errorf("%s: entry %d: bad URL %q", path, i, req.Url)
+		}
+		if isAsset(res.Content.MimeType) {
+			continue
+		}
+		x := &Exchange{
+			Title:          entry.Comment,
+			Method:         strings.ToUpper(req.Method),
+			Url:            u,
+			RequestHeader:  harHeaders(req.Headers),
+			Status:         res.Status,
+			ResponseHeader: harHeaders(res.Headers),
+			ResponseBody:   []byte(res.Content.Text),
+		}
+		if req.PostData != nil {
+			x.RequestBody = []byte(req.PostData.Text)
+			if req.PostData.MimeType != "" && x.RequestHeader.Get("Content-Type") == "" {
+				x.RequestHeader.Set("Content-Type", req.PostData.MimeType)
+			}
+		}
+		if res.Content.Encoding == "base64" {
+			if x.ResponseBody, err = base64.StdEncoding.DecodeString(res.Content.Text); err != nil {
+				return nil, fmt.Errorf("%s: entry %d: bad base64 content: %v", path, i, err)
+			}
+		}
+		exchanges = append(exchanges, x)
+	}
+	return exchanges, nil
+}
+
+func isAsset(mimeType string) bool {
+	for _, prefix := range []string{"text/html", "text/css", "text/javascript", "application/javascript", "image/", "font/"} {
+		if strings.HasPrefix(mimeType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func harHeaders(headers []*harHeader) http.Header {
+	h := make(http.Header, 0)
+	for _, hdr := range headers {
+		//HTTP/2 pseudo headers, like ":authority"
+		if !strings.HasPrefix(hdr.Name, ":") {
+			h.Add(hdr.Name, hdr.Value)
+		}
+	}
+	return h
+}
+
+var requestLine = regexp.MustCompile(`^([A-Z]+) (\S+)( HTTP/[0-9.]+)?$`)
+var statusLine = regexp.MustCompile(`^HTTP/[0-9.]+ ([0-9]{3})( .*)?$`)
+
+// ParseTrace returns the exchanges of an httptrace file: the format of the httptrace generator, and of the ".http"
+// files of the REST clients of editors. A "#" comment before a request is its title, "###" separates requests, and the
+// request line, headers, body, status line, headers, and body of each exchange are as in HTTP/1.1, without the
+// Content-Length framing. Request lines may have an absolute URL.
+func ParseTrace(text string, path string) ([]*Exchange, error) {
+	const (
+		between = iota
+		requestHeaders
+		requestBody
+		responseHeaders
+		responseBody
+	)
+	var exchanges []*Exchange
+	var x *Exchange
+	var body []string
+	title := ""
+	state := between
+	finishBody := func() {
+		if x == nil {
+			return
+		}
+		b := []byte(strings.TrimSpace(strings.Join(body, "\n")))
+		if state == requestBody {
+			x.RequestBody = b
+		} else if state == responseBody {
+			x.ResponseBody = b
+		}
+		body = nil
+	}
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if m := requestLine.FindStringSubmatch(line); m != nil && state != requestHeaders && state != responseHeaders {
+			finishBody()
+			u, err := url.Parse(m[2])
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: bad request URI %q", path, lineno, m[2])
+			}
+			x = &Exchange{Title: title, Method: m[1], Url: u, RequestHeader: make(http.Header, 0), ResponseHeader: make(http.Header, 0)}
+			exchanges = append(exchanges, x)
+			title = ""
+			state = requestHeaders
+			continue
+		}
+		if m := statusLine.FindStringSubmatch(line); m != nil && x != nil && (state == requestHeaders || state == requestBody) {
+			finishBody()
+			x.Status, _ = strconv.Atoi(m[1])
+			state = responseHeaders
+			continue
+		}
+		if strings.HasPrefix(line, "#") && state != requestHeaders && state != responseHeaders {
+			//a comment ends the body of the previous exchange, and may title the next one
+			finishBody()
+			state = between
+			if t := strings.TrimSpace(strings.TrimLeft(line, "#")); t != "" {
+				title = t
+			}
+			continue
+		}
+		switch state {
+		case requestHeaders, responseHeaders:
+			if strings.TrimSpace(line) == "" {
+				state++
+				continue
+			}
+			n := strings.Index(line, ":")
+			if n <= 0 {
+				return nil, fmt.Errorf("%s:%d: bad header line %q", path, lineno, line)
+			}
+			name, value := strings.TrimSpace(line[:n]), strings.TrimSpace(line[n+1:])
+			if state == requestHeaders {
+				x.RequestHeader.Add(name, value)
+			} else {
+				x.ResponseHeader.Add(name, value)
+			}
+		case requestBody, responseBody:
+			body = append(body, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	finishBody()
+	//a Host header makes the URL of a request absolute, like those of a HAR file
+	for _, x := range exchanges {
+		if host := x.RequestHeader.Get("Host"); x.Url.Host == "" && host != "" {
+			x.Url.Scheme, x.Url.Host = "https", host
+		}
+	}
+	return exchanges, nil
+}
@@ -0,0 +1,518 @@
+/*
+Copyright 2024 Lee R. Boynton
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package typescript
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/boynton/api/model"
+	"github.com/boynton/data"
+)
+
+const IndentAmount = "  "
+
+// Generator emits a single TypeScript module with the types of the model, and (unless "-a typescript.typesOnly" is
+// given) a fetch-based client class for the service. The types describe the JSON on the wire: timestamps, blobs and
+// arbitrary precision numbers are not converted.
+type Generator struct {
+	model.BaseGenerator
+	ns        model.Namespace
+	typesOnly bool
+	helpers   map[string]bool
+}
+
+func (gen *Generator) GenerateResource(rez *model.ResourceDef) error {
+	return nil
+}
+
+func (gen *Generator) GenerateOperation(op *model.OperationDef) error {
+	return nil
+}
+
+func (gen *Generator) GenerateException(op *model.OperationOutput) error {
+	return nil
+}
+
+func (gen *Generator) GenerateType(td *model.TypeDef) error {
+	return nil
+}
+
+func (gen *Generator) Generate(schema *model.Schema, config *data.Object) error {
+	err := gen.Configure(schema, config)
+	if err != nil {
+		return err
+	}
+	gen.ns = model.Namespace(config.GetString("namespace"))
+	if gen.ns == "" {
+		gen.ns = schema.ServiceNamespace()
+		if gen.ns == "" {
+			gen.ns = schema.Namespace
+		}
+	}
+	gen.typesOnly = config.GetBool("typescript.typesOnly")
+	gen.helpers = make(map[string]bool, 0)
+
+	gen.Begin()
+	gen.Emit("/* Generated */\n")
+	if schema.Comment != "" {
+		gen.Emit("\n")
+		gen.emitComment("", schema.Comment)
+	}
+	for _, td := range gen.Types() {
+		gen.Emit("\n")
+		gen.generateTypeDef(td)
+	}
+	if !gen.typesOnly && len(schema.Operations) > 0 {
+		for _, op := range gen.Operations() {
+			gen.generateOperationTypes(op)
+		}
+		gen.Emit("\n")
+		gen.Emit(apiErrorSource)
+		for _, edef := range gen.Exceptions() {
+			gen.Emit("\n")
+			gen.generateException(edef)
+		}
+		gen.Emit("\n")
+		gen.generateClient()
+		var names []string
+		for name := range gen.helpers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			gen.Emit("\n")
+			gen.Emit(helperSources[name])
+		}
+	}
+	return gen.Write(gen.End(), gen.FileName(string(gen.ns), ".ts"), "")
+}
+
+func (gen *Generator) emitComment(indent, comment string) {
+	comment = strings.TrimSpace(comment)
+	if comment == "" {
+		return
+	}
+	if !strings.Contains(comment, "\n") && len(indent)+len(comment) < 100 {
+		gen.Emitf("%s/** %s */\n", indent, strings.ReplaceAll(comment, "*/", "*\\/"))
+		return
+	}
+	gen.Emitf("%s/**\n", indent)
+	gen.Emit(model.FormatComment(indent, " * ", strings.ReplaceAll(comment, "*/", "*\\/"), 100, false))
+	gen.Emitf("%s */\n", indent)
+}
+
+// TypeRef returns the TypeScript type used to refer to the given type. Named types are referred to by name, base
+// types map to the JSON representation of their values.
+func (gen *Generator) TypeRef(id model.AbsoluteIdentifier) string {
+	switch id {
+	case "base#Bool":
+		return "boolean"
+	case "base#Int8", "base#Int16", "base#Int32", "base#Int64", "base#Float32", "base#Float64", "base#Integer", "base#Decimal":
+		return "number"
+	case "base#String", "base#Timestamp", "base#Bytes", "base#Blob":
+		return "string"
+	case "base#Any":
+		return "unknown"
+	case "base#List":
+		return "unknown[]"
+	case "base#Map", "base#Struct":
+		return "Record<string, unknown>"
+	}
+	return model.StripNamespace(id)
+}
+
+func (gen *Generator) baseTypeName(bt model.BaseType) string {
+	switch bt {
+	case model.BaseType_Bool:
+		return "boolean"
+	case model.BaseType_Int8, model.BaseType_Int16, model.BaseType_Int32, model.BaseType_Int64, model.BaseType_Float32, model.BaseType_Float64, model.BaseType_Integer, model.BaseType_Decimal:
+		return "number"
+	case model.BaseType_String, model.BaseType_Timestamp, model.BaseType_Blob:
+		return "string"
+	default:
+		return "unknown"
+	}
+}
+
+func propertyName(name string) string {
+	if model.IsSymbol(name) {
+		return name
+	}
+	return fmt.Sprintf("%q", name)
+}
+
+func (gen *Generator) generateTypeDef(td *model.TypeDef) {
+	name := model.StripNamespace(td.Id)
+	gen.emitComment("", td.Comment)
+	switch td.Base {
+	case model.BaseType_Struct:
+		gen.Emitf("export interface %s {\n", name)
+		for _, fd := range td.Fields {
+			gen.emitProperty(string(fd.Name), fd.Type, fd.Required, fd.Comment)
+		}
+		gen.Emit("}\n")
+	case model.BaseType_Union:
+		//the JSON of a union value has exactly one of the variant properties, each alternative here excludes the others
+		//so that checking a property narrows the type.
+		gen.Emitf("export type %s =\n", name)
+		for i, fd := range td.Fields {
+			var props []string
+			for _, other := range td.Fields {
+				if other == fd {
+					props = append(props, fmt.Sprintf("%s: %s", propertyName(string(fd.Name)), gen.TypeRef(fd.Type)))
+				} else {
+					props = append(props, fmt.Sprintf("%s?: never", propertyName(string(other.Name))))
+				}
+			}
+			end := ""
+			if i == len(td.Fields)-1 {
+				end = ";"
+			}
+			gen.Emitf("%s| { %s }%s\n", IndentAmount, strings.Join(props, "; "), end)
+		}
+	case model.BaseType_Enum:
+		var values []string
+		for _, el := range td.Elements {
+			if el.Value != "" {
+				values = append(values, fmt.Sprintf("%q", el.Value))
+			} else {
+				values = append(values, fmt.Sprintf("%q", el.Symbol))
+			}
+		}
+		gen.Emitf("export type %s = %s;\n", name, strings.Join(values, " | "))
+		gen.Emitf("export const %sValues: readonly %s[] = [%s];\n", name, name, strings.Join(values, ", "))
+	case model.BaseType_List:
+		gen.Emitf("export type %s = %s[];\n", name, gen.TypeRef(td.Items))
+	case model.BaseType_Map:
+		if gen.Schema.BaseType(td.Keys) == model.BaseType_Enum {
+			gen.Emitf("export type %s = Partial<Record<%s, %s>>;\n", name, gen.TypeRef(td.Keys), gen.TypeRef(td.Items))
+		} else {
+			gen.Emitf("export type %s = Record<string, %s>;\n", name, gen.TypeRef(td.Items))
+		}
+	default:
+		gen.Emitf("export type %s = %s;\n", name, gen.baseTypeName(td.Base))
+	}
+}
+
+func (gen *Generator) emitProperty(name string, tid model.AbsoluteIdentifier, required bool, comment string) {
+	gen.emitComment(IndentAmount, comment)
+	opt := "?"
+	if required {
+		opt = ""
+	}
+	gen.Emitf("%s%s%s: %s;\n", IndentAmount, propertyName(name), opt, gen.TypeRef(tid))
+}
+
+func (gen *Generator) generateOperationTypes(op *model.OperationDef) {
+	if op.Input != nil && len(op.Input.Fields) > 0 {
+		gen.Emit("\n")
+		gen.Emitf("export interface %s {\n", model.StripNamespace(op.Input.Id))
+		for _, f := range op.Input.Fields {
+			gen.emitProperty(string(f.Name), f.Type, f.Required, f.Comment)
+		}
+		gen.Emit("}\n")
+	}
+	if hasOutput(op) {
+		gen.Emit("\n")
+		gen.Emitf("export interface %s {\n", model.StripNamespace(op.Output.Id))
+		for _, f := range op.Output.Fields {
+			gen.emitProperty(string(f.Name), f.Type, f.Required, f.Comment)
+		}
+		gen.Emit("}\n")
+	}
+}
+
+func hasRequiredInput(op *model.OperationDef) bool {
+	for _, f := range op.Input.Fields {
+		if f.Required {
+			return true
+		}
+	}
+	return false
+}
+
+func hasOutput(op *model.OperationDef) bool {
+	return op.Output != nil && len(op.Output.Fields) > 0
+}
+
+func (gen *Generator) generateException(edef *model.OperationOutput) {
+	name := model.StripNamespace(edef.Id)
+	gen.emitComment("", edef.Comment)
+	gen.Emitf("export class %s extends ApiError {\n", name)
+	for _, f := range edef.Fields {
+		gen.emitProperty(string(f.Name), f.Type, false, f.Comment)
+	}
+	gen.Emit("\n")
+	gen.Emitf("%sconstructor(response: Response, body: unknown) {\n", IndentAmount)
+	gen.Emitf("%s%ssuper(%q, response.status, body);\n", IndentAmount, IndentAmount, name)
+	for _, f := range edef.Fields {
+		gen.Emitf("%s%sthis.%s = %s;\n", IndentAmount, IndentAmount, f.Name, gen.outputValue(f, false))
+	}
+	gen.Emitf("%s}\n", IndentAmount)
+	gen.Emit("}\n")
+}
+
+// outputValue returns the expression extracting an output or exception field from the response.
+func (gen *Generator) outputValue(f *model.OperationOutputField, required bool) string {
+	tref := gen.TypeRef(f.Type)
+	cast := " as " + tref
+	if !required {
+		cast += " | undefined"
+	}
+	if f.HttpPayload {
+		return "body" + cast
+	}
+	if f.HttpHeader != "" {
+		helper := "headerString"
+		switch gen.Schema.BaseType(f.Type) {
+		case model.BaseType_Bool:
+			helper = "headerBoolean"
+		case model.BaseType_Int8, model.BaseType_Int16, model.BaseType_Int32, model.BaseType_Int64, model.BaseType_Float32, model.BaseType_Float64, model.BaseType_Integer, model.BaseType_Decimal:
+			helper = "headerNumber"
+		}
+		gen.helpers[helper] = true
+		return fmt.Sprintf("%s(response, %q)%s", helper, f.HttpHeader, cast)
+	}
+	gen.helpers["member"] = true
+	return fmt.Sprintf("member(body, %q)%s", f.Name, cast)
+}
+
+func (gen *Generator) generateClient() {
+	schema := gen.Schema
+	serviceName := string(schema.ServiceName())
+	if serviceName == "" {
+		serviceName = model.Capitalize(strings.ReplaceAll(string(gen.ns), ".", "_"))
+	}
+	className := serviceName + "Client"
+	i1 := IndentAmount
+	i2 := i1 + IndentAmount
+	i3 := i2 + IndentAmount
+	gen.Emit(clientOptionsSource)
+	gen.Emit("\n")
+	gen.Emitf("export class %s {\n", className)
+	gen.Emitf("%sprivate readonly baseUrl: string;\n", i1)
+	gen.Emitf("%sprivate readonly options: ClientOptions;\n", i1)
+	gen.Emit("\n")
+	gen.Emitf("%sconstructor(baseUrl: string, options: ClientOptions = {}) {\n", i1)
+	gen.Emitf("%sthis.baseUrl = baseUrl.replace(/\\/+$/, \"\");\n", i2)
+	gen.Emitf("%sthis.options = options;\n", i2)
+	gen.Emitf("%s}\n", i1)
+	for _, op := range gen.Operations() {
+		gen.Emit("\n")
+		gen.emitComment(i1, op.Comment)
+		param := ""
+		if op.Input != nil && len(op.Input.Fields) > 0 {
+			param = "input: " + model.StripNamespace(op.Input.Id)
+			if !hasRequiredInput(op) {
+				param += " = {}"
+			}
+		}
+		result := "void"
+		if hasOutput(op) {
+			result = model.StripNamespace(op.Output.Id)
+		}
+		gen.Emitf("%sasync %s(%s): Promise<%s> {\n", i1, model.Uncapitalize(model.StripNamespace(op.Id)), param, result)
+		binding := model.HttpInputBinding(op)
+		gen.Emitf("%sconst path = %s;\n", i2, gen.pathExpression(binding))
+		query := "undefined"
+		if len(binding.Query) > 0 {
+			gen.Emitf("%sconst query = new URLSearchParams();\n", i2)
+			query = "query"
+		}
+		for _, f := range binding.Query {
+			gen.Emitf("%sif (input.%s !== undefined) {\n", i2, f.Name)
+			if gen.Schema.BaseType(f.Type) == model.BaseType_List {
+				gen.Emitf("%sfor (const value of input.%s) {\n", i3, f.Name)
+				gen.Emitf("%s%squery.append(%q, String(value));\n", i3, IndentAmount, f.HttpQuery)
+				gen.Emitf("%s}\n", i3)
+			} else {
+				gen.Emitf("%squery.append(%q, String(input.%s));\n", i3, f.HttpQuery, f.Name)
+			}
+			gen.Emitf("%s}\n", i2)
+		}
+		gen.Emitf("%sconst headers: Record<string, string> = {};\n", i2)
+		for _, f := range binding.Headers {
+			gen.Emitf("%sif (input.%s !== undefined) {\n", i2, f.Name)
+			gen.Emitf("%sheaders[%q] = String(input.%s);\n", i3, f.HttpHeader, f.Name)
+			gen.Emitf("%s}\n", i2)
+		}
+		body := "undefined"
+		if payload := binding.Payload; payload != nil {
+			if payload.Required {
+				body = fmt.Sprintf("JSON.stringify(input.%s)", payload.Name)
+			} else {
+				body = fmt.Sprintf("input.%s === undefined ? undefined : JSON.stringify(input.%s)", payload.Name, payload.Name)
+			}
+		} else if len(binding.Members) > 0 {
+			var props []string
+			for _, f := range binding.Members {
+				props = append(props, fmt.Sprintf("%s: input.%s", propertyName(string(f.Name)), f.Name))
+			}
+			body = fmt.Sprintf("JSON.stringify({ %s })", strings.Join(props, ", "))
+		}
+		gen.Emitf("%sconst response = await this.send(%q, path, %s, headers, %s);\n", i2, op.HttpMethod, query, body)
+		var exceptions []string
+		statuses := make(map[int32]bool, 0)
+		for _, eid := range op.Exceptions {
+			edef := schema.GetExceptionDef(eid)
+			if edef == nil || statuses[edef.HttpStatus] {
+				continue
+			}
+			statuses[edef.HttpStatus] = true
+			exceptions = append(exceptions, fmt.Sprintf("%d: %s", edef.HttpStatus, model.StripNamespace(eid)))
+		}
+		gen.Emitf("%sif (!response.ok) {\n", i2)
+		if len(exceptions) > 0 {
+			gen.Emitf("%sthrow await this.error(response, { %s });\n", i3, strings.Join(exceptions, ", "))
+		} else {
+			gen.Emitf("%sthrow await this.error(response, {});\n", i3)
+		}
+		gen.Emitf("%s}\n", i2)
+		if hasOutput(op) {
+			for _, f := range op.Output.Fields {
+				if f.HttpHeader == "" {
+					gen.Emitf("%sconst body = await readJson(response);\n", i2)
+					break
+				}
+			}
+			gen.Emitf("%sreturn {\n", i2)
+			for _, f := range op.Output.Fields {
+				gen.Emitf("%s%s: %s,\n", i3, propertyName(string(f.Name)), gen.outputValue(f, f.Required))
+			}
+			gen.Emitf("%s};\n", i2)
+		}
+		gen.Emitf("%s}\n", i1)
+	}
+	gen.Emit("\n")
+	gen.Emit(clientMethodsSource)
+	gen.Emit("}\n")
+	gen.helpers["readJson"] = true
+}
+
+// pathExpression returns a template literal for the operation's URI, substituting its path fields.
+func (gen *Generator) pathExpression(binding *model.InputBinding) string {
+	var b strings.Builder
+	b.WriteString("`")
+	for _, seg := range binding.Path {
+		if seg.Label == "" {
+			b.WriteString(escapeTemplate(seg.Literal))
+		} else if seg.Greedy {
+			gen.helpers["encodePath"] = true
+			b.WriteString(fmt.Sprintf("${encodePath(String(input.%s))}", seg.Label))
+		} else {
+			b.WriteString(fmt.Sprintf("${encodeURIComponent(String(input.%s))}", seg.Label))
+		}
+	}
+	b.WriteString("`")
+	return b.String()
+}
+
+func escapeTemplate(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "`", "\\`")
+	return strings.ReplaceAll(s, "${", "\\${")
+}
+
+var apiErrorSource = `/** The base of all errors thrown by the client. The body is the parsed JSON of the response, if any. */
+export class ApiError extends Error {
+  readonly status: number;
+  readonly body: unknown;
+
+  constructor(name: string, status: number, body: unknown) {
+    super(` + "`${name} (HTTP status ${status})`" + `);
+    Object.setPrototypeOf(this, new.target.prototype);
+    this.name = name;
+    this.status = status;
+    this.body = body;
+  }
+}
+`
+
+var clientOptionsSource = `export interface ClientOptions {
+  /** Headers sent with every request, i.e. Authorization */
+  headers?: Record<string, string>;
+  /** The fetch implementation to use, the global fetch by default */
+  fetch?: typeof fetch;
+}
+
+type ExceptionType = new (response: Response, body: unknown) => ApiError;
+`
+
+var clientMethodsSource = `  private async send(method: string, path: string, query: URLSearchParams | undefined, headers: Record<string, string>, body: string | undefined): Promise<Response> {
+    let url = this.baseUrl + path;
+    const q = query === undefined ? "" : query.toString();
+    if (q !== "") {
+      url += "?" + q;
+    }
+    const h: Record<string, string> = { Accept: "application/json", ...this.options.headers, ...headers };
+    if (body !== undefined) {
+      h["Content-Type"] = "application/json";
+    }
+    const f = this.options.fetch ?? fetch;
+    return f(url, { method, headers: h, body });
+  }
+
+  private async error(response: Response, exceptions: Record<number, ExceptionType>): Promise<ApiError> {
+    const body = await readJson(response);
+    const type = exceptions[response.status];
+    if (type !== undefined) {
+      return new type(response, body);
+    }
+    return new ApiError(response.statusText || "ApiError", response.status, body);
+  }
+`
+
+var helperSources = map[string]string{
+	"readJson": `async function readJson(response: Response): Promise<unknown> {
+  const text = await response.text();
+  if (text === "") {
+    return undefined;
+  }
+  try {
+    return JSON.parse(text);
+  } catch {
+    return text;
+  }
+}
+`,
+	"member": `function member(body: unknown, name: string): unknown {
+  if (typeof body === "object" && body !== null) {
+    return (body as Record<string, unknown>)[name];
+  }
+  return undefined;
+}
+`,
+	"encodePath": `function encodePath(value: string): string {
+  return value.split("/").map(encodeURIComponent).join("/");
+}
+`,
+	"headerString": `function headerString(response: Response, name: string): string | undefined {
+  return response.headers.get(name) ?? undefined;
+}
+`,
+	"headerNumber": `function headerNumber(response: Response, name: string): number | undefined {
+  const value = response.headers.get(name);
+  return value === null ? undefined : Number(value);
+}
+`,
+	"headerBoolean": `function headerBoolean(response: Response, name: string): boolean | undefined {
+  const value = response.headers.get(name);
+  return value === null ? undefined : value === "true";
+}
+`,
+}